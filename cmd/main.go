@@ -49,7 +49,13 @@ func main() {
 		},
 	}
 
-	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stdout, opts)))
+	// STDOUT output owns stdout for generated data, hence route program logs to stderr
+	logWriter := os.Stdout
+	if cfg.Output.Type == conf.OutputStdout {
+		logWriter = os.Stderr
+	}
+
+	slog.SetDefault(slog.New(slog.NewTextHandler(logWriter, opts)))
 
 	slog.Info("Starting data generator")
 	slog.Info("Input", "config", cfg.Input.Print())
@@ -78,7 +84,7 @@ func run(ctx context.Context, cfg *conf.Config, rt runtime.Runtime, sigStop cont
 		return fmt.Errorf("error creating generator: %s", err.Error())
 	}
 
-	exporter, err := exporters.ExporterFor(ctx, cfg, rt)
+	exporter, err := exporters.ExporterFor(ctx, cfg, rt, generator)
 	if err != nil {
 		return fmt.Errorf("error creating exporter: %s", err.Error())
 	}
@@ -16,6 +16,7 @@ const (
 	defaultDelay       = "1s"
 	defaultBatching    = "0s"
 	defaultMaxDuration = "0s"
+	s3ARNPrefix        = "arn:aws:s3:::"
 
 	EnvInputType             = "ENV_INPUT_TYPE"
 	EnvInputDelay            = "ENV_INPUT_DELAY"
@@ -28,12 +29,16 @@ const (
 	EnvOutType        = "ENV_OUT_TYPE"
 	EnvOutWait        = "ENV_OUT_WAIT_FOR_COMPLETION"
 	EnvOutLocation    = "ENV_OUT_LOCATION"
+	EnvOutFileMode    = "ENV_OUT_FILE_MODE"
 	EnvOutCompression = "ENV_OUT_COMPRESSION"
 	EnvOutS3Bucket    = "ENV_OUT_S3_BUCKET"
 	EnvOutPathPrefix  = "ENV_OUT_PATH_PREFIX"
 	EnvOutStreamName  = "ENV_OUT_STREAM_NAME"
 	EnvOutLogGroup    = "ENV_OUT_LOG_GROUP"
 	EnvOutLogStream   = "ENV_OUT_LOG_STREAM"
+	EnvOutPath        = "ENV_OUT_PATH"
+	EnvOutURL         = "ENV_OUT_URL"
+	EnvOutEncoding    = "ENV_OUT_ENCODING"
 
 	EnvOutEventHubNamespace        = "ENV_OUT_EVENTHUB_NAMESPACE"
 	EnvOutEventHubName             = "ENV_OUT_EVENTHUB_NAME"
@@ -42,21 +47,51 @@ const (
 	EnvAWSRegion  = "AWS_REGION"
 	EnvAWSProfile = "AWS_PROFILE"
 
-	InputLogs    = "LOGS"
-	InputMetrics = "METRICS"
-	InputALB     = "ALB"
-	InputNLB     = "NLB"
-	InputVPC     = "VPC"
-	InputWAF     = "WAF"
-	InputCT      = "CLOUDTRAIL"
-	InputAzures  = "AZURE_RESOURCE_LOGS"
-
-	OutputFile     = "FILE"
-	OutputS3       = "S3"
-	OutputFirehose = "FIREHOSE"
-	OutputCWLogs   = "CLOUDWATCH_LOG"
-	OutputEventHub = "EVENTHUB"
-	OutputDebug    = "DEBUG"
+	InputLogs       = "LOGS"
+	InputMetrics    = "METRICS"
+	InputALB        = "ALB"
+	InputNLB        = "NLB"
+	InputVPC        = "VPC"
+	InputWAF        = "WAF"
+	InputCT         = "CLOUDTRAIL"
+	InputAzures     = "AZURE_RESOURCE_LOGS"
+	InputCloudFront = "CLOUDFRONT"
+	InputS3Access   = "S3_ACCESS"
+	InputNFW        = "NETWORK_FIREWALL"
+	InputR53        = "ROUTE53_RESOLVER"
+	InputGuardDuty  = "GUARDDUTY"
+	InputSecHub     = "SECURITY_HUB"
+	InputAPIGW      = "APIGATEWAY"
+	InputLambda     = "LAMBDA"
+	InputK8sAudit   = "K8S_AUDIT"
+	InputContainer  = "CONTAINER_LOGS"
+	InputAccessLog  = "ACCESS_LOG"
+	InputSyslog     = "SYSLOG"
+	InputAuditd     = "AUDITD"
+	InputWindows    = "WINDOWS_EVENTS"
+	InputCEF        = "CEF"
+	InputLEEF       = "LEEF"
+	InputZeek       = "ZEEK"
+	InputSuricata   = "SURICATA"
+	InputAzureFlow  = "AZURE_FLOW_LOGS"
+	InputEntraID    = "AZURE_ENTRA_ID_LOGS"
+
+	OutputFile       = "FILE"
+	OutputS3         = "S3"
+	OutputFirehose   = "FIREHOSE"
+	OutputCWLogs     = "CLOUDWATCH_LOG"
+	OutputKinesis    = "KINESIS"
+	OutputHTTP       = "HTTP"
+	OutputEventHub   = "EVENTHUB"
+	OutputDebug      = "DEBUG"
+	OutputStdout     = "STDOUT"
+	OutputPipe       = "PIPE"
+	OutputUnixSocket = "UNIX_SOCKET"
+
+	EncodingCWSubscription = "CLOUDWATCH_SUBSCRIPTION"
+
+	// DefaultEKSClusterName is the cluster of generated Kubernetes audit logs unless configured
+	DefaultEKSClusterName = "data-gen-cluster"
 )
 
 // Config holds the complete configuration for the data generator including input, output, and AWS settings.
@@ -95,13 +130,13 @@ func (cfg *Config) Print() string {
 func (cfg *Config) UsesAWS() bool {
 	// Check if output type requires AWS
 	switch cfg.Output.Type {
-	case OutputS3, OutputFirehose, OutputCWLogs:
+	case OutputS3, OutputFirehose, OutputCWLogs, OutputKinesis:
 		return true
 	}
 
 	// Check if input type is AWS-specific (may need AWS config for region/profile context)
 	switch cfg.Input.Type {
-	case InputALB, InputNLB, InputVPC, InputWAF, InputCT:
+	case InputALB, InputNLB, InputVPC, InputWAF, InputCT, InputCloudFront, InputS3Access, InputNFW, InputR53, InputGuardDuty, InputSecHub, InputAPIGW, InputLambda, InputK8sAudit:
 		return true
 	}
 
@@ -153,9 +188,16 @@ func (cfg *InputConfig) Print() string {
 
 // OutputConfig specifies where and how to export generated data.
 type OutputConfig struct {
-	Type              string    `yaml:"type"`
-	WaitForCompletion bool      `yaml:"wait_for_completion"`
-	Conf              yaml.Node `yaml:"config"`
+	Type              string         `yaml:"type"`
+	WaitForCompletion bool           `yaml:"wait_for_completion"`
+	Encoding          EncodingConfig `yaml:"encoding"`
+	Conf              yaml.Node      `yaml:"config"`
+}
+
+// EncodingConfig defines an optional transformation applied to batches before they are exported.
+type EncodingConfig struct {
+	Type string    `yaml:"type"`
+	Conf yaml.Node `yaml:"config"`
 }
 
 func newDefaultOutputConfig() *OutputConfig {
@@ -165,9 +207,42 @@ func newDefaultOutputConfig() *OutputConfig {
 }
 
 func (cfg *OutputConfig) Print() string {
+	if cfg.Encoding.Type != "" {
+		return fmt.Sprintf("Type: %s, Encoding: %s", cfg.Type, cfg.Encoding.Type)
+	}
+
 	return fmt.Sprintf("Type: %s", cfg.Type)
 }
 
+// LogEvents returns true if records are exported as individual CloudWatch log events, either to CloudWatch Logs or
+// through subscription filter payloads. Generators then produce one record per line, without log file headers.
+func (cfg *OutputConfig) LogEvents() bool {
+	return cfg.Type == OutputCWLogs || cfg.Encoding.Type == EncodingCWSubscription
+}
+
+// S3OutputConfig specifies the bucket, key prefix and compression of S3 output.
+type S3OutputConfig struct {
+	Bucket      string `yaml:"s3_bucket"`
+	PathPrefix  string `yaml:"path_prefix"`
+	Compression string `yaml:"compression"`
+}
+
+// S3Config decodes the S3 output configuration, applying env variable overrides. Bucket ARNs are reduced to bucket names.
+// Generators naming their objects, ex:- CloudTrail digests referencing log files, use it to derive the keys S3 output stores them under.
+func (cfg *OutputConfig) S3Config() (S3OutputConfig, error) {
+	var s3 S3OutputConfig
+	err := cfg.Conf.Decode(&s3)
+	if err != nil {
+		return s3, err
+	}
+
+	s3.Bucket = strings.TrimPrefix(envOrDefault(EnvOutS3Bucket, s3.Bucket), s3ARNPrefix)
+	s3.PathPrefix = envOrDefault(EnvOutPathPrefix, s3.PathPrefix)
+	s3.Compression = envOrDefault(EnvOutCompression, s3.Compression)
+
+	return s3, nil
+}
+
 // AWSCfg contains AWS-specific configuration for credential profile and region.
 type AWSCfg struct {
 	Profile string `yaml:"profile"`
@@ -208,6 +283,7 @@ func NewConfig(input []byte) (*Config, error) {
 	cfg.Input.MaxRunTime = envOrDefault(EnvMaxRuntime, cfg.Input.MaxRunTime)
 	cfg.Output.Type = envOrDefault(EnvOutType, cfg.Output.Type)
 	cfg.Output.WaitForCompletion = envToBool(EnvOutWait, cfg.Output.WaitForCompletion)
+	cfg.Output.Encoding.Type = envOrDefault(EnvOutEncoding, cfg.Output.Encoding.Type)
 
 	cfg.Region = envOrDefault(EnvAWSRegion, cfg.Region)
 	cfg.Profile = envOrDefault(EnvAWSProfile, cfg.Profile)
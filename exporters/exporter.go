@@ -20,7 +20,17 @@ type output interface {
 	Send(*[]byte) error
 }
 
-func ExporterFor(ctx context.Context, cfg *conf.Config, runtime runtime.Runtime) (*Exporter, error) {
+// encoder transforms a batch before it is handed over to the output.
+type encoder interface {
+	Encode(*[]byte) (*[]byte, error)
+}
+
+// closer is implemented by outputs holding resources (ex:- open files) that must be released on shutdown.
+type closer interface {
+	Close() error
+}
+
+func ExporterFor(ctx context.Context, cfg *conf.Config, runtime runtime.Runtime, source internal.RecordSource) (*Exporter, error) {
 	var exporter output
 	var err error
 
@@ -31,7 +41,7 @@ func ExporterFor(ctx context.Context, cfg *conf.Config, runtime runtime.Runtime)
 			return nil, err
 		}
 	case conf.OutputS3:
-		exporter, err = internal.NewS3BucketExporter(ctx, cfg)
+		exporter, err = internal.NewS3BucketExporter(ctx, cfg, source)
 		if err != nil {
 			return nil, err
 		}
@@ -40,9 +50,13 @@ func ExporterFor(ctx context.Context, cfg *conf.Config, runtime runtime.Runtime)
 		if err != nil {
 			return nil, err
 		}
-
+	case conf.OutputKinesis:
+		exporter, err = internal.NewKinesisExporter(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
 	case conf.OutputCWLogs:
-		exporter, err = internal.NewCloudWatchLogExporter(ctx, cfg)
+		exporter, err = internal.NewCloudWatchLogExporter(ctx, cfg, source)
 		if err != nil {
 			return nil, err
 		}
@@ -51,16 +65,49 @@ func ExporterFor(ctx context.Context, cfg *conf.Config, runtime runtime.Runtime)
 		if err != nil {
 			return nil, err
 		}
+	case conf.OutputHTTP:
+		exporter, err = internal.NewHTTPExporter(cfg)
+		if err != nil {
+			return nil, err
+		}
 	case conf.OutputDebug:
 		exporter, err = internal.NewDebugExporter(cfg)
 		if err != nil {
 			return nil, err
 		}
+	case conf.OutputStdout:
+		exporter = internal.NewStdoutExporter()
+	case conf.OutputPipe, conf.OutputUnixSocket:
+		exporter, err = internal.NewPipeExporter(cfg)
+		if err != nil {
+			return nil, err
+		}
 	default:
 		return nil, fmt.Errorf("unknown output type: %s", cfg.Output.Type)
 	}
 
-	return newExporter(cfg, runtime, exporter), nil
+	enc, err := encoderFor(cfg, source)
+	if err != nil {
+		return nil, err
+	}
+
+	return newExporter(cfg, runtime, exporter, enc), nil
+}
+
+// encoderFor returns the configured encoder or nil when batches are exported as generated.
+func encoderFor(cfg *conf.Config, source internal.RecordSource) (encoder, error) {
+	switch cfg.Output.Encoding.Type {
+	case "":
+		return nil, nil
+	case conf.EncodingCWSubscription:
+		// outputs splitting batches into newline delimited records would cut the compressed payload apart
+		if cfg.Output.Type == conf.OutputCWLogs || cfg.Output.Type == conf.OutputEventHub {
+			return nil, fmt.Errorf("output encoding %s is not supported with output type %s", cfg.Output.Encoding.Type, cfg.Output.Type)
+		}
+		return internal.NewCWSubscriptionEncoder(cfg, source)
+	default:
+		return nil, fmt.Errorf("unknown output encoding: %s", cfg.Output.Encoding.Type)
+	}
 }
 
 // Exporter manages the lifecycle of sending generated data to configured outputs.
@@ -68,34 +115,38 @@ type Exporter struct {
 	cfg     *conf.Config
 	runtime runtime.Runtime
 	output  output
+	encoder encoder
 	errChan chan error
 	shChan  chan struct{}
+	done    chan struct{}
 
 	sending sync.Mutex
 }
 
-func newExporter(cfg *conf.Config, rt runtime.Runtime, output output) *Exporter {
+func newExporter(cfg *conf.Config, rt runtime.Runtime, output output, encoder encoder) *Exporter {
 	return &Exporter{
 		cfg:     cfg,
 		runtime: rt,
 		output:  output,
+		encoder: encoder,
 		errChan: make(chan error, 2),
 		shChan:  make(chan struct{}),
+		done:    make(chan struct{}),
 	}
 }
 
 func (e *Exporter) Start(data <-chan *[]byte) <-chan error {
 	go func() {
+		defer close(e.done)
+
 		for {
 			select {
 			case d := <-data:
-				e.sending.Lock()
-				err := e.output.Send(d)
-				if err != nil {
-					e.errChan <- err
-				}
-				e.sending.Unlock()
+				e.send(d)
 			case <-e.shChan:
+				if e.cfg.Output.WaitForCompletion {
+					e.drain(data)
+				}
 				slog.Info("Shutting down exporter")
 				return
 			}
@@ -106,17 +157,75 @@ func (e *Exporter) Start(data <-chan *[]byte) <-chan error {
 }
 
 func (e *Exporter) Stop() {
-	close(e.errChan)
 	close(e.shChan)
 
 	if e.cfg.Output.WaitForCompletion {
 		slog.Info("Waiting for final exports to complete")
-		e.sending.Lock()
-		// nolint: staticcheck
-		e.sending.Unlock()
+		<-e.done
+		e.closeOutput()
 		return
 	}
 
 	slog.Info("Shutting down exporter")
 	time.Sleep(defaultShutdownWait)
+
+	// do not block shutdown on an in-flight export
+	if e.sending.TryLock() {
+		e.closeOutput()
+		e.sending.Unlock()
+	}
+}
+
+func (e *Exporter) send(d *[]byte) {
+	e.sending.Lock()
+	defer e.sending.Unlock()
+
+	err := e.encodeAndSend(d)
+	if err == nil {
+		return
+	}
+
+	// never block on error reporting, consumer only reads the first error
+	select {
+	case e.errChan <- err:
+	default:
+		slog.Warn("Export error", "error", err)
+	}
+}
+
+func (e *Exporter) encodeAndSend(d *[]byte) error {
+	if e.encoder != nil {
+		encoded, err := e.encoder.Encode(d)
+		if err != nil {
+			return fmt.Errorf("failed to encode data: %w", err)
+		}
+		d = encoded
+	}
+
+	return e.output.Send(d)
+}
+
+// drain exports batches already emitted by the generator but not yet picked up by the exporter.
+func (e *Exporter) drain(data <-chan *[]byte) {
+	for {
+		select {
+		case d := <-data:
+			e.send(d)
+		default:
+			return
+		}
+	}
+}
+
+// closeOutput releases output resources if the output supports it.
+// Must not be called concurrently with send.
+func (e *Exporter) closeOutput() {
+	c, ok := e.output.(closer)
+	if !ok {
+		return
+	}
+
+	if err := c.Close(); err != nil {
+		slog.Warn("Error closing output", "error", err)
+	}
 }
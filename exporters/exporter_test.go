@@ -0,0 +1,182 @@
+package exporters
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"data-gen/conf"
+	"data-gen/generators"
+	"data-gen/internal/runtime"
+
+	"github.com/stretchr/testify/require"
+)
+
+// recordingOutput records sent batches and whether it was closed.
+type recordingOutput struct {
+	lock   sync.Mutex
+	sent   []string
+	closed bool
+	err    error
+}
+
+func (r *recordingOutput) Send(d *[]byte) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.sent = append(r.sent, string(*d))
+	return r.err
+}
+
+func (r *recordingOutput) Close() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.closed = true
+	return nil
+}
+
+func TestExporter_Stop(t *testing.T) {
+	t.Run("Drain buffered batches on stop", func(t *testing.T) {
+		out := &recordingOutput{}
+		exporter := newExporter(&conf.Config{Output: conf.OutputConfig{WaitForCompletion: true}}, nil, out, nil)
+
+		// batches emitted by the generator, but not yet picked up by the exporter
+		data := make(chan *[]byte, 3)
+		for _, d := range []string{"first", "second", "third"} {
+			b := []byte(d)
+			data <- &b
+		}
+
+		errChan := exporter.Start(data)
+		exporter.Stop()
+
+		require.Equal(t, []string{"first", "second", "third"}, out.sent)
+		require.True(t, out.closed)
+
+		// error channel stays open, a closed channel would be read as an export error
+		select {
+		case err := <-errChan:
+			require.Fail(t, "unexpected error", err)
+		default:
+		}
+	})
+
+	t.Run("Export errors do not block draining", func(t *testing.T) {
+		out := &recordingOutput{err: errors.New("export failed")}
+		exporter := newExporter(&conf.Config{Output: conf.OutputConfig{WaitForCompletion: true}}, nil, out, nil)
+
+		data := make(chan *[]byte, 5)
+		for range 5 {
+			b := []byte("batch")
+			data <- &b
+		}
+
+		errChan := exporter.Start(data)
+		exporter.Stop()
+
+		require.Len(t, out.sent, 5)
+		require.ErrorContains(t, <-errChan, "export failed")
+	})
+}
+
+// cwSubscriptionPayload decodes subscription filter payloads produced by the encoding.
+type cwSubscriptionPayload struct {
+	MessageType         string   `json:"messageType"`
+	Owner               string   `json:"owner"`
+	LogGroup            string   `json:"logGroup"`
+	LogStream           string   `json:"logStream"`
+	SubscriptionFilters []string `json:"subscriptionFilters"`
+	LogEvents           []struct {
+		ID        string `json:"id"`
+		Timestamp int64  `json:"timestamp"`
+		Message   string `json:"message"`
+	} `json:"logEvents"`
+}
+
+func TestEncoderFor_CWSubscription(t *testing.T) {
+	for _, input := range []string{conf.InputCT, conf.InputVPC, conf.InputK8sAudit, conf.InputZeek, conf.InputCloudFront} {
+		t.Run(input, func(t *testing.T) {
+			cfg, err := conf.NewConfig([]byte(`
+input:
+  type: ` + input + `
+  delay: 1ms
+  batching: 0s
+  max_batch_elements: 5
+  max_data_points: 5
+output:
+  type: FIREHOSE
+  encoding:
+    type: CLOUDWATCH_SUBSCRIPTION
+    config:
+      owner: "111122223333"
+      log_group: "data-gen/logs"
+      log_stream: "111122223333_us-east-1"
+      subscription_filters: ["Destination"]
+`))
+			require.NoError(t, err)
+
+			generator, err := generators.GeneratorFor(cfg, runtime.NewRuntime())
+			require.NoError(t, err)
+
+			enc, err := encoderFor(cfg, generator)
+			require.NoError(t, err)
+
+			data, _, _ := generator.Start()
+			batch := <-data
+			generator.Stop()
+
+			encoded, err := enc.Encode(batch)
+			require.NoError(t, err)
+
+			reader, err := gzip.NewReader(bytes.NewReader(*encoded))
+			require.NoError(t, err)
+			raw, err := io.ReadAll(reader)
+			require.NoError(t, err)
+
+			var payload cwSubscriptionPayload
+			require.NoError(t, json.Unmarshal(raw, &payload))
+
+			require.Equal(t, "DATA_MESSAGE", payload.MessageType)
+			require.Equal(t, "111122223333", payload.Owner)
+			require.Equal(t, "data-gen/logs", payload.LogGroup)
+			require.Equal(t, "111122223333_us-east-1", payload.LogStream)
+			require.Equal(t, []string{"Destination"}, payload.SubscriptionFilters)
+
+			// one log event per record, without log file headers or delivery envelopes
+			records := strings.Split(strings.TrimRight(string(*batch), "\n"), "\n")
+			require.Equal(t, len(records), len(payload.LogEvents))
+			for _, event := range payload.LogEvents {
+				require.NotEmpty(t, event.Message)
+				require.False(t, strings.HasPrefix(event.Message, "#"), event.Message)
+				require.False(t, strings.HasPrefix(event.Message, `{"Records"`), event.Message)
+				require.Len(t, event.ID, 56)
+				require.True(t, strings.HasPrefix(event.ID, strconv.FormatInt(event.Timestamp, 10)))
+
+				ts, ok := generator.RecordTimestamp(event.Message)
+				require.True(t, ok, event.Message)
+				require.Equal(t, ts.UnixMilli(), event.Timestamp)
+			}
+		})
+	}
+}
+
+func TestEncoderFor_RejectsSplittingOutputs(t *testing.T) {
+	for _, output := range []string{conf.OutputCWLogs, conf.OutputEventHub} {
+		t.Run(output, func(t *testing.T) {
+			cfg := &conf.Config{Output: conf.OutputConfig{
+				Type:     output,
+				Encoding: conf.EncodingConfig{Type: conf.EncodingCWSubscription},
+			}}
+
+			_, err := encoderFor(cfg, nil)
+			require.ErrorContains(t, err, "not supported with output type "+output)
+		})
+	}
+}
@@ -2,10 +2,14 @@ package internal
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"data-gen/conf"
 
@@ -15,20 +19,67 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
 )
 
+// PutLogEvents API limits.
+// See - https://docs.aws.amazon.com/AmazonCloudWatchLogs/latest/APIReference/API_PutLogEvents.html
+const (
+	cwMaxEventsPerCall   = 10_000
+	cwMaxBytesPerCall    = 1_048_576
+	cwEventOverheadBytes = 26
+	cwMaxCallSpan        = 24 * time.Hour
+	cwDefaultMaxEvent    = 262_144
+
+	cwOversizeTruncate = "truncate"
+	cwOversizeReject   = "reject"
+
+	cwTimestampRecord = "record"
+	cwTimestampNow    = "now"
+
+	placeholderIndex = "{index}"
+	placeholderDate  = "{date}"
+)
+
+// cwLogsClient is the subset of CloudWatch Logs API used by the exporter.
+type cwLogsClient interface {
+	PutLogEvents(ctx context.Context, params *cloudwatchlogs.PutLogEventsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error)
+	CreateLogGroup(ctx context.Context, params *cloudwatchlogs.CreateLogGroupInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogGroupOutput, error)
+	CreateLogStream(ctx context.Context, params *cloudwatchlogs.CreateLogStreamInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogStreamOutput, error)
+	PutRetentionPolicy(ctx context.Context, params *cloudwatchlogs.PutRetentionPolicyInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutRetentionPolicyOutput, error)
+}
+
 // CloudWatchExporter sends generated data to AWS CloudWatch Logs.
 type CloudWatchExporter struct {
 	cfg              cwLogCfg
-	cloudwatchClient *cloudwatchlogs.Client
+	source           RecordSource
+	cloudwatchClient cwLogsClient
+	createdStreams   map[string]bool
+	// nextStream is the index of the stream receiving the next batch
+	nextStream int
 }
 
-// cwLogCfg specifies the CloudWatch log group and stream names.
+// cwLogCfg specifies the CloudWatch log group, stream and delivery settings.
 type cwLogCfg struct {
-	LogGroupName  string `yaml:"log_group"`
-	LogStreamName string `yaml:"log_stream"`
+	LogGroupName    string `yaml:"log_group"`
+	LogStreamName   string `yaml:"log_stream"`
+	StreamCount     int    `yaml:"stream_count"`
+	CreateLogGroup  bool   `yaml:"create_log_group"`
+	CreateLogStream bool   `yaml:"create_log_stream"`
+	RetentionDays   int32  `yaml:"retention_days"`
+	MaxEventSize    int    `yaml:"max_event_size"`
+	Oversize        string `yaml:"oversize"`
+	TimestampSource string `yaml:"timestamp_source"`
 }
 
-func NewCloudWatchLogExporter(ctx context.Context, c *conf.Config) (*CloudWatchExporter, error) {
-	var cfg cwLogCfg
+func newDefaultCWLogCfg() cwLogCfg {
+	return cwLogCfg{
+		StreamCount:     1,
+		MaxEventSize:    cwDefaultMaxEvent,
+		Oversize:        cwOversizeTruncate,
+		TimestampSource: cwTimestampRecord,
+	}
+}
+
+func NewCloudWatchLogExporter(ctx context.Context, c *conf.Config, source RecordSource) (*CloudWatchExporter, error) {
+	cfg := newDefaultCWLogCfg()
 	err := c.Output.Conf.Decode(&cfg)
 	if err != nil {
 		return nil, err
@@ -42,55 +93,272 @@ func NewCloudWatchLogExporter(ctx context.Context, c *conf.Config) (*CloudWatchE
 		cfg.LogStreamName = v
 	}
 
+	// inputs mirroring service logs, ex:- Kubernetes audit logs of EKS, default to the log group & stream of the service
+	if group, stream, ok := source.LogDestination(); ok {
+		if cfg.LogGroupName == "" {
+			cfg.LogGroupName = group
+		}
+		if cfg.LogStreamName == "" {
+			cfg.LogStreamName = stream
+		}
+	}
+
 	if cfg.LogGroupName == "" || cfg.LogStreamName == "" {
 		return nil, fmt.Errorf("cloudwatch log group and/or stream name must be specified for output type %s", c.Output.Type)
 	}
 
+	if err = cfg.validate(); err != nil {
+		return nil, err
+	}
+
 	loadedAwsConfig, err := config.LoadDefaultConfig(ctx, config.WithSharedConfigProfile(c.Profile), config.WithRegion(c.Region))
 	if err != nil {
 		return nil, fmt.Errorf("failed to load default aws config: %w", err)
 	}
 
-	cloudwatchClient := cloudwatchlogs.NewFromConfig(loadedAwsConfig)
+	exporter := newCloudWatchExporter(cfg, source, cloudwatchlogs.NewFromConfig(loadedAwsConfig))
+
+	if cfg.CreateLogGroup {
+		err = exporter.ensureLogGroup(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
 
+	return exporter, nil
+}
+
+func newCloudWatchExporter(cfg cwLogCfg, source RecordSource, client cwLogsClient) *CloudWatchExporter {
 	return &CloudWatchExporter{
 		cfg:              cfg,
-		cloudwatchClient: cloudwatchClient,
-	}, nil
+		source:           source,
+		cloudwatchClient: client,
+		createdStreams:   map[string]bool{},
+	}
 }
 
-func (ce CloudWatchExporter) Send(data *[]byte) error {
-	now := time.Now().UnixMilli()
+func (c *cwLogCfg) validate() error {
+	if c.StreamCount < 1 {
+		c.StreamCount = 1
+	}
+
+	if c.StreamCount > 1 && !strings.Contains(c.LogStreamName, placeholderIndex) {
+		return fmt.Errorf("log_stream must contain the %s placeholder when stream_count is greater than 1", placeholderIndex)
+	}
+
+	maxAllowed := cwMaxBytesPerCall - cwEventOverheadBytes
+	if c.MaxEventSize <= cwEventOverheadBytes || c.MaxEventSize > maxAllowed {
+		return fmt.Errorf("max_event_size must be between %d and %d bytes", cwEventOverheadBytes+1, maxAllowed)
+	}
+
+	c.Oversize = strings.ToLower(c.Oversize)
+	if c.Oversize != cwOversizeTruncate && c.Oversize != cwOversizeReject {
+		return fmt.Errorf("unknown oversize handling: %s, use one of %s or %s", c.Oversize, cwOversizeTruncate, cwOversizeReject)
+	}
+
+	c.TimestampSource = strings.ToLower(c.TimestampSource)
+	if c.TimestampSource != cwTimestampRecord && c.TimestampSource != cwTimestampNow {
+		return fmt.Errorf("unknown timestamp source: %s, use one of %s or %s", c.TimestampSource, cwTimestampRecord, cwTimestampNow)
+	}
+
+	return nil
+}
+
+func (ce *CloudWatchExporter) Send(data *[]byte) error {
+	now := time.Now()
 
 	// Split batched payloads into individual log events.
 	// When batching is enabled, the generator concatenates multiple records
 	// separated by newlines into a single []byte payload.
-	lines := strings.Split(strings.TrimRight(string(*data), "\n"), "\n")
-	logEvents := make([]types.InputLogEvent, 0, len(lines))
-	for _, line := range lines {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimRight(string(*data), "\n"), "\n") {
 		if line == "" {
 			continue
 		}
+
+		line, err := ce.fitEventSize(line)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, line)
+	}
+
+	if len(lines) == 0 {
+		return nil
+	}
+
+	timestamps := ce.eventTimestamps(lines, now)
+	logEvents := make([]types.InputLogEvent, 0, len(lines))
+	for i, line := range lines {
 		logEvents = append(logEvents, types.InputLogEvent{
 			Message:   aws.String(line),
-			Timestamp: aws.Int64(now),
+			Timestamp: aws.Int64(timestamps[i].UnixMilli()),
 		})
 	}
 
-	if len(logEvents) == 0 {
-		return nil
+	// spread batches across configured streams in round-robin order, keeping records of a batch together,
+	// ex:- Lambda START, END and REPORT lines of an invocation
+	stream := ce.streamName(ce.nextStream, now)
+	ce.nextStream = (ce.nextStream + 1) % ce.cfg.StreamCount
+
+	return ce.sendToStream(stream, logEvents)
+}
+
+// eventTimestamps returns the timestamp of each log event. Records without a timestamp of their own, such as Lambda
+// START, END and REPORT lines, take the timestamp of the preceding record, or the following one at the start of the
+// batch, keeping them in order with their neighbours. Batches without any record timestamp are stamped with now.
+func (ce *CloudWatchExporter) eventTimestamps(lines []string, now time.Time) []time.Time {
+	timestamps := make([]time.Time, len(lines))
+	if ce.cfg.TimestampSource != cwTimestampRecord {
+		for i := range timestamps {
+			timestamps[i] = now
+		}
+		return timestamps
 	}
 
-	record := cloudwatchlogs.PutLogEventsInput{
-		LogGroupName:  aws.String(ce.cfg.LogGroupName),
-		LogStreamName: aws.String(ce.cfg.LogStreamName),
-		LogEvents:     logEvents,
+	first, lead := len(lines), now
+	for i, line := range lines {
+		ts, ok := ce.source.RecordTimestamp(line)
+		switch {
+		case ok:
+			timestamps[i] = ts
+			if i < first {
+				first, lead = i, ts
+			}
+		case i > first:
+			timestamps[i] = timestamps[i-1]
+		}
 	}
 
-	_, err := ce.cloudwatchClient.PutLogEvents(context.Background(), &record)
-	if err != nil {
-		return fmt.Errorf("unable to write to cloudwatch log group %s: %w", ce.cfg.LogGroupName, err)
+	for i := range first {
+		timestamps[i] = lead
+	}
+
+	return timestamps
+}
+
+func (ce *CloudWatchExporter) sendToStream(stream string, logEvents []types.InputLogEvent) error {
+	ctx := context.Background()
+
+	if ce.cfg.CreateLogStream && !ce.createdStreams[stream] {
+		err := ce.ensureLogStream(ctx, stream)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, chunk := range chunkLogEvents(logEvents) {
+		record := cloudwatchlogs.PutLogEventsInput{
+			LogGroupName:  aws.String(ce.cfg.LogGroupName),
+			LogStreamName: aws.String(stream),
+			LogEvents:     chunk,
+		}
+
+		_, err := ce.cloudwatchClient.PutLogEvents(ctx, &record)
+		if err != nil {
+			return fmt.Errorf("unable to write to cloudwatch log group %s: %w", ce.cfg.LogGroupName, err)
+		}
 	}
 
 	return nil
 }
+
+// fitEventSize applies configured oversize handling to a single log event.
+func (ce *CloudWatchExporter) fitEventSize(line string) (string, error) {
+	limit := ce.cfg.MaxEventSize - cwEventOverheadBytes
+	if len(line) <= limit {
+		return line, nil
+	}
+
+	if ce.cfg.Oversize == cwOversizeReject {
+		return "", fmt.Errorf("log event of %d bytes exceeds cloudwatch event size limit of %d bytes", len(line), limit)
+	}
+
+	// keep the truncated message valid UTF-8
+	for limit > 0 && !utf8.RuneStart(line[limit]) {
+		limit--
+	}
+
+	return line[:limit], nil
+}
+
+// streamName resolves the log stream name template for the given stream index.
+func (ce *CloudWatchExporter) streamName(index int, now time.Time) string {
+	return strings.NewReplacer(
+		placeholderIndex, strconv.Itoa(index),
+		placeholderDate, now.UTC().Format("2006/01/02"),
+	).Replace(ce.cfg.LogStreamName)
+}
+
+func (ce *CloudWatchExporter) ensureLogGroup(ctx context.Context) error {
+	_, err := ce.cloudwatchClient.CreateLogGroup(ctx, &cloudwatchlogs.CreateLogGroupInput{
+		LogGroupName: aws.String(ce.cfg.LogGroupName),
+	})
+	if err != nil && !isAlreadyExists(err) {
+		return fmt.Errorf("unable to create cloudwatch log group %s: %w", ce.cfg.LogGroupName, err)
+	}
+
+	if ce.cfg.RetentionDays > 0 {
+		_, err = ce.cloudwatchClient.PutRetentionPolicy(ctx, &cloudwatchlogs.PutRetentionPolicyInput{
+			LogGroupName:    aws.String(ce.cfg.LogGroupName),
+			RetentionInDays: aws.Int32(ce.cfg.RetentionDays),
+		})
+		if err != nil {
+			return fmt.Errorf("unable to set retention for cloudwatch log group %s: %w", ce.cfg.LogGroupName, err)
+		}
+	}
+
+	return nil
+}
+
+func (ce *CloudWatchExporter) ensureLogStream(ctx context.Context, stream string) error {
+	_, err := ce.cloudwatchClient.CreateLogStream(ctx, &cloudwatchlogs.CreateLogStreamInput{
+		LogGroupName:  aws.String(ce.cfg.LogGroupName),
+		LogStreamName: aws.String(stream),
+	})
+	if err != nil && !isAlreadyExists(err) {
+		return fmt.Errorf("unable to create cloudwatch log stream %s: %w", stream, err)
+	}
+
+	ce.createdStreams[stream] = true
+	return nil
+}
+
+func isAlreadyExists(err error) bool {
+	var exists *types.ResourceAlreadyExistsException
+	return errors.As(err, &exists)
+}
+
+// chunkLogEvents orders events chronologically (required by PutLogEvents) and splits them
+// into chunks honoring event count, payload size and 24-hour span limits of a single API call.
+func chunkLogEvents(events []types.InputLogEvent) [][]types.InputLogEvent {
+	sort.SliceStable(events, func(i, j int) bool {
+		return *events[i].Timestamp < *events[j].Timestamp
+	})
+
+	var chunks [][]types.InputLogEvent
+	var current []types.InputLogEvent
+	currentBytes := 0
+
+	for _, event := range events {
+		size := len(*event.Message) + cwEventOverheadBytes
+
+		if len(current) > 0 {
+			span := time.Duration(*event.Timestamp-*current[0].Timestamp) * time.Millisecond
+			if len(current) >= cwMaxEventsPerCall || currentBytes+size > cwMaxBytesPerCall || span > cwMaxCallSpan {
+				chunks = append(chunks, current)
+				current = nil
+				currentBytes = 0
+			}
+		}
+
+		current = append(current, event)
+		currentBytes += size
+	}
+
+	if len(current) > 0 {
+		chunks = append(chunks, current)
+	}
+
+	return chunks
+}
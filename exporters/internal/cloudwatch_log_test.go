@@ -0,0 +1,181 @@
+package internal
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCWClient records CloudWatch Logs API calls.
+type fakeCWClient struct {
+	puts    []*cloudwatchlogs.PutLogEventsInput
+	streams []string
+}
+
+func (f *fakeCWClient) PutLogEvents(_ context.Context, params *cloudwatchlogs.PutLogEventsInput, _ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error) {
+	f.puts = append(f.puts, params)
+	return &cloudwatchlogs.PutLogEventsOutput{}, nil
+}
+
+func (f *fakeCWClient) CreateLogGroup(_ context.Context, _ *cloudwatchlogs.CreateLogGroupInput, _ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogGroupOutput, error) {
+	return nil, &types.ResourceAlreadyExistsException{}
+}
+
+func (f *fakeCWClient) CreateLogStream(_ context.Context, params *cloudwatchlogs.CreateLogStreamInput, _ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogStreamOutput, error) {
+	f.streams = append(f.streams, *params.LogStreamName)
+	return &cloudwatchlogs.CreateLogStreamOutput{}, nil
+}
+
+func (f *fakeCWClient) PutRetentionPolicy(_ context.Context, _ *cloudwatchlogs.PutRetentionPolicyInput, _ ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutRetentionPolicyOutput, error) {
+	return &cloudwatchlogs.PutRetentionPolicyOutput{}, nil
+}
+
+// leadingTimestamps stands in for generators in exporter tests, reading the timestamp from the first field of records.
+type leadingTimestamps struct{}
+
+func (leadingTimestamps) RecordTimestamp(record string) (time.Time, bool) {
+	fields := strings.Fields(record)
+	if len(fields) == 0 {
+		return time.Time{}, false
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, fields[0])
+	return t, err == nil
+}
+
+func (leadingTimestamps) LogDestination() (string, string, bool) {
+	return "", "", false
+}
+
+func (leadingTimestamps) BatchKey([]byte) (string, bool) {
+	return "", false
+}
+
+func TestCloudWatchExporter_Send(t *testing.T) {
+	t.Run("Record timestamps, chronological order and stream creation", func(t *testing.T) {
+		client := &fakeCWClient{}
+		cfg := newDefaultCWLogCfg()
+		cfg.LogGroupName = "group"
+		cfg.LogStreamName = "stream-{index}"
+		cfg.StreamCount = 2
+		cfg.CreateLogStream = true
+		require.NoError(t, cfg.validate())
+
+		exporter := newCloudWatchExporter(cfg, leadingTimestamps{}, client)
+
+		data := []byte(strings.Join([]string{
+			"2026-01-01T00:00:03Z third",
+			"2026-01-01T00:00:02Z second",
+			"2026-01-01T00:00:01Z first",
+		}, "\n"))
+		for range 3 {
+			require.NoError(t, exporter.Send(&data))
+		}
+		require.NoError(t, exporter.ensureLogGroup(context.Background()))
+
+		// batches rotate across streams, with all records of a batch in the same stream
+		require.Equal(t, []string{"stream-0", "stream-1"}, client.streams)
+		require.Len(t, client.puts, 3)
+		for i, stream := range []string{"stream-0", "stream-1", "stream-0"} {
+			require.Equal(t, stream, *client.puts[i].LogStreamName)
+			require.Len(t, client.puts[i].LogEvents, 3)
+		}
+
+		first := client.puts[0]
+		require.Equal(t, time.Date(2026, 1, 1, 0, 0, 1, 0, time.UTC).UnixMilli(), *first.LogEvents[0].Timestamp)
+		require.Equal(t, time.Date(2026, 1, 1, 0, 0, 3, 0, time.UTC).UnixMilli(), *first.LogEvents[2].Timestamp)
+	})
+
+	t.Run("Stream count requires the index placeholder", func(t *testing.T) {
+		cfg := newDefaultCWLogCfg()
+		cfg.LogStreamName = "stream"
+		cfg.StreamCount = 2
+		require.ErrorContains(t, cfg.validate(), placeholderIndex)
+	})
+
+	t.Run("Records without timestamps follow their neighbours", func(t *testing.T) {
+		client := &fakeCWClient{}
+		cfg := newDefaultCWLogCfg()
+		cfg.LogGroupName = "/aws/lambda/orders"
+		cfg.LogStreamName = "stream"
+		require.NoError(t, cfg.validate())
+
+		exporter := newCloudWatchExporter(cfg, leadingTimestamps{}, client)
+
+		data := []byte(strings.Join([]string{
+			"START RequestId: 6f7f0961-1e7b-4d3a-9c1f-3d5e8e1b2a10 Version: $LATEST",
+			"2026-01-01T00:00:01.000Z\t6f7f0961-1e7b-4d3a-9c1f-3d5e8e1b2a10\tINFO\tProcessed 12 records",
+			"2026-01-01T00:00:02.000Z\t6f7f0961-1e7b-4d3a-9c1f-3d5e8e1b2a10\tINFO\tDone",
+			"END RequestId: 6f7f0961-1e7b-4d3a-9c1f-3d5e8e1b2a10",
+			"REPORT RequestId: 6f7f0961-1e7b-4d3a-9c1f-3d5e8e1b2a10\tDuration: 1002.50 ms",
+		}, "\n"))
+		require.NoError(t, exporter.Send(&data))
+
+		var messages []string
+		var timestamps []int64
+		for _, event := range client.puts[0].LogEvents {
+			messages = append(messages, strings.Fields(*event.Message)[0])
+			timestamps = append(timestamps, *event.Timestamp)
+		}
+
+		second := time.Date(2026, 1, 1, 0, 0, 1, 0, time.UTC).UnixMilli()
+		require.Equal(t, []string{"START", "2026-01-01T00:00:01.000Z", "2026-01-01T00:00:02.000Z", "END", "REPORT"}, messages)
+		require.Equal(t, []int64{second, second, second + 1000, second + 1000, second + 1000}, timestamps)
+	})
+
+	t.Run("Oversize events", func(t *testing.T) {
+		cfg := newDefaultCWLogCfg()
+		cfg.MaxEventSize = 36
+		require.NoError(t, cfg.validate())
+
+		exporter := newCloudWatchExporter(cfg, leadingTimestamps{}, &fakeCWClient{})
+		line, err := exporter.fitEventSize(strings.Repeat("a", 20))
+		require.NoError(t, err)
+		require.Len(t, line, 10)
+
+		exporter.cfg.Oversize = cwOversizeReject
+		_, err = exporter.fitEventSize(strings.Repeat("a", 20))
+		require.Error(t, err)
+	})
+}
+
+func TestChunkLogEvents(t *testing.T) {
+	t.Run("Split by event count", func(t *testing.T) {
+		events := make([]types.InputLogEvent, cwMaxEventsPerCall+1)
+		for i := range events {
+			events[i] = types.InputLogEvent{Message: aws.String("m"), Timestamp: aws.Int64(int64(i))}
+		}
+
+		chunks := chunkLogEvents(events)
+		require.Len(t, chunks, 2)
+		require.Len(t, chunks[0], cwMaxEventsPerCall)
+	})
+
+	t.Run("Split by payload size", func(t *testing.T) {
+		message := strings.Repeat("a", 300_000)
+		var events []types.InputLogEvent
+		for i := 0; i < 4; i++ {
+			events = append(events, types.InputLogEvent{Message: aws.String(message), Timestamp: aws.Int64(int64(i))})
+		}
+
+		chunks := chunkLogEvents(events)
+		require.Len(t, chunks, 2)
+		require.Len(t, chunks[0], 3)
+	})
+
+	t.Run("Split by 24 hour span", func(t *testing.T) {
+		day := (24 * time.Hour).Milliseconds()
+		events := []types.InputLogEvent{
+			{Message: aws.String("a"), Timestamp: aws.Int64(0)},
+			{Message: aws.String("b"), Timestamp: aws.Int64(day + 1)},
+		}
+
+		require.Len(t, chunkLogEvents(events), 2)
+	})
+}
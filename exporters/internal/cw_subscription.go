@@ -0,0 +1,136 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"data-gen/conf"
+)
+
+const (
+	cwMessageTypeData = "DATA_MESSAGE"
+	cwEventIDLength   = 56
+)
+
+// CWSubscriptionEncoder packages generated records into CloudWatch Logs subscription filter payloads.
+// Each batch becomes one gzip compressed DATA_MESSAGE, matching what CloudWatch Logs delivers to
+// subscription destinations such as Lambda, Kinesis and Firehose.
+// See - https://docs.aws.amazon.com/AmazonCloudWatch/latest/logs/SubscriptionFilters.html
+type CWSubscriptionEncoder struct {
+	cfg    cwSubscriptionCfg
+	source RecordSource
+}
+
+// cwSubscriptionCfg specifies the log group metadata carried by the subscription payload.
+type cwSubscriptionCfg struct {
+	Owner               string   `yaml:"owner"`
+	LogGroup            string   `yaml:"log_group"`
+	LogStream           string   `yaml:"log_stream"`
+	SubscriptionFilters []string `yaml:"subscription_filters"`
+	Compression         string   `yaml:"compression"`
+}
+
+func newDefaultCWSubscriptionCfg() *cwSubscriptionCfg {
+	return &cwSubscriptionCfg{
+		Owner:               "123456789012",
+		LogGroup:            "/aws/data-gen",
+		LogStream:           "data-gen",
+		SubscriptionFilters: []string{"data-gen-filter"},
+		Compression:         "gzip",
+	}
+}
+
+func NewCWSubscriptionEncoder(c *conf.Config, source RecordSource) (*CWSubscriptionEncoder, error) {
+	cfg := newDefaultCWSubscriptionCfg()
+	err := c.Output.Encoding.Conf.Decode(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Compression != "gzip" && cfg.Compression != "none" {
+		return nil, fmt.Errorf("unknown compression for encoding %s: %s", conf.EncodingCWSubscription, cfg.Compression)
+	}
+
+	return &CWSubscriptionEncoder{
+		cfg:    *cfg,
+		source: source,
+	}, nil
+}
+
+// cwSubscriptionPayload is the subscription filter envelope.
+type cwSubscriptionPayload struct {
+	MessageType         string              `json:"messageType"`
+	Owner               string              `json:"owner"`
+	LogGroup            string              `json:"logGroup"`
+	LogStream           string              `json:"logStream"`
+	SubscriptionFilters []string            `json:"subscriptionFilters"`
+	LogEvents           []cwSubscriptionLog `json:"logEvents"`
+}
+
+// cwSubscriptionLog is a single log event within the subscription envelope.
+type cwSubscriptionLog struct {
+	ID        string `json:"id"`
+	Timestamp int64  `json:"timestamp"`
+	Message   string `json:"message"`
+}
+
+func (e *CWSubscriptionEncoder) Encode(data *[]byte) (*[]byte, error) {
+	now := time.Now()
+	lines := strings.Split(strings.TrimRight(string(*data), "\n"), "\n")
+
+	payload := cwSubscriptionPayload{
+		MessageType:         cwMessageTypeData,
+		Owner:               e.cfg.Owner,
+		LogGroup:            e.cfg.LogGroup,
+		LogStream:           e.cfg.LogStream,
+		SubscriptionFilters: e.cfg.SubscriptionFilters,
+		LogEvents:           make([]cwSubscriptionLog, 0, len(lines)),
+	}
+
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+
+		ts := now
+		if recordTs, ok := e.source.RecordTimestamp(line); ok {
+			ts = recordTs
+		}
+
+		payload.LogEvents = append(payload.LogEvents, cwSubscriptionLog{
+			ID:        cwEventID(ts),
+			Timestamp: ts.UnixMilli(),
+			Message:   line,
+		})
+	}
+
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal subscription payload: %w", err)
+	}
+
+	if e.cfg.Compression == "none" {
+		return &encoded, nil
+	}
+
+	compressed, err := gzipCompress(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compress subscription payload: %w", err)
+	}
+
+	return &compressed, nil
+}
+
+// cwEventID mimics the 56 digit CloudWatch event ID, which is prefixed by the event timestamp.
+func cwEventID(ts time.Time) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%d", ts.UnixMilli()))
+	for sb.Len() < cwEventIDLength {
+		sb.WriteByte(byte('0' + rand.IntN(10)))
+	}
+
+	return sb.String()
+}
@@ -3,9 +3,12 @@ package internal
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math/rand/v2"
 	"os"
+	"strings"
 
 	"data-gen/conf"
 
@@ -13,17 +16,67 @@ import (
 	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azeventhubs/v2"
 )
 
+const (
+	partitionKeyStatic = "static"
+	partitionKeyRandom = "random"
+	partitionKeyField  = "field"
+
+	defaultRandomPartitionKeys = 8
+)
+
 // EventHubExporter sends generated data to Azure Event Hubs.
 type EventHubExporter struct {
 	cfg      eventHubCfg
 	producer *azeventhubs.ProducerClient
 }
 
-// eventHubCfg specifies the Event Hub namespace and name.
+// eventHubCfg specifies the Event Hub namespace, name and event routing metadata.
 type eventHubCfg struct {
-	Namespace        string `yaml:"namespace"`
-	EventHubName     string `yaml:"event_hub_name"`
-	ConnectionString string `yaml:"connection_string"`
+	Namespace        string            `yaml:"namespace"`
+	EventHubName     string            `yaml:"event_hub_name"`
+	ConnectionString string            `yaml:"connection_string"`
+	PartitionID      string            `yaml:"partition_id"`
+	PartitionKey     partitionKeyCfg   `yaml:"partition_key"`
+	Properties       map[string]string `yaml:"properties"`
+	ContentType      string            `yaml:"content_type"`
+}
+
+// partitionKeyCfg defines how the partition key of an event is derived.
+// - static: Value is used for all events
+// - random: a key is picked from a pool of RandomKeys keys for every event
+// - field: key is read from the record field at dotted path Field (ex:- resourceId, properties.category).
+// {"records": [...]} envelopes carrying records of different keys are split into an envelope per key.
+type partitionKeyCfg struct {
+	Strategy   string `yaml:"strategy"`
+	Value      string `yaml:"value"`
+	Field      string `yaml:"field"`
+	RandomKeys int    `yaml:"random_keys"`
+}
+
+func (c *eventHubCfg) validate() error {
+	if c.PartitionID != "" && c.PartitionKey.Strategy != "" {
+		return fmt.Errorf("event hub partition_id and partition_key are mutually exclusive")
+	}
+
+	switch c.PartitionKey.Strategy {
+	case "":
+	case partitionKeyStatic:
+		if c.PartitionKey.Value == "" {
+			return fmt.Errorf("event hub partition key strategy %s requires a value", partitionKeyStatic)
+		}
+	case partitionKeyRandom:
+		if c.PartitionKey.RandomKeys <= 0 {
+			c.PartitionKey.RandomKeys = defaultRandomPartitionKeys
+		}
+	case partitionKeyField:
+		if c.PartitionKey.Field == "" {
+			return fmt.Errorf("event hub partition key strategy %s requires a field", partitionKeyField)
+		}
+	default:
+		return fmt.Errorf("unknown event hub partition key strategy: %s", c.PartitionKey.Strategy)
+	}
+
+	return nil
 }
 
 func NewEventHubExporter(ctx context.Context, c *conf.Config) (*EventHubExporter, error) {
@@ -44,6 +97,10 @@ func NewEventHubExporter(ctx context.Context, c *conf.Config) (*EventHubExporter
 		cfg.ConnectionString = v
 	}
 
+	if err = cfg.validate(); err != nil {
+		return nil, err
+	}
+
 	var producer *azeventhubs.ProducerClient
 
 	// Support both connection string and Azure AD authentication
@@ -78,35 +135,155 @@ func NewEventHubExporter(ctx context.Context, c *conf.Config) (*EventHubExporter
 // Send delivers data to Event Hubs. The generator accumulates multiple
 // {"records": [...]} objects separated by newlines (NDJSON). Each line is
 // sent as its own EventData so that no individual message exceeds the 1 MB
-// Event Hubs limit. Lines are packed into batches; when a batch is full the
-// SDK returns ErrEventDataTooLarge, at which point the batch is flushed and a
-// new one is started for the remaining lines.
+// Event Hubs limit. Lines are grouped by partition key (batches share a single
+// key) and packed into batches; when a batch is full the SDK returns
+// ErrEventDataTooLarge, at which point the batch is flushed and a new one is
+// started for the remaining lines.
 func (e *EventHubExporter) Send(data *[]byte) error {
 	ctx := context.Background()
 	lines := bytes.Split(bytes.TrimRight(*data, "\n"), []byte("\n"))
 
-	batch, err := e.producer.NewEventDataBatch(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create event batch: %w", err)
+	keys, groups := e.groupByPartitionKey(lines)
+	for _, key := range keys {
+		options := e.batchOptions(key)
+
+		batch, err := e.producer.NewEventDataBatch(ctx, options)
+		if err != nil {
+			return fmt.Errorf("failed to create event batch: %w", err)
+		}
+
+		for _, line := range groups[key] {
+			batch, err = e.addEventToBatch(ctx, batch, options, line)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err = e.flushBatch(ctx, batch); err != nil {
+			return err
+		}
 	}
 
+	return nil
+}
+
+// groupByPartitionKey groups lines by their partition key while preserving key order of appearance.
+func (e *EventHubExporter) groupByPartitionKey(lines [][]byte) ([]string, map[string][][]byte) {
+	var keys []string
+	groups := map[string][][]byte{}
+
 	for _, line := range lines {
 		if len(line) == 0 {
 			continue
 		}
 
-		batch, err = e.addEventToBatch(ctx, batch, line)
+		events := [][]byte{line}
+		if e.cfg.PartitionKey.Strategy == partitionKeyField {
+			events = splitEnvelope(line, e.cfg.PartitionKey.Field)
+		}
+
+		for _, event := range events {
+			key := e.partitionKeyFor(event)
+			if _, ok := groups[key]; !ok {
+				keys = append(keys, key)
+			}
+			groups[key] = append(groups[key], event)
+		}
+	}
+
+	return keys, groups
+}
+
+// recordsEnvelope is the {"records": [...]} envelope of Azure resource logs.
+type recordsEnvelope struct {
+	Records []json.RawMessage `json:"records"`
+}
+
+// splitEnvelope splits a {"records": [...]} envelope into envelopes of records sharing the value of the field at path,
+// preserving record order. Lines other than envelopes, or envelopes of a single value, are returned as is.
+func splitEnvelope(line []byte, path string) [][]byte {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(line, &fields); err != nil || len(fields) != 1 {
+		return [][]byte{line}
+	}
+
+	var envelope recordsEnvelope
+	if err := json.Unmarshal(line, &envelope); err != nil || len(envelope.Records) < 2 {
+		return [][]byte{line}
+	}
+
+	var keys []string
+	groups := map[string][]json.RawMessage{}
+	for _, record := range envelope.Records {
+		key := recordField(record, path)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], record)
+	}
+
+	if len(keys) == 1 {
+		return [][]byte{line}
+	}
+
+	split := make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		b, err := json.Marshal(recordsEnvelope{Records: groups[key]})
 		if err != nil {
-			return err
+			return [][]byte{line}
 		}
+		split = append(split, b)
 	}
 
-	return e.flushBatch(ctx, batch)
+	return split
+}
+
+// partitionKeyFor derives the partition key of a line. An empty key means no partition key.
+func (e *EventHubExporter) partitionKeyFor(line []byte) string {
+	switch e.cfg.PartitionKey.Strategy {
+	case partitionKeyStatic:
+		return e.cfg.PartitionKey.Value
+	case partitionKeyRandom:
+		return fmt.Sprintf("key-%d", rand.IntN(e.cfg.PartitionKey.RandomKeys))
+	case partitionKeyField:
+		return recordField(line, e.cfg.PartitionKey.Field)
+	default:
+		return ""
+	}
+}
+
+func (e *EventHubExporter) batchOptions(key string) *azeventhubs.EventDataBatchOptions {
+	if e.cfg.PartitionID != "" {
+		return &azeventhubs.EventDataBatchOptions{PartitionID: &e.cfg.PartitionID}
+	}
+
+	if key != "" {
+		return &azeventhubs.EventDataBatchOptions{PartitionKey: &key}
+	}
+
+	return nil
+}
+
+func (e *EventHubExporter) eventFor(line []byte) *azeventhubs.EventData {
+	event := &azeventhubs.EventData{Body: line}
+
+	if e.cfg.ContentType != "" {
+		event.ContentType = &e.cfg.ContentType
+	}
+
+	if len(e.cfg.Properties) > 0 {
+		event.Properties = make(map[string]any, len(e.cfg.Properties))
+		for k, v := range e.cfg.Properties {
+			event.Properties[k] = v
+		}
+	}
+
+	return event
 }
 
 // addEventToBatch adds a line to the batch, flushing and creating a new batch if needed.
-func (e *EventHubExporter) addEventToBatch(ctx context.Context, batch *azeventhubs.EventDataBatch, line []byte) (*azeventhubs.EventDataBatch, error) {
-	err := batch.AddEventData(&azeventhubs.EventData{Body: line}, nil)
+func (e *EventHubExporter) addEventToBatch(ctx context.Context, batch *azeventhubs.EventDataBatch, options *azeventhubs.EventDataBatchOptions, line []byte) (*azeventhubs.EventDataBatch, error) {
+	err := batch.AddEventData(e.eventFor(line), nil)
 	if err == nil {
 		return batch, nil
 	}
@@ -125,13 +302,13 @@ func (e *EventHubExporter) addEventToBatch(ctx context.Context, batch *azeventhu
 		return nil, fmt.Errorf("failed to send event batch to %s: %w", e.cfg.EventHubName, err)
 	}
 
-	newBatch, err := e.producer.NewEventDataBatch(ctx, nil)
+	newBatch, err := e.producer.NewEventDataBatch(ctx, options)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create event batch: %w", err)
 	}
 
 	// Retry the line in the fresh batch
-	if err = newBatch.AddEventData(&azeventhubs.EventData{Body: line}, nil); err != nil {
+	if err = newBatch.AddEventData(e.eventFor(line), nil); err != nil {
 		return nil, fmt.Errorf("failed to add event to fresh batch: %w", err)
 	}
 
@@ -150,3 +327,40 @@ func (e *EventHubExporter) flushBatch(ctx context.Context, batch *azeventhubs.Ev
 
 	return nil
 }
+
+// recordField reads a string value from a JSON record at the given dotted path.
+// Azure {"records": [...]} envelopes are looked up through their first record, all records of envelopes
+// split by splitEnvelope share the value.
+// Returns an empty string if the field is not found.
+func recordField(line []byte, path string) string {
+	var record map[string]any
+	if err := json.Unmarshal(line, &record); err != nil {
+		return ""
+	}
+
+	if _, ok := record[path]; !ok {
+		if records, ok := record["records"].([]any); ok && len(records) > 0 {
+			if first, ok := records[0].(map[string]any); ok {
+				record = first
+			}
+		}
+	}
+
+	var current any = record
+	for _, part := range strings.Split(path, ".") {
+		obj, ok := current.(map[string]any)
+		if !ok {
+			return ""
+		}
+		current = obj[part]
+	}
+
+	switch v := current.(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,77 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventHubExporter_PartitionKeys(t *testing.T) {
+	lines := [][]byte{
+		[]byte(`{"records":[{"resourceId":"/subscriptions/a","category":"Administrative"}]}`),
+		[]byte(`{"records":[{"resourceId":"/subscriptions/b","category":"Policy"}]}`),
+		[]byte(`{"records":[{"resourceId":"/subscriptions/a","category":"Alert"}]}`),
+	}
+
+	t.Run("Field strategy groups by record field", func(t *testing.T) {
+		exporter := EventHubExporter{cfg: eventHubCfg{PartitionKey: partitionKeyCfg{Strategy: partitionKeyField, Field: "resourceId"}}}
+		require.NoError(t, exporter.cfg.validate())
+
+		keys, groups := exporter.groupByPartitionKey(lines)
+		require.Equal(t, []string{"/subscriptions/a", "/subscriptions/b"}, keys)
+		require.Len(t, groups["/subscriptions/a"], 2)
+		require.Equal(t, "/subscriptions/a", *exporter.batchOptions(keys[0]).PartitionKey)
+	})
+
+	t.Run("Field strategy splits envelopes of several records by record field", func(t *testing.T) {
+		exporter := EventHubExporter{cfg: eventHubCfg{PartitionKey: partitionKeyCfg{Strategy: partitionKeyField, Field: "resourceId"}}}
+		require.NoError(t, exporter.cfg.validate())
+
+		keys, groups := exporter.groupByPartitionKey([][]byte{
+			[]byte(`{"records":[{"resourceId":"/subscriptions/a","category":"Administrative"},{"resourceId":"/subscriptions/b","category":"Policy"},{"resourceId":"/subscriptions/a","category":"Alert"}]}`),
+			[]byte(`{"records":[{"resourceId":"/subscriptions/b","category":"Policy"},{"resourceId":"/subscriptions/b","category":"Alert"}]}`),
+		})
+		require.Equal(t, []string{"/subscriptions/a", "/subscriptions/b"}, keys)
+		require.Equal(t, [][]byte{
+			[]byte(`{"records":[{"resourceId":"/subscriptions/a","category":"Administrative"},{"resourceId":"/subscriptions/a","category":"Alert"}]}`),
+		}, groups["/subscriptions/a"])
+		require.Equal(t, [][]byte{
+			[]byte(`{"records":[{"resourceId":"/subscriptions/b","category":"Policy"}]}`),
+			[]byte(`{"records":[{"resourceId":"/subscriptions/b","category":"Policy"},{"resourceId":"/subscriptions/b","category":"Alert"}]}`),
+		}, groups["/subscriptions/b"])
+	})
+
+	t.Run("Static strategy uses a single key", func(t *testing.T) {
+		exporter := EventHubExporter{cfg: eventHubCfg{PartitionKey: partitionKeyCfg{Strategy: partitionKeyStatic, Value: "hot"}}}
+		require.NoError(t, exporter.cfg.validate())
+
+		keys, _ := exporter.groupByPartitionKey(lines)
+		require.Equal(t, []string{"hot"}, keys)
+	})
+
+	t.Run("Partition ID takes no key", func(t *testing.T) {
+		exporter := EventHubExporter{cfg: eventHubCfg{PartitionID: "3"}}
+		require.NoError(t, exporter.cfg.validate())
+
+		options := exporter.batchOptions("")
+		require.Equal(t, "3", *options.PartitionID)
+		require.Nil(t, options.PartitionKey)
+	})
+
+	t.Run("Invalid combinations", func(t *testing.T) {
+		cfg := eventHubCfg{PartitionID: "3", PartitionKey: partitionKeyCfg{Strategy: partitionKeyRandom}}
+		require.Error(t, cfg.validate())
+
+		cfg = eventHubCfg{PartitionKey: partitionKeyCfg{Strategy: partitionKeyField}}
+		require.Error(t, cfg.validate())
+	})
+}
+
+func TestRecordField(t *testing.T) {
+	line := []byte(`{"time":"2026-01-01T00:00:00Z","properties":{"category":"Policy","count":3}}`)
+
+	require.Equal(t, "Policy", recordField(line, "properties.category"))
+	require.Equal(t, "3", recordField(line, "properties.count"))
+	require.Equal(t, "", recordField(line, "missing.field"))
+	require.Equal(t, "", recordField([]byte("not json"), "time"))
+}
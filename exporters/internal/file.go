@@ -3,33 +3,68 @@ package internal
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"data-gen/conf"
 )
 
-const defaultLocation = "./out"
+const (
+	defaultLocation        = "./out"
+	defaultTimestampFormat = "20060102T150405"
 
-// FileExporter writes generated data to local files with incremental naming.
+	fileModeBatch  = "batch"
+	fileModeAppend = "append"
+	fileModeRotate = "rotate"
+
+	placeholderLocation  = "{location}"
+	placeholderSeq       = "{seq}"
+	placeholderTimestamp = "{timestamp}"
+)
+
+// FileExporter writes generated data to local files.
+// Supported modes are,
+// - batch: every batch is written to its own file (default)
+// - append: all batches are appended to a single file
+// - rotate: batches are appended to the current file until a rotation condition is met
 type FileExporter struct {
-	cfg    *fileCfg
-	entry  int
-	shChan chan struct{}
+	cfg      *fileCfg
+	interval time.Duration
+
+	seq     int
+	current *os.File
+	opened  time.Time
+	written int64
+	batches int
 }
 
-// fileCfg specifies the file output location.
+// fileCfg specifies the file output location, naming and rotation behavior.
 type fileCfg struct {
-	Location string `yaml:"location"`
+	Location        string `yaml:"location"`
+	Mode            string `yaml:"mode"`
+	NameTemplate    string `yaml:"name_template"`
+	TimestampFormat string `yaml:"timestamp_format"`
+	RotateSize      int64  `yaml:"rotate_size"`
+	RotateInterval  string `yaml:"rotate_interval"`
+	RotateBatches   int    `yaml:"rotate_batches"`
+	CreateDirs      bool   `yaml:"create_dirs"`
+	Fsync           bool   `yaml:"fsync"`
 }
 
 func newDefaultFileCfg() *fileCfg {
 	return &fileCfg{
-		Location: defaultLocation,
+		Location:        defaultLocation,
+		Mode:            fileModeBatch,
+		TimestampFormat: defaultTimestampFormat,
+		CreateDirs:      true,
 	}
 }
 
 func NewFileExporter(config *conf.Config) (*FileExporter, error) {
 	cfg := newDefaultFileCfg()
-	err := config.Output.Conf.Decode(&cfg)
+	err := config.Output.Conf.Decode(cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -38,23 +73,166 @@ func NewFileExporter(config *conf.Config) (*FileExporter, error) {
 	if v := os.Getenv(conf.EnvOutLocation); v != "" {
 		cfg.Location = v
 	}
+	if v := os.Getenv(conf.EnvOutFileMode); v != "" {
+		cfg.Mode = v
+	}
+
+	cfg.Mode = strings.ToLower(cfg.Mode)
+	switch cfg.Mode {
+	case fileModeBatch, fileModeAppend:
+	case fileModeRotate:
+		if cfg.RotateSize <= 0 && cfg.RotateInterval == "" && cfg.RotateBatches <= 0 {
+			return nil, fmt.Errorf("file mode %s requires at least one of rotate_size, rotate_interval or rotate_batches", fileModeRotate)
+		}
+	default:
+		return nil, fmt.Errorf("unknown file mode: %s", cfg.Mode)
+	}
+
+	var interval time.Duration
+	if cfg.RotateInterval != "" {
+		interval, err = time.ParseDuration(cfg.RotateInterval)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse rotate_interval: %w", err)
+		}
+	}
+
+	if cfg.NameTemplate == "" {
+		cfg.NameTemplate = defaultNameTemplate(cfg.Mode)
+	}
+
+	// batch & rotate modes open a new file for every batch or rotation, which must not append to the previous file.
+	// Timestamps alone are not unique, ex:- batches emitted within the same second of the default timestamp format
+	if cfg.Mode != fileModeAppend && !strings.Contains(cfg.NameTemplate, placeholderSeq) {
+		return nil, fmt.Errorf("name_template of file mode %s requires %s placeholder", cfg.Mode, placeholderSeq)
+	}
 
 	return &FileExporter{
-		cfg:    cfg,
-		shChan: make(chan struct{}),
+		cfg:      cfg,
+		interval: interval,
 	}, nil
 }
 
 func (f *FileExporter) Send(data *[]byte) error {
-	file, err := os.OpenFile(fmt.Sprintf("%s_%d", f.cfg.Location, f.entry), os.O_APPEND|os.O_RDWR|os.O_CREATE, 0666)
+	if f.current != nil && f.shouldRotate(int64(len(*data))) {
+		if err := f.closeCurrent(); err != nil {
+			return err
+		}
+	}
+
+	if f.current == nil {
+		if err := f.openNext(); err != nil {
+			return err
+		}
+	}
+
+	n, err := f.current.Write(*data)
+	f.written += int64(n)
+	f.batches++
+	if err != nil {
+		return fmt.Errorf("unable to write to file %s: %w", f.current.Name(), err)
+	}
+
+	// batch mode writes exactly one batch per file
+	if f.cfg.Mode == fileModeBatch {
+		return f.closeCurrent()
+	}
+
+	return nil
+}
+
+// Close releases the currently open file, if any.
+func (f *FileExporter) Close() error {
+	if f.current == nil {
+		return nil
+	}
+
+	return f.closeCurrent()
+}
+
+// shouldRotate checks rotation conditions against the currently open file.
+// A file is never rotated while it is still empty.
+func (f *FileExporter) shouldRotate(incoming int64) bool {
+	if f.cfg.Mode != fileModeRotate || f.batches == 0 {
+		return false
+	}
+
+	if f.cfg.RotateSize > 0 && f.written+incoming > f.cfg.RotateSize {
+		return true
+	}
+
+	if f.interval > 0 && time.Since(f.opened) >= f.interval {
+		return true
+	}
+
+	if f.cfg.RotateBatches > 0 && f.batches >= f.cfg.RotateBatches {
+		return true
+	}
+
+	return false
+}
+
+func (f *FileExporter) openNext() error {
+	name := resolveFileName(f.cfg.NameTemplate, f.cfg.Location, f.seq, time.Now().Format(f.cfg.TimestampFormat))
+
+	if f.cfg.CreateDirs {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			return fmt.Errorf("unable to create directory for file %s: %w", name, err)
+		}
+	}
+
+	file, err := os.OpenFile(name, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0666)
 	if err != nil {
-		return fmt.Errorf("unable to open file %s: %w", f.cfg.Location, err)
+		return fmt.Errorf("unable to open file %s: %w", name, err)
 	}
-	_, err = file.Write(*data)
+
+	stat, err := file.Stat()
 	if err != nil {
-		return fmt.Errorf("unable to write to file %s: %w", f.cfg.Location, err)
+		_ = file.Close()
+		return fmt.Errorf("unable to stat file %s: %w", name, err)
 	}
 
-	f.entry++
+	f.current = file
+	f.opened = time.Now()
+	f.written = stat.Size()
+	f.batches = 0
+	f.seq++
+
 	return nil
 }
+
+func (f *FileExporter) closeCurrent() error {
+	file := f.current
+	f.current = nil
+
+	if f.cfg.Fsync {
+		if err := file.Sync(); err != nil {
+			_ = file.Close()
+			return fmt.Errorf("unable to sync file %s: %w", file.Name(), err)
+		}
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("unable to close file %s: %w", file.Name(), err)
+	}
+
+	return nil
+}
+
+// defaultNameTemplate keeps the historic `<location>_<n>` naming for batch & rotate modes,
+// while append mode writes directly to the configured location.
+func defaultNameTemplate(mode string) string {
+	if mode == fileModeAppend {
+		return placeholderLocation
+	}
+
+	return placeholderLocation + "_" + placeholderSeq
+}
+
+// resolveFileName derives the file name from the template by replacing supported placeholders.
+func resolveFileName(template string, location string, seq int, timestamp string) string {
+	return strings.NewReplacer(
+		placeholderLocation, location,
+		placeholderSeq, strconv.Itoa(seq),
+		placeholderTimestamp, timestamp,
+	).Replace(template)
+}
@@ -0,0 +1,146 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"data-gen/conf"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestFileExporter(t *testing.T, outputCfg string) *FileExporter {
+	cfg, err := conf.NewConfig([]byte(fmt.Sprintf("output:\n  type: FILE\n  config:\n%s", outputCfg)))
+	require.NoError(t, err)
+
+	exporter, err := NewFileExporter(cfg)
+	require.NoError(t, err)
+
+	return exporter
+}
+
+func TestFileExporter_BatchMode(t *testing.T) {
+	location := filepath.Join(t.TempDir(), "out")
+	exporter := newTestFileExporter(t, fmt.Sprintf("    location: %s\n", location))
+
+	for _, d := range []string{"first\n", "second\n"} {
+		data := []byte(d)
+		require.NoError(t, exporter.Send(&data))
+	}
+	require.NoError(t, exporter.Close())
+
+	first, err := os.ReadFile(location + "_0")
+	require.NoError(t, err)
+	require.Equal(t, "first\n", string(first))
+
+	second, err := os.ReadFile(location + "_1")
+	require.NoError(t, err)
+	require.Equal(t, "second\n", string(second))
+}
+
+func TestFileExporter_AppendMode(t *testing.T) {
+	location := filepath.Join(t.TempDir(), "nested", "dir", "app.log")
+	exporter := newTestFileExporter(t, fmt.Sprintf("    location: %s\n    mode: append\n    fsync: true\n", location))
+
+	for _, d := range []string{"first\n", "second\n"} {
+		data := []byte(d)
+		require.NoError(t, exporter.Send(&data))
+	}
+	require.NoError(t, exporter.Close())
+
+	content, err := os.ReadFile(location)
+	require.NoError(t, err)
+	require.Equal(t, "first\nsecond\n", string(content))
+}
+
+func TestFileExporter_RotateMode(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("Rotate by batch count", func(t *testing.T) {
+		exporter := newTestFileExporter(t, fmt.Sprintf(
+			"    mode: rotate\n    rotate_batches: 2\n    name_template: %s/batches-{seq}.log\n", dir))
+
+		for i := 0; i < 5; i++ {
+			data := []byte(fmt.Sprintf("line-%d\n", i))
+			require.NoError(t, exporter.Send(&data))
+		}
+		require.NoError(t, exporter.Close())
+
+		content, err := os.ReadFile(filepath.Join(dir, "batches-0.log"))
+		require.NoError(t, err)
+		require.Equal(t, "line-0\nline-1\n", string(content))
+
+		content, err = os.ReadFile(filepath.Join(dir, "batches-2.log"))
+		require.NoError(t, err)
+		require.Equal(t, "line-4\n", string(content))
+	})
+
+	t.Run("Rotate by size", func(t *testing.T) {
+		exporter := newTestFileExporter(t, fmt.Sprintf(
+			"    mode: rotate\n    rotate_size: 11\n    name_template: %s/size-{seq}.log\n", dir))
+
+		for _, d := range []string{"12345\n", "1234\n", "123\n"} {
+			data := []byte(d)
+			require.NoError(t, exporter.Send(&data))
+		}
+		require.NoError(t, exporter.Close())
+
+		content, err := os.ReadFile(filepath.Join(dir, "size-0.log"))
+		require.NoError(t, err)
+		require.Equal(t, "12345\n1234\n", string(content))
+
+		content, err = os.ReadFile(filepath.Join(dir, "size-1.log"))
+		require.NoError(t, err)
+		require.Equal(t, "123\n", string(content))
+	})
+
+	t.Run("Rotate requires a condition", func(t *testing.T) {
+		cfg, err := conf.NewConfig([]byte("output:\n  type: FILE\n  config:\n    mode: rotate\n"))
+		require.NoError(t, err)
+
+		_, err = NewFileExporter(cfg)
+		require.Error(t, err)
+	})
+}
+
+func TestFileExporter_NameTemplate(t *testing.T) {
+	for _, mode := range []string{fileModeBatch, fileModeRotate} {
+		for _, template := range []string{"./logs/app.log", "./logs/app-{timestamp}.log"} {
+			cfg, err := conf.NewConfig([]byte(fmt.Sprintf(
+				"output:\n  type: FILE\n  config:\n    mode: %s\n    rotate_batches: 2\n    name_template: %s\n", mode, template)))
+			require.NoError(t, err)
+
+			_, err = NewFileExporter(cfg)
+			require.ErrorContains(t, err, "requires {seq} placeholder", mode)
+		}
+	}
+
+	// batches written within the same second resolve to distinct files
+	dir := t.TempDir()
+	batches := newTestFileExporter(t, fmt.Sprintf("    name_template: %s/app-{timestamp}-{seq}.log\n", dir))
+	for _, d := range []string{"first\n", "second\n"} {
+		data := []byte(d)
+		require.NoError(t, batches.Send(&data))
+	}
+	require.NoError(t, batches.Close())
+
+	files, err := filepath.Glob(filepath.Join(dir, "app-*.log"))
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		require.NoError(t, err)
+		require.Contains(t, []string{"first\n", "second\n"}, string(content))
+	}
+
+	// append mode writes to a single file
+	exporter := newTestFileExporter(t, "    mode: append\n    name_template: ./logs/app.log\n")
+	require.Equal(t, "./logs/app.log", exporter.cfg.NameTemplate)
+}
+
+func TestResolveFileName(t *testing.T) {
+	name := resolveFileName("{location}/app-{timestamp}-{seq}.log", "./logs", 3, "20260101T000000")
+	require.Equal(t, "./logs/app-20260101T000000-3.log", name)
+}
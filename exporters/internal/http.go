@@ -0,0 +1,101 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"data-gen/conf"
+)
+
+const (
+	httpDefaultMethod      = http.MethodPost
+	httpDefaultContentType = "application/octet-stream"
+	httpDefaultTimeout     = "10s"
+)
+
+// HTTPExporter sends each batch as the body of an HTTP request, ex:- to a collector or a function URL.
+type HTTPExporter struct {
+	cfg     httpCfg
+	timeout time.Duration
+	client  *http.Client
+}
+
+// httpCfg specifies the endpoint and request details.
+type httpCfg struct {
+	URL         string            `yaml:"url"`
+	Method      string            `yaml:"method"`
+	ContentType string            `yaml:"content_type"`
+	Headers     map[string]string `yaml:"headers"`
+	Timeout     string            `yaml:"timeout"`
+}
+
+func newDefaultHTTPCfg() *httpCfg {
+	return &httpCfg{
+		Method:      httpDefaultMethod,
+		ContentType: httpDefaultContentType,
+		Timeout:     httpDefaultTimeout,
+	}
+}
+
+func NewHTTPExporter(c *conf.Config) (*HTTPExporter, error) {
+	cfg := newDefaultHTTPCfg()
+	err := c.Output.Conf.Decode(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// load env variable overrides if any
+	if v := os.Getenv(conf.EnvOutURL); v != "" {
+		cfg.URL = v
+	}
+
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("url must be specified for output type %s", c.Output.Type)
+	}
+
+	timeout, err := time.ParseDuration(cfg.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse http timeout: %w", err)
+	}
+
+	return &HTTPExporter{
+		cfg:     *cfg,
+		timeout: timeout,
+		client:  &http.Client{},
+	}, nil
+}
+
+func (h *HTTPExporter) Send(data *[]byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, h.cfg.Method, h.cfg.URL, bytes.NewReader(*data))
+	if err != nil {
+		return fmt.Errorf("failed to create http request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", h.cfg.ContentType)
+	for k, v := range h.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to send to %s: %w", h.cfg.URL, err)
+	}
+	defer resp.Body.Close()
+
+	// drain the body, allowing the connection to be reused
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response from %s: %s", h.cfg.URL, resp.Status)
+	}
+
+	return nil
+}
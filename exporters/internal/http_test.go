@@ -0,0 +1,49 @@
+package internal
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"data-gen/conf"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestHTTPExporter_Send(t *testing.T) {
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, r.Method+" "+r.Header.Get("Content-Type")+" "+r.Header.Get("X-Api-Key")+" "+string(body))
+
+		if string(body) == "rejected" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	c := &conf.Config{Output: conf.OutputConfig{Type: conf.OutputHTTP}}
+	require.NoError(t, yaml.Unmarshal([]byte(`
+url: `+server.URL+`
+content_type: application/json
+headers:
+  X-Api-Key: secret
+`), &c.Output.Conf))
+
+	exporter, err := NewHTTPExporter(c)
+	require.NoError(t, err)
+
+	data := []byte(`{"records":[]}`)
+	require.NoError(t, exporter.Send(&data))
+	require.Equal(t, []string{`POST application/json secret {"records":[]}`}, received)
+
+	data = []byte("rejected")
+	require.ErrorContains(t, exporter.Send(&data), "400 Bad Request")
+}
+
+func TestNewHTTPExporter(t *testing.T) {
+	_, err := NewHTTPExporter(&conf.Config{Output: conf.OutputConfig{Type: conf.OutputHTTP}})
+	require.ErrorContains(t, err, "url must be specified")
+}
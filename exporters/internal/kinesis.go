@@ -0,0 +1,78 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"data-gen/conf"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/google/uuid"
+)
+
+// kinesisClient is the subset of Kinesis Data Streams API used by the exporter.
+type kinesisClient interface {
+	PutRecord(ctx context.Context, params *kinesis.PutRecordInput, optFns ...func(*kinesis.Options)) (*kinesis.PutRecordOutput, error)
+}
+
+// KinesisExporter sends generated data to an AWS Kinesis data stream, one record per batch.
+type KinesisExporter struct {
+	cfg    kinesisCfg
+	client kinesisClient
+}
+
+// kinesisCfg specifies the data stream and the partition key of records.
+// Records are spread across shards with a random partition key unless a fixed key is configured.
+type kinesisCfg struct {
+	StreamName   string `yaml:"stream_name"`
+	PartitionKey string `yaml:"partition_key"`
+}
+
+func NewKinesisExporter(ctx context.Context, c *conf.Config) (*KinesisExporter, error) {
+	var cfg kinesisCfg
+	err := c.Output.Conf.Decode(&cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// load env variable overrides if any
+	if v := os.Getenv(conf.EnvOutStreamName); v != "" {
+		cfg.StreamName = v
+	}
+
+	if cfg.StreamName == "" {
+		return nil, fmt.Errorf("stream_name must be specified for output type %s", c.Output.Type)
+	}
+
+	loadedAwsConfig, err := config.LoadDefaultConfig(ctx, config.WithSharedConfigProfile(c.Profile), config.WithRegion(c.Region))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load default aws config: %w", err)
+	}
+
+	return &KinesisExporter{
+		cfg:    cfg,
+		client: kinesis.NewFromConfig(loadedAwsConfig),
+	}, nil
+}
+
+func (k *KinesisExporter) Send(data *[]byte) error {
+	partitionKey := k.cfg.PartitionKey
+	if partitionKey == "" {
+		partitionKey = uuid.NewString()
+	}
+
+	input := kinesis.PutRecordInput{
+		StreamName:   &k.cfg.StreamName,
+		PartitionKey: &partitionKey,
+		Data:         *data,
+	}
+
+	_, err := k.client.PutRecord(context.Background(), &input)
+	if err != nil {
+		return fmt.Errorf("unable to write to kinesis stream %s: %w", k.cfg.StreamName, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeKinesisClient records Kinesis PutRecord calls.
+type fakeKinesisClient struct {
+	puts []*kinesis.PutRecordInput
+}
+
+func (f *fakeKinesisClient) PutRecord(_ context.Context, params *kinesis.PutRecordInput, _ ...func(*kinesis.Options)) (*kinesis.PutRecordOutput, error) {
+	f.puts = append(f.puts, params)
+	return &kinesis.PutRecordOutput{}, nil
+}
+
+func TestKinesisExporter_Send(t *testing.T) {
+	t.Run("Random partition key per record", func(t *testing.T) {
+		client := &fakeKinesisClient{}
+		exporter := &KinesisExporter{cfg: kinesisCfg{StreamName: "stream"}, client: client}
+
+		for _, d := range []string{"first", "second"} {
+			data := []byte(d)
+			require.NoError(t, exporter.Send(&data))
+		}
+
+		require.Len(t, client.puts, 2)
+		require.Equal(t, "stream", *client.puts[0].StreamName)
+		require.Equal(t, []byte("first"), client.puts[0].Data)
+		require.NotEmpty(t, *client.puts[0].PartitionKey)
+		require.NotEqual(t, *client.puts[0].PartitionKey, *client.puts[1].PartitionKey)
+	})
+
+	t.Run("Configured partition key", func(t *testing.T) {
+		client := &fakeKinesisClient{}
+		exporter := &KinesisExporter{cfg: kinesisCfg{StreamName: "stream", PartitionKey: "data-gen"}, client: client}
+
+		data := []byte("record")
+		require.NoError(t, exporter.Send(&data))
+		require.Equal(t, "data-gen", *client.puts[0].PartitionKey)
+	})
+}
@@ -0,0 +1,102 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+
+	"data-gen/conf"
+)
+
+// PipeExporter writes generated data as raw bytes to a named pipe (FIFO) or a Unix domain socket.
+type PipeExporter struct {
+	cfg        pipeCfg
+	outputType string
+	writer     io.WriteCloser
+}
+
+// pipeCfg specifies the pipe or socket path.
+type pipeCfg struct {
+	Path string `yaml:"path"`
+}
+
+func NewPipeExporter(c *conf.Config) (*PipeExporter, error) {
+	var cfg pipeCfg
+	err := c.Output.Conf.Decode(&cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// load env variable overrides if any
+	if v := os.Getenv(conf.EnvOutPath); v != "" {
+		cfg.Path = v
+	}
+
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("path must be specified for output type %s", c.Output.Type)
+	}
+
+	p := &PipeExporter{
+		cfg:        cfg,
+		outputType: c.Output.Type,
+	}
+
+	p.writer, err = p.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (p *PipeExporter) Send(data *[]byte) error {
+	_, err := p.writer.Write(*data)
+	if err == nil {
+		return nil
+	}
+
+	if p.outputType != conf.OutputUnixSocket {
+		return fmt.Errorf("unable to write to pipe %s: %w", p.cfg.Path, err)
+	}
+
+	// socket peers may restart, hence reconnect once before failing
+	slog.Warn("Unix socket write failed, reconnecting", "path", p.cfg.Path, "error", err)
+	_ = p.writer.Close()
+
+	p.writer, err = p.connect()
+	if err != nil {
+		return err
+	}
+
+	_, err = p.writer.Write(*data)
+	if err != nil {
+		return fmt.Errorf("unable to write to unix socket %s: %w", p.cfg.Path, err)
+	}
+
+	return nil
+}
+
+func (p *PipeExporter) Close() error {
+	return p.writer.Close()
+}
+
+// connect opens the configured destination. Note that opening a FIFO blocks until a reader is attached.
+func (p *PipeExporter) connect() (io.WriteCloser, error) {
+	if p.outputType == conf.OutputUnixSocket {
+		conn, err := net.Dial("unix", p.cfg.Path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to connect to unix socket %s: %w", p.cfg.Path, err)
+		}
+
+		return conn, nil
+	}
+
+	file, err := os.OpenFile(p.cfg.Path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open pipe %s: %w", p.cfg.Path, err)
+	}
+
+	return file, nil
+}
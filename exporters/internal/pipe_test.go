@@ -0,0 +1,96 @@
+//go:build unix
+
+package internal
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"data-gen/conf"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPipeExporter(t *testing.T, outputType string, path string) *PipeExporter {
+	cfg, err := conf.NewConfig([]byte(fmt.Sprintf("output:\n  type: %s\n  config:\n    path: %s\n", outputType, path)))
+	require.NoError(t, err)
+
+	exporter, err := NewPipeExporter(cfg)
+	require.NoError(t, err)
+
+	return exporter
+}
+
+func TestPipeExporter_UnixSocket(t *testing.T) {
+	// unix socket paths are limited in length, hence avoid long test directory names
+	dir, err := os.MkdirTemp("", "sock")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "out.sock")
+	listener, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	received := make(chan []byte)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			close(received)
+			return
+		}
+
+		content, _ := io.ReadAll(conn)
+		received <- content
+	}()
+
+	exporter := newTestPipeExporter(t, conf.OutputUnixSocket, path)
+	for _, d := range []string{"first\n", "second\n"} {
+		data := []byte(d)
+		require.NoError(t, exporter.Send(&data))
+	}
+	require.NoError(t, exporter.Close())
+
+	require.Equal(t, "first\nsecond\n", string(<-received))
+}
+
+func TestPipeExporter_FIFO(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.fifo")
+	require.NoError(t, syscall.Mkfifo(path, 0600))
+
+	// opening a FIFO for writing blocks until a reader is attached
+	received := make(chan []byte)
+	go func() {
+		reader, err := os.Open(path)
+		if err != nil {
+			close(received)
+			return
+		}
+		defer reader.Close()
+
+		content, _ := io.ReadAll(reader)
+		received <- content
+	}()
+
+	exporter := newTestPipeExporter(t, conf.OutputPipe, path)
+	for _, d := range []string{"first\n", "second\n"} {
+		data := []byte(d)
+		require.NoError(t, exporter.Send(&data))
+	}
+	require.NoError(t, exporter.Close())
+
+	require.Equal(t, "first\nsecond\n", string(<-received))
+}
+
+func TestPipeExporter_MissingPath(t *testing.T) {
+	cfg, err := conf.NewConfig([]byte("output:\n  type: PIPE\n"))
+	require.NoError(t, err)
+
+	_, err = NewPipeExporter(cfg)
+	require.ErrorContains(t, err, "path must be specified for output type PIPE")
+}
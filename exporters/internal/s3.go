@@ -6,7 +6,6 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"os"
 	"strings"
 	"time"
 
@@ -18,53 +17,28 @@ import (
 
 const (
 	defaultBucketPrefix = "logFile-"
-	arnPrefix           = "arn:aws:s3:::"
 )
 
 // S3BucketExporter uploads generated data to AWS S3 with optional gzip compression.
 type S3BucketExporter struct {
-	cfg    s3Config
+	cfg    conf.S3OutputConfig
+	source RecordSource
 	client *awss3.Client
 }
 
-// s3Config defines S3 bucket, path, and compression settings.
-type s3Config struct {
-	Bucket      string `yaml:"s3_bucket"`
-	PathPrefix  string `yaml:"path_prefix"`
-	Compression string `yaml:"compression"`
-}
-
-func newDefaultS3Config() s3Config {
-	return s3Config{
-		PathPrefix: defaultBucketPrefix,
-	}
-}
-
-func NewS3BucketExporter(ctx context.Context, configuration *conf.Config) (*S3BucketExporter, error) {
-	cfg := newDefaultS3Config()
-	err := configuration.Output.Conf.Decode(&cfg)
+func NewS3BucketExporter(ctx context.Context, configuration *conf.Config, source RecordSource) (*S3BucketExporter, error) {
+	cfg, err := configuration.Output.S3Config()
 	if err != nil {
 		return nil, err
 	}
 
-	// load env variable overrides if any
-	if v := os.Getenv(conf.EnvOutS3Bucket); v != "" {
-		cfg.Bucket = v
-	}
-
-	if v := os.Getenv(conf.EnvOutPathPrefix); v != "" {
-		cfg.PathPrefix = v
-	}
-
-	if v := os.Getenv(conf.EnvOutCompression); v != "" {
-		cfg.Compression = v
-	}
-
 	if cfg.Bucket == "" {
 		return nil, fmt.Errorf("s3 Bucket Name is empty, please configure and try again")
 	}
 
-	cfg.Bucket = toBucketName(cfg.Bucket)
+	if cfg.PathPrefix == "" {
+		cfg.PathPrefix = defaultBucketPrefix
+	}
 
 	loadedAwsConfig, err := config.LoadDefaultConfig(ctx, config.WithSharedConfigProfile(configuration.Profile), config.WithRegion(configuration.Region))
 	if err != nil {
@@ -73,6 +47,7 @@ func NewS3BucketExporter(ctx context.Context, configuration *conf.Config) (*S3Bu
 
 	return &S3BucketExporter{
 		cfg:    cfg,
+		source: source,
 		client: awss3.NewFromConfig(loadedAwsConfig),
 	}, nil
 }
@@ -81,7 +56,7 @@ func (s *S3BucketExporter) Send(data *[]byte) error {
 	var content io.Reader
 	var encoding string
 
-	key := fmt.Sprintf("%s%s", s.cfg.PathPrefix, time.Now().Format("2006-01-02T15:04:05.000"))
+	key := s.objectKey(*data, time.Now())
 
 	// check and compress
 	if s.cfg.Compression == "gzip" {
@@ -112,6 +87,28 @@ func (s *S3BucketExporter) Send(data *[]byte) error {
 	return nil
 }
 
+// objectKey returns the key of a batch. Batches carrying a key of their own (ex:- CloudTrail log files referenced by
+// digests) are stored under that key as is, which already includes the path prefix, others under the path prefix and
+// the export time.
+func (s *S3BucketExporter) objectKey(data []byte, now time.Time) string {
+	if key, ok := s.source.BatchKey(data); ok {
+		return key
+	}
+
+	return fmt.Sprintf("%s%s", resolvePathPrefix(s.cfg.PathPrefix, now.UTC()), now.Format("2006-01-02T15:04:05.000"))
+}
+
+// resolvePathPrefix replaces {year}, {month}, {day} and {hour} placeholders of the prefix,
+// allowing date partitioned layouts such as Hive compatible partitions.
+func resolvePathPrefix(prefix string, t time.Time) string {
+	return strings.NewReplacer(
+		"{year}", t.Format("2006"),
+		"{month}", t.Format("01"),
+		"{day}", t.Format("02"),
+		"{hour}", t.Format("15"),
+	).Replace(prefix)
+}
+
 func gzipCompress(input []byte) ([]byte, error) {
 	var buf bytes.Buffer
 	gz := gzip.NewWriter(&buf)
@@ -128,11 +125,3 @@ func gzipCompress(input []byte) ([]byte, error) {
 
 	return buf.Bytes(), nil
 }
-
-func toBucketName(bucket string) string {
-	if strings.HasPrefix(bucket, arnPrefix) {
-		return strings.TrimPrefix(bucket, arnPrefix)
-	}
-
-	return bucket
-}
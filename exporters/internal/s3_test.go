@@ -0,0 +1,40 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"data-gen/conf"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolvePathPrefix(t *testing.T) {
+	ts := time.Date(2024, 3, 7, 9, 30, 0, 0, time.UTC)
+
+	require.Equal(t, "logFile-", resolvePathPrefix("logFile-", ts))
+	require.Equal(t,
+		"AWSLogs/aws-account-id=123456789012/aws-service=vpcflowlogs/aws-region=us-east-1/year=2024/month=03/day=07/hour=09/",
+		resolvePathPrefix("AWSLogs/aws-account-id=123456789012/aws-service=vpcflowlogs/aws-region=us-east-1/year={year}/month={month}/day={day}/hour={hour}/", ts))
+}
+
+// keyedBatches stands in for generators naming their batches.
+type keyedBatches struct {
+	leadingTimestamps
+}
+
+func (keyedBatches) BatchKey(batch []byte) (string, bool) {
+	if string(batch) == "keyed" {
+		return "AWSLogs/123456789012/CloudTrail/us-east-1/2024/03/07/log.json", true
+	}
+
+	return "", false
+}
+
+func TestS3BucketExporter_ObjectKey(t *testing.T) {
+	exporter := &S3BucketExporter{cfg: conf.S3OutputConfig{PathPrefix: "logs/{year}/"}, source: keyedBatches{}}
+	ts := time.Date(2024, 3, 7, 9, 30, 0, 0, time.UTC)
+
+	require.Equal(t, "AWSLogs/123456789012/CloudTrail/us-east-1/2024/03/07/log.json", exporter.objectKey([]byte("keyed"), ts))
+	require.Equal(t, "logs/2024/2024-03-07T09:30:00.000", exporter.objectKey([]byte("other"), ts))
+}
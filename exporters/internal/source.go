@@ -0,0 +1,16 @@
+package internal
+
+import "time"
+
+// RecordSource exposes details of generated records to outputs depending on record contents.
+// Implemented by the generator of the configured input, keeping outputs unaware of input formats.
+type RecordSource interface {
+	// RecordTimestamp returns the timestamp of a single generated record, false if the record does not carry one
+	RecordTimestamp(record string) (time.Time, bool)
+
+	// LogDestination returns the default CloudWatch log group and stream of records, false if records have none
+	LogDestination() (group string, stream string, ok bool)
+
+	// BatchKey returns the object key of an emitted batch, false if batches are named by the output
+	BatchKey(batch []byte) (string, bool)
+}
@@ -0,0 +1,28 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// StdoutExporter writes generated data to stdout as raw bytes, enabling shell piping.
+// Unlike DebugExporter, data is written as is without any formatting.
+type StdoutExporter struct {
+	writer io.Writer
+}
+
+func NewStdoutExporter() *StdoutExporter {
+	return &StdoutExporter{
+		writer: os.Stdout,
+	}
+}
+
+func (s *StdoutExporter) Send(data *[]byte) error {
+	_, err := s.writer.Write(*data)
+	if err != nil {
+		return fmt.Errorf("unable to write to stdout: %w", err)
+	}
+
+	return nil
+}
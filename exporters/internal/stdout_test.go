@@ -0,0 +1,20 @@
+package internal
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStdoutExporter_Send(t *testing.T) {
+	var buf bytes.Buffer
+	exporter := &StdoutExporter{writer: &buf}
+
+	// batches are written as is, without formatting or added separators
+	for _, d := range [][]byte{[]byte("first\n"), {0x1f, 0x8b, 0x00, 0xff}, []byte("no newline")} {
+		require.NoError(t, exporter.Send(&d))
+	}
+
+	require.Equal(t, append(append([]byte("first\n"), 0x1f, 0x8b, 0x00, 0xff), []byte("no newline")...), buf.Bytes())
+}
@@ -17,29 +17,91 @@ type input interface {
 	GetAndReset() []byte
 }
 
+// recordTimestamper is implemented by inputs whose records carry their own timestamp.
+type recordTimestamper interface {
+	// RecordTimestamp returns the timestamp of a single generated record, false if the record does not carry one
+	RecordTimestamp(record string) (time.Time, bool)
+}
+
+// logDestinationProvider is implemented by inputs mirroring logs of a service delivering to fixed CloudWatch log groups.
+type logDestinationProvider interface {
+	// LogDestination returns the log group and stream the service delivers records to
+	LogDestination() (string, string, bool)
+}
+
+// batchKeyProvider is implemented by inputs whose batches are delivered under keys of their own, ex:- CloudTrail digests
+// referencing log files by key.
+type batchKeyProvider interface {
+	// BatchKey returns the object key of an emitted batch
+	BatchKey(batch []byte) (string, bool)
+}
+
 func GeneratorFor(cfg *conf.Config, runtime runtime.Runtime) (*Generator, error) {
 	var in input
+	var err error
 	switch cfg.Input.Type {
 	case conf.InputLogs:
 		in = internal.NewLogGenerator()
 	case conf.InputMetrics:
 		in = internal.NewMetricGenerator()
 	case conf.InputALB:
-		in = internal.NewALBGen()
+		in, err = internal.NewALBGen(cfg.Input)
 	case conf.InputNLB:
-		in = internal.NewNLBGen()
+		in, err = internal.NewNLBGen(cfg.Input)
 	case conf.InputVPC:
-		in = internal.NewVPCGen(cfg.Output)
+		in, err = internal.NewVPCGen(cfg.Input, cfg.Output)
 	case conf.InputWAF:
 		in = internal.NewWAFGen()
 	case conf.InputCT:
-		in = internal.NewCloudTrailGen(cfg.Output)
+		in, err = internal.NewCloudTrailGen(cfg.Input, cfg.Output)
 	case conf.InputAzures:
 		in = internal.NewAzureResourceLogGen(cfg.Input)
+	case conf.InputCloudFront:
+		in, err = internal.NewCloudFrontGen(cfg.Input, cfg.Output)
+	case conf.InputS3Access:
+		in = internal.NewS3AccessGen()
+	case conf.InputNFW:
+		in, err = internal.NewNetworkFirewallGen(cfg.Input)
+	case conf.InputR53:
+		in = internal.NewRoute53ResolverGen()
+	case conf.InputGuardDuty:
+		in, err = internal.NewGuardDutyGen(cfg.Input)
+	case conf.InputSecHub:
+		in, err = internal.NewSecurityHubGen(cfg.Input)
+	case conf.InputAPIGW:
+		in, err = internal.NewAPIGatewayGen(cfg.Input)
+	case conf.InputLambda:
+		in, err = internal.NewLambdaGen(cfg.Input)
+	case conf.InputK8sAudit:
+		in, err = internal.NewK8sAuditGen(cfg.Input, cfg.Output)
+	case conf.InputContainer:
+		in, err = internal.NewContainerLogsGen(cfg.Input)
+	case conf.InputAccessLog:
+		in, err = internal.NewAccessLogGen(cfg.Input)
+	case conf.InputSyslog:
+		in, err = internal.NewSyslogGen(cfg.Input)
+	case conf.InputAuditd:
+		in, err = internal.NewAuditdGen(cfg.Input)
+	case conf.InputWindows:
+		in, err = internal.NewWindowsEventsGen(cfg.Input)
+	case conf.InputCEF, conf.InputLEEF:
+		in, err = internal.NewSIEMGen(cfg.Input)
+	case conf.InputZeek:
+		in, err = internal.NewZeekGen(cfg.Input, cfg.Output)
+	case conf.InputSuricata:
+		in, err = internal.NewSuricataGen(cfg.Input)
+	case conf.InputAzureFlow:
+		in, err = internal.NewAzureFlowLogGen(cfg.Input)
+	case conf.InputEntraID:
+		in, err = internal.NewAzureEntraIDLogGen(cfg.Input)
 	default:
 		return nil, fmt.Errorf("unknown generator type: %s", cfg.Input.Type)
 	}
 
+	if err != nil {
+		return nil, err
+	}
+
 	return newGenerator(cfg.Input, runtime, in)
 }
 
@@ -112,6 +174,33 @@ func (g *Generator) Stop() {
 	close(g.errChan)
 }
 
+// RecordTimestamp returns the timestamp of a single generated record, false if the input records do not carry one.
+func (g *Generator) RecordTimestamp(record string) (time.Time, bool) {
+	if t, ok := g.input.(recordTimestamper); ok {
+		return t.RecordTimestamp(record)
+	}
+
+	return time.Time{}, false
+}
+
+// LogDestination returns the CloudWatch log group and stream records of the input are delivered to, false if the input has none.
+func (g *Generator) LogDestination() (string, string, bool) {
+	if d, ok := g.input.(logDestinationProvider); ok {
+		return d.LogDestination()
+	}
+
+	return "", "", false
+}
+
+// BatchKey returns the object key an emitted batch is delivered under, false if the input batches have none.
+func (g *Generator) BatchKey(batch []byte) (string, bool) {
+	if k, ok := g.input.(batchKeyProvider); ok {
+		return k.BatchKey(batch)
+	}
+
+	return "", false
+}
+
 // runGenerator manages the data generation loop, handling timing, batching, and shutdown conditions.
 // Contains blocking calls hence should be run in a separate goroutine.
 func (g *Generator) runGenerator() {
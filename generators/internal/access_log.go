@@ -0,0 +1,376 @@
+package internal
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"data-gen/conf"
+)
+
+const (
+	accessLogPresetApacheCommon   = "apache_common"
+	accessLogPresetApacheCombined = "apache_combined"
+	accessLogPresetNginx          = "nginx"
+	accessLogPresetNginxJSON      = "nginx_json"
+	accessLogPresetHAProxy        = "haproxy"
+	accessLogPresetEnvoy          = "envoy"
+
+	accessLogEscapeDefault = "default"
+	accessLogEscapeJSON    = "json"
+
+	accessLogTimeFormat  = "02/Jan/2006:15:04:05 -0700"
+	haproxyAcceptFormat  = "02/Jan/2006:15:04:05.000"
+	envoyStartTimeFormat = "2006-01-02T15:04:05.000Z"
+)
+
+// accessLogPreset is a web server access log format, written with nginx style $variables.
+type accessLogPreset struct {
+	format string
+	escape string
+}
+
+// accessLogPresets are the default access log formats of web servers & proxies.
+// Product specific fields use variables prefixed with the product name (eg:- $haproxy_timers).
+var accessLogPresets = map[string]accessLogPreset{
+	// LogFormat "%h %l %u %t \"%r\" %>s %b" common
+	accessLogPresetApacheCommon: {
+		format: `$remote_addr - $remote_user [$time_local] "$request" $status $clf_body_bytes_sent`,
+		escape: accessLogEscapeDefault,
+	},
+	// LogFormat "%h %l %u %t \"%r\" %>s %b \"%{Referer}i\" \"%{User-agent}i\"" combined
+	accessLogPresetApacheCombined: {
+		format: `$remote_addr - $remote_user [$time_local] "$request" $status $clf_body_bytes_sent "$http_referer" "$http_user_agent"`,
+		escape: accessLogEscapeDefault,
+	},
+	// predefined combined log_format of nginx
+	accessLogPresetNginx: {
+		format: `$remote_addr - $remote_user [$time_local] "$request" $status $body_bytes_sent "$http_referer" "$http_user_agent"`,
+		escape: accessLogEscapeDefault,
+	},
+	// log_format json escape=json, with request timing & upstream details
+	accessLogPresetNginxJSON: {
+		format: `{"time_local":"$time_local","remote_addr":"$remote_addr","remote_user":"$remote_user","request":"$request","status":"$status","body_bytes_sent":"$body_bytes_sent","request_time":"$request_time","http_referer":"$http_referer","http_user_agent":"$http_user_agent","http_x_forwarded_for":"$http_x_forwarded_for","upstream_addr":"$upstream_addr","upstream_response_time":"$upstream_response_time","request_id":"$request_id"}`,
+		escape: accessLogEscapeJSON,
+	},
+	// option httplog, without the syslog header and header captures
+	accessLogPresetHAProxy: {
+		format: `$remote_addr:$remote_port [$haproxy_accept_date] $haproxy_frontend $haproxy_backend_server $haproxy_timers $status $bytes_sent - - $haproxy_termination_state $haproxy_conn_counts $haproxy_queues "$request"`,
+		escape: accessLogEscapeDefault,
+	},
+	// default format of the Envoy HTTP connection manager access log
+	accessLogPresetEnvoy: {
+		format: `[$envoy_start_time] "$request_method $request_uri $server_protocol" $status $envoy_response_flags $envoy_bytes_received $body_bytes_sent $envoy_duration $envoy_upstream_service_time "$http_x_forwarded_for" "$http_user_agent" "$request_id" "$host" "$upstream_addr"`,
+		escape: accessLogEscapeDefault,
+	},
+}
+
+// accessLogVariables lists supported $variables.
+// Variables without a value for the request are logged as "-", or as an empty string with json escaping, same as nginx.
+var accessLogVariables = []string{
+	"args", "body_bytes_sent", "bytes_sent", "clf_body_bytes_sent", "envoy_bytes_received", "envoy_duration",
+	"envoy_response_flags", "envoy_start_time", "envoy_upstream_service_time", "haproxy_accept_date",
+	"haproxy_backend_server", "haproxy_conn_counts", "haproxy_frontend", "haproxy_queues", "haproxy_termination_state",
+	"haproxy_timers", "host", "http_referer", "http_user_agent", "http_x_forwarded_for", "msec", "remote_addr",
+	"remote_port", "remote_user", "request", "request_id", "request_length", "request_method", "request_time",
+	"request_uri", "scheme", "server_port", "server_protocol", "ssl_cipher", "ssl_protocol", "status", "time_iso8601",
+	"time_local", "upstream_addr", "upstream_response_time", "uri",
+}
+
+var accessLogVariablePattern = regexp.MustCompile(`\$([a-z0-9_]+)`)
+
+var accessLogUsers = []string{"alice", "bob", "deploy", "monitoring", "admin"}
+var accessLogBackends = []string{"app", "api", "static"}
+
+// accessLogProxyFailure describes a response generated by the proxy, along with HAProxy & Envoy failure indicators.
+type accessLogProxyFailure struct {
+	haproxyState string
+	envoyFlags   string
+	// upstream is set when an upstream server was selected and connected to
+	upstream bool
+}
+
+// accessLogProxyFailures maps status codes to failures of the proxy itself, or of reaching the upstream.
+var accessLogProxyFailures = map[int]accessLogProxyFailure{
+	429: {"PR--", "RL", false},
+	502: {"SH--", "UC", true},
+	503: {"SC--", "UH", false},
+	504: {"sH--", "UT", true},
+}
+
+// AccessLogGen generates web server access logs of Apache, nginx, HAProxy or Envoy, or of a custom $variable format.
+type AccessLogGen struct {
+	buf    trackedBuffer
+	format string
+	escape string
+}
+
+// accessLogCfg specifies the access log format.
+// Format takes precedence over Preset and accepts any access log format built from $variables.
+type accessLogCfg struct {
+	Preset string `yaml:"preset"`
+	Format string `yaml:"format"`
+	Escape string `yaml:"escape"`
+}
+
+func NewAccessLogGen(input conf.InputConfig) (*AccessLogGen, error) {
+	cfg := accessLogCfg{
+		Preset: accessLogPresetNginx,
+		Escape: accessLogEscapeDefault,
+	}
+
+	err := input.Conf.Decode(&cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode access log configuration: %w", err)
+	}
+
+	preset := accessLogPreset{format: cfg.Format, escape: cfg.Escape}
+	if cfg.Format == "" {
+		var ok bool
+		preset, ok = accessLogPresets[cfg.Preset]
+		if !ok {
+			return nil, fmt.Errorf("unknown access log preset: %s", cfg.Preset)
+		}
+	}
+
+	if preset.escape != accessLogEscapeDefault && preset.escape != accessLogEscapeJSON {
+		return nil, fmt.Errorf("unknown access log escape: %s", preset.escape)
+	}
+
+	for _, match := range accessLogVariablePattern.FindAllStringSubmatch(preset.format, -1) {
+		if !slices.Contains(accessLogVariables, match[1]) {
+			return nil, fmt.Errorf("unknown access log variable: $%s", match[1])
+		}
+	}
+
+	return &AccessLogGen{
+		buf:    newTrackedBuffer(),
+		format: preset.format,
+		escape: preset.escape,
+	}, nil
+}
+
+func (a *AccessLogGen) Generate() (int64, error) {
+	c := newAccessLogCustomizer()
+	err := a.buf.write([]byte(buildAccessLog(a.format, a.escape, accessLogVariableValues(c)) + "\n"))
+	if err != nil {
+		return 0, err
+	}
+
+	return a.buf.size(), nil
+}
+
+func (a *AccessLogGen) GetAndReset() []byte {
+	return a.buf.getAndReset()
+}
+
+func (a *AccessLogGen) RecordTimestamp(record string) (time.Time, bool) {
+	// JSON formats such as nginx_json log $time_local or $time_iso8601, while others bracket the request time
+	if strings.HasPrefix(record, "{") {
+		return jsonTimestamp(record, "time_local", "time_iso8601")
+	}
+
+	return bracketedTimestamp(record)
+}
+
+// accessLogCustomizer holds parameters of a single request proxied to an upstream server.
+// Durations are in milliseconds, where upstream values of -1 mark requests not reaching an upstream.
+type accessLogCustomizer struct {
+	time         time.Time
+	request      httpRequest
+	clientIP     string
+	clientPort   int
+	user         string
+	forwardedFor string
+	requestID    string
+	cipher       string
+	sslProtocol  string
+	status       int
+	requestBody  int
+	headerBytes  int
+	bodyBytes    int
+	backend      string
+	server       int
+	readMs       int
+	connectMs    int
+	upstreamMs   int
+	durationMs   int
+	connections  int
+}
+
+func newAccessLogCustomizer() accessLogCustomizer {
+	req := randomHTTPRequest()
+
+	c := accessLogCustomizer{
+		time:        time.Now().UTC(),
+		request:     req,
+		clientIP:    randomIP(),
+		clientPort:  randomPort(),
+		requestID:   randomHexString(32),
+		status:      randomHTTPStatus(),
+		headerBytes: rand.IntN(250) + 150,
+		bodyBytes:   randomBytesSize(),
+		backend:     accessLogBackends[rand.IntN(len(accessLogBackends))],
+		server:      rand.IntN(3) + 1,
+		readMs:      rand.IntN(5),
+		connectMs:   rand.IntN(3),
+		upstreamMs:  rand.IntN(250) + 2,
+		connections: rand.IntN(200) + 1,
+	}
+
+	if req.Scheme == "https" {
+		c.cipher = randomSSLCipher()
+		c.sslProtocol = randomTLSProtocol()
+	}
+
+	if rand.IntN(10) == 0 {
+		c.user = accessLogUsers[rand.IntN(len(accessLogUsers))]
+	}
+
+	if rand.IntN(3) == 0 {
+		c.forwardedFor = randomIP()
+	}
+
+	switch req.Method {
+	case "POST", "PUT", "PATCH":
+		c.requestBody = rand.IntN(2000) + 50
+	}
+
+	if req.Method == "HEAD" || c.status == 204 || c.status == 304 {
+		c.bodyBytes = 0
+	}
+
+	if failure, ok := accessLogProxyFailures[c.status]; ok {
+		switch {
+		case !failure.upstream:
+			c.connectMs, c.upstreamMs = -1, -1
+		case c.status == 504:
+			// proxy_read_timeout & timeout server defaults
+			c.upstreamMs = 60000 + rand.IntN(5)
+		}
+		c.bodyBytes = rand.IntN(400) + 150
+	}
+
+	c.durationMs = c.readMs + max(c.connectMs, 0) + max(c.upstreamMs, 0) + rand.IntN(3)
+	return c
+}
+
+// accessLogVariableValues returns the $variable values of the request, keyed by variable name without prefix.
+func accessLogVariableValues(c accessLogCustomizer) map[string]string {
+	req := c.request
+	failure, failed := accessLogProxyFailures[c.status]
+
+	frontend := "http-in"
+	if req.Scheme == "https" {
+		// HAProxy marks frontends accepting SSL connections with a tilde
+		frontend = "https-in~"
+	}
+
+	server := fmt.Sprintf("%s%d", c.backend, c.server)
+	if failed && !failure.upstream {
+		server = "<NOSRV>"
+	}
+
+	// HAProxy logs -1 for the server response time when no complete response headers were received
+	responseMs := c.upstreamMs
+	if failed {
+		responseMs = -1
+	}
+
+	bodyBytes := "-"
+	if c.bodyBytes > 0 {
+		bodyBytes = strconv.Itoa(c.bodyBytes)
+	}
+
+	vars := map[string]string{
+		"body_bytes_sent":           strconv.Itoa(c.bodyBytes),
+		"bytes_sent":                strconv.Itoa(c.headerBytes + c.bodyBytes),
+		"clf_body_bytes_sent":       bodyBytes,
+		"envoy_bytes_received":      strconv.Itoa(c.requestBody),
+		"envoy_duration":            strconv.Itoa(c.durationMs),
+		"envoy_response_flags":      "-",
+		"envoy_start_time":          c.time.Format(envoyStartTimeFormat),
+		"haproxy_accept_date":       c.time.Format(haproxyAcceptFormat),
+		"haproxy_backend_server":    c.backend + "/" + server,
+		"haproxy_conn_counts":       fmt.Sprintf("%d/%d/%d/%d/0", c.connections, c.connections, max(c.connections/2, 1), max(c.connections/8, 1)),
+		"haproxy_frontend":          frontend,
+		"haproxy_queues":            "0/0",
+		"haproxy_termination_state": "----",
+		"haproxy_timers":            fmt.Sprintf("%d/0/%d/%d/%d", c.readMs, c.connectMs, responseMs, c.durationMs),
+		"host":                      req.Host,
+		"http_user_agent":           req.UserAgent,
+		"msec":                      fmt.Sprintf("%d.%03d", c.time.Unix(), c.time.Nanosecond()/int(time.Millisecond)),
+		"remote_addr":               c.clientIP,
+		"remote_port":               strconv.Itoa(c.clientPort),
+		"request":                   fmt.Sprintf("%s %s %s", req.Method, req.uri(), req.Protocol),
+		"request_id":                c.requestID,
+		"request_length":            strconv.Itoa(len(req.uri()) + c.headerBytes + c.requestBody),
+		"request_method":            req.Method,
+		"request_time":              fmt.Sprintf("%.3f", float64(c.durationMs)/1000),
+		"request_uri":               req.uri(),
+		"scheme":                    req.Scheme,
+		"server_port":               strconv.Itoa(req.Port),
+		"server_protocol":           req.Protocol,
+		"status":                    strconv.Itoa(c.status),
+		"time_iso8601":              c.time.Format(time.RFC3339),
+		"time_local":                c.time.Format(accessLogTimeFormat),
+		"uri":                       req.Path,
+	}
+
+	optional := map[string]string{
+		"args":                 req.Query,
+		"remote_user":          c.user,
+		"http_x_forwarded_for": c.forwardedFor,
+		"ssl_cipher":           c.cipher,
+		"ssl_protocol":         c.sslProtocol,
+	}
+	if req.Referer != "-" {
+		optional["http_referer"] = req.Referer
+	}
+	for k, v := range optional {
+		if v != "" {
+			vars[k] = v
+		}
+	}
+
+	if failed {
+		vars["envoy_response_flags"] = failure.envoyFlags
+		vars["haproxy_termination_state"] = failure.haproxyState
+	}
+
+	if c.upstreamMs >= 0 {
+		vars["upstream_addr"] = fmt.Sprintf("10.0.2.%d:8080", 10+c.server)
+		vars["upstream_response_time"] = fmt.Sprintf("%.3f", float64(c.upstreamMs)/1000)
+
+		// the header is only set on responses received from upstream
+		if !failed {
+			vars["envoy_upstream_service_time"] = strconv.Itoa(c.upstreamMs)
+		}
+	}
+
+	return vars
+}
+
+// buildAccessLog renders the access log format, escaping values the same way nginx does.
+// Default escaping logs variables without a value as "-", while json escaping logs them as an empty string.
+func buildAccessLog(format string, escape string, vars map[string]string) string {
+	replacer := strings.NewReplacer(`"`, `\x22`, `\`, `\x5C`)
+	if escape == accessLogEscapeJSON {
+		replacer = strings.NewReplacer(`"`, `\"`, `\`, `\\`)
+	}
+
+	return accessLogVariablePattern.ReplaceAllStringFunc(format, func(variable string) string {
+		if v, ok := vars[strings.TrimPrefix(variable, "$")]; ok {
+			return replacer.Replace(v)
+		}
+
+		if escape == accessLogEscapeJSON {
+			return ""
+		}
+		return "-"
+	})
+}
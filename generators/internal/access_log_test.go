@@ -0,0 +1,135 @@
+package internal
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"data-gen/conf"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func Test_buildAccessLog(t *testing.T) {
+	c := accessLogCustomizer{
+		time: time.Date(2026, 10, 19, 10, 20, 30, 456e6, time.UTC),
+		request: httpRequest{
+			Method:    "GET",
+			Scheme:    "https",
+			Host:      "www.example.com",
+			Port:      443,
+			Path:      "/search",
+			Query:     "q=shoes",
+			Protocol:  "HTTP/1.1",
+			UserAgent: "curl/8.4.0",
+			Referer:   "-",
+		},
+		clientIP:    "192.0.2.10",
+		clientPort:  58080,
+		requestID:   "0a1b2c3d4e5f60718293a4b5c6d7e8f9",
+		status:      200,
+		headerBytes: 200,
+		bodyBytes:   2326,
+		backend:     "app",
+		server:      1,
+		readMs:      1,
+		connectMs:   0,
+		upstreamMs:  12,
+		durationMs:  14,
+		connections: 8,
+	}
+	vars := accessLogVariableValues(c)
+
+	tests := []struct {
+		preset   string
+		expected string
+	}{
+		{accessLogPresetApacheCommon, `192.0.2.10 - - [19/Oct/2026:10:20:30 +0000] "GET /search?q=shoes HTTP/1.1" 200 2326`},
+		{accessLogPresetNginx, `192.0.2.10 - - [19/Oct/2026:10:20:30 +0000] "GET /search?q=shoes HTTP/1.1" 200 2326 "-" "curl/8.4.0"`},
+		{accessLogPresetHAProxy, `192.0.2.10:58080 [19/Oct/2026:10:20:30.456] https-in~ app/app1 1/0/0/12/14 200 2526 - - ---- 8/8/4/1/0 0/0 "GET /search?q=shoes HTTP/1.1"`},
+		{accessLogPresetEnvoy, `[2026-10-19T10:20:30.456Z] "GET /search?q=shoes HTTP/1.1" 200 - 0 2326 14 12 "-" "curl/8.4.0" "0a1b2c3d4e5f60718293a4b5c6d7e8f9" "www.example.com" "10.0.2.11:8080"`},
+	}
+
+	for _, tt := range tests {
+		t.Run("Preset "+tt.preset, func(t *testing.T) {
+			preset := accessLogPresets[tt.preset]
+			require.Equal(t, tt.expected, buildAccessLog(preset.format, preset.escape, vars))
+		})
+	}
+
+	t.Run("Apache logs empty responses as -", func(t *testing.T) {
+		notModified := c
+		notModified.status = 304
+		notModified.bodyBytes = 0
+
+		preset := accessLogPresets[accessLogPresetApacheCombined]
+		line := buildAccessLog(preset.format, preset.escape, accessLogVariableValues(notModified))
+		require.Equal(t, `192.0.2.10 - - [19/Oct/2026:10:20:30 +0000] "GET /search?q=shoes HTTP/1.1" 304 - "-" "curl/8.4.0"`, line)
+	})
+
+	t.Run("Upstream failures", func(t *testing.T) {
+		unavailable := c
+		unavailable.status = 503
+		unavailable.connectMs, unavailable.upstreamMs, unavailable.durationMs = -1, -1, 1
+		vars := accessLogVariableValues(unavailable)
+
+		require.Equal(t, "app/<NOSRV>", vars["haproxy_backend_server"])
+		require.Equal(t, "1/0/-1/-1/1", vars["haproxy_timers"])
+		require.Equal(t, "SC--", vars["haproxy_termination_state"])
+		require.Equal(t, "UH", vars["envoy_response_flags"])
+		require.NotContains(t, vars, "upstream_addr")
+		require.NotContains(t, vars, "envoy_upstream_service_time")
+	})
+
+	t.Run("Escaping", func(t *testing.T) {
+		values := map[string]string{"http_user_agent": `say "hi"`}
+		require.Equal(t, `"say \x22hi\x22" -`, buildAccessLog(`"$http_user_agent" $remote_user`, accessLogEscapeDefault, values))
+		require.Equal(t, `{"ua":"say \"hi\"","user":""}`, buildAccessLog(`{"ua":"$http_user_agent","user":"$remote_user"}`, accessLogEscapeJSON, values))
+	})
+}
+
+func Test_NewAccessLogGen(t *testing.T) {
+	t.Run("nginx JSON preset", func(t *testing.T) {
+		var input conf.InputConfig
+		require.NoError(t, yaml.Unmarshal([]byte("preset: nginx_json"), &input.Conf))
+
+		gen, err := NewAccessLogGen(input)
+		require.NoError(t, err)
+
+		for range 200 {
+			_, err = gen.Generate()
+			require.NoError(t, err)
+		}
+
+		for _, line := range strings.Split(strings.TrimSuffix(string(gen.GetAndReset()), "\n"), "\n") {
+			var record map[string]string
+			require.NoError(t, json.Unmarshal([]byte(line), &record))
+			require.NotEmpty(t, record["request"])
+		}
+	})
+
+	t.Run("Custom format", func(t *testing.T) {
+		var input conf.InputConfig
+		require.NoError(t, yaml.Unmarshal([]byte(`format: '$remote_addr $host "$request" $status $request_time'`), &input.Conf))
+
+		gen, err := NewAccessLogGen(input)
+		require.NoError(t, err)
+
+		_, err = gen.Generate()
+		require.NoError(t, err)
+		require.Len(t, strings.Fields(string(gen.GetAndReset())), 7)
+	})
+
+	t.Run("Invalid configuration", func(t *testing.T) {
+		var input conf.InputConfig
+		require.NoError(t, yaml.Unmarshal([]byte("preset: iis"), &input.Conf))
+		_, err := NewAccessLogGen(input)
+		require.ErrorContains(t, err, "unknown access log preset")
+
+		require.NoError(t, yaml.Unmarshal([]byte("format: '$remote_addr $upstream_cache_status'"), &input.Conf))
+		_, err = NewAccessLogGen(input)
+		require.ErrorContains(t, err, "$upstream_cache_status")
+	})
+}
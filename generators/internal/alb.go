@@ -2,49 +2,66 @@ package internal
 
 import (
 	"fmt"
-	"math/rand"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"data-gen/conf"
+)
+
+const (
+	albLogTypeAccess     = "access"
+	albLogTypeConnection = "connection"
+
+	albTimeFormat = "2006-01-02T15:04:05.000000Z"
 )
 
-// ALBGen generates AWS Application Load Balancer access logs in standard format.
+var albAuthErrorReasons = []string{"AuthInvalidCookie", "AuthInvalidIdToken", "AuthInvalidStateParam", "AuthTokenEpRequestFailed", "AuthUserinfoEpRequestFailed"}
+var albWAFErrorReasons = []string{"WAFConnectionError", "WAFConnectionTimeout", "WAFResponseReadTimeout", "WAFServiceError"}
+var albClassificationReasons = map[string][]string{
+	"Ambiguous": {"UndefinedContentLengthSemantics", "UndefinedTransferEncodingSemantics", "AmbiguousUri"},
+	"Severe":    {"BothTeClPresent", "MultipleContentLength", "BadContentLength", "BadTransferEncoding"},
+}
+var albGRPCMethods = []string{"/helloworld.Greeter/SayHello", "/orders.OrderService/GetOrder", "/inventory.Stock/List"}
+var albTLSVerifyFailures = []string{"Failed:ClientCertNotFound", "Failed:ClientCertUntrusted", "Failed:ClientCertExpired", "Failed:ClientCertRevoked"}
+
+// ALBGen generates AWS Application Load Balancer access or connection logs.
 type ALBGen struct {
-	buf trackedBuffer
+	buf     trackedBuffer
+	logType string
 }
 
-func NewALBGen() *ALBGen {
-	return &ALBGen{
-		buf: newTrackedBuffer(),
+// albCfg specifies the type of ALB logs to generate.
+type albCfg struct {
+	LogType string `yaml:"log_type"`
+}
+
+func NewALBGen(input conf.InputConfig) (*ALBGen, error) {
+	cfg := albCfg{LogType: albLogTypeAccess}
+	err := input.Conf.Decode(&cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode alb configuration: %w", err)
 	}
+
+	if cfg.LogType != albLogTypeAccess && cfg.LogType != albLogTypeConnection {
+		return nil, fmt.Errorf("unknown alb log type: %s", cfg.LogType)
+	}
+
+	return &ALBGen{
+		buf:     newTrackedBuffer(),
+		logType: cfg.LogType,
+	}, nil
 }
 
 func (a *ALBGen) Generate() (int64, error) {
-	accountID := randomSampleAccountID()
+	var line string
+	if a.logType == albLogTypeConnection {
+		line = buildALBConnectionLogLine(newALBConnectionCustomizer())
+	} else {
+		line = buildALBLogLine(newALBCustomizer())
+	}
 
-	customizer := albCustomizer{
-		cipher:           randomSSLCipher(),
-		clientIPPort:     fmt.Sprintf("%s:%d", randomIP(), randomPort()),
-		creationTime:     iso8601Now(),
-		domain:           randomDomain(),
-		elbID:            fmt.Sprintf("appA/loadbalancer/%s", accountID),
-		elbStatus:        randomStatus(),
-		logType:          randomSchema(),
-		receivedBytes:    randomBytesSize(),
-		request:          fmt.Sprintf("\"GET %s://%s:80/ HTTP/1.1\"", randomSchema(), randomDomain()),
-		requestID:        randomAZ09String(5),
-		requestProcTime:  randomProcessingTime(),
-		responseProcTime: randomProcessingTime(),
-		sentBytes:        randomBytesSize(),
-		sslProtocol:      randomTLSProtocol(),
-		targetARN:        fmt.Sprintf("arn:aws:elasticloadbalancing:%s:%s:targetID", randomRegion(), accountID),
-		targetIPPort:     fmt.Sprintf("%s:%d", randomIP(), randomPort()),
-		targetPortList:   fmt.Sprintf("%s:%d", randomIP(), randomPort()),
-		targetProcTime:   randomProcessingTime(),
-		targetStatus:     randomStatus(),
-		timestamp:        iso8601Now(),
-		traceID:          fmt.Sprintf("\"trace=%d\"", rand.Intn(1000)),
-		userAgent:        fmt.Sprintf("\"%s\"", userAgents[rand.Intn(len(userAgents))]),
-	}
-
-	err := a.buf.write([]byte(buildALBLogLine(customizer)))
+	err := a.buf.write([]byte(line))
 	if err != nil {
 		return 0, err
 	}
@@ -56,63 +73,301 @@ func (a *ALBGen) GetAndReset() []byte {
 	return a.buf.getAndReset()
 }
 
-// helpers
+func (a *ALBGen) RecordTimestamp(record string) (time.Time, bool) {
+	// connection logs start with the timestamp, following the type field of access logs
+	if a.logType == albLogTypeConnection {
+		return fieldTimestamp(record, 0)
+	}
 
-const (
-	matchedRulePriority     = "0"
-	actionsExecuted         = `"forward"`
-	targetStatusList        = `"200"`
-	chosenCertArn           = `"-"`
-	redirectURL             = `"-"`
-	errorReason             = `"-"`
-	targetHealthReason      = `"-"`
-	targetHealthDescription = `"-"`
-)
+	return fieldTimestamp(record, 1)
+}
 
-// albCustomizer holds all fields needed to construct an ALB log entry.
+// helpers
+
+// albCustomizer holds all fields needed to construct an ALB access log entry.
+// Values are unquoted, quoting follows the documented log format.
+// Negative processing times are rendered as -1, marking requests which could not be dispatched or completed.
 type albCustomizer struct {
-	cipher           string
-	clientIPPort     string
-	creationTime     string
-	domain           string
-	elbID            string
-	elbStatus        string
-	logType          string
-	receivedBytes    int
-	request          string
-	requestID        string
-	requestProcTime  float32
-	responseProcTime float32
-	sentBytes        int
-	sslProtocol      string
-	targetARN        string
-	targetIPPort     string
-	targetPortList   string
-	targetProcTime   float32
-	targetStatus     string
-	timestamp        string
-	traceID          string
-	userAgent        string
+	logType              string
+	timestamp            string
+	elbID                string
+	clientIPPort         string
+	targetIPPort         string
+	requestProcTime      float64
+	targetProcTime       float64
+	responseProcTime     float64
+	elbStatus            string
+	targetStatus         string
+	receivedBytes        int
+	sentBytes            int
+	request              string
+	userAgent            string
+	cipher               string
+	sslProtocol          string
+	targetARN            string
+	traceID              string
+	domain               string
+	chosenCertArn        string
+	matchedRulePriority  int
+	creationTime         string
+	actionsExecuted      string
+	redirectURL          string
+	errorReason          string
+	targetPortList       string
+	targetStatusList     string
+	classification       string
+	classificationReason string
+	connTraceID          string
+}
+
+func newALBCustomizer() albCustomizer {
+	accountID := randomSampleAccountID()
+	region := randomRegion()
+	now := time.Now().UTC()
+	req := randomHTTPRequest()
+
+	c := albCustomizer{
+		logType:              req.Scheme,
+		timestamp:            now.Format(albTimeFormat),
+		elbID:                fmt.Sprintf("app/my-loadbalancer/%s", randomHexString(16)),
+		clientIPPort:         fmt.Sprintf("%s:%d", randomIP(), randomPort()),
+		targetIPPort:         fmt.Sprintf("10.0.%d.%d:%d", rand.IntN(4), rand.IntN(254)+1, []int{80, 8080, 8443}[rand.IntN(3)]),
+		requestProcTime:      rand.Float64() / 1000,
+		targetProcTime:       rand.Float64() * 0.5,
+		responseProcTime:     rand.Float64() / 1000,
+		receivedBytes:        randomBytesSize(),
+		sentBytes:            randomBytesSize(),
+		userAgent:            req.UserAgent,
+		cipher:               "-",
+		sslProtocol:          "-",
+		targetARN:            fmt.Sprintf("arn:aws:elasticloadbalancing:%s:%s:targetgroup/my-targets/%s", region, accountID, randomHexString(16)),
+		traceID:              fmt.Sprintf("Root=1-%08x-%s", now.Unix(), randomHexString(24)),
+		domain:               "-",
+		chosenCertArn:        "-",
+		creationTime:         now.Add(-time.Duration(rand.IntN(2000)) * time.Millisecond).Format(albTimeFormat),
+		actionsExecuted:      "forward",
+		redirectURL:          "-",
+		errorReason:          "-",
+		classification:       "-",
+		classificationReason: "-",
+		connTraceID:          "TID_" + randomHexString(32),
+	}
+
+	// HTTP/2, WebSocket and gRPC requests
+	switch n := rand.IntN(20); {
+	case req.Scheme == "https" && n < 5:
+		c.logType = "h2"
+		req.Protocol = "HTTP/2.0"
+	case n == 5:
+		c.logType = "ws"
+		req.Method, req.Scheme, req.Port, req.Protocol = "GET", "ws", 80, "HTTP/1.1"
+	case n == 6:
+		c.logType = "wss"
+		req.Method, req.Scheme, req.Port, req.Protocol = "GET", "wss", 443, "HTTP/1.1"
+	case req.Scheme == "https" && n < 9:
+		c.logType = "grpcs"
+		req.Method, req.Path, req.Query, req.Protocol = "POST", albGRPCMethods[rand.IntN(len(albGRPCMethods))], "", "HTTP/2.0"
+		req.UserAgent = "grpc-go/1.60.1"
+		c.userAgent = req.UserAgent
+	}
+	c.request = fmt.Sprintf("%s %s %s", req.Method, req.url(), req.Protocol)
+
+	if req.Port == 443 {
+		c.cipher = randomSSLCipher()
+		c.sslProtocol = randomTLSProtocol()
+		c.domain = req.Host
+		c.chosenCertArn = randomCertArn()
+	}
+
+	if rand.IntN(3) > 0 {
+		c.matchedRulePriority = rand.IntN(50) + 1
+	}
+
+	status := randomHTTPStatus()
+	c.elbStatus = httpStatusOrDash(status)
+	c.targetStatus = c.elbStatus
+
+	switch n := rand.IntN(100); {
+	case n < 6:
+		// redirect to HTTPS or a new location without reaching a target
+		c.actionsExecuted = "redirect"
+		c.redirectURL = fmt.Sprintf("https://%s:443%s", req.Host, req.uri())
+		c.elbStatus = []string{"301", "302"}[rand.IntN(2)]
+		albWithoutTarget(&c)
+	case n < 10:
+		c.actionsExecuted = "fixed-response"
+		c.elbStatus = []string{"200", "404", "503"}[rand.IntN(3)]
+		albWithoutTarget(&c)
+	case n < 16:
+		c.actionsExecuted = "authenticate,forward"
+		if rand.IntN(4) == 0 {
+			// authentication failures stop before forwarding
+			c.actionsExecuted = "authenticate"
+			c.errorReason = albAuthErrorReasons[rand.IntN(len(albAuthErrorReasons))]
+			c.elbStatus = []string{"401", "500"}[rand.IntN(2)]
+			albWithoutTarget(&c)
+		}
+	case n < 20:
+		c.actionsExecuted = "waf,forward"
+		if rand.IntN(2) == 0 {
+			c.actionsExecuted = "waf"
+			c.elbStatus = "403"
+			albWithoutTarget(&c)
+		} else if rand.IntN(4) == 0 {
+			// WAF fail open, the request is still forwarded
+			c.actionsExecuted = "waf-failed,forward"
+			c.errorReason = albWAFErrorReasons[rand.IntN(len(albWAFErrorReasons))]
+		}
+	case n < 26:
+		albTargetFailure(&c)
+	}
+
+	if c.targetIPPort != "-" {
+		c.targetPortList = c.targetIPPort
+		c.targetStatusList = c.targetStatus
+	} else {
+		c.targetPortList = "-"
+		c.targetStatusList = "-"
+	}
+
+	// requests violating RFC 7230 are classified by desync mitigation
+	if rand.IntN(50) == 0 {
+		c.classification = []string{"Ambiguous", "Severe"}[rand.IntN(2)]
+		reasons := albClassificationReasons[c.classification]
+		c.classificationReason = reasons[rand.IntN(len(reasons))]
+	}
+
+	return c
+}
+
+// albWithoutTarget clears target details of requests answered by the load balancer itself.
+func albWithoutTarget(c *albCustomizer) {
+	c.targetIPPort = "-"
+	c.targetStatus = "-"
+	c.targetARN = "-"
+	c.targetProcTime = -1
+	c.responseProcTime = -1
+}
+
+// albTargetFailure simulates target connection errors, timeouts and client disconnects.
+func albTargetFailure(c *albCustomizer) {
+	c.targetStatus = "-"
+	c.targetProcTime = -1
+	c.responseProcTime = -1
+	c.sentBytes = rand.IntN(300)
+
+	switch rand.IntN(4) {
+	case 0:
+		// no healthy target could be found, hence no target group or target is logged
+		c.elbStatus = "503"
+		albWithoutTarget(c)
+	case 1:
+		c.elbStatus = "502"
+	case 2:
+		c.elbStatus = "504"
+	default:
+		// client closed the connection before the load balancer could respond
+		c.elbStatus = "460"
+		c.sentBytes = 0
+	}
+}
+
+func albProcessingTime(v float64) string {
+	if v < 0 {
+		return "-1"
+	}
+
+	return fmt.Sprintf("%0.3f", v)
 }
 
 func buildALBLogLine(input albCustomizer) string {
-	logLine := fmt.Sprintf(
-		"%s %s %s %s %s %0.3f %0.3f %0.3f %s %s %d %d %s %s %s %s %s %s %s %s %s %s %s %s %s %s %s %s %s %s\n",
+	return fmt.Sprintf(
+		"%s %s %s %s %s %s %s %s %s %s %d %d \"%s\" \"%s\" %s %s %s \"%s\" \"%s\" \"%s\" %d %s \"%s\" \"%s\" \"%s\" \"%s\" \"%s\" \"%s\" \"%s\" %s\n",
 		input.logType, input.timestamp, input.elbID,
 		input.clientIPPort, input.targetIPPort,
-		input.requestProcTime, input.targetProcTime, input.responseProcTime,
+		albProcessingTime(input.requestProcTime), albProcessingTime(input.targetProcTime), albProcessingTime(input.responseProcTime),
 		input.elbStatus, input.targetStatus,
 		input.receivedBytes, input.sentBytes,
 		input.request, input.userAgent,
 		input.cipher, input.sslProtocol,
 		input.targetARN, input.traceID,
-		input.domain, chosenCertArn,
-		matchedRulePriority, input.creationTime,
-		actionsExecuted, redirectURL, errorReason,
-		input.targetPortList, targetStatusList,
-		targetHealthReason, targetHealthDescription,
-		input.requestID,
+		input.domain, input.chosenCertArn,
+		input.matchedRulePriority, input.creationTime,
+		input.actionsExecuted, input.redirectURL, input.errorReason,
+		input.targetPortList, input.targetStatusList,
+		input.classification, input.classificationReason,
+		input.connTraceID,
 	)
+}
+
+// albConnectionCustomizer holds all fields needed to construct an ALB connection log entry.
+// See - https://docs.aws.amazon.com/elasticloadbalancing/latest/application/load-balancer-connection-logs.html
+type albConnectionCustomizer struct {
+	timestamp        string
+	clientIP         string
+	clientPort       int
+	listenerPort     int
+	tlsProtocol      string
+	tlsCipher        string
+	handshakeLatency float64
+	certSubject      string
+	certValidity     string
+	certSerial       string
+	tlsVerifyStatus  string
+	connTraceID      string
+}
+
+func newALBConnectionCustomizer() albConnectionCustomizer {
+	now := time.Now().UTC()
 
-	return logLine
+	c := albConnectionCustomizer{
+		timestamp:        now.Format(albTimeFormat),
+		clientIP:         randomIP(),
+		clientPort:       randomPort(),
+		listenerPort:     443,
+		tlsProtocol:      randomTLSProtocol(),
+		tlsCipher:        randomSSLCipher(),
+		handshakeLatency: 1 + rand.Float64()*20,
+		certSubject:      "-",
+		certValidity:     "-",
+		certSerial:       "-",
+		tlsVerifyStatus:  "Success",
+		connTraceID:      "TID_" + randomHexString(32),
+	}
+
+	// mutual TLS listeners verify the leaf client certificate
+	if rand.IntN(2) == 0 {
+		c.certSubject = fmt.Sprintf("CN=client-%d.%s", rand.IntN(100), randomDomain())
+		c.certValidity = fmt.Sprintf("NotBefore=%s;NotAfter=%s",
+			now.AddDate(0, -6, 0).Format(time.RFC3339), now.AddDate(1, 0, 0).Format(time.RFC3339))
+		c.certSerial = strings.ToUpper(randomHexString(16))
+
+		if rand.IntN(10) == 0 {
+			c.tlsVerifyStatus = albTLSVerifyFailures[rand.IntN(len(albTLSVerifyFailures))]
+		}
+	}
+
+	// failed handshakes carry no negotiated protocol or cipher
+	if rand.IntN(20) == 0 {
+		c.tlsProtocol = "-"
+		c.tlsCipher = "-"
+		c.handshakeLatency = -1
+		c.tlsVerifyStatus = "Failed:UnmappedConnectionError"
+	}
+
+	return c
+}
+
+func buildALBConnectionLogLine(c albConnectionCustomizer) string {
+	latency := "-"
+	if c.handshakeLatency >= 0 {
+		latency = fmt.Sprintf("%0.3f", c.handshakeLatency)
+	}
+
+	return fmt.Sprintf("%s %s %d %d %s %s %s \"%s\" %s %s %s %s\n",
+		c.timestamp, c.clientIP, c.clientPort, c.listenerPort,
+		c.tlsProtocol, c.tlsCipher, latency,
+		c.certSubject, c.certValidity, c.certSerial,
+		c.tlsVerifyStatus, c.connTraceID,
+	)
 }
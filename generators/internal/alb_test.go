@@ -13,30 +13,80 @@ const upstreamALBHTTP = `http 2018-07-02T22:23:00.186641Z app/my-loadbalancer/50
 func Test_buildALB(t *testing.T) {
 	t.Run("Validate AWS documented HTTP ALB line", func(t *testing.T) {
 		line := buildALBLogLine(albCustomizer{
-			logType:          "http",
-			timestamp:        "2018-07-02T22:23:00.186641Z",
-			creationTime:     "2018-07-02T22:22:48.364000Z",
-			request:          `"GET http://www.example.com:80/ HTTP/1.1"`,
-			elbID:            "app/my-loadbalancer/50dc6c495c0c9188",
-			targetARN:        "arn:aws:elasticloadbalancing:us-east-2:123456789012:targetgroup/my-targets/73e2d6bc24d8a067",
-			traceID:          `"Root=1-58337262-36d228ad5d99923122bbe354"`,
-			userAgent:        `"curl/7.46.0"`,
-			clientIPPort:     "192.168.131.39:2817",
-			targetIPPort:     "10.0.0.1:80",
-			targetPortList:   `"10.0.0.1:80"`,
-			requestID:        "TID_1234abcd5678ef90",
-			requestProcTime:  0.000,
-			targetProcTime:   0.001,
-			responseProcTime: 0.000,
-			elbStatus:        "200",
-			targetStatus:     "200",
-			cipher:           "-",
-			sslProtocol:      "-",
-			domain:           `"-"`,
-			receivedBytes:    34,
-			sentBytes:        366,
+			logType:              "http",
+			timestamp:            "2018-07-02T22:23:00.186641Z",
+			creationTime:         "2018-07-02T22:22:48.364000Z",
+			request:              "GET http://www.example.com:80/ HTTP/1.1",
+			elbID:                "app/my-loadbalancer/50dc6c495c0c9188",
+			targetARN:            "arn:aws:elasticloadbalancing:us-east-2:123456789012:targetgroup/my-targets/73e2d6bc24d8a067",
+			traceID:              "Root=1-58337262-36d228ad5d99923122bbe354",
+			userAgent:            "curl/7.46.0",
+			clientIPPort:         "192.168.131.39:2817",
+			targetIPPort:         "10.0.0.1:80",
+			targetPortList:       "10.0.0.1:80",
+			targetStatusList:     "200",
+			connTraceID:          "TID_1234abcd5678ef90",
+			requestProcTime:      0.000,
+			targetProcTime:       0.001,
+			responseProcTime:     0.000,
+			elbStatus:            "200",
+			targetStatus:         "200",
+			cipher:               "-",
+			sslProtocol:          "-",
+			domain:               "-",
+			chosenCertArn:        "-",
+			actionsExecuted:      "forward",
+			redirectURL:          "-",
+			errorReason:          "-",
+			classification:       "-",
+			classificationReason: "-",
+			receivedBytes:        34,
+			sentBytes:            366,
 		})
 
 		require.Equal(t, upstreamALBHTTP, strings.TrimSpace(line))
 	})
+	t.Run("Failed targets log -1 processing times", func(t *testing.T) {
+		c := albCustomizer{requestProcTime: 0.001}
+		albTargetFailure(&c)
+
+		line := buildALBLogLine(c)
+		require.Contains(t, line, " 0.001 -1 -1 ")
+		require.Equal(t, "-", c.targetStatus)
+	})
+
+	t.Run("Generated status codes are consistent", func(t *testing.T) {
+		for range 200 {
+			c := newALBCustomizer()
+			if c.targetStatus != "-" {
+				require.Equal(t, c.elbStatus, c.targetStatus)
+				require.Equal(t, c.targetStatus, c.targetStatusList)
+			}
+			if c.targetIPPort == "-" {
+				require.Equal(t, "-", c.targetPortList)
+				require.Equal(t, "-", c.targetStatusList)
+				require.Equal(t, "-", c.targetARN)
+			}
+			require.Len(t, strings.Split(c.request, " "), 3)
+		}
+	})
+
+	t.Run("Connection log line", func(t *testing.T) {
+		line := buildALBConnectionLogLine(albConnectionCustomizer{
+			timestamp:        "2023-10-04T17:12:29.311497Z",
+			clientIP:         "192.0.2.1",
+			clientPort:       39455,
+			listenerPort:     443,
+			tlsProtocol:      "TLSv1.2",
+			tlsCipher:        "ECDHE-RSA-AES128-GCM-SHA256",
+			handshakeLatency: 4.036,
+			certSubject:      "CN=client.example.com",
+			certValidity:     "NotBefore=2023-09-21T22:43:21Z;NotAfter=2026-09-20T22:43:21Z",
+			certSerial:       "FEF257E2E0B2E9B9",
+			tlsVerifyStatus:  "Success",
+			connTraceID:      "TID_ee4dcf5cbeb1bd42a4b3d1749c1ab0b7",
+		})
+
+		require.Equal(t, `2023-10-04T17:12:29.311497Z 192.0.2.1 39455 443 TLSv1.2 ECDHE-RSA-AES128-GCM-SHA256 4.036 "CN=client.example.com" NotBefore=2023-09-21T22:43:21Z;NotAfter=2026-09-20T22:43:21Z FEF257E2E0B2E9B9 Success TID_ee4dcf5cbeb1bd42a4b3d1749c1ab0b7`, strings.TrimSpace(line))
+	})
 }
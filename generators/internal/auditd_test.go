@@ -0,0 +1,120 @@
+package internal
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"data-gen/conf"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func Test_auditdString(t *testing.T) {
+	require.Equal(t, `"/usr/bin/ls"`, auditdString("/usr/bin/ls"))
+	require.Equal(t, "2F746D702F6D792066696C65", auditdString("/tmp/my file"))
+	require.Equal(t, "7361792022686922", auditdString(`say "hi"`))
+	require.Equal(t, "6C73002D6C61", auditdString("ls\x00-la"))
+}
+
+func Test_buildAuditdRecords(t *testing.T) {
+	c := auditdCustomizer{
+		event:    auditdEventExec,
+		time:     time.Date(2026, 10, 19, 10, 20, 30, 123e6, time.UTC),
+		serial:   24287,
+		user:     auditdUser{"alice", 1000},
+		session:  3,
+		tty:      0,
+		pid:      2401,
+		ppid:     2345,
+		command:  auditdCommand{"/usr/bin/bash", []string{"bash", "-c", "id"}},
+		success:  true,
+		inode:    1835142,
+		cwd:      "/home/alice",
+		syscallA: [4]uint64{0x55d0c8a4b2a0, 0x55d0c8a4e8c0, 0x55d0c8a4c010, 8},
+	}
+
+	t.Run("Program execution", func(t *testing.T) {
+		require.Equal(t, []string{
+			`type=SYSCALL msg=audit(1792405230.123:24287): arch=c000003e syscall=59 success=yes exit=0 a0=55d0c8a4b2a0 a1=55d0c8a4e8c0 a2=55d0c8a4c010 a3=8 items=2 ppid=2345 pid=2401 auid=1000 uid=1000 gid=1000 euid=1000 suid=1000 fsuid=1000 egid=1000 sgid=1000 fsgid=1000 tty=pts0 ses=3 comm="bash" exe="/usr/bin/bash" subj=unconfined key="exec"`,
+			`type=EXECVE msg=audit(1792405230.123:24287): argc=3 a0="bash" a1="-c" a2="id"`,
+			`type=CWD msg=audit(1792405230.123:24287): cwd="/home/alice"`,
+			`type=PATH msg=audit(1792405230.123:24287): item=0 name="/usr/bin/bash" inode=1835142 dev=fd:01 mode=0100755 ouid=0 ogid=0 rdev=00:00 nametype=NORMAL cap_fp=0 cap_fi=0 cap_fe=0 cap_fver=0`,
+			`type=PATH msg=audit(1792405230.123:24287): item=1 name="/lib64/ld-linux-x86-64.so.2" inode=1835149 dev=fd:01 mode=0100755 ouid=0 ogid=0 rdev=00:00 nametype=NORMAL cap_fp=0 cap_fi=0 cap_fe=0 cap_fver=0`,
+			`type=PROCTITLE msg=audit(1792405230.123:24287): proctitle=62617368002D63006964`,
+		}, buildAuditdRecords(c, false))
+	})
+
+	t.Run("Enriched records", func(t *testing.T) {
+		records := buildAuditdRecords(c, true)
+		require.True(t, strings.HasSuffix(records[0], `key="exec"`+"\x1d"+`ARCH=x86_64 SYSCALL=execve AUID="alice" UID="alice" GID="alice" EUID="alice" SUID="alice" FSUID="alice" EGID="alice" SGID="alice" FSGID="alice"`))
+		require.NotContains(t, records[1], "\x1d")
+		require.True(t, strings.HasSuffix(records[3], "\x1d"+`OUID="root" OGID="root"`))
+	})
+
+	t.Run("Denied file access", func(t *testing.T) {
+		open := c
+		open.event = auditdEventOpen
+		open.watch = auditdWatch{"/etc/shadow", "identity", "0100640"}
+		open.success = false
+
+		records := buildAuditdRecords(open, false)
+		require.Len(t, records, 4)
+		require.Contains(t, records[0], "syscall=257 success=no exit=-13 a0=ffffff9c")
+		require.True(t, strings.HasSuffix(records[0], `comm="cat" exe="/usr/bin/cat" subj=unconfined key="identity"`))
+		require.Contains(t, records[2], `name="/etc/shadow" inode=1835142 dev=fd:01 mode=0100640`)
+		require.True(t, strings.HasSuffix(records[3], "proctitle=636174002F6574632F736861646F77"))
+	})
+
+	t.Run("User records", func(t *testing.T) {
+		login := c
+		login.event = auditdEventUserLogin
+		login.success = false
+		login.acct = "(invalid user)"
+		login.addr = "203.0.113.5"
+		require.Equal(t, []string{
+			`type=USER_LOGIN msg=audit(1792405230.123:24287): pid=2401 uid=0 auid=4294967295 ses=4294967295 subj=unconfined msg='op=login acct=28696E76616C6964207573657229 exe="/usr/sbin/sshd" hostname=? addr=203.0.113.5 terminal=ssh res=failed'`,
+		}, buildAuditdRecords(login, false))
+
+		cmd := c
+		cmd.event = auditdEventUserCmd
+		cmd.command = auditdCommand{args: []string{"/usr/bin/apt-get", "update"}}
+		require.Equal(t, []string{
+			`type=USER_CMD msg=audit(1792405230.123:24287): pid=2401 uid=1000 auid=1000 ses=3 subj=unconfined msg='cwd="/home/alice" cmd=2F7573722F62696E2F6170742D67657420757064617465 exe="/usr/bin/sudo" terminal=pts/0 res=success'`,
+		}, buildAuditdRecords(cmd, false))
+	})
+}
+
+func Test_NewAuditdGen(t *testing.T) {
+	var input conf.InputConfig
+	require.NoError(t, yaml.Unmarshal([]byte("log_format: enriched"), &input.Conf))
+
+	gen, err := NewAuditdGen(input)
+	require.NoError(t, err)
+
+	// records of an event share a single event ID, which differs across events
+	eventID := regexp.MustCompile(`^type=[A-Z_]+ msg=audit\((\d+\.\d{3}:\d+)\): `)
+	var last string
+	for range 200 {
+		_, err = gen.Generate()
+		require.NoError(t, err)
+
+		lines := strings.Split(strings.TrimSuffix(string(gen.GetAndReset()), "\n"), "\n")
+		id := eventID.FindStringSubmatch(lines[0])
+		require.NotNil(t, id)
+		require.NotEqual(t, last, id[1])
+		last = id[1]
+
+		for _, line := range lines[1:] {
+			match := eventID.FindStringSubmatch(line)
+			require.NotNil(t, match)
+			require.Equal(t, id[1], match[1])
+		}
+	}
+
+	require.NoError(t, yaml.Unmarshal([]byte("log_format: json"), &input.Conf))
+	_, err = NewAuditdGen(input)
+	require.ErrorContains(t, err, "unknown auditd log format")
+}
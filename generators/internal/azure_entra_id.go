@@ -0,0 +1,519 @@
+package internal
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+
+	"data-gen/conf"
+
+	"github.com/google/uuid"
+)
+
+const (
+	entraCategorySignIn = "SignInLogs"
+	entraCategoryAudit  = "AuditLogs"
+
+	entraTimeFormat = "2006-01-02T15:04:05.0000000Z"
+	entraDomain     = "example.com"
+)
+
+// entraApp is an application users sign in to, along with the resource it requests tokens for.
+type entraApp struct {
+	id           string
+	name         string
+	resourceID   string
+	resourceName string
+	browser      bool
+}
+
+var entraApps = []entraApp{
+	{"c44b4083-3bb0-49c1-b47d-974e53cbdf3c", "Azure Portal", "797f4846-ba00-4fd7-ba43-dac1f8f63013", "Windows Azure Service Management API", true},
+	{"4765445b-32c6-49b0-83e6-1d93765276ca", "OfficeHome", "00000003-0000-0000-c000-000000000000", "Microsoft Graph", true},
+	{"1fec8e78-bce4-4aaf-ab1b-5451cc387264", "Microsoft Teams", "00000003-0000-0000-c000-000000000000", "Microsoft Graph", false},
+	{"d3590ed6-52b3-4102-aeff-aad2292ab01c", "Microsoft Office", "00000002-0000-0ff1-ce00-000000000000", "Office 365 Exchange Online", false},
+	{"04b07795-8ddb-461a-bbee-02f9e1bf7b46", "Microsoft Azure CLI", "797f4846-ba00-4fd7-ba43-dac1f8f63013", "Windows Azure Service Management API", false},
+}
+
+// entraSignInError is a sign-in failure, or an interrupt such as an MFA prompt.
+type entraSignInError struct {
+	code        int
+	description string
+}
+
+var entraSignInErrors = []entraSignInError{
+	{50126, "Error validating credentials due to invalid username or password."},
+	{50074, "Strong Authentication is required."},
+	{50053, "Account is locked because user tried to sign in too many times with an incorrect user ID or password."},
+	{53003, "Access has been blocked by Conditional Access policies. The access policy does not allow token issuance."},
+	{500121, "Authentication failed during strong authentication request."},
+}
+
+var entraLocations = []entraLocation{
+	{"Seattle", "Washington", "US", entraGeoCoordinates{47.6062, -122.3321}},
+	{"London", "England", "GB", entraGeoCoordinates{51.5072, -0.1276}},
+	{"Berlin", "Berlin", "DE", entraGeoCoordinates{52.52, 13.405}},
+	{"Sydney", "New South Wales", "AU", entraGeoCoordinates{-33.8688, 151.2093}},
+	{"Bengaluru", "Karnataka", "IN", entraGeoCoordinates{12.9716, 77.5946}},
+}
+
+var entraDevices = []entraDeviceDetail{
+	{OperatingSystem: "Windows10", Browser: "Edge 129.0.0", TrustType: "Azure AD joined", IsCompliant: true, IsManaged: true},
+	{OperatingSystem: "MacOs", Browser: "Chrome 130.0.0"},
+	{OperatingSystem: "Ios", Browser: "Mobile Safari 18.0"},
+	{OperatingSystem: "Linux", Browser: "Firefox 131.0"},
+}
+
+// entraAuditActivity is a directory change logged to audit logs, along with the type of the changed resource.
+type entraAuditActivity struct {
+	name          string
+	category      string
+	operationType string
+	service       string
+	targetType    string
+	// property is the modified property of the target, if any
+	property string
+}
+
+var entraAuditActivities = []entraAuditActivity{
+	{"Add member to group", "GroupManagement", "Assign", "Core Directory", "User", "Group.DisplayName"},
+	{"Remove member from group", "GroupManagement", "Unassign", "Core Directory", "User", "Group.DisplayName"},
+	{"Add user", "UserManagement", "Add", "Core Directory", "User", "AccountEnabled"},
+	{"Update user", "UserManagement", "Update", "Core Directory", "User", "StrongAuthenticationMethod"},
+	{"Reset user password", "UserManagement", "Reset", "Core Directory", "User", ""},
+	{"Add member to role", "RoleManagement", "Assign", "Core Directory", "User", "Role.DisplayName"},
+	{"Consent to application", "ApplicationManagement", "Assign", "Core Directory", "ServicePrincipal", "ConsentAction.Permissions"},
+	{"Add service principal credentials", "ApplicationManagement", "Update", "Core Directory", "ServicePrincipal", "KeyDescription"},
+	{"Update conditional access policy", "Policy", "Update", "Conditional Access", "Policy", "ConditionalAccessPolicy"},
+}
+
+// AzureEntraIDLogGen generates Microsoft Entra ID (Azure AD) sign-in and audit logs of a single tenant,
+// as delivered by diagnostic settings.
+type AzureEntraIDLogGen struct {
+	cfg      azureEntraIDLogCfg
+	buf      trackedBuffer
+	tenantID string
+	users    []entraUser
+}
+
+// azureEntraIDLogCfg specifies the log categories and the records per {"records": [...]} envelope.
+type azureEntraIDLogCfg struct {
+	Categories      []string `yaml:"categories"`
+	RecordsPerBatch int      `yaml:"records_per_batch"`
+}
+
+// entraUser is a user of the tenant.
+type entraUser struct {
+	id          string
+	displayName string
+	upn         string
+}
+
+func NewAzureEntraIDLogGen(input conf.InputConfig) (*AzureEntraIDLogGen, error) {
+	cfg := azureEntraIDLogCfg{Categories: []string{entraCategorySignIn, entraCategoryAudit}, RecordsPerBatch: 1}
+	err := input.Conf.Decode(&cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode azure entra id configuration: %w", err)
+	}
+
+	if len(cfg.Categories) == 0 {
+		return nil, fmt.Errorf("azure entra id categories must not be empty")
+	}
+
+	for _, c := range cfg.Categories {
+		if c != entraCategorySignIn && c != entraCategoryAudit {
+			return nil, fmt.Errorf("unknown azure entra id log category: %s", c)
+		}
+	}
+
+	cfg.RecordsPerBatch = azureRecordsPerBatch(cfg.RecordsPerBatch)
+	if cfg.RecordsPerBatch < 1 {
+		return nil, fmt.Errorf("azure entra id records_per_batch must be positive")
+	}
+
+	var users []entraUser
+	for _, first := range firstNames {
+		last := lastNames[rand.IntN(len(lastNames))]
+		users = append(users, entraUser{
+			id:          uuid.NewString(),
+			displayName: first + " " + last,
+			upn:         strings.ToLower(first+"."+last) + "@" + entraDomain,
+		})
+	}
+
+	return &AzureEntraIDLogGen{
+		cfg:      cfg,
+		buf:      newTrackedBuffer(),
+		tenantID: uuid.NewString(),
+		users:    users,
+	}, nil
+}
+
+func (a *AzureEntraIDLogGen) Generate() (int64, error) {
+	records := make([]entraLog, a.cfg.RecordsPerBatch)
+	for i := range records {
+		if a.cfg.Categories[rand.IntN(len(a.cfg.Categories))] == entraCategorySignIn {
+			records[i] = a.buildSignInLog(time.Now().UTC())
+		} else {
+			records[i] = a.buildAuditLog(time.Now().UTC())
+		}
+	}
+
+	return writeAzureRecords(&a.buf, records)
+}
+
+func (a *AzureEntraIDLogGen) GetAndReset() []byte {
+	return a.buf.getAndReset()
+}
+
+// entraLog is a sign-in or audit log record, with category specific details in properties.
+// See - https://learn.microsoft.com/en-us/entra/identity/monitoring-health/howto-stream-logs-to-event-hub
+type entraLog struct {
+	Time              string `json:"time"`
+	ResourceID        string `json:"resourceId"`
+	OperationName     string `json:"operationName"`
+	OperationVersion  string `json:"operationVersion"`
+	Category          string `json:"category"`
+	TenantID          string `json:"tenantId"`
+	ResultType        string `json:"resultType,omitempty"`
+	ResultSignature   string `json:"resultSignature"`
+	ResultDescription string `json:"resultDescription,omitempty"`
+	DurationMs        int    `json:"durationMs"`
+	CallerIPAddress   string `json:"callerIpAddress"`
+	CorrelationID     string `json:"correlationId"`
+	Identity          string `json:"identity,omitempty"`
+	Level             int    `json:"Level"`
+	Location          string `json:"location,omitempty"`
+	Properties        any    `json:"properties"`
+}
+
+// entraSignIn is the properties of a sign-in log record, same as the signIn resource of Microsoft Graph.
+type entraSignIn struct {
+	ID                               string               `json:"id"`
+	CreatedDateTime                  string               `json:"createdDateTime"`
+	UserDisplayName                  string               `json:"userDisplayName"`
+	UserPrincipalName                string               `json:"userPrincipalName"`
+	UserID                           string               `json:"userId"`
+	AppID                            string               `json:"appId"`
+	AppDisplayName                   string               `json:"appDisplayName"`
+	IPAddress                        string               `json:"ipAddress"`
+	Status                           entraSignInStatus    `json:"status"`
+	ClientAppUsed                    string               `json:"clientAppUsed"`
+	UserAgent                        string               `json:"userAgent"`
+	DeviceDetail                     entraDeviceDetail    `json:"deviceDetail"`
+	Location                         entraLocation        `json:"location"`
+	CorrelationID                    string               `json:"correlationId"`
+	ConditionalAccessStatus          string               `json:"conditionalAccessStatus"`
+	AppliedConditionalAccessPolicies []entraAppliedPolicy `json:"appliedConditionalAccessPolicies"`
+	AuthenticationRequirement        string               `json:"authenticationRequirement"`
+	IsInteractive                    bool                 `json:"isInteractive"`
+	TokenIssuerType                  string               `json:"tokenIssuerType"`
+	RiskDetail                       string               `json:"riskDetail"`
+	RiskLevelAggregated              string               `json:"riskLevelAggregated"`
+	RiskLevelDuringSignIn            string               `json:"riskLevelDuringSignIn"`
+	RiskState                        string               `json:"riskState"`
+	RiskEventTypes                   []string             `json:"riskEventTypes"`
+	RiskEventTypesV2                 []string             `json:"riskEventTypes_v2"`
+	ResourceDisplayName              string               `json:"resourceDisplayName"`
+	ResourceID                       string               `json:"resourceId"`
+	AuthenticationMethodsUsed        []string             `json:"authenticationMethodsUsed"`
+}
+
+type entraSignInStatus struct {
+	ErrorCode         int    `json:"errorCode"`
+	FailureReason     string `json:"failureReason,omitempty"`
+	AdditionalDetails string `json:"additionalDetails,omitempty"`
+}
+
+type entraDeviceDetail struct {
+	DeviceID        string `json:"deviceId"`
+	DisplayName     string `json:"displayName"`
+	OperatingSystem string `json:"operatingSystem"`
+	Browser         string `json:"browser"`
+	IsCompliant     bool   `json:"isCompliant"`
+	IsManaged       bool   `json:"isManaged"`
+	TrustType       string `json:"trustType"`
+}
+
+type entraLocation struct {
+	City            string              `json:"city"`
+	State           string              `json:"state"`
+	CountryOrRegion string              `json:"countryOrRegion"`
+	GeoCoordinates  entraGeoCoordinates `json:"geoCoordinates"`
+}
+
+type entraGeoCoordinates struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+type entraAppliedPolicy struct {
+	ID                      string   `json:"id"`
+	DisplayName             string   `json:"displayName"`
+	EnforcedGrantControls   []string `json:"enforcedGrantControls"`
+	EnforcedSessionControls []string `json:"enforcedSessionControls"`
+	Result                  string   `json:"result"`
+}
+
+// entraAudit is the properties of an audit log record, same as the directoryAudit resource of Microsoft Graph.
+type entraAudit struct {
+	ID                  string                `json:"id"`
+	Category            string                `json:"category"`
+	CorrelationID       string                `json:"correlationId"`
+	Result              string                `json:"result"`
+	ResultReason        string                `json:"resultReason"`
+	ActivityDisplayName string                `json:"activityDisplayName"`
+	ActivityDateTime    string                `json:"activityDateTime"`
+	LoggedByService     string                `json:"loggedByService"`
+	OperationType       string                `json:"operationType"`
+	InitiatedBy         entraInitiatedBy      `json:"initiatedBy"`
+	TargetResources     []entraTargetResource `json:"targetResources"`
+	AdditionalDetails   []entraKeyValue       `json:"additionalDetails"`
+}
+
+// entraInitiatedBy is the user or the application initiating the activity.
+type entraInitiatedBy struct {
+	User *entraAuditUser `json:"user,omitempty"`
+	App  *entraAuditApp  `json:"app,omitempty"`
+}
+
+type entraAuditUser struct {
+	ID                string   `json:"id"`
+	DisplayName       *string  `json:"displayName"`
+	UserPrincipalName string   `json:"userPrincipalName"`
+	IPAddress         string   `json:"ipAddress"`
+	Roles             []string `json:"roles"`
+}
+
+type entraAuditApp struct {
+	AppID                *string `json:"appId"`
+	DisplayName          string  `json:"displayName"`
+	ServicePrincipalID   string  `json:"servicePrincipalId"`
+	ServicePrincipalName *string `json:"servicePrincipalName"`
+}
+
+type entraTargetResource struct {
+	ID                  string                  `json:"id"`
+	DisplayName         *string                 `json:"displayName"`
+	Type                string                  `json:"type"`
+	UserPrincipalName   string                  `json:"userPrincipalName,omitempty"`
+	ModifiedProperties  []entraModifiedProperty `json:"modifiedProperties"`
+	AdministrativeUnits []string                `json:"administrativeUnits"`
+}
+
+// entraModifiedProperty is a changed property of the target, with JSON encoded values.
+type entraModifiedProperty struct {
+	DisplayName string  `json:"displayName"`
+	OldValue    *string `json:"oldValue"`
+	NewValue    string  `json:"newValue"`
+}
+
+type entraKeyValue struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// resourceID returns the resource ID of Entra ID diagnostic logs, which is the tenant rather than an ARM resource.
+func (a *AzureEntraIDLogGen) resourceID() string {
+	return "/tenants/" + a.tenantID + "/providers/Microsoft.aadiam"
+}
+
+func (a *AzureEntraIDLogGen) buildSignInLog(now time.Time) entraLog {
+	user := a.users[rand.IntN(len(a.users))]
+	app := entraApps[rand.IntN(len(entraApps))]
+	location := entraLocations[rand.IntN(len(entraLocations))]
+	device := entraDevices[rand.IntN(len(entraDevices))]
+	correlationID := uuid.NewString()
+	ip := randomIP()
+
+	s := entraSignIn{
+		ID:                               uuid.NewString(),
+		CreatedDateTime:                  now.Format(entraTimeFormat),
+		UserDisplayName:                  user.displayName,
+		UserPrincipalName:                user.upn,
+		UserID:                           user.id,
+		AppID:                            app.id,
+		AppDisplayName:                   app.name,
+		IPAddress:                        ip,
+		ClientAppUsed:                    "Mobile Apps and Desktop clients",
+		UserAgent:                        httpRequestUserAgents[rand.IntN(len(httpRequestUserAgents))],
+		DeviceDetail:                     device,
+		Location:                         location,
+		CorrelationID:                    correlationID,
+		ConditionalAccessStatus:          "notApplied",
+		AppliedConditionalAccessPolicies: []entraAppliedPolicy{},
+		AuthenticationRequirement:        "singleFactorAuthentication",
+		IsInteractive:                    true,
+		TokenIssuerType:                  "AzureAD",
+		RiskDetail:                       "none",
+		RiskLevelAggregated:              "none",
+		RiskLevelDuringSignIn:            "none",
+		RiskState:                        "none",
+		RiskEventTypes:                   []string{},
+		RiskEventTypesV2:                 []string{},
+		ResourceDisplayName:              app.resourceName,
+		ResourceID:                       app.resourceID,
+		AuthenticationMethodsUsed:        []string{"Password"},
+	}
+	if app.browser {
+		s.ClientAppUsed = "Browser"
+	}
+	if device.TrustType != "" {
+		s.DeviceDetail.DeviceID = uuid.NewString()
+		s.DeviceDetail.DisplayName = "WS-" + strings.ToUpper(randomAZ09String(7))
+	}
+
+	// administrative apps require MFA through conditional access, while others sign in with a password only
+	mfa := entraAppliedPolicy{
+		ID:                      "a3f1b7c4-6d2e-4f5a-9b8c-1e2d3f4a5b6c",
+		DisplayName:             "Require MFA for Azure management",
+		EnforcedGrantControls:   []string{"Mfa"},
+		EnforcedSessionControls: []string{},
+		Result:                  "notApplied",
+	}
+	if app.resourceName == "Windows Azure Service Management API" {
+		mfa.Result = "success"
+		s.ConditionalAccessStatus = "success"
+		s.AuthenticationRequirement = "multiFactorAuthentication"
+		s.AuthenticationMethodsUsed = append(s.AuthenticationMethodsUsed, "Mobile app notification")
+	}
+	s.AppliedConditionalAccessPolicies = append(s.AppliedConditionalAccessPolicies, mfa)
+
+	log := entraLog{
+		Time:             now.Format(entraTimeFormat),
+		ResourceID:       a.resourceID(),
+		OperationName:    "Sign-in activity",
+		OperationVersion: "1.0",
+		Category:         entraCategorySignIn,
+		TenantID:         a.tenantID,
+		ResultType:       "0",
+		ResultSignature:  "None",
+		CallerIPAddress:  ip,
+		CorrelationID:    correlationID,
+		Identity:         user.displayName,
+		Level:            4,
+		Location:         location.CountryOrRegion,
+	}
+
+	if rand.IntN(4) == 0 {
+		e := entraSignInErrors[rand.IntN(len(entraSignInErrors))]
+		s.Status = entraSignInStatus{ErrorCode: e.code, FailureReason: e.description}
+		log.ResultType = fmt.Sprint(e.code)
+		log.ResultDescription = e.description
+
+		switch e.code {
+		case 50074:
+			s.Status.AdditionalDetails = "MFA required in Azure AD"
+			s.AuthenticationRequirement = "multiFactorAuthentication"
+		case 53003:
+			s.ConditionalAccessStatus = "failure"
+			s.AppliedConditionalAccessPolicies[0] = entraAppliedPolicy{
+				ID:                      "5e8b2c1d-3a4f-4b6e-8d7c-9f0a1b2c3d4e",
+				DisplayName:             "Block access from unmanaged devices",
+				EnforcedGrantControls:   []string{"Block"},
+				EnforcedSessionControls: []string{},
+				Result:                  "failure",
+			}
+		}
+	} else if rand.IntN(10) == 0 {
+		// risky sign-ins are still successful, while flagged by Identity Protection
+		s.RiskLevelAggregated, s.RiskLevelDuringSignIn, s.RiskState = "medium", "medium", "atRisk"
+		s.RiskEventTypes = []string{"unfamiliarFeatures"}
+		s.RiskEventTypesV2 = []string{"unfamiliarFeatures"}
+	}
+
+	log.Properties = s
+	return log
+}
+
+func (a *AzureEntraIDLogGen) buildAuditLog(now time.Time) entraLog {
+	activity := entraAuditActivities[rand.IntN(len(entraAuditActivities))]
+	admin := a.users[0]
+	correlationID := uuid.NewString()
+
+	audit := entraAudit{
+		ID:                  fmt.Sprintf("Directory_%s_%s", correlationID, randomAZ09String(5)),
+		Category:            activity.category,
+		CorrelationID:       correlationID,
+		Result:              "success",
+		ActivityDisplayName: activity.name,
+		ActivityDateTime:    now.Format(entraTimeFormat),
+		LoggedByService:     activity.service,
+		OperationType:       activity.operationType,
+		InitiatedBy: entraInitiatedBy{User: &entraAuditUser{
+			ID:                admin.id,
+			UserPrincipalName: admin.upn,
+			IPAddress:         randomIP(),
+			Roles:             []string{},
+		}},
+		AdditionalDetails: []entraKeyValue{},
+	}
+
+	target := entraTargetResource{
+		ID:                  uuid.NewString(),
+		Type:                activity.targetType,
+		ModifiedProperties:  []entraModifiedProperty{},
+		AdministrativeUnits: []string{},
+	}
+	switch activity.targetType {
+	case "User":
+		user := a.users[rand.IntN(len(a.users)-1)+1]
+		target.ID, target.UserPrincipalName = user.id, user.upn
+	case "ServicePrincipal":
+		name := entraApps[rand.IntN(len(entraApps))].name
+		target.DisplayName = &name
+	case "Policy":
+		name := "Require MFA for Azure management"
+		target.DisplayName = &name
+	}
+
+	switch activity.property {
+	case "":
+	case "Group.DisplayName":
+		target.ModifiedProperties = append(target.ModifiedProperties, entraModifiedProperty{DisplayName: "Group.ObjectID", NewValue: fmt.Sprintf("%q", uuid.NewString())})
+		target.ModifiedProperties = append(target.ModifiedProperties, entraModifiedProperty{DisplayName: activity.property, NewValue: `"` + []string{"Finance", "Engineering", "VPN Users"}[rand.IntN(3)] + `"`})
+	case "Role.DisplayName":
+		target.ModifiedProperties = append(target.ModifiedProperties, entraModifiedProperty{DisplayName: activity.property, NewValue: `"` + []string{"Global Administrator", "User Administrator", "Security Reader"}[rand.IntN(3)] + `"`})
+	case "AccountEnabled":
+		target.ModifiedProperties = append(target.ModifiedProperties, entraModifiedProperty{DisplayName: activity.property, NewValue: "[true]"})
+	default:
+		old := "[]"
+		target.ModifiedProperties = append(target.ModifiedProperties, entraModifiedProperty{DisplayName: activity.property, OldValue: &old, NewValue: fmt.Sprintf(`[{"Id":%q}]`, uuid.NewString())})
+	}
+	audit.TargetResources = []entraTargetResource{target}
+
+	// user updates are also made by directory synchronization, initiated by an application
+	if activity.name == "Update user" && rand.IntN(2) == 0 {
+		audit.InitiatedBy = entraInitiatedBy{App: &entraAuditApp{DisplayName: "Microsoft Entra Connect Sync", ServicePrincipalID: uuid.NewString()}}
+	}
+
+	if rand.IntN(20) == 0 {
+		audit.Result = "failure"
+		audit.ResultReason = "Microsoft.Online.Directory.Core.Common.DirectoryObjectAlreadyExistsException"
+	}
+
+	callerIP := "<null>"
+	if audit.InitiatedBy.User != nil {
+		callerIP = audit.InitiatedBy.User.IPAddress
+	}
+
+	if slices.Contains([]string{"Add member to role", "Consent to application"}, activity.name) {
+		audit.AdditionalDetails = append(audit.AdditionalDetails, entraKeyValue{Key: "User-Agent", Value: httpRequestUserAgents[rand.IntN(len(httpRequestUserAgents))]})
+	}
+
+	return entraLog{
+		Time:             now.Format(entraTimeFormat),
+		ResourceID:       a.resourceID(),
+		OperationName:    activity.name,
+		OperationVersion: "1.0",
+		Category:         entraCategoryAudit,
+		TenantID:         a.tenantID,
+		ResultSignature:  "None",
+		CallerIPAddress:  callerIP,
+		CorrelationID:    correlationID,
+		Level:            4,
+		Properties:       audit,
+	}
+}
@@ -0,0 +1,80 @@
+package internal
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"data-gen/conf"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func Test_NewAzureEntraIDLogGen(t *testing.T) {
+	t.Run("All categories", func(t *testing.T) {
+		var input conf.InputConfig
+		require.NoError(t, yaml.Unmarshal([]byte("records_per_batch: 200"), &input.Conf))
+
+		gen, err := NewAzureEntraIDLogGen(input)
+		require.NoError(t, err)
+
+		_, err = gen.Generate()
+		require.NoError(t, err)
+
+		var envelope struct {
+			Records []map[string]any `json:"records"`
+		}
+		require.NoError(t, json.Unmarshal(gen.GetAndReset(), &envelope))
+		require.Len(t, envelope.Records, 200)
+
+		categories := map[string]int{}
+		for _, r := range envelope.Records {
+			categories[r["category"].(string)]++
+			require.Equal(t, gen.resourceID(), r["resourceId"])
+
+			properties := r["properties"].(map[string]any)
+			require.Equal(t, r["correlationId"], properties["correlationId"])
+
+			switch r["category"] {
+			case entraCategorySignIn:
+				// failed sign-ins carry the error code as the result type
+				status := properties["status"].(map[string]any)
+				require.Equal(t, strconv.Itoa(int(status["errorCode"].(float64))), r["resultType"])
+				require.Equal(t, r["callerIpAddress"], properties["ipAddress"])
+			case entraCategoryAudit:
+				require.Equal(t, r["operationName"], properties["activityDisplayName"])
+				require.Len(t, properties["targetResources"], 1)
+			}
+		}
+		require.Len(t, categories, 2)
+	})
+
+	t.Run("Sign-in logs only", func(t *testing.T) {
+		var input conf.InputConfig
+		require.NoError(t, yaml.Unmarshal([]byte("categories: [SignInLogs]\nrecords_per_batch: 20"), &input.Conf))
+
+		gen, err := NewAzureEntraIDLogGen(input)
+		require.NoError(t, err)
+
+		_, err = gen.Generate()
+		require.NoError(t, err)
+
+		var envelope struct {
+			Records []entraLog `json:"records"`
+		}
+		require.NoError(t, json.Unmarshal(gen.GetAndReset(), &envelope))
+		for _, r := range envelope.Records {
+			require.Equal(t, entraCategorySignIn, r.Category)
+			require.Equal(t, "Sign-in activity", r.OperationName)
+		}
+	})
+
+	t.Run("Invalid category", func(t *testing.T) {
+		var input conf.InputConfig
+		require.NoError(t, yaml.Unmarshal([]byte("categories: [SignInLogs, RiskyUsers]"), &input.Conf))
+
+		_, err := NewAzureEntraIDLogGen(input)
+		require.ErrorContains(t, err, "unknown azure entra id log category: RiskyUsers")
+	})
+}
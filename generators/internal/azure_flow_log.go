@@ -0,0 +1,338 @@
+package internal
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"data-gen/conf"
+
+	"github.com/google/uuid"
+)
+
+const (
+	azureFlowLogNSG  = "nsg"
+	azureFlowLogVNet = "vnet"
+
+	azureFlowLogTimeFormat = "2006-01-02T15:04:05.0000000Z"
+)
+
+// azureFlowRule is a network security rule along with the traffic it matches.
+type azureFlowRule struct {
+	name     string
+	inbound  bool
+	allow    bool
+	protocol int
+	ports    []int
+	fromVNet bool
+}
+
+var azureFlowRules = []azureFlowRule{
+	{"DefaultRule_AllowInternetOutBound", false, true, vpcProtocolTCP, []int{443, 443, 443, 80}, false},
+	{"DefaultRule_AllowVnetOutBound", false, true, vpcProtocolUDP, []int{53, 123}, true},
+	{"DefaultRule_AllowVnetInBound", true, true, vpcProtocolTCP, []int{443, 1433, 5432}, true},
+	{"UserRule_Allow-HTTPS-Inbound", true, true, vpcProtocolTCP, []int{443}, false},
+	{"UserRule_Deny-SSH-RDP-Internet", true, false, vpcProtocolTCP, []int{22, 3389}, false},
+	{"DefaultRule_DenyAllInBound", true, false, vpcProtocolTCP, []int{23, 445, 1433, 3306, 5900, 8080}, false},
+}
+
+// AzureFlowLogGen generates Azure NSG flow logs (version 2) or VNet flow logs of a single network interface,
+// in the JSON shape written to storage blobs and delivered through Event Hubs.
+type AzureFlowLogGen struct {
+	cfg            azureFlowLogCfg
+	buf            trackedBuffer
+	subscriptionID string
+	resourceGroup  string
+	region         string
+	macAddress     string
+	privateIP      string
+	// systemID & aclID identify the NSG, while flowLogGUID identifies the VNet flow log resource
+	systemID    string
+	aclID       string
+	flowLogGUID string
+}
+
+// azureFlowLogCfg specifies the flow log type and the records per {"records": [...]} envelope.
+type azureFlowLogCfg struct {
+	LogType         string `yaml:"log_type"`
+	RecordsPerBatch int    `yaml:"records_per_batch"`
+}
+
+func NewAzureFlowLogGen(input conf.InputConfig) (*AzureFlowLogGen, error) {
+	cfg := azureFlowLogCfg{LogType: azureFlowLogNSG, RecordsPerBatch: 1}
+	err := input.Conf.Decode(&cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode azure flow logs configuration: %w", err)
+	}
+
+	if cfg.LogType != azureFlowLogNSG && cfg.LogType != azureFlowLogVNet {
+		return nil, fmt.Errorf("unknown azure flow log type: %s", cfg.LogType)
+	}
+
+	cfg.RecordsPerBatch = azureRecordsPerBatch(cfg.RecordsPerBatch)
+	if cfg.RecordsPerBatch < 1 {
+		return nil, fmt.Errorf("azure flow logs records_per_batch must be positive")
+	}
+
+	return &AzureFlowLogGen{
+		cfg:            cfg,
+		buf:            newTrackedBuffer(),
+		subscriptionID: uuid.NewString(),
+		resourceGroup:  "rg-" + strings.ToLower(randomAZaz09String(8)),
+		region:         randomAzureRegion(),
+		macAddress:     "000D3A" + strings.ToUpper(randomHexString(6)),
+		privateIP:      fmt.Sprintf("10.1.%d.%d", rand.IntN(4), rand.IntN(250)+4),
+		systemID:       uuid.NewString(),
+		aclID:          uuid.NewString(),
+		flowLogGUID:    uuid.NewString(),
+	}, nil
+}
+
+func (a *AzureFlowLogGen) Generate() (int64, error) {
+	// each record covers a minute of flows of the network interface
+	now := time.Now().UTC()
+
+	if a.cfg.LogType == azureFlowLogVNet {
+		records := make([]azureVNetFlowRecord, a.cfg.RecordsPerBatch)
+		for i := range records {
+			records[i] = a.buildVNetFlowRecord(now, newAzureFlowGroups(a.privateIP, now))
+		}
+		return writeAzureRecords(&a.buf, records)
+	}
+
+	records := make([]azureNSGFlowRecord, a.cfg.RecordsPerBatch)
+	for i := range records {
+		records[i] = a.buildNSGFlowRecord(now, newAzureFlowGroups(a.privateIP, now))
+	}
+	return writeAzureRecords(&a.buf, records)
+}
+
+func (a *AzureFlowLogGen) GetAndReset() []byte {
+	return a.buf.getAndReset()
+}
+
+// azureNSGFlowRecord is an NSG flow log record, with flows grouped by rule and by MAC address.
+// See - https://learn.microsoft.com/en-us/azure/network-watcher/nsg-flow-logs-overview#log-format
+type azureNSGFlowRecord struct {
+	Time          string                 `json:"time"`
+	SystemID      string                 `json:"systemId"`
+	MacAddress    string                 `json:"macAddress"`
+	Category      string                 `json:"category"`
+	ResourceID    string                 `json:"resourceId"`
+	OperationName string                 `json:"operationName"`
+	Properties    azureNSGFlowProperties `json:"properties"`
+}
+
+type azureNSGFlowProperties struct {
+	Version int                `json:"Version"`
+	Flows   []azureNSGRuleFlow `json:"flows"`
+}
+
+type azureNSGRuleFlow struct {
+	Rule  string            `json:"rule"`
+	Flows []azureNSGMACFlow `json:"flows"`
+}
+
+type azureNSGMACFlow struct {
+	MAC        string   `json:"mac"`
+	FlowTuples []string `json:"flowTuples"`
+}
+
+// azureVNetFlowRecord is a VNet flow log record, with flows grouped by the NSG ACL and by rule.
+// See - https://learn.microsoft.com/en-us/azure/network-watcher/vnet-flow-logs-overview#log-format
+type azureVNetFlowRecord struct {
+	Time              string               `json:"time"`
+	FlowLogGUID       string               `json:"flowLogGUID"`
+	MacAddress        string               `json:"macAddress"`
+	Category          string               `json:"category"`
+	FlowLogResourceID string               `json:"flowLogResourceID"`
+	TargetResourceID  string               `json:"targetResourceID"`
+	OperationName     string               `json:"operationName"`
+	FlowRecords       azureVNetFlowRecords `json:"flowRecords"`
+}
+
+type azureVNetFlowRecords struct {
+	Flows []azureVNetACLFlow `json:"flows"`
+}
+
+type azureVNetACLFlow struct {
+	ACLID      string               `json:"aclID"`
+	FlowGroups []azureVNetFlowGroup `json:"flowGroups"`
+}
+
+type azureVNetFlowGroup struct {
+	Rule       string   `json:"rule"`
+	FlowTuples []string `json:"flowTuples"`
+}
+
+// azureFlow is a flow of the network interface, along with its packet & byte counters.
+// Source to destination (s2d) and destination to source (d2s) counters follow the flow direction.
+type azureFlow struct {
+	start      time.Time
+	end        time.Time
+	srcIP      string
+	dstIP      string
+	srcPort    int
+	dstPort    int
+	protocol   int
+	inbound    bool
+	allowed    bool
+	packetsS2D int
+	bytesS2D   int
+	packetsD2S int
+	bytesD2S   int
+}
+
+// azureFlowGroup is the flows matching a rule.
+type azureFlowGroup struct {
+	rule  string
+	flows []azureFlow
+}
+
+func newAzureFlowGroups(privateIP string, now time.Time) []azureFlowGroup {
+	rules := slices.Clone(azureFlowRules)
+	rand.Shuffle(len(rules), func(i, j int) { rules[i], rules[j] = rules[j], rules[i] })
+
+	groups := make([]azureFlowGroup, 0, 3)
+	for _, rule := range rules[:rand.IntN(3)+1] {
+		group := azureFlowGroup{rule: rule.name}
+		for range rand.IntN(4) + 1 {
+			group.flows = append(group.flows, newAzureFlow(rule, privateIP, now))
+		}
+		groups = append(groups, group)
+	}
+
+	return groups
+}
+
+func newAzureFlow(rule azureFlowRule, privateIP string, now time.Time) azureFlow {
+	remote := randomIP()
+	if rule.fromVNet {
+		remote = fmt.Sprintf("10.1.%d.%d", rand.IntN(4), rand.IntN(250)+4)
+	}
+
+	f := azureFlow{
+		start:    now.Add(-time.Duration(rand.IntN(60_000)) * time.Millisecond),
+		srcIP:    privateIP,
+		dstIP:    remote,
+		srcPort:  rand.IntN(65535-49152) + 49152,
+		dstPort:  rule.ports[rand.IntN(len(rule.ports))],
+		protocol: rule.protocol,
+		inbound:  rule.inbound,
+		allowed:  rule.allow,
+	}
+	if rule.inbound {
+		f.srcIP, f.dstIP = remote, privateIP
+	}
+
+	if f.allowed {
+		f.end = f.start.Add(time.Duration(rand.IntN(int(now.Sub(f.start).Milliseconds())+1)) * time.Millisecond)
+		f.packetsS2D = rand.IntN(40) + 1
+		f.bytesS2D = f.packetsS2D * (rand.IntN(900) + 60)
+		f.packetsD2S = rand.IntN(60) + 1
+		f.bytesD2S = f.packetsD2S * (rand.IntN(1400) + 60)
+	}
+
+	return f
+}
+
+// direction returns I for inbound flows, O for outbound flows.
+func (f azureFlow) direction() string {
+	if f.inbound {
+		return "I"
+	}
+	return "O"
+}
+
+// nsgFlowTuples returns version 2 tuples of the flow, a begin (B) tuple without counters followed by an end (E)
+// tuple of allowed flows. Denied flows only log the begin tuple.
+// ex:- 1487282421,42.119.146.95,10.1.0.4,51529,5358,T,I,D,B,,,,
+func (f azureFlow) nsgFlowTuples() []string {
+	protocol, decision := "T", "A"
+	if f.protocol == vpcProtocolUDP {
+		protocol = "U"
+	}
+	if !f.allowed {
+		decision = "D"
+	}
+
+	tuple := func(t time.Time, state string, counters string) string {
+		return strings.Join([]string{
+			strconv.FormatInt(t.Unix(), 10), f.srcIP, f.dstIP, strconv.Itoa(f.srcPort), strconv.Itoa(f.dstPort),
+			protocol, f.direction(), decision, state, counters,
+		}, ",")
+	}
+
+	tuples := []string{tuple(f.start, "B", ",,,")}
+	if f.allowed {
+		tuples = append(tuples, tuple(f.end, "E", fmt.Sprintf("%d,%d,%d,%d", f.packetsS2D, f.bytesS2D, f.packetsD2S, f.bytesD2S)))
+	}
+	return tuples
+}
+
+// vnetFlowTuples returns tuples of the flow, a begin (B) and an end (E) tuple of allowed flows or a single denied (D)
+// tuple. Unlike NSG flow logs, timestamps are in epoch milliseconds, protocols are IANA numbers and the decision is
+// part of the flow state, followed by the encryption status.
+// ex:- 1663146003599,10.0.0.6,192.0.2.180,23956,443,6,O,B,NX,0,0,0,0
+func (f azureFlow) vnetFlowTuples() []string {
+	tuple := func(t time.Time, state string, packetsS2D, bytesS2D, packetsD2S, bytesD2S int) string {
+		return fmt.Sprintf("%d,%s,%s,%d,%d,%d,%s,%s,NX,%d,%d,%d,%d", t.UnixMilli(), f.srcIP, f.dstIP, f.srcPort, f.dstPort,
+			f.protocol, f.direction(), state, packetsS2D, bytesS2D, packetsD2S, bytesD2S)
+	}
+
+	if !f.allowed {
+		return []string{tuple(f.start, "D", 0, 0, 0, 0)}
+	}
+	return []string{
+		tuple(f.start, "B", 0, 0, 0, 0),
+		tuple(f.end, "E", f.packetsS2D, f.bytesS2D, f.packetsD2S, f.bytesD2S),
+	}
+}
+
+func (a *AzureFlowLogGen) buildNSGFlowRecord(now time.Time, groups []azureFlowGroup) azureNSGFlowRecord {
+	var flows []azureNSGRuleFlow
+	for _, g := range groups {
+		var tuples []string
+		for _, f := range g.flows {
+			tuples = append(tuples, f.nsgFlowTuples()...)
+		}
+		flows = append(flows, azureNSGRuleFlow{Rule: g.rule, Flows: []azureNSGMACFlow{{MAC: a.macAddress, FlowTuples: tuples}}})
+	}
+
+	// NSG flow logs carry upper cased resource IDs
+	return azureNSGFlowRecord{
+		Time:          now.Format(azureFlowLogTimeFormat),
+		SystemID:      a.systemID,
+		MacAddress:    a.macAddress,
+		Category:      "NetworkSecurityGroupFlowEvent",
+		ResourceID:    strings.ToUpper(fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Network/networkSecurityGroups/vm-app-01-nsg", a.subscriptionID, a.resourceGroup)),
+		OperationName: "NetworkSecurityGroupFlowEvents",
+		Properties:    azureNSGFlowProperties{Version: 2, Flows: flows},
+	}
+}
+
+func (a *AzureFlowLogGen) buildVNetFlowRecord(now time.Time, groups []azureFlowGroup) azureVNetFlowRecord {
+	var flowGroups []azureVNetFlowGroup
+	for _, g := range groups {
+		var tuples []string
+		for _, f := range g.flows {
+			tuples = append(tuples, f.vnetFlowTuples()...)
+		}
+		flowGroups = append(flowGroups, azureVNetFlowGroup{Rule: g.rule, FlowTuples: tuples})
+	}
+
+	return azureVNetFlowRecord{
+		Time:        now.Format(azureFlowLogTimeFormat),
+		FlowLogGUID: a.flowLogGUID,
+		MacAddress:  a.macAddress,
+		Category:    "FlowLogFlowEvent",
+		FlowLogResourceID: strings.ToUpper(fmt.Sprintf("/subscriptions/%s/resourceGroups/NetworkWatcherRG/providers/Microsoft.Network/networkWatchers/NetworkWatcher_%s/flowLogs/vnet-app-flowlog",
+			a.subscriptionID, a.region)),
+		TargetResourceID: fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Network/virtualNetworks/vnet-app", a.subscriptionID, a.resourceGroup),
+		OperationName:    "FlowLogFlowEvent",
+		FlowRecords:      azureVNetFlowRecords{Flows: []azureVNetACLFlow{{ACLID: a.aclID, FlowGroups: flowGroups}}},
+	}
+}
@@ -0,0 +1,124 @@
+package internal
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"data-gen/conf"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func Test_azureFlowTuples(t *testing.T) {
+	allowed := azureFlow{
+		start:      time.Date(2026, 10, 19, 10, 20, 30, 123000000, time.UTC),
+		end:        time.Date(2026, 10, 19, 10, 20, 45, 456000000, time.UTC),
+		srcIP:      "10.1.0.4",
+		dstIP:      "203.0.113.10",
+		srcPort:    51529,
+		dstPort:    443,
+		protocol:   vpcProtocolTCP,
+		allowed:    true,
+		packetsS2D: 10,
+		bytesS2D:   1200,
+		packetsD2S: 8,
+		bytesD2S:   9600,
+	}
+
+	denied := azureFlow{
+		start:    allowed.start,
+		srcIP:    "198.51.100.7",
+		dstIP:    "10.1.0.4",
+		srcPort:  40122,
+		dstPort:  53,
+		protocol: vpcProtocolUDP,
+		inbound:  true,
+	}
+
+	t.Run("NSG flow logs", func(t *testing.T) {
+		require.Equal(t, []string{
+			"1792405230,10.1.0.4,203.0.113.10,51529,443,T,O,A,B,,,,",
+			"1792405245,10.1.0.4,203.0.113.10,51529,443,T,O,A,E,10,1200,8,9600",
+		}, allowed.nsgFlowTuples())
+		require.Equal(t, []string{"1792405230,198.51.100.7,10.1.0.4,40122,53,U,I,D,B,,,,"}, denied.nsgFlowTuples())
+	})
+
+	t.Run("VNet flow logs", func(t *testing.T) {
+		require.Equal(t, []string{
+			"1792405230123,10.1.0.4,203.0.113.10,51529,443,6,O,B,NX,0,0,0,0",
+			"1792405245456,10.1.0.4,203.0.113.10,51529,443,6,O,E,NX,10,1200,8,9600",
+		}, allowed.vnetFlowTuples())
+		require.Equal(t, []string{"1792405230123,198.51.100.7,10.1.0.4,40122,53,17,I,D,NX,0,0,0,0"}, denied.vnetFlowTuples())
+	})
+}
+
+func Test_NewAzureFlowLogGen(t *testing.T) {
+	t.Run("NSG flow logs", func(t *testing.T) {
+		var input conf.InputConfig
+		require.NoError(t, yaml.Unmarshal([]byte("records_per_batch: 3"), &input.Conf))
+
+		gen, err := NewAzureFlowLogGen(input)
+		require.NoError(t, err)
+
+		_, err = gen.Generate()
+		require.NoError(t, err)
+
+		var envelope struct {
+			Records []azureNSGFlowRecord `json:"records"`
+		}
+		require.NoError(t, json.Unmarshal(gen.GetAndReset(), &envelope))
+		require.Len(t, envelope.Records, 3)
+
+		for _, r := range envelope.Records {
+			require.Equal(t, "NetworkSecurityGroupFlowEvent", r.Category)
+			require.Equal(t, 2, r.Properties.Version)
+			require.NotEmpty(t, r.Properties.Flows)
+			for _, rule := range r.Properties.Flows {
+				require.Equal(t, r.MacAddress, rule.Flows[0].MAC)
+				for _, tuple := range rule.Flows[0].FlowTuples {
+					require.Len(t, strings.Split(tuple, ","), 13)
+				}
+			}
+		}
+	})
+
+	t.Run("VNet flow logs", func(t *testing.T) {
+		var input conf.InputConfig
+		require.NoError(t, yaml.Unmarshal([]byte("log_type: vnet"), &input.Conf))
+
+		gen, err := NewAzureFlowLogGen(input)
+		require.NoError(t, err)
+
+		_, err = gen.Generate()
+		require.NoError(t, err)
+
+		var envelope struct {
+			Records []azureVNetFlowRecord `json:"records"`
+		}
+		require.NoError(t, json.Unmarshal(gen.GetAndReset(), &envelope))
+		require.Len(t, envelope.Records, 1)
+
+		r := envelope.Records[0]
+		require.Equal(t, "FlowLogFlowEvent", r.Category)
+		require.NotEmpty(t, r.FlowRecords.Flows[0].FlowGroups)
+		for _, group := range r.FlowRecords.Flows[0].FlowGroups {
+			for _, tuple := range group.FlowTuples {
+				require.Len(t, strings.Split(tuple, ","), 13)
+			}
+		}
+	})
+
+	t.Run("Invalid configuration", func(t *testing.T) {
+		var input conf.InputConfig
+		require.NoError(t, yaml.Unmarshal([]byte("log_type: vpc"), &input.Conf))
+		_, err := NewAzureFlowLogGen(input)
+		require.ErrorContains(t, err, "unknown azure flow log type: vpc")
+
+		require.NoError(t, yaml.Unmarshal([]byte("records_per_batch: -1"), &input.Conf))
+		_, err = NewAzureFlowLogGen(input)
+		require.ErrorContains(t, err, "records_per_batch must be positive")
+	})
+}
@@ -36,12 +36,7 @@ func NewAzureResourceLogGen(cfg conf.InputConfig) *AzureResourceLogGen {
 		config = newDefaultAzureResourceLogCfg()
 	}
 
-	// load env variable overrides if any
-	if v := os.Getenv("ENV_AZURE_RECORDS_PER_BATCH"); v != "" {
-		if count, err := strconv.Atoi(v); err == nil && count > 0 {
-			config.RecordsPerBatch = count
-		}
-	}
+	config.RecordsPerBatch = azureRecordsPerBatch(config.RecordsPerBatch)
 
 	return &AzureResourceLogGen{
 		cfg: *config,
@@ -56,7 +51,26 @@ func (a *AzureResourceLogGen) Generate() (int64, error) {
 		records[i] = buildAzureResourceLog()
 	}
 
-	// Wrap the log entries in a records field (Azure standard format)
+	return writeAzureRecords(&a.buf, records)
+}
+
+func (a *AzureResourceLogGen) GetAndReset() []byte {
+	return a.buf.getAndReset()
+}
+
+// azureRecordsPerBatch returns the records per {"records": [...]} envelope, applying env variable overrides if any.
+func azureRecordsPerBatch(configured int) int {
+	if v := os.Getenv("ENV_AZURE_RECORDS_PER_BATCH"); v != "" {
+		if count, err := strconv.Atoi(v); err == nil && count > 0 {
+			return count
+		}
+	}
+
+	return configured
+}
+
+// writeAzureRecords wraps records in a records field (Azure standard format) and writes them as a single line.
+func writeAzureRecords(buf *trackedBuffer, records any) (int64, error) {
 	wrapper := map[string]interface{}{
 		"records": records,
 	}
@@ -69,16 +83,12 @@ func (a *AzureResourceLogGen) Generate() (int64, error) {
 	// Azure resource logs are newline delimited
 	marshaled = append(marshaled, '\n')
 
-	err = a.buf.write(marshaled)
+	err = buf.write(marshaled)
 	if err != nil {
 		return 0, err
 	}
 
-	return a.buf.size(), nil
-}
-
-func (a *AzureResourceLogGen) GetAndReset() []byte {
-	return a.buf.getAndReset()
+	return buf.size(), nil
 }
 
 // azureResourceLog represents an Azure resource log entry.
@@ -0,0 +1,428 @@
+package internal
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"data-gen/conf"
+)
+
+const (
+	cloudFrontFormatStandard = "standard"
+	cloudFrontFormatRealtime = "realtime"
+)
+
+// cloudFrontStandardFields lists the fields of CloudFront standard (legacy) access logs in order.
+// See - https://docs.aws.amazon.com/AmazonCloudFront/latest/DeveloperGuide/standard-logs-reference.html
+var cloudFrontStandardFields = []string{
+	"date", "time", "x-edge-location", "sc-bytes", "c-ip", "cs-method", "cs(Host)", "cs-uri-stem", "sc-status",
+	"cs(Referer)", "cs(User-Agent)", "cs-uri-query", "cs(Cookie)", "x-edge-result-type", "x-edge-request-id",
+	"x-host-header", "cs-protocol", "cs-bytes", "time-taken", "x-forwarded-for", "ssl-protocol", "ssl-cipher",
+	"x-edge-response-result-type", "cs-protocol-version", "fle-status", "fle-encrypted-fields", "c-port",
+	"time-to-first-byte", "x-edge-detailed-result-type", "sc-content-type", "sc-content-len", "sc-range-start",
+	"sc-range-end",
+}
+
+// cloudFrontRealtimeFields lists all CloudFront real-time log fields in the order they are delivered.
+// See - https://docs.aws.amazon.com/AmazonCloudFront/latest/DeveloperGuide/real-time-logs.html
+var cloudFrontRealtimeFields = []string{
+	"timestamp", "c-ip", "time-to-first-byte", "sc-status", "sc-bytes", "cs-method", "cs-protocol", "cs-host",
+	"cs-uri-stem", "cs-bytes", "x-edge-location", "x-edge-request-id", "x-host-header", "time-taken",
+	"cs-protocol-version", "c-ip-version", "cs-user-agent", "cs-referer", "cs-cookie", "cs-uri-query",
+	"x-edge-response-result-type", "x-forwarded-for", "ssl-protocol", "ssl-cipher", "x-edge-result-type",
+	"fle-encrypted-fields", "fle-status", "sc-content-type", "sc-content-len", "sc-range-start", "sc-range-end",
+	"c-port", "x-edge-detailed-result-type", "c-country", "cs-accept-encoding", "cs-accept",
+	"cache-behavior-path-pattern", "cs-headers", "cs-header-names", "cs-headers-count", "primary-distribution-id",
+	"primary-distribution-dns-name", "origin-fbl", "origin-lbl", "asn",
+}
+
+var cloudFrontEdgeLocations = []string{"LAX1", "IAD89-C1", "FRA56-P2", "NRT57-C3", "SIN2-C1", "LHR61-P4", "GRU3-C2"}
+var cloudFrontResultTypes = []string{"Hit", "Hit", "Hit", "RefreshHit", "Miss", "Miss", "Redirect", "Error", "LimitExceeded"}
+var cloudFrontContentTypes = []string{"text/html", "application/json", "image/png", "text/css", "application/javascript"}
+var cloudFrontProtocolVersions = []string{"HTTP/1.1", "HTTP/2.0", "HTTP/3.0"}
+var cloudFrontPathPatterns = []string{"*", "/api/*", "/static/*", "/images/*"}
+var cloudFrontASNs = []int{16509, 15169, 7922, 3320, 4134, 2914}
+
+// CloudFrontGen generates AWS CloudFront standard or real-time access logs.
+type CloudFrontGen struct {
+	cfg          cloudFrontCfg
+	buf          trackedBuffer
+	init         bool
+	logEvents    bool
+	distribution cloudFrontDistribution
+}
+
+// cloudFrontDistribution identifies the distribution logs are generated for.
+type cloudFrontDistribution struct {
+	id     string
+	domain string
+}
+
+// cloudFrontCfg specifies the CloudFront log format and real-time field selection.
+type cloudFrontCfg struct {
+	LogFormat string   `yaml:"log_format"`
+	Fields    []string `yaml:"fields"`
+}
+
+func newDefaultCloudFrontCfg() *cloudFrontCfg {
+	return &cloudFrontCfg{
+		LogFormat: cloudFrontFormatStandard,
+	}
+}
+
+func NewCloudFrontGen(input conf.InputConfig, output conf.OutputConfig) (*CloudFrontGen, error) {
+	cfg := newDefaultCloudFrontCfg()
+	err := input.Conf.Decode(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode cloudfront configuration: %w", err)
+	}
+
+	switch cfg.LogFormat {
+	case cloudFrontFormatStandard:
+		cfg.Fields = cloudFrontStandardFields
+	case cloudFrontFormatRealtime:
+		fields, err := realtimeFieldSelection(cfg.Fields)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Fields = fields
+	default:
+		return nil, fmt.Errorf("unknown cloudfront log format: %s", cfg.LogFormat)
+	}
+
+	return &CloudFrontGen{
+		cfg:       *cfg,
+		buf:       newTrackedBuffer(),
+		init:      true,
+		logEvents: output.LogEvents(),
+		distribution: cloudFrontDistribution{
+			id:     "E" + randomAZ09String(13),
+			domain: fmt.Sprintf("%s.cloudfront.net", strings.ToLower(randomAZ09String(14))),
+		},
+	}, nil
+}
+
+func (c *CloudFrontGen) Generate() (int64, error) {
+	var data []byte
+
+	// Standard logs carry version & field headers at the top of each log file.
+	// Similar to VPC logs, CloudWatch log events do not include them.
+	if c.init && c.cfg.LogFormat == cloudFrontFormatStandard && !c.logEvents {
+		c.init = false
+		data = []byte(fmt.Sprintf("#Version: 1.0\n#Fields: %s\n", strings.Join(cloudFrontStandardFields, " ")))
+	}
+
+	customizer := newCloudFrontCustomizer(c.distribution)
+	if c.cfg.LogFormat == cloudFrontFormatRealtime {
+		data = append(data, []byte(buildCloudFrontRealtimeLogLine(customizer, c.cfg.Fields))...)
+	} else {
+		data = append(data, []byte(buildCloudFrontLogLine(customizer))...)
+	}
+
+	err := c.buf.write(data)
+	return c.buf.size(), err
+}
+
+func (c *CloudFrontGen) GetAndReset() []byte {
+	c.init = true
+	return c.buf.getAndReset()
+}
+
+// RecordTimestamp returns the request time of standard log records, delivered as separate date and time fields,
+// or the timestamp field of real-time log records if selected.
+func (c *CloudFrontGen) RecordTimestamp(record string) (time.Time, bool) {
+	fields := strings.Split(record, "\t")
+
+	if c.cfg.LogFormat == cloudFrontFormatRealtime {
+		i := slices.Index(c.cfg.Fields, "timestamp")
+		if i < 0 || len(fields) <= i {
+			return time.Time{}, false
+		}
+		return parseTimestamp(fields[i])
+	}
+
+	if len(fields) < 2 {
+		return time.Time{}, false
+	}
+
+	t, err := time.Parse("2006-01-02 15:04:05", fields[0]+" "+fields[1])
+	return t, err == nil
+}
+
+// realtimeFieldSelection validates user selected fields and returns them in delivery order.
+// Real-time logs always deliver fields in the documented order, regardless of the configured order.
+func realtimeFieldSelection(selected []string) ([]string, error) {
+	if len(selected) == 0 {
+		return cloudFrontRealtimeFields, nil
+	}
+
+	for _, f := range selected {
+		if !slices.Contains(cloudFrontRealtimeFields, f) {
+			return nil, fmt.Errorf("unknown cloudfront real-time log field: %s", f)
+		}
+	}
+
+	var ordered []string
+	for _, f := range cloudFrontRealtimeFields {
+		if slices.Contains(selected, f) {
+			ordered = append(ordered, f)
+		}
+	}
+
+	return ordered, nil
+}
+
+// cloudFrontCustomizer holds all fields needed to construct a CloudFront log entry.
+type cloudFrontCustomizer struct {
+	timestamp                time.Time
+	edgeLocation             string
+	scBytes                  int
+	clientIP                 string
+	method                   string
+	host                     string
+	uriStem                  string
+	status                   int
+	referer                  string
+	userAgent                string
+	uriQuery                 string
+	cookie                   string
+	edgeResultType           string
+	edgeRequestID            string
+	hostHeader               string
+	protocol                 string
+	csBytes                  int
+	timeTaken                float64
+	forwardedFor             string
+	sslProtocol              string
+	sslCipher                string
+	edgeResponseResultType   string
+	protocolVersion          string
+	fleStatus                string
+	fleEncryptedFields       string
+	clientPort               int
+	timeToFirstByte          float64
+	edgeDetailedResultType   string
+	contentType              string
+	contentLen               string
+	rangeStart               string
+	rangeEnd                 string
+	country                  string
+	acceptEncoding           string
+	accept                   string
+	cacheBehaviorPathPattern string
+	distributionID           string
+	originFBL                string
+	originLBL                string
+	asn                      int
+}
+
+func newCloudFrontCustomizer(distribution cloudFrontDistribution) cloudFrontCustomizer {
+	resultType := cloudFrontResultTypes[rand.IntN(len(cloudFrontResultTypes))]
+	status := cloudFrontStatusFor(resultType)
+	protocol := randomSchema()
+	timeTaken := float64(rand.IntN(900)+1) / 1000
+	contentType := cloudFrontContentTypes[rand.IntN(len(cloudFrontContentTypes))]
+
+	customizer := cloudFrontCustomizer{
+		timestamp:                time.Now().UTC(),
+		edgeLocation:             cloudFrontEdgeLocations[rand.IntN(len(cloudFrontEdgeLocations))],
+		scBytes:                  randomBytesSize(),
+		clientIP:                 randomIP(),
+		method:                   randomHTTPMethod(),
+		host:                     distribution.domain,
+		uriStem:                  randomURIPath(),
+		status:                   status,
+		referer:                  "-",
+		userAgent:                cloudFrontEncode(randomUserAgent()),
+		uriQuery:                 "-",
+		cookie:                   "-",
+		edgeResultType:           resultType,
+		edgeRequestID:            randomAZaz09String(52) + "==",
+		hostHeader:               randomDomain(),
+		protocol:                 protocol,
+		csBytes:                  rand.IntN(500) + 20,
+		timeTaken:                timeTaken,
+		forwardedFor:             "-",
+		sslProtocol:              "-",
+		sslCipher:                "-",
+		edgeResponseResultType:   resultType,
+		protocolVersion:          cloudFrontProtocolVersions[rand.IntN(len(cloudFrontProtocolVersions))],
+		fleStatus:                "-",
+		fleEncryptedFields:       "-",
+		clientPort:               randomPort(),
+		timeToFirstByte:          timeTaken * rand.Float64(),
+		edgeDetailedResultType:   resultType,
+		contentType:              contentType,
+		contentLen:               strconv.Itoa(randomBytesSize()),
+		rangeStart:               "-",
+		rangeEnd:                 "-",
+		country:                  randomCountryCode(),
+		acceptEncoding:           "gzip,deflate,br",
+		accept:                   "*/*",
+		cacheBehaviorPathPattern: cloudFrontPathPatterns[rand.IntN(len(cloudFrontPathPatterns))],
+		distributionID:           distribution.id,
+		originFBL:                "-",
+		originLBL:                "-",
+		asn:                      cloudFrontASNs[rand.IntN(len(cloudFrontASNs))],
+	}
+
+	if q := randomQueryString(); q != "" {
+		customizer.uriQuery = q
+	}
+
+	if protocol == "https" {
+		customizer.sslProtocol = randomTLSProtocol()
+		customizer.sslCipher = randomSSLCipher()
+	}
+
+	if rand.IntN(4) == 0 {
+		customizer.referer = fmt.Sprintf("https://%s/", randomDomain())
+	}
+
+	if rand.IntN(5) == 0 {
+		customizer.forwardedFor = randomIP()
+	}
+
+	// partial content responses carry the byte range
+	if status == 206 {
+		customizer.rangeStart = "0"
+		customizer.rangeEnd = strconv.Itoa(customizer.scBytes - 1)
+	}
+
+	// origin latencies are only available when the origin was contacted
+	if resultType == "Miss" || resultType == "RefreshHit" {
+		customizer.originFBL = fmt.Sprintf("%.3f", customizer.timeToFirstByte*0.8)
+		customizer.originLBL = fmt.Sprintf("%.3f", timeTaken*0.9)
+	}
+
+	if resultType == "Error" || resultType == "LimitExceeded" {
+		customizer.contentType = "text/html"
+		customizer.edgeDetailedResultType = "Error"
+		if status >= 500 && rand.IntN(2) == 0 {
+			customizer.edgeDetailedResultType = "OriginConnectError"
+		}
+	}
+
+	return customizer
+}
+
+func cloudFrontStatusFor(resultType string) int {
+	switch resultType {
+	case "Redirect":
+		return []int{301, 302, 307}[rand.IntN(3)]
+	case "Error":
+		return []int{400, 403, 404, 500, 502, 503, 504}[rand.IntN(7)]
+	case "LimitExceeded":
+		return 503
+	case "RefreshHit":
+		return []int{200, 304}[rand.IntN(2)]
+	default:
+		return []int{200, 200, 200, 206}[rand.IntN(4)]
+	}
+}
+
+// cloudFrontEncode applies CloudFront log encoding for whitespace within field values.
+func cloudFrontEncode(value string) string {
+	return strings.NewReplacer(" ", "%20", "\t", "%09", "\n", "%0A").Replace(value)
+}
+
+func buildCloudFrontLogLine(c cloudFrontCustomizer) string {
+	values := []string{
+		c.timestamp.Format("2006-01-02"),
+		c.timestamp.Format("15:04:05"),
+		c.edgeLocation,
+		strconv.Itoa(c.scBytes),
+		c.clientIP,
+		c.method,
+		c.host,
+		c.uriStem,
+		strconv.Itoa(c.status),
+		c.referer,
+		c.userAgent,
+		c.uriQuery,
+		c.cookie,
+		c.edgeResultType,
+		c.edgeRequestID,
+		c.hostHeader,
+		c.protocol,
+		strconv.Itoa(c.csBytes),
+		fmt.Sprintf("%.3f", c.timeTaken),
+		c.forwardedFor,
+		c.sslProtocol,
+		c.sslCipher,
+		c.edgeResponseResultType,
+		c.protocolVersion,
+		c.fleStatus,
+		c.fleEncryptedFields,
+		strconv.Itoa(c.clientPort),
+		fmt.Sprintf("%.3f", c.timeToFirstByte),
+		c.edgeDetailedResultType,
+		c.contentType,
+		c.contentLen,
+		c.rangeStart,
+		c.rangeEnd,
+	}
+
+	return strings.Join(values, "\t") + "\n"
+}
+
+func buildCloudFrontRealtimeLogLine(c cloudFrontCustomizer, fields []string) string {
+	all := map[string]string{
+		"timestamp":                     fmt.Sprintf("%.3f", float64(c.timestamp.UnixMilli())/1000),
+		"c-ip":                          c.clientIP,
+		"time-to-first-byte":            fmt.Sprintf("%.3f", c.timeToFirstByte),
+		"sc-status":                     strconv.Itoa(c.status),
+		"sc-bytes":                      strconv.Itoa(c.scBytes),
+		"cs-method":                     c.method,
+		"cs-protocol":                   c.protocol,
+		"cs-host":                       c.host,
+		"cs-uri-stem":                   c.uriStem,
+		"cs-bytes":                      strconv.Itoa(c.csBytes),
+		"x-edge-location":               c.edgeLocation,
+		"x-edge-request-id":             c.edgeRequestID,
+		"x-host-header":                 c.hostHeader,
+		"time-taken":                    fmt.Sprintf("%.3f", c.timeTaken),
+		"cs-protocol-version":           c.protocolVersion,
+		"c-ip-version":                  "IPv4",
+		"cs-user-agent":                 c.userAgent,
+		"cs-referer":                    c.referer,
+		"cs-cookie":                     c.cookie,
+		"cs-uri-query":                  c.uriQuery,
+		"x-edge-response-result-type":   c.edgeResponseResultType,
+		"x-forwarded-for":               c.forwardedFor,
+		"ssl-protocol":                  c.sslProtocol,
+		"ssl-cipher":                    c.sslCipher,
+		"x-edge-result-type":            c.edgeResultType,
+		"fle-encrypted-fields":          c.fleEncryptedFields,
+		"fle-status":                    c.fleStatus,
+		"sc-content-type":               c.contentType,
+		"sc-content-len":                c.contentLen,
+		"sc-range-start":                c.rangeStart,
+		"sc-range-end":                  c.rangeEnd,
+		"c-port":                        strconv.Itoa(c.clientPort),
+		"x-edge-detailed-result-type":   c.edgeDetailedResultType,
+		"c-country":                     c.country,
+		"cs-accept-encoding":            c.acceptEncoding,
+		"cs-accept":                     c.accept,
+		"cache-behavior-path-pattern":   c.cacheBehaviorPathPattern,
+		"cs-headers":                    cloudFrontEncode(fmt.Sprintf("Host:%s\nUser-Agent:%s\nAccept:%s\n", c.host, c.userAgent, c.accept)),
+		"cs-header-names":               cloudFrontEncode("Host\nUser-Agent\nAccept\n"),
+		"cs-headers-count":              "3",
+		"primary-distribution-id":       c.distributionID,
+		"primary-distribution-dns-name": c.host,
+		"origin-fbl":                    c.originFBL,
+		"origin-lbl":                    c.originLBL,
+		"asn":                           strconv.Itoa(c.asn),
+	}
+
+	values := make([]string, len(fields))
+	for i, f := range fields {
+		values[i] = all[f]
+	}
+
+	return strings.Join(values, "\t") + "\n"
+}
@@ -0,0 +1,94 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"data-gen/conf"
+
+	"github.com/stretchr/testify/require"
+)
+
+// refer example of https://docs.aws.amazon.com/AmazonCloudFront/latest/DeveloperGuide/standard-logs-reference.html
+const upstreamCloudFront = "2019-12-04\t21:02:31\tLAX1\t392\t192.0.2.100\tGET\td111111abcdef8.cloudfront.net\t/index.html\t200\t-\tMozilla/5.0%20(Windows%20NT%2010.0;%20Win64;%20x64)%20AppleWebKit/537.36%20(KHTML,%20like%20Gecko)%20Chrome/78.0.3904.108%20Safari/537.36\t-\t-\tHit\tSOX4xwn4XV6Q4rgb7XiVGOHms_BGlTAC4KyHmureZmBNrjGdRLiNIQ==\td111111abcdef8.cloudfront.net\thttps\t23\t0.001\t-\tTLSv1.2\tECDHE-RSA-AES128-GCM-SHA256\tHit\tHTTP/2.0\t-\t-\t11040\t0.001\tHit\ttext/html\t78\t-\t-"
+
+func upstreamCloudFrontCustomizer() cloudFrontCustomizer {
+	return cloudFrontCustomizer{
+		timestamp:              time.Date(2019, 12, 4, 21, 2, 31, 0, time.UTC),
+		edgeLocation:           "LAX1",
+		scBytes:                392,
+		clientIP:               "192.0.2.100",
+		method:                 "GET",
+		host:                   "d111111abcdef8.cloudfront.net",
+		uriStem:                "/index.html",
+		status:                 200,
+		referer:                "-",
+		userAgent:              cloudFrontEncode("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/78.0.3904.108 Safari/537.36"),
+		uriQuery:               "-",
+		cookie:                 "-",
+		edgeResultType:         "Hit",
+		edgeRequestID:          "SOX4xwn4XV6Q4rgb7XiVGOHms_BGlTAC4KyHmureZmBNrjGdRLiNIQ==",
+		hostHeader:             "d111111abcdef8.cloudfront.net",
+		protocol:               "https",
+		csBytes:                23,
+		timeTaken:              0.001,
+		forwardedFor:           "-",
+		sslProtocol:            "TLSv1.2",
+		sslCipher:              "ECDHE-RSA-AES128-GCM-SHA256",
+		edgeResponseResultType: "Hit",
+		protocolVersion:        "HTTP/2.0",
+		fleStatus:              "-",
+		fleEncryptedFields:     "-",
+		clientPort:             11040,
+		timeToFirstByte:        0.001,
+		edgeDetailedResultType: "Hit",
+		contentType:            "text/html",
+		contentLen:             "78",
+		rangeStart:             "-",
+		rangeEnd:               "-",
+	}
+}
+
+func Test_buildCloudFront(t *testing.T) {
+	t.Run("Validate AWS documented standard log line", func(t *testing.T) {
+		line := buildCloudFrontLogLine(upstreamCloudFrontCustomizer())
+
+		require.Equal(t, upstreamCloudFront, strings.TrimSuffix(line, "\n"))
+		require.Len(t, strings.Split(line, "\t"), len(cloudFrontStandardFields))
+	})
+
+	t.Run("Real-time log line follows delivery order", func(t *testing.T) {
+		fields, err := realtimeFieldSelection([]string{"sc-status", "timestamp", "c-ip"})
+		require.NoError(t, err)
+		require.Equal(t, []string{"timestamp", "c-ip", "sc-status"}, fields)
+
+		line := buildCloudFrontRealtimeLogLine(upstreamCloudFrontCustomizer(), fields)
+		require.Equal(t, "1575493351.000\t192.0.2.100\t200\n", line)
+	})
+
+	t.Run("Unknown real-time field", func(t *testing.T) {
+		_, err := realtimeFieldSelection([]string{"unknown"})
+		require.Error(t, err)
+	})
+
+	t.Run("Lines share the distribution domain", func(t *testing.T) {
+		gen, err := NewCloudFrontGen(conf.InputConfig{}, conf.OutputConfig{Type: conf.OutputCWLogs})
+		require.NoError(t, err)
+
+		for range 5 {
+			_, err = gen.Generate()
+			require.NoError(t, err)
+		}
+
+		lines := strings.Split(strings.TrimSuffix(string(gen.GetAndReset()), "\n"), "\n")
+		require.Len(t, lines, 5)
+
+		domains := map[string]bool{}
+		for _, line := range lines {
+			domains[strings.Split(line, "\t")[6]] = true
+		}
+		require.Equal(t, map[string]bool{gen.distribution.domain: true}, domains)
+		require.True(t, strings.HasSuffix(gen.distribution.domain, ".cloudfront.net"))
+	})
+}
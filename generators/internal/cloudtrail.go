@@ -1,68 +1,104 @@
 package internal
 
 import (
-	"bytes"
 	"encoding/json"
+	"fmt"
 	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
 
 	"data-gen/conf"
-
-	"github.com/google/uuid"
 )
 
-// CloudTrail generates AWS CloudTrail logs for S3 data events.
+// CloudTrail generates AWS CloudTrail logs for management, data and insight events of the configured event sources.
 type CloudTrail struct {
 	current     []cloudTrailRecord
 	currentSize int64
-	outputType  string
+	delivery    string
+	events      []cloudTrailEventSpec
+	digester    *cloudTrailDigester
 }
 
-func NewCloudTrailGen(cfg conf.OutputConfig) *CloudTrail {
-	return &CloudTrail{
-		current:    []cloudTrailRecord{},
-		outputType: cfg.Type,
+// cloudTrailCfg specifies the event sources and categories to generate.
+type cloudTrailCfg struct {
+	EventSources    []string `yaml:"event_sources"`
+	EventCategories []string `yaml:"event_categories"`
+	DeliveryFormat  string   `yaml:"delivery_format"`
+	DigestInterval  string   `yaml:"digest_interval"`
+}
+
+// applyDefaults selects S3 data events unless event sources are configured.
+// Categories of configured sources default to all categories.
+func (c *cloudTrailCfg) applyDefaults() {
+	if len(c.EventSources) > 0 {
+		return
+	}
+
+	c.EventSources = []string{ctSourceS3}
+	if len(c.EventCategories) == 0 {
+		c.EventCategories = []string{ctCategoryData}
+	}
+}
+
+func NewCloudTrailGen(input conf.InputConfig, output conf.OutputConfig) (*CloudTrail, error) {
+	cfg := &cloudTrailCfg{DigestInterval: ctDefaultDigestInterval}
+	err := input.Conf.Decode(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode cloudtrail configuration: %w", err)
+	}
+	cfg.applyDefaults()
+
+	events, err := cloudTrailEventSelection(cfg.EventSources, cfg.EventCategories)
+	if err != nil {
+		return nil, err
+	}
+
+	// default delivery follows the output, CloudWatch log events carry individual records
+	if cfg.DeliveryFormat == "" {
+		cfg.DeliveryFormat = ctDeliveryS3
+		if output.LogEvents() {
+			cfg.DeliveryFormat = ctDeliveryCloudWatch
+		}
+	}
+
+	var digester *cloudTrailDigester
+	switch cfg.DeliveryFormat {
+	case ctDeliveryS3, ctDeliveryCloudWatch, ctDeliveryEventBridge:
+	case ctDeliveryDigest:
+		interval, err := time.ParseDuration(cfg.DigestInterval)
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid cloudtrail digest_interval: %s", cfg.DigestInterval)
+		}
+
+		// digests reference log files by the keys S3 output stores them under
+		var s3 conf.S3OutputConfig
+		if output.Type == conf.OutputS3 {
+			s3, err = output.S3Config()
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode s3 output configuration: %w", err)
+			}
+		}
+		digester = newCloudTrailDigester(s3, interval)
+	default:
+		return nil, fmt.Errorf("unknown cloudtrail delivery format: %s", cfg.DeliveryFormat)
 	}
+
+	return &CloudTrail{
+		current:  []cloudTrailRecord{},
+		delivery: cfg.DeliveryFormat,
+		events:   events,
+		digester: digester,
+	}, nil
 }
 
 func (c *CloudTrail) Generate() (int64, error) {
-	id := randomAZ09String(12)
-	accountID := randomSampleAccountID()
-	s3EventName := randomS3EventName()
-	parameters, resources := generateRequestAndResource(s3EventName, accountID)
-	responseElements := map[string]any{
-		"requestId": id,
-		"kmsKeyId":  "arn:aws:kms:us-east-1:123456789012:key/" + randomAZ09String(1),
-	}
-
-	customizer := cloudTrailCustomizer{
-		awsRegion:          randomRegion(),
-		eventCategory:      eventCategory,
-		eventID:            uuid.NewString(),
-		eventName:          s3EventName,
-		eventSource:        s3EventSource,
-		eventTime:          iso8601Now(),
-		eventType:          eventType,
-		eventVersion:       eventVersion,
-		managementEvent:    &ff,
-		readOnly:           &ff,
-		recipientAccountID: accountID,
-		requestID:          id,
-		requestParameters:  parameters,
-		resources:          []any{resources},
-		responseElements:   responseElements,
-		sharedEventID:      randomAZ09String(16),
-		sourceIPAddress:    randomIP(),
-		userAgent:          randomUserAgent(),
-		userIdentity:       ctUserIdentity(),
-		tlsDetails: map[string]any{
-			"tlsVersion":  randomTLSProtocol(),
-			"cipherSuite": randomSSLCipher(),
-		},
-	}
-
-	// 10% chance of error
-	if rand.IntN(10) < 1 {
-		customizer.errorCode, customizer.errorMessage = randomErrorCodeAndMessage()
+	spec := c.events[rand.IntN(len(c.events))]
+
+	customizer := newCloudTrailCustomizer(spec)
+	if c.digester != nil {
+		// a trail logs the events of its own account
+		customizer = newCloudTrailCustomizerOf(spec, c.digester.accountID, c.digester.region)
 	}
 
 	newRecord := cloudTrailRecordFor(customizer)
@@ -80,20 +116,26 @@ func (c *CloudTrail) Generate() (int64, error) {
 func (c *CloudTrail) GetAndReset() []byte {
 	var marshal []byte
 
-	// CloudWatch Logs: emit each record as a separate JSON line (NDJSON)
-	// so the CloudWatch exporter sends one record per log event.
-	// S3: wrap all records in the standard CloudTrail {"Records": [...]} format.
-	if c.outputType == conf.OutputCWLogs {
-		var buf bytes.Buffer
-		for i, record := range c.current {
-			line, _ := json.Marshal(record)
-			buf.Write(line)
-			if i < len(c.current)-1 {
-				buf.WriteByte('\n')
-			}
+	switch c.delivery {
+	case ctDeliveryCloudWatch:
+		// emit each record as a separate JSON line (NDJSON)
+		// so the CloudWatch exporter sends one record per log event.
+		marshal = ndjson(c.current)
+	case ctDeliveryEventBridge:
+		events := make([]eventBridgeEvent[cloudTrailRecord], 0, len(c.current))
+		for _, record := range c.current {
+			events = append(events, eventBridgeEventFor(record))
+		}
+		marshal = ndjson(events)
+	case ctDeliveryDigest:
+		// a digest is delivered once per interval, referencing all log files emitted since the previous digest.
+		// Records are kept for the log file following the digest
+		if now := time.Now(); c.digester.due(now) {
+			return c.digester.digestFor(now)
 		}
-		marshal = buf.Bytes()
-	} else {
+		marshal = c.digester.logFileFor(c.current)
+	default:
+		// wrap all records in the standard CloudTrail {"Records": [...]} format.
 		marshal, _ = json.Marshal(cloudTrailLogFor(c.current))
 	}
 
@@ -102,13 +144,64 @@ func (c *CloudTrail) GetAndReset() []byte {
 	return marshal
 }
 
+func (c *CloudTrail) RecordTimestamp(record string) (time.Time, bool) {
+	// EventBridge events carry the event time in the envelope
+	return jsonTimestamp(record, "eventTime", "time")
+}
+
+// BatchKey returns the S3 key of log files and digests of digest delivery, which digests reference log files by.
+func (c *CloudTrail) BatchKey(batch []byte) (string, bool) {
+	if c.digester == nil {
+		return "", false
+	}
+
+	return c.digester.batchKey(batch)
+}
+
 // helpers
 
+// cloudTrailEventSelection filters the event catalog by the configured sources and categories.
+// An empty category list selects all categories of the selected sources.
+func cloudTrailEventSelection(sources []string, categories []string) ([]cloudTrailEventSpec, error) {
+	knownSources := map[string]bool{}
+	for _, spec := range cloudTrailEvents {
+		knownSources[spec.source] = true
+	}
+
+	for _, source := range sources {
+		if !knownSources[strings.ToLower(source)] {
+			return nil, fmt.Errorf("unknown cloudtrail event source: %s", source)
+		}
+	}
+
+	for _, category := range categories {
+		switch strings.ToLower(category) {
+		case strings.ToLower(ctCategoryData), strings.ToLower(ctCategoryManagement), strings.ToLower(ctCategoryInsight):
+		default:
+			return nil, fmt.Errorf("unknown cloudtrail event category: %s", category)
+		}
+	}
+
+	var selected []cloudTrailEventSpec
+	for _, spec := range cloudTrailEvents {
+		sourceMatch := slices.ContainsFunc(sources, func(s string) bool { return strings.EqualFold(s, spec.source) })
+		categoryMatch := len(categories) == 0 || slices.ContainsFunc(categories, func(s string) bool { return strings.EqualFold(s, spec.category) })
+		if sourceMatch && categoryMatch {
+			selected = append(selected, spec)
+		}
+	}
+
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no cloudtrail events match event sources %v and categories %v", sources, categories)
+	}
+
+	return selected, nil
+}
+
 const (
 	eventVersion  = "1.11"
 	s3EventSource = "s3.amazonaws.com"
 	eventType     = "AwsApiCall"
-	eventCategory = "Data"
 )
 
 // cloudTrailCustomizer holds parameters for generating a CloudTrail log record.
@@ -163,9 +256,9 @@ type cloudTrailRecord struct {
 	SessionCredentialFromConsole string                 `json:"sessionCredentialFromConsole,omitempty"`
 	SharedEventID                string                 `json:"sharedEventID,omitempty"`
 	SourceIPAddress              string                 `json:"sourceIPAddress,omitempty"`
-	TLSDetails                   map[string]any         `json:"tlsDetails"`
+	TLSDetails                   map[string]any         `json:"tlsDetails,omitempty"`
 	UserAgent                    string                 `json:"userAgent,omitempty"`
-	UserIdentity                 UserIdentity           `json:"userIdentity"`
+	UserIdentity                 UserIdentity           `json:"userIdentity,omitzero"`
 }
 
 // UserIdentity identifies the AWS principal that made the API call.
@@ -177,6 +270,29 @@ type UserIdentity struct {
 	AccessKeyID string `json:"accessKeyId,omitempty"`
 	UserName    string `json:"userName,omitempty"`
 	InvokedBy   string `json:"invokedBy,omitempty"`
+
+	SessionContext *SessionContext `json:"sessionContext,omitempty"`
+}
+
+// SessionContext describes the temporary security credentials used for the call.
+type SessionContext struct {
+	SessionIssuer *SessionIssuer    `json:"sessionIssuer,omitempty"`
+	Attributes    SessionAttributes `json:"attributes"`
+}
+
+// SessionIssuer identifies the entity that provided the temporary credentials.
+type SessionIssuer struct {
+	Type        string `json:"type,omitempty"`
+	PrincipalID string `json:"principalId,omitempty"`
+	Arn         string `json:"arn,omitempty"`
+	AccountID   string `json:"accountId,omitempty"`
+	UserName    string `json:"userName,omitempty"`
+}
+
+// SessionAttributes holds the session creation time and MFA state.
+type SessionAttributes struct {
+	CreationDate     string `json:"creationDate"`
+	MFAAuthenticated string `json:"mfaAuthenticated"`
 }
 
 // cloudTrailLog wraps CloudTrail records in the standard log format.
@@ -0,0 +1,307 @@
+package internal
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"data-gen/conf"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ctDeliveryS3          = "s3"
+	ctDeliveryCloudWatch  = "cloudwatch"
+	ctDeliveryEventBridge = "eventbridge"
+	ctDeliveryDigest      = "digest"
+
+	ctDigestTimeFormat = "2006-01-02T15:04:05Z"
+	ctDigestTrailName  = "data-gen-trail"
+	// CloudTrail delivers digests hourly
+	ctDefaultDigestInterval = "1h"
+)
+
+// cloudTrailDetailTypes maps CloudTrail event types to their EventBridge detail-type.
+// See - https://docs.aws.amazon.com/eventbridge/latest/ref/events-ref-cloudtrail.html
+var cloudTrailDetailTypes = map[string]string{
+	eventType:                "AWS API Call via CloudTrail",
+	ctEventTypeConsoleSignIn: "AWS Console Sign In via CloudTrail",
+	ctEventTypeInsight:       "AWS Insight via CloudTrail",
+	"AwsServiceEvent":        "AWS Service Event via CloudTrail",
+}
+
+// eventBridgeEvent is an event detail, ex:- a CloudTrail record, wrapped in an EventBridge event envelope.
+type eventBridgeEvent[T any] struct {
+	Version    string   `json:"version"`
+	ID         string   `json:"id"`
+	DetailType string   `json:"detail-type"`
+	Source     string   `json:"source"`
+	Account    string   `json:"account"`
+	Time       string   `json:"time"`
+	Region     string   `json:"region"`
+	Resources  []string `json:"resources"`
+	Detail     T        `json:"detail"`
+}
+
+func eventBridgeEventFor(record cloudTrailRecord) eventBridgeEvent[cloudTrailRecord] {
+	source := "aws.cloudtrail"
+	if record.EventSource != "" {
+		source = "aws." + strings.TrimSuffix(record.EventSource, ".amazonaws.com")
+	}
+
+	eventTime := record.EventTime
+	if t, err := time.Parse(time.RFC3339Nano, record.EventTime); err == nil {
+		eventTime = t.UTC().Format(ctDigestTimeFormat)
+	}
+
+	return eventBridgeEvent[cloudTrailRecord]{
+		Version:    "0",
+		ID:         uuid.NewString(),
+		DetailType: cloudTrailDetailTypes[record.EventType],
+		Source:     source,
+		Account:    record.RecipientAccountID,
+		Time:       eventTime,
+		Region:     record.AwsRegion,
+		Resources:  []string{},
+		Detail:     record,
+	}
+}
+
+// ndjson marshals each element as a separate JSON line without a trailing newline.
+func ndjson[T any](elements []T) []byte {
+	var buf bytes.Buffer
+	for i, element := range elements {
+		line, _ := json.Marshal(element)
+		buf.Write(line)
+		if i < len(elements)-1 {
+			buf.WriteByte('\n')
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// cloudTrailDigest is a CloudTrail log file integrity digest.
+// Previous digest fields are null for the first digest of the chain.
+// See - https://docs.aws.amazon.com/awscloudtrail/latest/userguide/cloudtrail-log-file-validation-digest-file-structure.html
+type cloudTrailDigest struct {
+	AWSAccountID                string              `json:"awsAccountId"`
+	DigestStartTime             string              `json:"digestStartTime"`
+	DigestEndTime               string              `json:"digestEndTime"`
+	DigestS3Bucket              string              `json:"digestS3Bucket"`
+	DigestS3Object              string              `json:"digestS3Object"`
+	DigestPublicKeyFingerprint  string              `json:"digestPublicKeyFingerprint"`
+	DigestSignatureAlgorithm    string              `json:"digestSignatureAlgorithm"`
+	NewestEventTime             *string             `json:"newestEventTime"`
+	OldestEventTime             *string             `json:"oldestEventTime"`
+	PreviousDigestS3Bucket      *string             `json:"previousDigestS3Bucket"`
+	PreviousDigestS3Object      *string             `json:"previousDigestS3Object"`
+	PreviousDigestHashValue     *string             `json:"previousDigestHashValue"`
+	PreviousDigestHashAlgorithm *string             `json:"previousDigestHashAlgorithm"`
+	PreviousDigestSignature     *string             `json:"previousDigestSignature"`
+	LogFiles                    []cloudTrailLogFile `json:"logFiles"`
+}
+
+// cloudTrailLogFile references a delivered log file and the SHA-256 hash of its uncompressed content.
+type cloudTrailLogFile struct {
+	S3Bucket        string `json:"s3Bucket"`
+	S3Object        string `json:"s3Object"`
+	HashValue       string `json:"hashValue"`
+	HashAlgorithm   string `json:"hashAlgorithm"`
+	NewestEventTime string `json:"newestEventTime"`
+	OldestEventTime string `json:"oldestEventTime"`
+}
+
+// cloudTrailDigester builds log files and the chained digests referencing them for a single trail, account and region.
+// Records of the trail are generated for the account and region of the digester.
+type cloudTrailDigester struct {
+	accountID   string
+	region      string
+	bucket      string
+	prefix      string
+	extension   string
+	fingerprint string
+	interval    time.Duration
+
+	lastEnd  time.Time
+	next     time.Time
+	previous *cloudTrailDigestRef
+	// log files emitted since the previous digest
+	pending []cloudTrailLogFile
+}
+
+// cloudTrailDigestRef holds the details of the previous digest used for chaining.
+type cloudTrailDigestRef struct {
+	object    string
+	hash      string
+	signature string
+}
+
+// newCloudTrailDigester creates a digester delivering to the bucket and key prefix of S3 output. The .gz extension of
+// referenced keys follows the output compression. Without a configured bucket, ex:- FILE output, the default bucket
+// name of CloudTrail console trails is used.
+func newCloudTrailDigester(output conf.S3OutputConfig, interval time.Duration) *cloudTrailDigester {
+	accountID := randomSampleAccountID()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	d := &cloudTrailDigester{
+		accountID:   accountID,
+		region:      randomRegion(),
+		bucket:      output.Bucket,
+		prefix:      output.PathPrefix,
+		fingerprint: randomHexString(32),
+		interval:    interval,
+		lastEnd:     now,
+		next:        now.Add(interval),
+	}
+
+	if d.bucket == "" {
+		d.bucket = fmt.Sprintf("aws-cloudtrail-logs-%s-%s", accountID, randomHexString(8))
+	}
+	if d.prefix != "" && !strings.HasSuffix(d.prefix, "/") {
+		d.prefix += "/"
+	}
+	if output.Compression == "gzip" {
+		d.extension = ".gz"
+	}
+
+	return d
+}
+
+// due returns true once the digest interval elapsed since the previous digest.
+func (d *cloudTrailDigester) due(now time.Time) bool {
+	return !now.Before(d.next)
+}
+
+// logFileFor builds the log file of the records, referenced by the next digest.
+func (d *cloudTrailDigester) logFileFor(records []cloudTrailRecord) []byte {
+	content, _ := json.Marshal(cloudTrailLogFor(records))
+	hash := sha256.Sum256(content)
+	oldest, newest := eventTimeRange(records)
+	key, _ := d.logFileKey(content)
+
+	d.pending = append(d.pending, cloudTrailLogFile{
+		S3Bucket:        d.bucket,
+		S3Object:        key + d.extension,
+		HashValue:       hex.EncodeToString(hash[:]),
+		HashAlgorithm:   "SHA-256",
+		NewestEventTime: newest,
+		OldestEventTime: oldest,
+	})
+
+	return content
+}
+
+// digestFor references the log files emitted since the previous digest and chains to the previous digest.
+func (d *cloudTrailDigester) digestFor(now time.Time) []byte {
+	// digest keys carry the end time in seconds, hence digests emitted within a second are moved apart
+	now = now.UTC().Truncate(time.Second)
+	if !now.After(d.lastEnd) {
+		now = d.lastEnd.Add(time.Second)
+	}
+
+	digest := cloudTrailDigest{
+		AWSAccountID:               d.accountID,
+		DigestStartTime:            d.lastEnd.Format(ctDigestTimeFormat),
+		DigestEndTime:              now.Format(ctDigestTimeFormat),
+		DigestS3Bucket:             d.bucket,
+		DigestS3Object:             d.objectKey("CloudTrail-Digest", now, fmt.Sprintf("%s_%s_%sZ.json%s", ctDigestTrailName, d.region, now.Format("20060102T150405"), d.extension)),
+		DigestPublicKeyFingerprint: d.fingerprint,
+		DigestSignatureAlgorithm:   "SHA256withRSA",
+		LogFiles:                   []cloudTrailLogFile{},
+	}
+
+	for _, logFile := range d.pending {
+		if digest.OldestEventTime == nil || logFile.OldestEventTime < *digest.OldestEventTime {
+			digest.OldestEventTime = &logFile.OldestEventTime
+		}
+		if digest.NewestEventTime == nil || logFile.NewestEventTime > *digest.NewestEventTime {
+			digest.NewestEventTime = &logFile.NewestEventTime
+		}
+		digest.LogFiles = append(digest.LogFiles, logFile)
+	}
+
+	if d.previous != nil {
+		algorithm := "SHA-256"
+		digest.PreviousDigestS3Bucket = &d.bucket
+		digest.PreviousDigestS3Object = &d.previous.object
+		digest.PreviousDigestHashValue = &d.previous.hash
+		digest.PreviousDigestHashAlgorithm = &algorithm
+		digest.PreviousDigestSignature = &d.previous.signature
+	}
+
+	content, _ := json.Marshal(digest)
+	hash := sha256.Sum256(content)
+
+	d.lastEnd = now
+	d.next = now.Add(d.interval)
+	d.pending = nil
+	d.previous = &cloudTrailDigestRef{
+		object: digest.DigestS3Object,
+		hash:   hex.EncodeToString(hash[:]),
+		// signatures are delivered as S3 object metadata, hence a random value stands in for the RSA signature
+		signature: randomHexString(512),
+	}
+
+	return content
+}
+
+// batchKey returns the S3 key of an emitted log file or digest, excluding the .gz extension S3 output appends on compression.
+func (d *cloudTrailDigester) batchKey(batch []byte) (string, bool) {
+	var digest struct {
+		DigestS3Object string `json:"digestS3Object"`
+	}
+	if err := json.Unmarshal(batch, &digest); err == nil && digest.DigestS3Object != "" {
+		return strings.TrimSuffix(digest.DigestS3Object, d.extension), true
+	}
+
+	return d.logFileKey(batch)
+}
+
+// logFileKey derives the S3 key of a log file from its content, hence log files and digests agree on it.
+// The newest event time stands in for the delivery time, while the content hash provides the unique suffix.
+func (d *cloudTrailDigester) logFileKey(content []byte) (string, bool) {
+	var log cloudTrailLog
+	if err := json.Unmarshal(content, &log); err != nil || len(log.Records) == 0 {
+		return "", false
+	}
+
+	_, newest := eventTimeRange(log.Records)
+	delivered, err := time.Parse(ctDigestTimeFormat, newest)
+	if err != nil {
+		return "", false
+	}
+
+	hash := sha256.Sum256(content)
+	return d.objectKey("CloudTrail", delivered, fmt.Sprintf("%sZ_%s.json", delivered.Format("20060102T1504"), hex.EncodeToString(hash[:8]))), true
+}
+
+// objectKey derives the S3 key CloudTrail uses for log and digest files, below the S3 key prefix of the trail.
+func (d *cloudTrailDigester) objectKey(kind string, t time.Time, suffix string) string {
+	return fmt.Sprintf("%sAWSLogs/%s/%s/%s/%s/%s_%s_%s_%s",
+		d.prefix, d.accountID, kind, d.region, t.Format("2006/01/02"), d.accountID, kind, d.region, suffix)
+}
+
+func eventTimeRange(records []cloudTrailRecord) (string, string) {
+	var oldest, newest time.Time
+	for _, record := range records {
+		t, err := time.Parse(time.RFC3339Nano, record.EventTime)
+		if err != nil {
+			continue
+		}
+
+		if oldest.IsZero() || t.Before(oldest) {
+			oldest = t
+		}
+		if newest.IsZero() || t.After(newest) {
+			newest = t
+		}
+	}
+
+	return oldest.UTC().Format(ctDigestTimeFormat), newest.UTC().Format(ctDigestTimeFormat)
+}
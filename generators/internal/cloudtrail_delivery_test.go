@@ -0,0 +1,165 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"data-gen/conf"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func Test_eventBridgeEventFor(t *testing.T) {
+	record := cloudTrailRecordFor(newCloudTrailCustomizer(cloudTrailEvents[0]))
+
+	event := eventBridgeEventFor(record)
+	require.Equal(t, "AWS API Call via CloudTrail", event.DetailType)
+	require.Equal(t, "aws.s3", event.Source)
+	require.Equal(t, record.RecipientAccountID, event.Account)
+	require.Equal(t, record.AwsRegion, event.Region)
+	require.Equal(t, record, event.Detail)
+}
+
+func Test_cloudTrailDigester(t *testing.T) {
+	d := newCloudTrailDigester(conf.S3OutputConfig{Bucket: "trail-bucket", PathPrefix: "org", Compression: "gzip"}, time.Hour)
+	d.lastEnd = time.Date(2019, 2, 1, 3, 0, 0, 0, time.UTC)
+	records := []cloudTrailRecord{
+		{EventTime: "2019-02-01T03:18:19.000000Z"},
+		{EventTime: "2019-02-01T03:10:00.000000Z"},
+	}
+
+	logFile := d.logFileFor(records)
+	first := d.digestFor(time.Date(2019, 2, 1, 4, 0, 0, 0, time.UTC))
+
+	var digest cloudTrailDigest
+	require.NoError(t, json.Unmarshal(first, &digest))
+	require.Nil(t, digest.PreviousDigestHashValue)
+	require.Len(t, digest.LogFiles, 1)
+	require.Equal(t, "2019-02-01T03:10:00Z", digest.LogFiles[0].OldestEventTime)
+	require.Equal(t, "2019-02-01T03:18:19Z", digest.LogFiles[0].NewestEventTime)
+	require.Equal(t, "2019-02-01T03:18:19Z", *digest.NewestEventTime)
+	require.Equal(t, "trail-bucket", digest.DigestS3Bucket)
+	require.Equal(t, "trail-bucket", digest.LogFiles[0].S3Bucket)
+
+	logHash := sha256.Sum256(logFile)
+	require.Equal(t, hex.EncodeToString(logHash[:]), digest.LogFiles[0].HashValue)
+
+	key, ok := d.batchKey(logFile)
+	require.True(t, ok)
+	require.Equal(t, key+".gz", digest.LogFiles[0].S3Object)
+	require.True(t, strings.HasPrefix(key, "org/AWSLogs/"+d.accountID+"/CloudTrail/"+d.region+"/2019/02/01/"), key)
+	require.True(t, d.due(time.Date(2019, 2, 1, 5, 0, 0, 0, time.UTC)))
+	require.False(t, d.due(time.Date(2019, 2, 1, 4, 59, 59, 0, time.UTC)))
+
+	second := d.digestFor(time.Date(2019, 2, 1, 5, 0, 0, 0, time.UTC))
+
+	var next cloudTrailDigest
+	require.NoError(t, json.Unmarshal(second, &next))
+	require.Empty(t, next.LogFiles)
+	require.Equal(t, digest.DigestEndTime, next.DigestStartTime)
+	require.Equal(t, digest.DigestS3Object, *next.PreviousDigestS3Object)
+
+	firstHash := sha256.Sum256(first)
+	require.Equal(t, hex.EncodeToString(firstHash[:]), *next.PreviousDigestHashValue)
+
+	key, ok = d.batchKey(first)
+	require.True(t, ok)
+	require.Equal(t, key+".gz", digest.DigestS3Object)
+}
+
+func TestCloudTrail_DigestDelivery(t *testing.T) {
+	tests := []struct {
+		name      string
+		output    string
+		extension string
+	}{
+		{name: "Uncompressed objects", output: "s3_bucket: arn:aws:s3:::trail-bucket"},
+		{name: "Gzip compressed objects", output: "{s3_bucket: trail-bucket, compression: gzip}", extension: ".gz"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := conf.InputConfig{Type: conf.InputCT}
+			require.NoError(t, yaml.Unmarshal([]byte("delivery_format: digest"), &input.Conf))
+			output := conf.OutputConfig{Type: conf.OutputS3}
+			require.NoError(t, yaml.Unmarshal([]byte(tt.output), &output.Conf))
+
+			gen, err := NewCloudTrailGen(input, output)
+			require.NoError(t, err)
+
+			// emitted objects by key, as stored by the S3 output
+			objects := map[string][]byte{}
+			emit := func() []byte {
+				for range 3 {
+					_, err = gen.Generate()
+					require.NoError(t, err)
+				}
+
+				batch := gen.GetAndReset()
+				key, ok := gen.BatchKey(batch)
+				require.True(t, ok)
+				objects[key+tt.extension] = batch
+				return batch
+			}
+
+			var digests []cloudTrailDigest
+			for range 3 {
+				// log files are emitted until the digest interval elapses
+				for range 4 {
+					var log cloudTrailLog
+					require.NoError(t, json.Unmarshal(emit(), &log))
+					require.NotEmpty(t, log.Records)
+				}
+
+				gen.digester.next = time.Now()
+				var digest cloudTrailDigest
+				require.NoError(t, json.Unmarshal(emit(), &digest))
+				require.NotEmpty(t, digest.DigestS3Object)
+				digests = append(digests, digest)
+			}
+
+			records := 0
+			for i, digest := range digests {
+				require.Contains(t, objects, digest.DigestS3Object)
+				require.Equal(t, "trail-bucket", digest.DigestS3Bucket)
+				require.Len(t, digest.LogFiles, 4)
+
+				for _, logFile := range digest.LogFiles {
+					content, ok := objects[logFile.S3Object]
+					require.True(t, ok, logFile.S3Object)
+
+					var log cloudTrailLog
+					require.NoError(t, json.Unmarshal(content, &log))
+					records += len(log.Records)
+					for _, record := range log.Records {
+						require.Equal(t, digest.AWSAccountID, record.RecipientAccountID)
+					}
+
+					hash := sha256.Sum256(content)
+					require.Equal(t, hex.EncodeToString(hash[:]), logFile.HashValue)
+				}
+
+				if i > 0 {
+					previous := sha256.Sum256(objects[digests[i-1].DigestS3Object])
+					require.Equal(t, hex.EncodeToString(previous[:]), *digest.PreviousDigestHashValue)
+				}
+			}
+
+			// records generated along with a digest go to the following log file, all but the last ones are delivered
+			require.Equal(t, 3*4*3+2*3, records)
+		})
+	}
+}
+
+func TestNewCloudTrailGen_DigestInterval(t *testing.T) {
+	input := conf.InputConfig{Type: conf.InputCT}
+	require.NoError(t, yaml.Unmarshal([]byte("{delivery_format: digest, digest_interval: 0s}"), &input.Conf))
+
+	_, err := NewCloudTrailGen(input, conf.OutputConfig{Type: conf.OutputFile})
+	require.ErrorContains(t, err, "digest_interval")
+}
@@ -0,0 +1,623 @@
+package internal
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ctCategoryData       = "Data"
+	ctCategoryManagement = "Management"
+	ctCategoryInsight    = "Insight"
+
+	ctEventTypeConsoleSignIn = "AwsConsoleSignIn"
+	ctEventTypeInsight       = "AwsCloudTrailInsight"
+
+	ctSourceS3      = "s3"
+	ctSourceIAM     = "iam"
+	ctSourceEC2     = "ec2"
+	ctSourceSTS     = "sts"
+	ctSourceSignIn  = "signin"
+	ctSourceKMS     = "kms"
+	ctSourceLambda  = "lambda"
+	ctSourceInsight = "insight"
+
+	// ctGlobalRegion is the region recorded for global services such as IAM and console sign-in
+	ctGlobalRegion = "us-east-1"
+)
+
+var ctRoleNames = []string{"Admin", "ReadOnly", "DeploymentRole", "OrganizationAccountAccessRole", "LambdaExecutionRole"}
+var ctFunctionNames = []string{"order-processor", "image-resizer", "audit-forwarder", "nightly-report"}
+var ctAMIIDs = []string{"ami-0abcdef1234567890", "ami-0fedcba0987654321", "ami-0123456789abcdef0"}
+var ctInstanceTypes = []string{"t3.micro", "t3.large", "m5.xlarge", "c6i.2xlarge"}
+var ctManagedPolicies = []string{"arn:aws:iam::aws:policy/AdministratorAccess", "arn:aws:iam::aws:policy/ReadOnlyAccess", "arn:aws:iam::aws:policy/AmazonS3FullAccess"}
+
+// ctInsightTargets lists write management APIs that insight events report unusual activity for.
+var ctInsightTargets = []struct{ eventSource, eventName string }{
+	{"iam.amazonaws.com", "CreateAccessKey"},
+	{"ec2.amazonaws.com", "RunInstances"},
+	{"ec2.amazonaws.com", "AuthorizeSecurityGroupIngress"},
+	{"kms.amazonaws.com", "ScheduleKeyDeletion"},
+	{"lambda.amazonaws.com", "UpdateFunctionCode20150331v2"},
+	{"s3.amazonaws.com", "PutBucketPolicy"},
+}
+
+// cloudTrailEventSpec describes an API event the CloudTrail generator can emit.
+type cloudTrailEventSpec struct {
+	source      string // event_sources configuration key
+	eventSource string
+	eventName   string
+	category    string
+	readOnly    bool
+	global      bool     // recorded in ctGlobalRegion regardless of the selected region
+	invokedBy   []string // AWS service principals which may call this API on behalf of the account
+	build       func(c *cloudTrailCustomizer)
+}
+
+// cloudTrailEvents is the catalog of supported events, grouped by event source.
+var cloudTrailEvents = []cloudTrailEventSpec{
+	{source: ctSourceS3, eventSource: s3EventSource, eventName: "GetObject", category: ctCategoryData, readOnly: true, build: buildS3DataEvent},
+	{source: ctSourceS3, eventSource: s3EventSource, eventName: "PutObject", category: ctCategoryData, build: buildS3DataEvent},
+	{source: ctSourceS3, eventSource: s3EventSource, eventName: "DeleteObject", category: ctCategoryData, build: buildS3DataEvent},
+	{source: ctSourceS3, eventSource: s3EventSource, eventName: "ListObjects", category: ctCategoryData, readOnly: true, build: buildS3DataEvent},
+	{source: ctSourceS3, eventSource: s3EventSource, eventName: "CreateBucket", category: ctCategoryManagement, build: buildS3CreateBucket},
+	{source: ctSourceS3, eventSource: s3EventSource, eventName: "PutBucketPolicy", category: ctCategoryManagement, build: buildS3PutBucketPolicy},
+
+	{source: ctSourceIAM, eventSource: "iam.amazonaws.com", eventName: "CreateUser", category: ctCategoryManagement, global: true, build: buildIAMCreateUser},
+	{source: ctSourceIAM, eventSource: "iam.amazonaws.com", eventName: "AttachUserPolicy", category: ctCategoryManagement, global: true, build: buildIAMAttachUserPolicy},
+	{source: ctSourceIAM, eventSource: "iam.amazonaws.com", eventName: "CreateAccessKey", category: ctCategoryManagement, global: true, build: buildIAMCreateAccessKey},
+	{source: ctSourceIAM, eventSource: "iam.amazonaws.com", eventName: "ListUsers", category: ctCategoryManagement, readOnly: true, global: true, build: buildNoParameters},
+
+	{source: ctSourceEC2, eventSource: "ec2.amazonaws.com", eventName: "RunInstances", category: ctCategoryManagement, invokedBy: []string{"autoscaling.amazonaws.com"}, build: buildEC2RunInstances},
+	{source: ctSourceEC2, eventSource: "ec2.amazonaws.com", eventName: "DescribeInstances", category: ctCategoryManagement, readOnly: true, build: buildEC2DescribeInstances},
+	{source: ctSourceEC2, eventSource: "ec2.amazonaws.com", eventName: "AuthorizeSecurityGroupIngress", category: ctCategoryManagement, build: buildEC2AuthorizeSecurityGroupIngress},
+	{source: ctSourceEC2, eventSource: "ec2.amazonaws.com", eventName: "TerminateInstances", category: ctCategoryManagement, invokedBy: []string{"autoscaling.amazonaws.com"}, build: buildEC2TerminateInstances},
+
+	{source: ctSourceSTS, eventSource: "sts.amazonaws.com", eventName: "AssumeRole", category: ctCategoryManagement, readOnly: true, invokedBy: []string{"lambda.amazonaws.com", "ec2.amazonaws.com", "ecs-tasks.amazonaws.com"}, build: buildSTSAssumeRole},
+	{source: ctSourceSTS, eventSource: "sts.amazonaws.com", eventName: "GetCallerIdentity", category: ctCategoryManagement, readOnly: true, build: buildNoParameters},
+
+	{source: ctSourceSignIn, eventSource: "signin.amazonaws.com", eventName: "ConsoleLogin", category: ctCategoryManagement, global: true, build: buildConsoleLogin},
+
+	{source: ctSourceKMS, eventSource: "kms.amazonaws.com", eventName: "Decrypt", category: ctCategoryManagement, readOnly: true, invokedBy: []string{"s3.amazonaws.com", "secretsmanager.amazonaws.com"}, build: buildKMSDecrypt},
+	{source: ctSourceKMS, eventSource: "kms.amazonaws.com", eventName: "GenerateDataKey", category: ctCategoryManagement, readOnly: true, invokedBy: []string{"s3.amazonaws.com", "dynamodb.amazonaws.com"}, build: buildKMSGenerateDataKey},
+	{source: ctSourceKMS, eventSource: "kms.amazonaws.com", eventName: "CreateKey", category: ctCategoryManagement, build: buildKMSCreateKey},
+	{source: ctSourceKMS, eventSource: "kms.amazonaws.com", eventName: "ScheduleKeyDeletion", category: ctCategoryManagement, build: buildKMSScheduleKeyDeletion},
+
+	{source: ctSourceLambda, eventSource: "lambda.amazonaws.com", eventName: "Invoke", category: ctCategoryData, invokedBy: []string{"apigateway.amazonaws.com", "events.amazonaws.com"}, build: buildLambdaInvoke},
+	{source: ctSourceLambda, eventSource: "lambda.amazonaws.com", eventName: "CreateFunction20150331", category: ctCategoryManagement, build: buildLambdaCreateFunction},
+	{source: ctSourceLambda, eventSource: "lambda.amazonaws.com", eventName: "UpdateFunctionCode20150331v2", category: ctCategoryManagement, build: buildLambdaUpdateFunctionCode},
+	{source: ctSourceLambda, eventSource: "lambda.amazonaws.com", eventName: "ListFunctions20150331", category: ctCategoryManagement, readOnly: true, build: buildNoParameters},
+
+	{source: ctSourceInsight, category: ctCategoryInsight, build: buildInsight},
+}
+
+// newCloudTrailCustomizer derives a randomized customizer for the given event.
+func newCloudTrailCustomizer(spec cloudTrailEventSpec) cloudTrailCustomizer {
+	return newCloudTrailCustomizerOf(spec, randomSampleAccountID(), randomRegion())
+}
+
+// newCloudTrailCustomizerOf derives a randomized customizer for the given event of an account and region.
+// Events of global services are logged in the region of the service endpoint regardless.
+func newCloudTrailCustomizerOf(spec cloudTrailEventSpec, accountID string, region string) cloudTrailCustomizer {
+	if spec.global {
+		region = ctGlobalRegion
+	}
+
+	c := cloudTrailCustomizer{
+		awsRegion:          region,
+		eventCategory:      spec.category,
+		eventID:            uuid.NewString(),
+		eventName:          spec.eventName,
+		eventSource:        spec.eventSource,
+		eventTime:          iso8601Now(),
+		eventType:          eventType,
+		eventVersion:       eventVersion,
+		recipientAccountID: accountID,
+	}
+
+	// insight events summarize API activity and carry no caller details
+	if spec.category == ctCategoryInsight {
+		c.eventType = ctEventTypeInsight
+		c.sharedEventID = uuid.NewString()
+		spec.build(&c)
+		return c
+	}
+
+	managementEvent := spec.category == ctCategoryManagement
+	readOnly := spec.readOnly
+	c.managementEvent = &managementEvent
+	c.readOnly = &readOnly
+	c.requestID = randomAZ09String(12)
+	c.sharedEventID = randomAZ09String(16)
+	host := fmt.Sprintf("%s.%s.amazonaws.com", strings.TrimSuffix(spec.eventSource, ".amazonaws.com"), region)
+	if spec.global {
+		host = spec.eventSource
+	}
+	c.tlsDetails = map[string]any{
+		"tlsVersion":               randomTLSProtocol(),
+		"cipherSuite":              randomSSLCipher(),
+		"clientProvidedHostHeader": host,
+	}
+
+	if len(spec.invokedBy) > 0 && rand.IntN(10) < 3 {
+		// calls made by AWS services on behalf of the account
+		service := spec.invokedBy[rand.IntN(len(spec.invokedBy))]
+		c.userIdentity = UserIdentity{Type: "AWSService", InvokedBy: service}
+		c.sourceIPAddress = service
+		c.userAgent = service
+		c.tlsDetails = nil
+	} else {
+		c.userIdentity = randomCTUserIdentity(accountID)
+		c.sourceIPAddress = randomIP()
+		c.userAgent = randomUserAgent()
+	}
+
+	spec.build(&c)
+
+	// 10% chance of error, console sign-in failures are recorded by the builder
+	if spec.eventName != "ConsoleLogin" && rand.IntN(10) < 1 {
+		c.errorCode, c.errorMessage = randomErrorCodeAndMessage()
+		c.responseElements = nil
+	}
+
+	return c
+}
+
+// randomCTUserIdentity returns an IAMUser, AssumedRole, Root or FederatedUser identity of the account.
+func randomCTUserIdentity(accountID string) UserIdentity {
+	switch n := rand.IntN(20); {
+	case n < 8:
+		return ctUserIdentity(accountID)
+	case n < 17:
+		return ctAssumedRoleIdentity(accountID)
+	case n < 18:
+		return UserIdentity{
+			Type:           "Root",
+			PrincipalID:    accountID,
+			Arn:            fmt.Sprintf("arn:aws:iam::%s:root", accountID),
+			AccountID:      accountID,
+			AccessKeyID:    "ASIA" + randomAZ09String(16),
+			SessionContext: ctSessionContext(nil, true),
+		}
+	default:
+		userName := fmt.Sprintf("user%d", rand.IntN(10))
+		federatedName := fmt.Sprintf("federated-%s", randomAZ09String(6))
+		return UserIdentity{
+			Type:        "FederatedUser",
+			PrincipalID: fmt.Sprintf("%s:%s", accountID, federatedName),
+			Arn:         fmt.Sprintf("arn:aws:sts::%s:federated-user/%s", accountID, federatedName),
+			AccountID:   accountID,
+			AccessKeyID: "ASIA" + randomAZ09String(16),
+			SessionContext: ctSessionContext(&SessionIssuer{
+				Type:        "IAMUser",
+				PrincipalID: "AIDA" + randomAZ09String(17),
+				Arn:         randomIAMArn(accountID, userName),
+				AccountID:   accountID,
+				UserName:    userName,
+			}, false),
+		}
+	}
+}
+
+func ctAssumedRoleIdentity(accountID string) UserIdentity {
+	role := ctRoleNames[rand.IntN(len(ctRoleNames))]
+	roleID := "AROA" + randomAZ09String(17)
+	session := fmt.Sprintf("session-%s", randomAZ09String(8))
+
+	return UserIdentity{
+		Type:        "AssumedRole",
+		PrincipalID: fmt.Sprintf("%s:%s", roleID, session),
+		Arn:         fmt.Sprintf("arn:aws:sts::%s:assumed-role/%s/%s", accountID, role, session),
+		AccountID:   accountID,
+		AccessKeyID: "ASIA" + randomAZ09String(16),
+		SessionContext: ctSessionContext(&SessionIssuer{
+			Type:        "Role",
+			PrincipalID: roleID,
+			Arn:         fmt.Sprintf("arn:aws:iam::%s:role/%s", accountID, role),
+			AccountID:   accountID,
+			UserName:    role,
+		}, rand.IntN(2) == 0),
+	}
+}
+
+func ctSessionContext(issuer *SessionIssuer, mfa bool) *SessionContext {
+	return &SessionContext{
+		SessionIssuer: issuer,
+		Attributes: SessionAttributes{
+			CreationDate:     time.Now().UTC().Add(-time.Duration(rand.IntN(3600)) * time.Second).Format(time.RFC3339),
+			MFAAuthenticated: fmt.Sprintf("%t", mfa),
+		},
+	}
+}
+
+func ctResource(arn string, resourceType string, accountID string) map[string]any {
+	return map[string]any{
+		"ARN":       arn,
+		"type":      resourceType,
+		"accountId": accountID,
+	}
+}
+
+func ctTimestamp(offset time.Duration) string {
+	return time.Now().UTC().Add(offset).Format("Jan 2, 2006, 3:04:05 PM")
+}
+
+// event builders
+
+func buildNoParameters(_ *cloudTrailCustomizer) {}
+
+func buildS3DataEvent(c *cloudTrailCustomizer) {
+	parameters, resources := generateRequestAndResource(c.eventName, c.recipientAccountID)
+	c.requestParameters = parameters
+	c.resources = []any{resources}
+	c.responseElements = map[string]any{
+		"requestId": c.requestID,
+		"kmsKeyId":  fmt.Sprintf("arn:aws:kms:%s:%s:key/%s", c.awsRegion, c.recipientAccountID, uuid.NewString()),
+	}
+}
+
+func buildS3CreateBucket(c *cloudTrailCustomizer) {
+	bucket := randomBucketName()
+	c.requestParameters = map[string]any{
+		"bucketName": bucket,
+		"Host":       fmt.Sprintf("%s.s3.%s.amazonaws.com", bucket, c.awsRegion),
+		"CreateBucketConfiguration": map[string]any{
+			"LocationConstraint": c.awsRegion,
+		},
+	}
+	c.resources = []any{ctResource("arn:aws:s3:::"+bucket, "AWS::S3::Bucket", c.recipientAccountID)}
+}
+
+func buildS3PutBucketPolicy(c *cloudTrailCustomizer) {
+	bucket := randomBucketName()
+	c.requestParameters = map[string]any{
+		"bucketName": bucket,
+		"policy":     "",
+		"bucketPolicy": map[string]any{
+			"Version": "2012-10-17",
+			"Statement": []any{map[string]any{
+				"Effect":    "Allow",
+				"Principal": map[string]any{"AWS": fmt.Sprintf("arn:aws:iam::%s:root", randomSampleAccountID())},
+				"Action":    "s3:GetObject",
+				"Resource":  fmt.Sprintf("arn:aws:s3:::%s/*", bucket),
+			}},
+		},
+	}
+	c.resources = []any{ctResource("arn:aws:s3:::"+bucket, "AWS::S3::Bucket", c.recipientAccountID)}
+}
+
+func buildIAMCreateUser(c *cloudTrailCustomizer) {
+	userName := fmt.Sprintf("user-%s", randomAZ09String(6))
+	c.requestParameters = map[string]any{
+		"userName": userName,
+	}
+	c.responseElements = map[string]any{
+		"user": map[string]any{
+			"path":       "/",
+			"userName":   userName,
+			"userId":     "AIDA" + randomAZ09String(17),
+			"arn":        randomIAMArn(c.recipientAccountID, userName),
+			"createDate": ctTimestamp(0),
+		},
+	}
+}
+
+func buildIAMAttachUserPolicy(c *cloudTrailCustomizer) {
+	c.requestParameters = map[string]any{
+		"userName":  fmt.Sprintf("user%d", rand.IntN(10)),
+		"policyArn": ctManagedPolicies[rand.IntN(len(ctManagedPolicies))],
+	}
+}
+
+func buildIAMCreateAccessKey(c *cloudTrailCustomizer) {
+	userName := fmt.Sprintf("user%d", rand.IntN(10))
+	c.requestParameters = map[string]any{
+		"userName": userName,
+	}
+	c.responseElements = map[string]any{
+		"accessKey": map[string]any{
+			"accessKeyId": "AKIA" + randomAZ09String(16),
+			"status":      "Active",
+			"userName":    userName,
+			"createDate":  ctTimestamp(0),
+		},
+	}
+}
+
+func buildEC2RunInstances(c *cloudTrailCustomizer) {
+	imageID := ctAMIIDs[rand.IntN(len(ctAMIIDs))]
+	instanceType := ctInstanceTypes[rand.IntN(len(ctInstanceTypes))]
+	instanceID := "i-" + randomHexString(17)
+
+	c.requestParameters = map[string]any{
+		"instancesSet": map[string]any{
+			"items": []any{map[string]any{"imageId": imageID, "minCount": 1, "maxCount": 1}},
+		},
+		"instanceType":                      instanceType,
+		"monitoring":                        map[string]any{"enabled": false},
+		"disableApiTermination":             false,
+		"instanceInitiatedShutdownBehavior": "stop",
+	}
+	c.responseElements = map[string]any{
+		"requestId":     uuid.NewString(),
+		"reservationId": "r-" + randomHexString(17),
+		"ownerId":       c.recipientAccountID,
+		"instancesSet": map[string]any{
+			"items": []any{map[string]any{
+				"instanceId":    instanceID,
+				"imageId":       imageID,
+				"instanceType":  instanceType,
+				"instanceState": map[string]any{"code": 0, "name": "pending"},
+			}},
+		},
+	}
+	c.resources = []any{ctResource(fmt.Sprintf("arn:aws:ec2:%s:%s:instance/%s", c.awsRegion, c.recipientAccountID, instanceID), "AWS::EC2::Instance", c.recipientAccountID)}
+}
+
+func buildEC2DescribeInstances(c *cloudTrailCustomizer) {
+	c.requestParameters = map[string]any{
+		"instancesSet": map[string]any{},
+		"filterSet":    map[string]any{},
+	}
+}
+
+func buildEC2AuthorizeSecurityGroupIngress(c *cloudTrailCustomizer) {
+	port := []int{22, 80, 443, 3389}[rand.IntN(4)]
+	c.requestParameters = map[string]any{
+		"groupId": "sg-" + randomHexString(17),
+		"ipPermissions": map[string]any{
+			"items": []any{map[string]any{
+				"ipProtocol": "tcp",
+				"fromPort":   port,
+				"toPort":     port,
+				"ipRanges":   map[string]any{"items": []any{map[string]any{"cidrIp": "0.0.0.0/0"}}},
+			}},
+		},
+	}
+	c.responseElements = map[string]any{
+		"requestId": uuid.NewString(),
+		"_return":   true,
+	}
+}
+
+func buildEC2TerminateInstances(c *cloudTrailCustomizer) {
+	instanceID := "i-" + randomHexString(17)
+	c.requestParameters = map[string]any{
+		"instancesSet": map[string]any{
+			"items": []any{map[string]any{"instanceId": instanceID}},
+		},
+	}
+	c.responseElements = map[string]any{
+		"requestId": uuid.NewString(),
+		"instancesSet": map[string]any{
+			"items": []any{map[string]any{
+				"instanceId":    instanceID,
+				"currentState":  map[string]any{"code": 32, "name": "shutting-down"},
+				"previousState": map[string]any{"code": 16, "name": "running"},
+			}},
+		},
+	}
+}
+
+func buildSTSAssumeRole(c *cloudTrailCustomizer) {
+	role := ctRoleNames[rand.IntN(len(ctRoleNames))]
+	roleArn := fmt.Sprintf("arn:aws:iam::%s:role/%s", c.recipientAccountID, role)
+	roleID := "AROA" + randomAZ09String(17)
+	session := fmt.Sprintf("session-%s", randomAZ09String(8))
+
+	c.requestParameters = map[string]any{
+		"roleArn":         roleArn,
+		"roleSessionName": session,
+		"durationSeconds": 3600,
+	}
+	c.responseElements = map[string]any{
+		"credentials": map[string]any{
+			"accessKeyId": "ASIA" + randomAZ09String(16),
+			"expiration":  ctTimestamp(time.Hour),
+		},
+		"assumedRoleUser": map[string]any{
+			"assumedRoleId": fmt.Sprintf("%s:%s", roleID, session),
+			"arn":           fmt.Sprintf("arn:aws:sts::%s:assumed-role/%s/%s", c.recipientAccountID, role, session),
+		},
+	}
+	c.resources = []any{ctResource(roleArn, "AWS::IAM::Role", c.recipientAccountID)}
+}
+
+func buildConsoleLogin(c *cloudTrailCustomizer) {
+	c.eventType = ctEventTypeConsoleSignIn
+	c.requestParameters = nil
+	c.tlsDetails = nil
+	c.userAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+
+	mfa := "No"
+	if rand.IntN(2) == 0 {
+		mfa = "Yes"
+	}
+	c.AdditionalEventData = map[string]any{
+		"LoginTo":       "https://console.aws.amazon.com/console/home",
+		"MobileVersion": "No",
+		"MFAUsed":       mfa,
+	}
+
+	// 10% chance of failed authentication
+	result := "Success"
+	if rand.IntN(10) < 1 {
+		result = "Failure"
+		c.errorMessage = "Failed authentication"
+	}
+	c.responseElements = map[string]any{
+		"ConsoleLogin": result,
+	}
+}
+
+func ctKMSKeyArn(c *cloudTrailCustomizer) string {
+	return fmt.Sprintf("arn:aws:kms:%s:%s:key/%s", c.awsRegion, c.recipientAccountID, uuid.NewString())
+}
+
+func buildKMSDecrypt(c *cloudTrailCustomizer) {
+	keyArn := ctKMSKeyArn(c)
+	c.requestParameters = map[string]any{
+		"encryptionAlgorithm": "SYMMETRIC_DEFAULT",
+		"encryptionContext": map[string]any{
+			"aws:s3:arn": fmt.Sprintf("arn:aws:s3:::%s/%s", randomBucketName(), randomS3ObjectKey()),
+		},
+	}
+	c.resources = []any{ctResource(keyArn, "AWS::KMS::Key", c.recipientAccountID)}
+}
+
+func buildKMSGenerateDataKey(c *cloudTrailCustomizer) {
+	keyArn := ctKMSKeyArn(c)
+	c.requestParameters = map[string]any{
+		"keyId":   keyArn,
+		"keySpec": "AES_256",
+		"encryptionContext": map[string]any{
+			"aws:s3:arn": fmt.Sprintf("arn:aws:s3:::%s", randomBucketName()),
+		},
+	}
+	c.resources = []any{ctResource(keyArn, "AWS::KMS::Key", c.recipientAccountID)}
+}
+
+func buildKMSCreateKey(c *cloudTrailCustomizer) {
+	keyID := uuid.NewString()
+	keyArn := fmt.Sprintf("arn:aws:kms:%s:%s:key/%s", c.awsRegion, c.recipientAccountID, keyID)
+	c.requestParameters = map[string]any{
+		"description": "data-gen key",
+		"keyUsage":    "ENCRYPT_DECRYPT",
+		"keySpec":     "SYMMETRIC_DEFAULT",
+	}
+	c.responseElements = map[string]any{
+		"keyMetadata": map[string]any{
+			"aWSAccountId": c.recipientAccountID,
+			"keyId":        keyID,
+			"arn":          keyArn,
+			"creationDate": ctTimestamp(0),
+			"enabled":      true,
+			"description":  "data-gen key",
+			"keyUsage":     "ENCRYPT_DECRYPT",
+			"keyState":     "Enabled",
+			"origin":       "AWS_KMS",
+			"keyManager":   "CUSTOMER",
+			"keySpec":      "SYMMETRIC_DEFAULT",
+		},
+	}
+	c.resources = []any{ctResource(keyArn, "AWS::KMS::Key", c.recipientAccountID)}
+}
+
+func buildKMSScheduleKeyDeletion(c *cloudTrailCustomizer) {
+	keyArn := ctKMSKeyArn(c)
+	c.requestParameters = map[string]any{
+		"keyId":               keyArn,
+		"pendingWindowInDays": 7,
+	}
+	c.responseElements = map[string]any{
+		"keyId":               keyArn,
+		"deletionDate":        ctTimestamp(7 * 24 * time.Hour),
+		"keyState":            "PendingDeletion",
+		"pendingWindowInDays": 7,
+	}
+	c.resources = []any{ctResource(keyArn, "AWS::KMS::Key", c.recipientAccountID)}
+}
+
+func ctFunctionArn(c *cloudTrailCustomizer, name string) string {
+	return fmt.Sprintf("arn:aws:lambda:%s:%s:function:%s", c.awsRegion, c.recipientAccountID, name)
+}
+
+func buildLambdaInvoke(c *cloudTrailCustomizer) {
+	functionArn := ctFunctionArn(c, ctFunctionNames[rand.IntN(len(ctFunctionNames))])
+	c.requestParameters = map[string]any{
+		"functionName":   functionArn,
+		"invocationType": "RequestResponse",
+	}
+	c.AdditionalEventData = map[string]any{
+		"functionVersion": functionArn + ":$LATEST",
+	}
+	c.resources = []any{ctResource(functionArn, "AWS::Lambda::Function", c.recipientAccountID)}
+}
+
+func buildLambdaCreateFunction(c *cloudTrailCustomizer) {
+	name := ctFunctionNames[rand.IntN(len(ctFunctionNames))]
+	role := fmt.Sprintf("arn:aws:iam::%s:role/LambdaExecutionRole", c.recipientAccountID)
+	c.requestParameters = map[string]any{
+		"functionName": name,
+		"runtime":      "python3.12",
+		"role":         role,
+		"handler":      "index.handler",
+		"timeout":      30,
+		"memorySize":   128,
+		"publish":      false,
+	}
+	c.responseElements = map[string]any{
+		"functionName":    name,
+		"functionArn":     ctFunctionArn(c, name),
+		"runtime":         "python3.12",
+		"role":            role,
+		"handler":         "index.handler",
+		"codeSize":        rand.IntN(5_000_000) + 500,
+		"state":           "Pending",
+		"stateReasonCode": "Creating",
+	}
+}
+
+func buildLambdaUpdateFunctionCode(c *cloudTrailCustomizer) {
+	name := ctFunctionNames[rand.IntN(len(ctFunctionNames))]
+	c.requestParameters = map[string]any{
+		"functionName": name,
+		"publish":      false,
+		"dryRun":       false,
+	}
+	c.responseElements = map[string]any{
+		"functionName":     name,
+		"functionArn":      ctFunctionArn(c, name),
+		"codeSha256":       randomAZaz09String(43) + "=",
+		"codeSize":         rand.IntN(5_000_000) + 500,
+		"lastUpdateStatus": "InProgress",
+	}
+}
+
+// buildInsight records unusual API call or error rates of a management event.
+// See - https://docs.aws.amazon.com/awscloudtrail/latest/userguide/cloudtrail-insights-events.html
+func buildInsight(c *cloudTrailCustomizer) {
+	target := ctInsightTargets[rand.IntN(len(ctInsightTargets))]
+
+	baseline := rand.Float64() * 10
+	insightAverage := baseline * (5 + rand.Float64()*20)
+
+	statistics := map[string]any{
+		"baseline": map[string]any{"average": baseline},
+		"insight":  map[string]any{"average": insightAverage},
+	}
+
+	state := "Start"
+	if rand.IntN(2) == 0 {
+		state = "End"
+		statistics["insightDuration"] = rand.IntN(30) + 1
+		statistics["baselineDuration"] = 10080
+	}
+
+	details := map[string]any{
+		"state":       state,
+		"eventSource": target.eventSource,
+		"eventName":   target.eventName,
+		"insightType": "ApiCallRateInsight",
+		"insightContext": map[string]any{
+			"statistics": statistics,
+			"attributions": []any{map[string]any{
+				"attribute": "userIdentityArn",
+				"insight":   []any{map[string]any{"value": ctAssumedRoleIdentity(c.recipientAccountID).Arn, "average": insightAverage}},
+				"baseline":  []any{map[string]any{"value": ctAssumedRoleIdentity(c.recipientAccountID).Arn, "average": baseline}},
+			}},
+		},
+	}
+
+	if rand.IntN(4) == 0 {
+		code, _ := randomErrorCodeAndMessage()
+		details["insightType"] = "ApiErrorRateInsight"
+		details["errorCode"] = code
+	}
+
+	c.insightDetails = details
+}
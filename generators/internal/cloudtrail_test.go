@@ -2,6 +2,7 @@ package internal
 
 import (
 	"encoding/json"
+	"slices"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -112,3 +113,65 @@ func Test_CloudTrailLog(t *testing.T) {
 
 	require.Equal(t, marshal, generated)
 }
+
+func Test_cloudTrailEventSelection(t *testing.T) {
+	t.Run("Default selection keeps S3 data events", func(t *testing.T) {
+		cfg := cloudTrailCfg{}
+		cfg.applyDefaults()
+
+		events, err := cloudTrailEventSelection(cfg.EventSources, cfg.EventCategories)
+		require.NoError(t, err)
+		for _, event := range events {
+			require.Equal(t, s3EventSource, event.eventSource)
+			require.Equal(t, ctCategoryData, event.category)
+		}
+	})
+
+	t.Run("Configured sources default to all categories", func(t *testing.T) {
+		cfg := cloudTrailCfg{EventSources: []string{ctSourceS3}}
+		cfg.applyDefaults()
+
+		events, err := cloudTrailEventSelection(cfg.EventSources, cfg.EventCategories)
+		require.NoError(t, err)
+		require.True(t, slices.ContainsFunc(events, func(e cloudTrailEventSpec) bool { return e.category == ctCategoryManagement }))
+	})
+
+	t.Run("Filter sources by category", func(t *testing.T) {
+		events, err := cloudTrailEventSelection([]string{"lambda", "IAM"}, []string{"data"})
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		require.Equal(t, "Invoke", events[0].eventName)
+	})
+
+	t.Run("Unknown source or empty selection", func(t *testing.T) {
+		_, err := cloudTrailEventSelection([]string{"unknown"}, nil)
+		require.Error(t, err)
+
+		_, err = cloudTrailEventSelection([]string{"iam"}, []string{"Data"})
+		require.Error(t, err)
+	})
+}
+
+func Test_newCloudTrailCustomizer(t *testing.T) {
+	for _, spec := range cloudTrailEvents {
+		t.Run(spec.source+"/"+spec.eventName, func(t *testing.T) {
+			record := cloudTrailRecordFor(newCloudTrailCustomizer(spec))
+			require.Equal(t, spec.category, record.EventCategory)
+
+			if spec.category == ctCategoryInsight {
+				require.Equal(t, ctEventTypeInsight, record.EventType)
+				require.NotEmpty(t, record.InsightDetails)
+				require.Zero(t, record.UserIdentity)
+				return
+			}
+
+			require.Equal(t, spec.eventName, record.EventName)
+			require.Equal(t, spec.category == ctCategoryManagement, *record.ManagementEvent)
+			require.NotEmpty(t, record.UserIdentity.Type)
+			if record.UserIdentity.Type == "AssumedRole" {
+				require.NotNil(t, record.UserIdentity.SessionContext)
+				require.Equal(t, "Role", record.UserIdentity.SessionContext.SessionIssuer.Type)
+			}
+		})
+	}
+}
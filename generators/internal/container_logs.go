@@ -0,0 +1,184 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"data-gen/conf"
+)
+
+const (
+	containerFormatCRI    = "cri"
+	containerFormatDocker = "docker"
+
+	containerStreamStdout = "stdout"
+	containerStreamStderr = "stderr"
+
+	// criTagPartial & criTagFull mark CRI log lines split from a longer line and the final (or only) line
+	criTagPartial = "P"
+	criTagFull    = "F"
+
+	// defaultContainerMaxLineSize matches the 16 KiB buffer of containerd and Docker log drivers
+	defaultContainerMaxLineSize = 16 * 1024
+)
+
+// containerStderrMessages are plain text lines containers write to stderr, formatted with a random number.
+var containerStderrMessages = []string{
+	"WARNING: connection pool exhausted, waiting for idle connection (%d waiters)",
+	"error: upstream request timeout after %d ms",
+	"panic: runtime error: index out of range [%d] with length 0",
+	"ERROR: could not refresh token, retrying in %d seconds",
+	"Unhandled promise rejection: Error: socket hang up (attempt %d)",
+}
+
+// ContainerLogsGen generates Kubernetes container logs as written by the container runtime on the node.
+// Application messages are ECS logs on stdout and plain text on stderr, wrapped in the CRI or Docker json-file format.
+// Messages longer than max_line_size are split into partial lines the same way the runtime does.
+type ContainerLogsGen struct {
+	cfg containerLogsCfg
+	buf trackedBuffer
+	app *LogGenerator
+}
+
+// containerLogsCfg specifies the log file format and the size at which messages are split.
+type containerLogsCfg struct {
+	LogFormat   string `yaml:"log_format"`
+	MaxLineSize int    `yaml:"max_line_size"`
+}
+
+func NewContainerLogsGen(input conf.InputConfig) (*ContainerLogsGen, error) {
+	cfg := containerLogsCfg{
+		LogFormat:   containerFormatCRI,
+		MaxLineSize: defaultContainerMaxLineSize,
+	}
+
+	err := input.Conf.Decode(&cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode container logs configuration: %w", err)
+	}
+
+	if cfg.LogFormat != containerFormatCRI && cfg.LogFormat != containerFormatDocker {
+		return nil, fmt.Errorf("unknown container log format: %s", cfg.LogFormat)
+	}
+
+	if cfg.MaxLineSize <= 0 {
+		return nil, fmt.Errorf("container logs max_line_size must be greater than 0")
+	}
+
+	return &ContainerLogsGen{
+		cfg: cfg,
+		buf: newTrackedBuffer(),
+		app: NewLogGenerator(),
+	}, nil
+}
+
+func (g *ContainerLogsGen) Generate() (int64, error) {
+	c := newContainerLogCustomizer(g.app, g.cfg.MaxLineSize)
+
+	lines, err := buildContainerLogLines(c, g.cfg)
+	if err != nil {
+		return 0, err
+	}
+
+	err = g.buf.write([]byte(strings.Join(lines, "\n") + "\n"))
+	if err != nil {
+		return 0, err
+	}
+
+	return g.buf.size(), nil
+}
+
+func (g *ContainerLogsGen) GetAndReset() []byte {
+	return g.buf.getAndReset()
+}
+
+func (g *ContainerLogsGen) RecordTimestamp(record string) (time.Time, bool) {
+	if g.cfg.LogFormat == containerFormatDocker {
+		return jsonTimestamp(record, "time")
+	}
+
+	return fieldTimestamp(record, 0)
+}
+
+// containerLogCustomizer holds a single message written by the container, without its trailing newline.
+type containerLogCustomizer struct {
+	time    time.Time
+	stream  string
+	message string
+}
+
+func newContainerLogCustomizer(app *LogGenerator, maxLineSize int) containerLogCustomizer {
+	c := containerLogCustomizer{
+		time:   time.Now().UTC(),
+		stream: containerStreamStdout,
+	}
+
+	switch n := rand.IntN(100); {
+	case n < 10:
+		c.stream = containerStreamStderr
+		c.message = fmt.Sprintf(containerStderrMessages[rand.IntN(len(containerStderrMessages))], rand.IntN(500)+1)
+	case n < 12:
+		// occasional oversized messages (eg:- payload dumps) exceed the runtime buffer and get split
+		app.logger.Info(fmt.Sprintf("payload dump: %s", randomLogString(maxLineSize+rand.IntN(maxLineSize*2))))
+		c.message = strings.TrimSuffix(string(app.writer.data), "\n")
+	default:
+		app.logger.Info(fmt.Sprintf("log entry: %s", randomLogString(100)))
+		c.message = strings.TrimSuffix(string(app.writer.data), "\n")
+	}
+
+	return c
+}
+
+// dockerJSONLog is a record of the Docker json-file log driver.
+type dockerJSONLog struct {
+	Log    string `json:"log"`
+	Stream string `json:"stream"`
+	Time   string `json:"time"`
+}
+
+// buildContainerLogLines wraps the message in the configured format, splitting it into chunks of at most max_line_size bytes.
+// CRI lines tag all but the last chunk as partial (P), while Docker omits the newline from all but the last chunk.
+func buildContainerLogLines(c containerLogCustomizer, cfg containerLogsCfg) ([]string, error) {
+	chunks := splitContainerMessage(c.message, cfg.MaxLineSize)
+	timestamp := c.time.Format(time.RFC3339Nano)
+
+	lines := make([]string, 0, len(chunks))
+	for i, chunk := range chunks {
+		last := i == len(chunks)-1
+
+		if cfg.LogFormat == containerFormatCRI {
+			tag := criTagPartial
+			if last {
+				tag = criTagFull
+			}
+			lines = append(lines, fmt.Sprintf("%s %s %s %s", timestamp, c.stream, tag, chunk))
+			continue
+		}
+
+		if last {
+			chunk += "\n"
+		}
+		line, err := json.Marshal(dockerJSONLog{Log: chunk, Stream: c.stream, Time: timestamp})
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal docker log line: %w", err)
+		}
+		lines = append(lines, string(line))
+	}
+
+	return lines, nil
+}
+
+// splitContainerMessage splits the message into chunks of at most size bytes.
+// Empty messages still produce a single (empty) chunk.
+func splitContainerMessage(message string, size int) []string {
+	chunks := []string{}
+	for len(message) > size {
+		chunks = append(chunks, message[:size])
+		message = message[size:]
+	}
+
+	return append(chunks, message)
+}
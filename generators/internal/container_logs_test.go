@@ -0,0 +1,101 @@
+package internal
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"data-gen/conf"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func Test_buildContainerLogLines(t *testing.T) {
+	c := containerLogCustomizer{
+		time:    time.Date(2026, 10, 19, 10, 20, 30, 123456789, time.UTC),
+		stream:  containerStreamStdout,
+		message: "abcdefghij",
+	}
+
+	t.Run("CRI full line", func(t *testing.T) {
+		lines, err := buildContainerLogLines(c, containerLogsCfg{LogFormat: containerFormatCRI, MaxLineSize: 16})
+		require.NoError(t, err)
+		require.Equal(t, []string{"2026-10-19T10:20:30.123456789Z stdout F abcdefghij"}, lines)
+	})
+
+	t.Run("CRI partial lines", func(t *testing.T) {
+		lines, err := buildContainerLogLines(c, containerLogsCfg{LogFormat: containerFormatCRI, MaxLineSize: 4})
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			"2026-10-19T10:20:30.123456789Z stdout P abcd",
+			"2026-10-19T10:20:30.123456789Z stdout P efgh",
+			"2026-10-19T10:20:30.123456789Z stdout F ij",
+		}, lines)
+	})
+
+	t.Run("Docker partial lines", func(t *testing.T) {
+		stderr := c
+		stderr.stream = containerStreamStderr
+
+		lines, err := buildContainerLogLines(stderr, containerLogsCfg{LogFormat: containerFormatDocker, MaxLineSize: 5})
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			`{"log":"abcde","stream":"stderr","time":"2026-10-19T10:20:30.123456789Z"}`,
+			`{"log":"fghij\n","stream":"stderr","time":"2026-10-19T10:20:30.123456789Z"}`,
+		}, lines)
+	})
+}
+
+func Test_NewContainerLogsGen(t *testing.T) {
+	t.Run("Default CRI format", func(t *testing.T) {
+		gen, err := NewContainerLogsGen(conf.InputConfig{})
+		require.NoError(t, err)
+		require.Equal(t, containerFormatCRI, gen.cfg.LogFormat)
+		require.Equal(t, defaultContainerMaxLineSize, gen.cfg.MaxLineSize)
+	})
+
+	t.Run("Docker messages are reassembled from partial lines", func(t *testing.T) {
+		var input conf.InputConfig
+		require.NoError(t, yaml.Unmarshal([]byte("log_format: docker\nmax_line_size: 64"), &input.Conf))
+
+		gen, err := NewContainerLogsGen(input)
+		require.NoError(t, err)
+
+		for range 50 {
+			_, err = gen.Generate()
+			require.NoError(t, err)
+		}
+
+		var message strings.Builder
+		for _, line := range strings.Split(strings.TrimSuffix(string(gen.GetAndReset()), "\n"), "\n") {
+			var record dockerJSONLog
+			require.NoError(t, json.Unmarshal([]byte(line), &record))
+			require.LessOrEqual(t, len(record.Log), 65)
+
+			message.WriteString(record.Log)
+			if !strings.HasSuffix(record.Log, "\n") {
+				continue
+			}
+
+			// stdout messages are ECS logs once reassembled
+			if record.Stream == containerStreamStdout {
+				require.True(t, json.Valid([]byte(message.String())))
+			}
+			message.Reset()
+		}
+		require.Zero(t, message.Len())
+	})
+
+	t.Run("Invalid configuration", func(t *testing.T) {
+		var input conf.InputConfig
+		require.NoError(t, yaml.Unmarshal([]byte("log_format: journald"), &input.Conf))
+		_, err := NewContainerLogsGen(input)
+		require.ErrorContains(t, err, "unknown container log format")
+
+		require.NoError(t, yaml.Unmarshal([]byte("max_line_size: -1"), &input.Conf))
+		_, err = NewContainerLogsGen(input)
+		require.ErrorContains(t, err, "max_line_size")
+	})
+}
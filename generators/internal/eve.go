@@ -0,0 +1,154 @@
+package internal
+
+// eveSignature is a Suricata compatible rule along with the app-layer protocol it inspects, empty for any protocol.
+type eveSignature struct {
+	id        int
+	rev       int
+	signature string
+	category  string
+	severity  int
+	appProto  string
+}
+
+// eveSignatures is the Emerging Threats (ET) rule catalog shared by Suricata sensors and Network Firewall.
+var eveSignatures = []eveSignature{
+	{2013028, 7, "ET POLICY curl User-Agent Outbound", "Attempted Information Leak", 2, "http"},
+	{2027863, 3, "ET INFO Observed DNS Query to .onion proxy Domain", "Potentially Bad Traffic", 2, "dns"},
+	{2029340, 2, "ET INFO Observed Telegram API Domain in TLS SNI", "Misc activity", 3, "tls"},
+	{2001219, 20, "ET SCAN Potential SSH Scan", "Attempted Information Leak", 2, "ssh"},
+	{2008581, 3, "ET P2P BitTorrent DHT ping request", "Potential Corporate Privacy Violation", 3, ""},
+}
+
+// eveEvent is a Suricata EVE JSON event, logged by Suricata sensors and embedded in Network Firewall alert and flow logs.
+// See - https://docs.suricata.io/en/latest/output/eve/eve-json-format.html
+type eveEvent struct {
+	Timestamp string    `json:"timestamp"`
+	FlowID    int64     `json:"flow_id"`
+	InIface   string    `json:"in_iface,omitempty"`
+	EventType string    `json:"event_type"`
+	SrcIP     string    `json:"src_ip"`
+	SrcPort   int       `json:"src_port,omitempty"`
+	DestIP    string    `json:"dest_ip"`
+	DestPort  int       `json:"dest_port,omitempty"`
+	Proto     string    `json:"proto"`
+	ICMPType  int       `json:"icmp_type,omitempty"`
+	TxID      *int      `json:"tx_id,omitempty"`
+	Alert     *eveAlert `json:"alert,omitempty"`
+	AppProto  string    `json:"app_proto,omitempty"`
+	Direction string    `json:"direction,omitempty"`
+	// Flow is the flow summary of flow events, or the flow counters at the time of the alert
+	Flow    any         `json:"flow,omitempty"`
+	Netflow *eveNetflow `json:"netflow,omitempty"`
+	DNS     *eveDNS     `json:"dns,omitempty"`
+	HTTP    *eveHTTP    `json:"http,omitempty"`
+	TLS     *eveTLS     `json:"tls,omitempty"`
+	TCP     *eveTCP     `json:"tcp,omitempty"`
+}
+
+// eveAlert is the rule match of an alert event. Network Firewall omits the generator ID (gid).
+type eveAlert struct {
+	Action      string `json:"action"`
+	GID         int    `json:"gid,omitempty"`
+	SignatureID int    `json:"signature_id"`
+	Rev         int    `json:"rev"`
+	Signature   string `json:"signature"`
+	Category    string `json:"category"`
+	Severity    int    `json:"severity"`
+}
+
+type eveFlowCounters struct {
+	PktsToserver  int    `json:"pkts_toserver"`
+	PktsToclient  int    `json:"pkts_toclient"`
+	BytesToserver int    `json:"bytes_toserver"`
+	BytesToclient int    `json:"bytes_toclient"`
+	Start         string `json:"start"`
+}
+
+type eveFlow struct {
+	eveFlowCounters
+	End     string `json:"end"`
+	Age     int    `json:"age"`
+	State   string `json:"state"`
+	Reason  string `json:"reason"`
+	Alerted bool   `json:"alerted"`
+}
+
+// eveNetflow is a unidirectional flow summary, as logged by Network Firewall flow logs.
+type eveNetflow struct {
+	Pkts   int    `json:"pkts"`
+	Bytes  int    `json:"bytes"`
+	Start  string `json:"start"`
+	End    string `json:"end"`
+	Age    int    `json:"age"`
+	MinTTL int    `json:"min_ttl"`
+	MaxTTL int    `json:"max_ttl"`
+}
+
+// eveTCP holds the cumulative TCP flags of a flow, along with the flags seen to server (ts) and to client (tc) when logged.
+type eveTCP struct {
+	TCPFlags   string `json:"tcp_flags"`
+	TCPFlagsTS string `json:"tcp_flags_ts,omitempty"`
+	TCPFlagsTC string `json:"tcp_flags_tc,omitempty"`
+	Syn        bool   `json:"syn,omitempty"`
+	Fin        bool   `json:"fin,omitempty"`
+	Rst        bool   `json:"rst,omitempty"`
+	Psh        bool   `json:"psh,omitempty"`
+	Ack        bool   `json:"ack,omitempty"`
+	State      string `json:"state,omitempty"`
+}
+
+// eveDNS is a DNS query, or a version 2 DNS answer carrying both detailed and grouped answers.
+type eveDNS struct {
+	Version int                 `json:"version,omitempty"`
+	Type    string              `json:"type"`
+	ID      int                 `json:"id"`
+	Flags   string              `json:"flags,omitempty"`
+	QR      bool                `json:"qr,omitempty"`
+	RD      bool                `json:"rd"`
+	RA      bool                `json:"ra,omitempty"`
+	Opcode  int                 `json:"opcode"`
+	RRName  string              `json:"rrname"`
+	RRType  string              `json:"rrtype"`
+	RCode   string              `json:"rcode,omitempty"`
+	Answers []eveDNSAnswer      `json:"answers,omitempty"`
+	Grouped map[string][]string `json:"grouped,omitempty"`
+}
+
+type eveDNSAnswer struct {
+	RRName string `json:"rrname"`
+	RRType string `json:"rrtype"`
+	TTL    int    `json:"ttl"`
+	RData  string `json:"rdata"`
+}
+
+// eveHTTP is an HTTP transaction, where the response status is only known to sensors logging full transactions.
+type eveHTTP struct {
+	Hostname        string `json:"hostname"`
+	URL             string `json:"url"`
+	HTTPUserAgent   string `json:"http_user_agent"`
+	HTTPContentType string `json:"http_content_type,omitempty"`
+	HTTPRefer       string `json:"http_refer,omitempty"`
+	HTTPMethod      string `json:"http_method"`
+	Protocol        string `json:"protocol"`
+	Status          int    `json:"status,omitempty"`
+	Length          int    `json:"length"`
+}
+
+// eveTLS is a TLS handshake. Certificate details are only logged up to TLS 1.2.
+type eveTLS struct {
+	Subject     string  `json:"subject,omitempty"`
+	IssuerDN    string  `json:"issuerdn,omitempty"`
+	Serial      string  `json:"serial,omitempty"`
+	Fingerprint string  `json:"fingerprint,omitempty"`
+	SNI         string  `json:"sni"`
+	Version     string  `json:"version,omitempty"`
+	NotBefore   string  `json:"notbefore,omitempty"`
+	NotAfter    string  `json:"notafter,omitempty"`
+	JA3         *eveJA3 `json:"ja3,omitempty"`
+	JA3S        *eveJA3 `json:"ja3s,omitempty"`
+}
+
+type eveJA3 struct {
+	Hash   string `json:"hash"`
+	String string `json:"string"`
+}
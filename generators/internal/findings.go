@@ -0,0 +1,190 @@
+package internal
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"data-gen/conf"
+
+	"github.com/google/uuid"
+)
+
+const (
+	findingResourceEC2 = "ec2"
+	findingResourceS3  = "s3"
+	findingResourceIAM = "iam"
+	findingResourceEKS = "eks"
+
+	findingDeliveryFinding     = "finding"
+	findingDeliveryEventBridge = "eventbridge"
+
+	findingTimeFormat     = "2006-01-02T15:04:05.000Z"
+	eventBridgeTimeFormat = "2006-01-02T15:04:05Z"
+)
+
+var findingResourceTypes = []string{findingResourceEC2, findingResourceS3, findingResourceIAM, findingResourceEKS}
+
+var findingInstanceTypes = []string{"t3.micro", "t3.large", "m5.xlarge", "c6g.2xlarge", "r6i.large"}
+var findingKubernetesVersions = []string{"1.27", "1.28", "1.29", "1.30"}
+
+// findingCfg specifies the resource types findings are generated for and how findings are delivered.
+type findingCfg struct {
+	ResourceTypes  []string `yaml:"resource_types"`
+	DeliveryFormat string   `yaml:"delivery_format"`
+}
+
+// decodeFindingCfg decodes and validates the finding configuration of the named input.
+func decodeFindingCfg(input conf.InputConfig, name string) (*findingCfg, error) {
+	cfg := findingCfg{
+		ResourceTypes:  findingResourceTypes,
+		DeliveryFormat: findingDeliveryFinding,
+	}
+
+	err := input.Conf.Decode(&cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s configuration: %w", name, err)
+	}
+
+	if len(cfg.ResourceTypes) == 0 {
+		return nil, fmt.Errorf("%s resource_types must not be empty", name)
+	}
+
+	for _, t := range cfg.ResourceTypes {
+		if !slices.Contains(findingResourceTypes, t) {
+			return nil, fmt.Errorf("unknown %s resource type: %s", name, t)
+		}
+	}
+
+	switch cfg.DeliveryFormat {
+	case findingDeliveryFinding, findingDeliveryEventBridge:
+	default:
+		return nil, fmt.Errorf("unknown %s delivery format: %s", name, cfg.DeliveryFormat)
+	}
+
+	return &cfg, nil
+}
+
+// findingResource is an affected resource shared by GuardDuty and Security Hub findings.
+// Only the fields of the resource kind are set.
+type findingResource struct {
+	kind      string
+	accountID string
+	region    string
+	createdAt time.Time
+
+	// ec2
+	instanceID      string
+	instanceType    string
+	imageID         string
+	vpcID           string
+	subnetID        string
+	eniID           string
+	securityGroupID string
+	privateIP       string
+	publicIP        string
+
+	// s3
+	bucketName string
+
+	// iam
+	userName    string
+	principalID string
+	accessKeyID string
+
+	// eks
+	clusterName       string
+	kubernetesVersion string
+}
+
+func newFindingResource(kind, accountID, region string) findingResource {
+	r := findingResource{
+		kind:      kind,
+		accountID: accountID,
+		region:    region,
+		createdAt: time.Now().UTC().Add(-time.Duration(rand.IntN(365*24)+1) * time.Hour),
+		vpcID:     "vpc-" + randomHexString(17),
+	}
+
+	switch kind {
+	case findingResourceEC2:
+		r.instanceID = "i-" + randomHexString(17)
+		r.instanceType = findingInstanceTypes[rand.IntN(len(findingInstanceTypes))]
+		r.imageID = "ami-" + randomHexString(17)
+		r.subnetID = "subnet-" + randomHexString(17)
+		r.eniID = "eni-" + randomHexString(17)
+		r.securityGroupID = "sg-" + randomHexString(17)
+		r.privateIP = fmt.Sprintf("10.0.%d.%d", rand.IntN(4), rand.IntN(254)+1)
+		r.publicIP = randomIP()
+	case findingResourceS3:
+		r.bucketName = fmt.Sprintf("%s-%s", randomBucketName(), randomHexString(6))
+	case findingResourceIAM:
+		r.userName = fmt.Sprintf("user%d", rand.IntN(10))
+		r.principalID = "AIDA" + randomAZ09String(17)
+		r.accessKeyID = "AKIA" + randomAZ09String(16)
+	case findingResourceEKS:
+		r.clusterName = []string{"prod", "staging", "platform"}[rand.IntN(3)] + "-cluster"
+		r.kubernetesVersion = findingKubernetesVersions[rand.IntN(len(findingKubernetesVersions))]
+	}
+
+	return r
+}
+
+// arn returns the ARN of the resource.
+func (r findingResource) arn() string {
+	switch r.kind {
+	case findingResourceEC2:
+		return fmt.Sprintf("arn:aws:ec2:%s:%s:instance/%s", r.region, r.accountID, r.instanceID)
+	case findingResourceS3:
+		return fmt.Sprintf("arn:aws:s3:::%s", r.bucketName)
+	case findingResourceIAM:
+		return randomIAMArn(r.accountID, r.userName)
+	default:
+		return fmt.Sprintf("arn:aws:eks:%s:%s:cluster/%s", r.region, r.accountID, r.clusterName)
+	}
+}
+
+// name returns the identifier used for the resource in finding titles.
+func (r findingResource) name() string {
+	switch r.kind {
+	case findingResourceEC2:
+		return r.instanceID
+	case findingResourceS3:
+		return r.bucketName
+	case findingResourceIAM:
+		return r.userName
+	default:
+		return r.clusterName
+	}
+}
+
+// findingSeverityLabel maps GuardDuty severity values to Security Hub severity labels.
+func findingSeverityLabel(severity float64) string {
+	switch {
+	case severity >= 9:
+		return "CRITICAL"
+	case severity >= 7:
+		return "HIGH"
+	case severity >= 4:
+		return "MEDIUM"
+	case severity >= 1:
+		return "LOW"
+	default:
+		return "INFORMATIONAL"
+	}
+}
+
+func newFindingEventBridgeEvent[T any](detailType, source, accountID, region string, t time.Time, resources []string, detail T) eventBridgeEvent[T] {
+	return eventBridgeEvent[T]{
+		Version:    "0",
+		ID:         uuid.NewString(),
+		DetailType: detailType,
+		Source:     source,
+		Account:    accountID,
+		Time:       t.UTC().Format(eventBridgeTimeFormat),
+		Region:     region,
+		Resources:  resources,
+		Detail:     detail,
+	}
+}
@@ -0,0 +1,599 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"data-gen/conf"
+)
+
+const (
+	gdSchemaVersion = "2.0"
+
+	gdActionPortProbe         = "PORT_PROBE"
+	gdActionNetworkConnection = "NETWORK_CONNECTION"
+	gdActionDNSRequest        = "DNS_REQUEST"
+	gdActionAWSAPICall        = "AWS_API_CALL"
+	gdActionKubernetesAPICall = "KUBERNETES_API_CALL"
+)
+
+// guardDutyFindingType is a GuardDuty finding type along with the resource and activity it reports.
+// Titles carry a placeholder for the affected resource name.
+type guardDutyFindingType struct {
+	name        string
+	resource    string
+	severity    float64
+	action      string
+	feature     string
+	api         string
+	title       string
+	description string
+}
+
+// guardDutyFindingTypes lists common finding types per resource type.
+// See - https://docs.aws.amazon.com/guardduty/latest/ug/guardduty_finding-types-active.html
+var guardDutyFindingTypes = []guardDutyFindingType{
+	{"Recon:EC2/PortProbeUnprotectedPort", findingResourceEC2, 2, gdActionPortProbe, "FlowLogs", "",
+		"Unprotected port on EC2 instance %s is being probed.", "EC2 instance has an unprotected port which is being probed by a known malicious host."},
+	{"UnauthorizedAccess:EC2/SSHBruteForce", findingResourceEC2, 5, gdActionNetworkConnection, "FlowLogs", "",
+		"SSH brute force attacks against %s.", "A host is performing SSH brute force attacks against the EC2 instance."},
+	{"CryptoCurrency:EC2/BitcoinTool.B!DNS", findingResourceEC2, 8, gdActionDNSRequest, "DnsLogs", "",
+		"Bitcoin-related domain name queried by EC2 instance %s.", "EC2 instance is querying a domain name that is associated with Bitcoin-related activity."},
+	{"Backdoor:EC2/C&CActivity.B!DNS", findingResourceEC2, 8, gdActionDNSRequest, "DnsLogs", "",
+		"Command and Control server domain name queried by EC2 instance %s.", "EC2 instance is querying a domain name associated with a known Command & Control server."},
+	{"Trojan:EC2/DNSDataExfiltration", findingResourceEC2, 8, gdActionDNSRequest, "DnsLogs", "",
+		"Data exfiltration through DNS queries from EC2 instance %s.", "EC2 instance is exfiltrating data through DNS queries."},
+	{"Policy:S3/BucketBlockPublicAccessDisabled", findingResourceS3, 2, gdActionAWSAPICall, "CloudTrail", "DeletePublicAccessBlock",
+		"Amazon S3 Block Public Access was disabled for S3 bucket %s.", "An IAM entity invoked an API used to disable S3 Block Public Access on a bucket."},
+	{"Discovery:S3/MaliciousIPCaller", findingResourceS3, 5, gdActionAWSAPICall, "CloudTrail", "GetBucketPolicy",
+		"S3 API GetBucketPolicy was invoked from a known malicious IP address for bucket %s.", "An API commonly used to discover resources was invoked from a known malicious IP address."},
+	{"Exfiltration:S3/AnomalousBehavior", findingResourceS3, 8, gdActionAWSAPICall, "S3DataEvents", "GetObject",
+		"Anomalous S3 GetObject requests were made on bucket %s.", "An IAM entity invoked an S3 API in a suspicious way."},
+	{"UnauthorizedAccess:IAMUser/InstanceCredentialExfiltration.OutsideAWS", findingResourceIAM, 8, gdActionAWSAPICall, "CloudTrail", "DescribeInstances",
+		"Credentials for instance role %s used from external IP address.", "Credentials that were created exclusively for an EC2 instance are being used from an external IP address."},
+	{"Policy:IAMUser/RootCredentialUsage", findingResourceIAM, 2, gdActionAWSAPICall, "CloudTrail", "ListBuckets",
+		"API ListBuckets was invoked using root credentials by %s.", "An API was invoked using root credentials."},
+	{"CredentialAccess:IAMUser/AnomalousBehavior", findingResourceIAM, 5, gdActionAWSAPICall, "CloudTrail", "GetSecretValue",
+		"The API GetSecretValue was used to access credentials in an unusual way by %s.", "An API used to gain access to an AWS environment was invoked in an anomalous way."},
+	{"UnauthorizedAccess:IAMUser/ConsoleLoginSuccess.B", findingResourceIAM, 5, gdActionAWSAPICall, "CloudTrail", "ConsoleLogin",
+		"Unusual console login was seen for principal %s.", "Multiple worldwide successful console logins were observed."},
+	{"Policy:Kubernetes/AnonymousAccessGranted", findingResourceEKS, 8, gdActionKubernetesAPICall, "KubernetesAuditLogs", "create",
+		"Anonymous access granted to Kubernetes cluster %s.", "The system:anonymous user was granted API permission on a Kubernetes cluster."},
+	{"Execution:Kubernetes/ExecInKubeSystemPod", findingResourceEKS, 5, gdActionKubernetesAPICall, "KubernetesAuditLogs", "get",
+		"Command executed inside a pod in kube-system namespace of cluster %s.", "A command was executed inside a pod in the kube-system namespace."},
+	{"PrivilegeEscalation:Kubernetes/PrivilegedContainer", findingResourceEKS, 5, gdActionKubernetesAPICall, "KubernetesAuditLogs", "create",
+		"Privileged container with root level access launched on cluster %s.", "A privileged container with root level access was launched on your Kubernetes cluster."},
+	{"Discovery:Kubernetes/MaliciousIPCaller", findingResourceEKS, 5, gdActionKubernetesAPICall, "KubernetesAuditLogs", "list",
+		"Kubernetes API invoked from a known malicious IP address on cluster %s.", "A Kubernetes API commonly used to discover resources was invoked from a known malicious IP address."},
+}
+
+// gdRemoteOrganizations lists network owners of remote callers.
+var gdRemoteOrganizations = []gdOrganization{
+	{Asn: "16509", AsnOrg: "AMAZON-02", Isp: "Amazon.com", Org: "Amazon.com"},
+	{Asn: "14061", AsnOrg: "DIGITALOCEAN-ASN", Isp: "DigitalOcean", Org: "DigitalOcean"},
+	{Asn: "4134", AsnOrg: "Chinanet", Isp: "China Telecom", Org: "China Telecom"},
+	{Asn: "9009", AsnOrg: "M247 Ltd", Isp: "M247 Ltd", Org: "M247 Ltd"},
+}
+
+var gdCountries = []string{"United States", "Germany", "China", "Russia", "Brazil", "Netherlands"}
+
+// gdSuspiciousDomains lists domains queried by compromised instances.
+var gdSuspiciousDomains = []string{"pool.minexmr.example", "c2.badactor.example", "exfil-data.example.net", "stratum.btc-pool.example"}
+
+// GuardDutyGen generates Amazon GuardDuty findings.
+type GuardDutyGen struct {
+	buf          trackedBuffer
+	delivery     string
+	findingTypes []guardDutyFindingType
+	accountID    string
+	region       string
+	detectorID   string
+}
+
+func NewGuardDutyGen(input conf.InputConfig) (*GuardDutyGen, error) {
+	cfg, err := decodeFindingCfg(input, "guardduty")
+	if err != nil {
+		return nil, err
+	}
+
+	return &GuardDutyGen{
+		buf:          newTrackedBuffer(),
+		delivery:     cfg.DeliveryFormat,
+		findingTypes: guardDutyFindingTypesFor(cfg.ResourceTypes),
+		accountID:    randomSampleAccountID(),
+		region:       randomRegion(),
+		detectorID:   randomHexString(32),
+	}, nil
+}
+
+func (g *GuardDutyGen) Generate() (int64, error) {
+	findingType := g.findingTypes[rand.IntN(len(g.findingTypes))]
+	finding := buildGuardDutyFinding(newGuardDutyCustomizer(findingType, g.accountID, g.region, g.detectorID))
+
+	var record any = finding
+	if g.delivery == findingDeliveryEventBridge {
+		record = guardDutyEventBridgeEvent(finding)
+	}
+
+	marshaled, err := json.Marshal(record)
+	if err != nil {
+		return 0, err
+	}
+
+	// findings are newline delimited, matching GuardDuty S3 exports
+	marshaled = append(marshaled, '\n')
+
+	err = g.buf.write(marshaled)
+	if err != nil {
+		return 0, err
+	}
+
+	return g.buf.size(), nil
+}
+
+func (g *GuardDutyGen) GetAndReset() []byte {
+	return g.buf.getAndReset()
+}
+
+func (g *GuardDutyGen) RecordTimestamp(record string) (time.Time, bool) {
+	// EventBridge events carry the event time in the envelope
+	return jsonTimestamp(record, "time", "updatedAt")
+}
+
+// guardDutyFinding is a GuardDuty finding.
+// See - https://docs.aws.amazon.com/guardduty/latest/ug/guardduty_findings-summary.html
+type guardDutyFinding struct {
+	SchemaVersion string     `json:"schemaVersion"`
+	AccountID     string     `json:"accountId"`
+	Region        string     `json:"region"`
+	Partition     string     `json:"partition"`
+	ID            string     `json:"id"`
+	Arn           string     `json:"arn"`
+	Type          string     `json:"type"`
+	Resource      gdResource `json:"resource"`
+	Service       gdService  `json:"service"`
+	Severity      float64    `json:"severity"`
+	CreatedAt     string     `json:"createdAt"`
+	UpdatedAt     string     `json:"updatedAt"`
+	Title         string     `json:"title"`
+	Description   string     `json:"description"`
+}
+
+type gdResource struct {
+	ResourceType      string               `json:"resourceType"`
+	InstanceDetails   *gdInstanceDetails   `json:"instanceDetails,omitempty"`
+	S3BucketDetails   []gdS3BucketDetail   `json:"s3BucketDetails,omitempty"`
+	AccessKeyDetails  *gdAccessKeyDetails  `json:"accessKeyDetails,omitempty"`
+	EKSClusterDetails *gdEKSClusterDetails `json:"eksClusterDetails,omitempty"`
+	KubernetesDetails *gdKubernetesDetails `json:"kubernetesDetails,omitempty"`
+}
+
+type gdInstanceDetails struct {
+	InstanceID        string               `json:"instanceId"`
+	InstanceType      string               `json:"instanceType"`
+	InstanceState     string               `json:"instanceState"`
+	AvailabilityZone  string               `json:"availabilityZone"`
+	ImageID           string               `json:"imageId"`
+	LaunchTime        string               `json:"launchTime"`
+	NetworkInterfaces []gdNetworkInterface `json:"networkInterfaces"`
+	Tags              []gdTag              `json:"tags"`
+}
+
+type gdNetworkInterface struct {
+	NetworkInterfaceID string            `json:"networkInterfaceId"`
+	PrivateIPAddress   string            `json:"privateIpAddress"`
+	PublicIP           string            `json:"publicIp"`
+	SubnetID           string            `json:"subnetId"`
+	VPCID              string            `json:"vpcId"`
+	SecurityGroups     []gdSecurityGroup `json:"securityGroups"`
+}
+
+type gdSecurityGroup struct {
+	GroupID   string `json:"groupId"`
+	GroupName string `json:"groupName"`
+}
+
+type gdTag struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type gdS3BucketDetail struct {
+	Arn          string         `json:"arn"`
+	Name         string         `json:"name"`
+	Type         string         `json:"type"`
+	CreatedAt    string         `json:"createdAt"`
+	Owner        gdBucketOwner  `json:"owner"`
+	PublicAccess gdPublicAccess `json:"publicAccess"`
+}
+
+type gdBucketOwner struct {
+	ID string `json:"id"`
+}
+
+type gdPublicAccess struct {
+	EffectivePermission string `json:"effectivePermission"`
+}
+
+type gdAccessKeyDetails struct {
+	AccessKeyID string `json:"accessKeyId"`
+	PrincipalID string `json:"principalId"`
+	UserName    string `json:"userName"`
+	UserType    string `json:"userType"`
+}
+
+type gdEKSClusterDetails struct {
+	Name      string `json:"name"`
+	Arn       string `json:"arn"`
+	VPCID     string `json:"vpcId"`
+	Status    string `json:"status"`
+	CreatedAt string `json:"createdAt"`
+}
+
+type gdKubernetesDetails struct {
+	KubernetesUserDetails gdKubernetesUser `json:"kubernetesUserDetails"`
+}
+
+type gdKubernetesUser struct {
+	Username string   `json:"username"`
+	UID      string   `json:"uid"`
+	Groups   []string `json:"groups"`
+}
+
+// gdService describes the detected activity. Archived findings are hidden from the active findings list.
+type gdService struct {
+	ServiceName    string           `json:"serviceName"`
+	DetectorID     string           `json:"detectorId"`
+	Action         gdAction         `json:"action"`
+	ResourceRole   string           `json:"resourceRole"`
+	AdditionalInfo gdAdditionalInfo `json:"additionalInfo"`
+	FeatureName    string           `json:"featureName"`
+	EventFirstSeen string           `json:"eventFirstSeen"`
+	EventLastSeen  string           `json:"eventLastSeen"`
+	Archived       bool             `json:"archived"`
+	Count          int              `json:"count"`
+}
+
+type gdAdditionalInfo struct {
+	Value string `json:"value"`
+	Type  string `json:"type"`
+}
+
+// gdAction carries the details of the action type only.
+type gdAction struct {
+	ActionType              string                     `json:"actionType"`
+	PortProbeAction         *gdPortProbeAction         `json:"portProbeAction,omitempty"`
+	NetworkConnectionAction *gdNetworkConnectionAction `json:"networkConnectionAction,omitempty"`
+	DNSRequestAction        *gdDNSRequestAction        `json:"dnsRequestAction,omitempty"`
+	AWSAPICallAction        *gdAWSAPICallAction        `json:"awsApiCallAction,omitempty"`
+	KubernetesAPICallAction *gdKubernetesAPICallAction `json:"kubernetesApiCallAction,omitempty"`
+}
+
+type gdPortProbeAction struct {
+	Blocked          bool                `json:"blocked"`
+	PortProbeDetails []gdPortProbeDetail `json:"portProbeDetails"`
+}
+
+type gdPortProbeDetail struct {
+	LocalPortDetails gdPortDetails     `json:"localPortDetails"`
+	RemoteIPDetails  gdRemoteIPDetails `json:"remoteIpDetails"`
+}
+
+type gdNetworkConnectionAction struct {
+	ConnectionDirection string            `json:"connectionDirection"`
+	Protocol            string            `json:"protocol"`
+	Blocked             bool              `json:"blocked"`
+	LocalPortDetails    gdPortDetails     `json:"localPortDetails"`
+	RemotePortDetails   gdPortDetails     `json:"remotePortDetails"`
+	RemoteIPDetails     gdRemoteIPDetails `json:"remoteIpDetails"`
+}
+
+type gdDNSRequestAction struct {
+	Domain   string `json:"domain"`
+	Protocol string `json:"protocol"`
+	Blocked  bool   `json:"blocked"`
+}
+
+type gdAWSAPICallAction struct {
+	API             string            `json:"api"`
+	ServiceName     string            `json:"serviceName"`
+	CallerType      string            `json:"callerType"`
+	RemoteIPDetails gdRemoteIPDetails `json:"remoteIpDetails"`
+}
+
+type gdKubernetesAPICallAction struct {
+	RequestURI      string            `json:"requestUri"`
+	Verb            string            `json:"verb"`
+	SourceIPs       []string          `json:"sourceIps"`
+	UserAgent       string            `json:"userAgent"`
+	StatusCode      int               `json:"statusCode"`
+	RemoteIPDetails gdRemoteIPDetails `json:"remoteIpDetails"`
+}
+
+type gdPortDetails struct {
+	Port     int    `json:"port"`
+	PortName string `json:"portName"`
+}
+
+type gdRemoteIPDetails struct {
+	IPAddressV4  string         `json:"ipAddressV4"`
+	Country      gdCountry      `json:"country"`
+	Organization gdOrganization `json:"organization"`
+}
+
+type gdCountry struct {
+	CountryName string `json:"countryName"`
+}
+
+type gdOrganization struct {
+	Asn    string `json:"asn"`
+	AsnOrg string `json:"asnOrg"`
+	Isp    string `json:"isp"`
+	Org    string `json:"org"`
+}
+
+// guardDutyCustomizer holds parameters for generating a GuardDuty finding.
+type guardDutyCustomizer struct {
+	findingType guardDutyFindingType
+	resource    findingResource
+	detectorID  string
+	id          string
+	severity    float64
+	firstSeen   time.Time
+	lastSeen    time.Time
+	count       int
+	archived    bool
+	remoteIP    gdRemoteIPDetails
+	localPort   int
+	domain      string
+}
+
+func newGuardDutyCustomizer(findingType guardDutyFindingType, accountID, region, detectorID string) guardDutyCustomizer {
+	now := time.Now().UTC()
+	count := 1
+	if rand.IntN(3) == 0 {
+		count = rand.IntN(50) + 2
+	}
+
+	resource := newFindingResource(findingType.resource, accountID, region)
+	if findingType.name == "UnauthorizedAccess:IAMUser/InstanceCredentialExfiltration.OutsideAWS" {
+		resource.userName = "EC2InstanceRole"
+		resource.principalID = "AROA" + randomAZ09String(17)
+		resource.accessKeyID = "ASIA" + randomAZ09String(16)
+	}
+
+	return guardDutyCustomizer{
+		findingType: findingType,
+		resource:    resource,
+		detectorID:  detectorID,
+		id:          randomHexString(32),
+		// severities vary within the band of the finding type, ex:- 5.0 to 5.9 for medium findings
+		severity:  findingType.severity + math.Round(rand.Float64()*9)/10,
+		firstSeen: now.Add(-time.Duration(rand.IntN(72)+1) * time.Hour),
+		lastSeen:  now.Add(-time.Duration(rand.IntN(3600)) * time.Second),
+		count:     count,
+		// 10% of findings have been archived by analysts or suppression rules
+		archived: rand.IntN(10) == 0,
+		remoteIP: gdRemoteIPDetails{
+			IPAddressV4:  randomIP(),
+			Country:      gdCountry{CountryName: gdCountries[rand.IntN(len(gdCountries))]},
+			Organization: gdRemoteOrganizations[rand.IntN(len(gdRemoteOrganizations))],
+		},
+		localPort: []int{22, 80, 443, 3389, 5432}[rand.IntN(5)],
+		domain:    gdSuspiciousDomains[rand.IntN(len(gdSuspiciousDomains))],
+	}
+}
+
+func buildGuardDutyFinding(c guardDutyCustomizer) guardDutyFinding {
+	r := c.resource
+	resourceRole := "TARGET"
+	if c.findingType.action == gdActionDNSRequest {
+		// the instance itself reached out to the suspicious domain
+		resourceRole = "ACTOR"
+	}
+
+	return guardDutyFinding{
+		SchemaVersion: gdSchemaVersion,
+		AccountID:     r.accountID,
+		Region:        r.region,
+		Partition:     "aws",
+		ID:            c.id,
+		Arn:           fmt.Sprintf("arn:aws:guardduty:%s:%s:detector/%s/finding/%s", r.region, r.accountID, c.detectorID, c.id),
+		Type:          c.findingType.name,
+		Resource:      gdResourceFor(c),
+		Service: gdService{
+			ServiceName:    "guardduty",
+			DetectorID:     c.detectorID,
+			Action:         gdActionFor(c),
+			ResourceRole:   resourceRole,
+			AdditionalInfo: gdAdditionalInfo{Value: "{}", Type: "default"},
+			FeatureName:    c.findingType.feature,
+			EventFirstSeen: c.firstSeen.Format(findingTimeFormat),
+			EventLastSeen:  c.lastSeen.Format(findingTimeFormat),
+			Archived:       c.archived,
+			Count:          c.count,
+		},
+		Severity:    c.severity,
+		CreatedAt:   c.firstSeen.Format(findingTimeFormat),
+		UpdatedAt:   c.lastSeen.Format(findingTimeFormat),
+		Title:       fmt.Sprintf(c.findingType.title, r.name()),
+		Description: c.findingType.description,
+	}
+}
+
+func gdResourceFor(c guardDutyCustomizer) gdResource {
+	r := c.resource
+
+	switch r.kind {
+	case findingResourceEC2:
+		return gdResource{
+			ResourceType: "Instance",
+			InstanceDetails: &gdInstanceDetails{
+				InstanceID:       r.instanceID,
+				InstanceType:     r.instanceType,
+				InstanceState:    "running",
+				AvailabilityZone: r.region + "a",
+				ImageID:          r.imageID,
+				LaunchTime:       r.createdAt.Format(findingTimeFormat),
+				NetworkInterfaces: []gdNetworkInterface{{
+					NetworkInterfaceID: r.eniID,
+					PrivateIPAddress:   r.privateIP,
+					PublicIP:           r.publicIP,
+					SubnetID:           r.subnetID,
+					VPCID:              r.vpcID,
+					SecurityGroups:     []gdSecurityGroup{{GroupID: r.securityGroupID, GroupName: "web-sg"}},
+				}},
+				Tags: []gdTag{{Key: "Name", Value: "web-server"}},
+			},
+		}
+	case findingResourceS3:
+		return gdResource{
+			ResourceType: "S3Bucket",
+			S3BucketDetails: []gdS3BucketDetail{{
+				Arn:          r.arn(),
+				Name:         r.bucketName,
+				Type:         "Destination",
+				CreatedAt:    r.createdAt.Format(findingTimeFormat),
+				Owner:        gdBucketOwner{ID: randomHexString(64)},
+				PublicAccess: gdPublicAccess{EffectivePermission: "NOT_PUBLIC"},
+			}},
+			AccessKeyDetails: &gdAccessKeyDetails{
+				AccessKeyID: "ASIA" + randomAZ09String(16),
+				PrincipalID: "AROA" + randomAZ09String(17),
+				UserName:    ctRoleNames[rand.IntN(len(ctRoleNames))],
+				UserType:    "AssumedRole",
+			},
+		}
+	case findingResourceIAM:
+		userType, userName := "IAMUser", r.userName
+		switch c.findingType.name {
+		case "Policy:IAMUser/RootCredentialUsage":
+			userType, userName = "Root", "Root"
+		case "UnauthorizedAccess:IAMUser/InstanceCredentialExfiltration.OutsideAWS":
+			userType = "AssumedRole"
+		}
+
+		return gdResource{
+			ResourceType: "AccessKey",
+			AccessKeyDetails: &gdAccessKeyDetails{
+				AccessKeyID: r.accessKeyID,
+				PrincipalID: r.principalID,
+				UserName:    userName,
+				UserType:    userType,
+			},
+		}
+	default:
+		return gdResource{
+			ResourceType: "EKSCluster",
+			EKSClusterDetails: &gdEKSClusterDetails{
+				Name:      r.clusterName,
+				Arn:       r.arn(),
+				VPCID:     r.vpcID,
+				Status:    "ACTIVE",
+				CreatedAt: r.createdAt.Format(findingTimeFormat),
+			},
+			KubernetesDetails: &gdKubernetesDetails{
+				KubernetesUserDetails: gdKubernetesUser{
+					Username: "system:anonymous",
+					UID:      "",
+					Groups:   []string{"system:unauthenticated"},
+				},
+			},
+		}
+	}
+}
+
+func gdActionFor(c guardDutyCustomizer) gdAction {
+	action := gdAction{ActionType: c.findingType.action}
+	port := gdPortDetails{Port: c.localPort, PortName: gdPortName(c.localPort)}
+
+	switch c.findingType.action {
+	case gdActionPortProbe:
+		action.PortProbeAction = &gdPortProbeAction{
+			Blocked:          false,
+			PortProbeDetails: []gdPortProbeDetail{{LocalPortDetails: port, RemoteIPDetails: c.remoteIP}},
+		}
+	case gdActionNetworkConnection:
+		action.NetworkConnectionAction = &gdNetworkConnectionAction{
+			ConnectionDirection: "INBOUND",
+			Protocol:            "TCP",
+			Blocked:             false,
+			LocalPortDetails:    gdPortDetails{Port: 22, PortName: "SSH"},
+			RemotePortDetails:   gdPortDetails{Port: rand.IntN(65535-32768) + 32768, PortName: "Unknown"},
+			RemoteIPDetails:     c.remoteIP,
+		}
+	case gdActionDNSRequest:
+		action.DNSRequestAction = &gdDNSRequestAction{Domain: c.domain, Protocol: "UDP", Blocked: false}
+	case gdActionAWSAPICall:
+		service := "s3.amazonaws.com"
+		switch c.findingType.api {
+		case "DescribeInstances":
+			service = "ec2.amazonaws.com"
+		case "GetSecretValue":
+			service = "secretsmanager.amazonaws.com"
+		case "ConsoleLogin":
+			service = "signin.amazonaws.com"
+		}
+
+		action.AWSAPICallAction = &gdAWSAPICallAction{
+			API:             c.findingType.api,
+			ServiceName:     service,
+			CallerType:      "Remote IP",
+			RemoteIPDetails: c.remoteIP,
+		}
+	case gdActionKubernetesAPICall:
+		uri := "/api/v1/namespaces/kube-system/pods"
+		if c.findingType.api == "create" && c.findingType.name == "Policy:Kubernetes/AnonymousAccessGranted" {
+			uri = "/apis/rbac.authorization.k8s.io/v1/clusterrolebindings"
+		}
+
+		action.KubernetesAPICallAction = &gdKubernetesAPICallAction{
+			RequestURI:      uri,
+			Verb:            c.findingType.api,
+			SourceIPs:       []string{c.remoteIP.IPAddressV4},
+			UserAgent:       "kubectl/v1.29.0 (linux/amd64) kubernetes/3f7a50f",
+			StatusCode:      []int{200, 201, 403}[rand.IntN(3)],
+			RemoteIPDetails: c.remoteIP,
+		}
+	}
+
+	return action
+}
+
+func gdPortName(port int) string {
+	switch port {
+	case 22:
+		return "SSH"
+	case 80:
+		return "HTTP"
+	case 443:
+		return "HTTPS"
+	case 3389:
+		return "RDP"
+	case 5432:
+		return "PostgreSQL"
+	default:
+		return "Unknown"
+	}
+}
+
+// guardDutyFindingTypesFor returns the finding types of the given resource types.
+func guardDutyFindingTypesFor(resourceTypes []string) []guardDutyFindingType {
+	var selected []guardDutyFindingType
+	for _, t := range guardDutyFindingTypes {
+		if slices.Contains(resourceTypes, t.resource) {
+			selected = append(selected, t)
+		}
+	}
+
+	return selected
+}
+
+func guardDutyEventBridgeEvent(finding guardDutyFinding) eventBridgeEvent[guardDutyFinding] {
+	updatedAt, _ := time.Parse(findingTimeFormat, finding.UpdatedAt)
+	return newFindingEventBridgeEvent("GuardDuty Finding", "aws.guardduty", finding.AccountID, finding.Region, updatedAt, []string{}, finding)
+}
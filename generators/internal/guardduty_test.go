@@ -0,0 +1,93 @@
+package internal
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"data-gen/conf"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func Test_buildGuardDutyFinding(t *testing.T) {
+	t.Run("Findings are consistent with finding type", func(t *testing.T) {
+		for _, findingType := range guardDutyFindingTypes {
+			for range 20 {
+				finding := buildGuardDutyFinding(newGuardDutyCustomizer(findingType, "111122223333", "us-east-1", "12abc34d567e8fa901bc2d34e56789f0"))
+
+				require.Equal(t, findingType.name, finding.Type)
+				require.Equal(t, findingType.action, finding.Service.Action.ActionType)
+				require.GreaterOrEqual(t, finding.Severity, findingType.severity)
+				require.Less(t, finding.Severity, findingType.severity+1)
+				require.True(t, strings.HasPrefix(finding.Arn, "arn:aws:guardduty:us-east-1:111122223333:detector/12abc34d567e8fa901bc2d34e56789f0/finding/"))
+				require.NotContains(t, finding.Title, "%")
+				require.LessOrEqual(t, finding.Service.EventFirstSeen, finding.Service.EventLastSeen)
+
+				switch findingType.resource {
+				case findingResourceEC2:
+					require.Equal(t, "Instance", finding.Resource.ResourceType)
+					require.NotNil(t, finding.Resource.InstanceDetails)
+				case findingResourceS3:
+					require.Equal(t, "S3Bucket", finding.Resource.ResourceType)
+					require.Len(t, finding.Resource.S3BucketDetails, 1)
+				case findingResourceIAM:
+					require.Equal(t, "AccessKey", finding.Resource.ResourceType)
+					require.NotNil(t, finding.Resource.AccessKeyDetails)
+				case findingResourceEKS:
+					require.Equal(t, "EKSCluster", finding.Resource.ResourceType)
+					require.NotNil(t, finding.Resource.EKSClusterDetails)
+				}
+
+				line, err := json.Marshal(finding)
+				require.NoError(t, err)
+
+				var action map[string]any
+				require.NoError(t, json.Unmarshal(line, &action))
+				// only the details of the action type are present
+				require.Len(t, action["service"].(map[string]any)["action"], 2)
+			}
+		}
+	})
+}
+
+func Test_NewGuardDutyGen(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    string
+		wantErr bool
+	}{
+		{name: "Defaults", conf: "{}"},
+		{name: "EventBridge delivery of S3 findings", conf: "{resource_types: [s3], delivery_format: eventbridge}"},
+		{name: "Unknown resource type", conf: "{resource_types: [rds]}", wantErr: true},
+		{name: "Unknown delivery format", conf: "{delivery_format: sns}", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var input conf.InputConfig
+			require.NoError(t, yaml.Unmarshal([]byte(tt.conf), &input.Conf))
+
+			gen, err := NewGuardDutyGen(input)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			_, err = gen.Generate()
+			require.NoError(t, err)
+
+			var record map[string]any
+			require.NoError(t, json.Unmarshal(gen.GetAndReset(), &record))
+			if gen.delivery == findingDeliveryEventBridge {
+				require.Equal(t, "GuardDuty Finding", record["detail-type"])
+				require.Equal(t, "aws.guardduty", record["source"])
+				record = record["detail"].(map[string]any)
+			}
+
+			require.Equal(t, gdSchemaVersion, record["schemaVersion"])
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package internal
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+)
+
+var httpRequestPaths = []string{
+	"/", "/index.html", "/login", "/logout", "/health", "/search", "/api/v1/users", "/api/v1/users/1024",
+	"/api/v1/orders", "/api/v1/orders/98231", "/static/app.js", "/static/styles.css", "/images/logo.png",
+}
+var httpRequestQueries = []string{"", "", "", "page=2", "q=shoes&sort=price", "id=12345", "lang=en-US", "token=abc123&redirect=%2Fhome"}
+var httpRequestUserAgents = []string{
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_2) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Safari/605.1.15",
+	"Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148",
+	"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+	"curl/8.4.0",
+	"python-requests/2.31.0",
+	"Go-http-client/2.0",
+}
+var httpRequestReferers = []string{"-", "-", "https://www.google.com/", "https://www.example.com/", "https://www.bing.com/"}
+
+// httpStatusWeights lists response codes weighted towards success.
+var httpStatusWeights = []int{
+	200, 200, 200, 200, 200, 200, 200, 200, 200, 200, 200, 200,
+	201, 204, 301, 302, 304, 400, 401, 403, 404, 404, 429, 500, 502, 503,
+}
+
+// httpRequest is a randomized HTTP request shared by HTTP access log generators.
+type httpRequest struct {
+	Method    string
+	Scheme    string
+	Host      string
+	Port      int
+	Path      string
+	Query     string
+	Protocol  string
+	UserAgent string
+	Referer   string
+}
+
+// randomHTTPRequest returns a request to a random host, favoring GET requests over TLS.
+func randomHTTPRequest() httpRequest {
+	method := "GET"
+	if rand.IntN(10) < 3 {
+		method = httpMethods[rand.IntN(len(httpMethods))]
+	}
+
+	r := httpRequest{
+		Method:    method,
+		Scheme:    "https",
+		Host:      []string{"www.", "api.", "app."}[rand.IntN(3)] + randomDomain(),
+		Port:      443,
+		Path:      httpRequestPaths[rand.IntN(len(httpRequestPaths))],
+		Query:     httpRequestQueries[rand.IntN(len(httpRequestQueries))],
+		Protocol:  []string{"HTTP/1.1", "HTTP/1.1", "HTTP/2.0"}[rand.IntN(3)],
+		UserAgent: httpRequestUserAgents[rand.IntN(len(httpRequestUserAgents))],
+		Referer:   httpRequestReferers[rand.IntN(len(httpRequestReferers))],
+	}
+
+	if rand.IntN(5) == 0 {
+		r.Scheme = "http"
+		r.Port = 80
+		r.Protocol = "HTTP/1.1"
+	}
+
+	return r
+}
+
+// uri returns the request path including the query string, if any.
+func (r httpRequest) uri() string {
+	if r.Query == "" {
+		return r.Path
+	}
+
+	return r.Path + "?" + r.Query
+}
+
+// url returns the absolute request URL including the port.
+func (r httpRequest) url() string {
+	return fmt.Sprintf("%s://%s:%d%s", r.Scheme, r.Host, r.Port, r.uri())
+}
+
+func randomHTTPStatus() int {
+	return httpStatusWeights[rand.IntN(len(httpStatusWeights))]
+}
+
+// httpStatusOrDash renders a status code, where zero represents a missing response.
+func httpStatusOrDash(status int) string {
+	if status == 0 {
+		return "-"
+	}
+
+	return strconv.Itoa(status)
+}
@@ -0,0 +1,585 @@
+package internal
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"data-gen/conf"
+
+	"github.com/google/uuid"
+)
+
+const (
+	k8sDeliveryCloudWatch = "cloudwatch"
+	k8sDeliveryJSON       = "json"
+
+	k8sStageRequestReceived  = "RequestReceived"
+	k8sStageResponseStarted  = "ResponseStarted"
+	k8sStageResponseComplete = "ResponseComplete"
+	k8sStagePanic            = "Panic"
+
+	k8sLevelMetadata        = "Metadata"
+	k8sLevelRequest         = "Request"
+	k8sLevelRequestResponse = "RequestResponse"
+
+	k8sActorHuman          = "human"
+	k8sActorServiceAccount = "serviceaccount"
+	k8sActorControlPlane   = "controlplane"
+	k8sActorNode           = "node"
+
+	// k8sTimeFormat is the RFC3339 micro time format of audit event timestamps
+	k8sTimeFormat = "2006-01-02T15:04:05.000000Z"
+)
+
+// k8sResource is an API resource along with the name objects are given.
+type k8sResource struct {
+	resource    string
+	subresource string
+	apiGroup    string
+	apiVersion  string
+	kind        string
+	namespaced  bool
+	name        func() string
+}
+
+var k8sResources = map[string]k8sResource{
+	"pods":                {"pods", "", "", "v1", "Pod", true, k8sPodName},
+	"pods/log":            {"pods", "log", "", "v1", "Pod", true, k8sPodName},
+	"pods/exec":           {"pods", "exec", "", "v1", "Pod", true, k8sPodName},
+	"pods/status":         {"pods", "status", "", "v1", "Pod", true, k8sPodName},
+	"services":            {"services", "", "", "v1", "Service", true, k8sAppName},
+	"configmaps":          {"configmaps", "", "", "v1", "ConfigMap", true, func() string { return k8sAppName() + "-config" }},
+	"secrets":             {"secrets", "", "", "v1", "Secret", true, func() string { return k8sAppName() + "-credentials" }},
+	"serviceaccounts":     {"serviceaccounts", "", "", "v1", "ServiceAccount", true, k8sAppName},
+	"nodes":               {"nodes", "", "", "v1", "Node", false, k8sNodeName},
+	"nodes/status":        {"nodes", "status", "", "v1", "Node", false, k8sNodeName},
+	"namespaces":          {"namespaces", "", "", "v1", "Namespace", false, k8sNamespace},
+	"deployments":         {"deployments", "", "apps", "v1", "Deployment", true, k8sAppName},
+	"replicasets":         {"replicasets", "", "apps", "v1", "ReplicaSet", true, func() string { return k8sAppName() + "-" + randomHexString(9) }},
+	"endpointslices":      {"endpointslices", "", "discovery.k8s.io", "v1", "EndpointSlice", true, func() string { return k8sAppName() + "-" + strings.ToLower(randomAZ09String(5)) }},
+	"leases":              {"leases", "", "coordination.k8s.io", "v1", "Lease", true, k8sNodeName},
+	"clusterrolebindings": {"clusterrolebindings", "", "rbac.authorization.k8s.io", "v1", "ClusterRoleBinding", false, func() string { return k8sAppName() + "-admin" }},
+}
+
+// k8sActorOperations lists resources and verbs each kind of actor typically requests.
+var k8sActorOperations = map[string]struct {
+	resources []string
+	verbs     []string
+}{
+	k8sActorHuman:          {[]string{"pods", "pods", "pods/log", "pods/exec", "deployments", "services", "configmaps", "secrets", "namespaces", "nodes", "clusterrolebindings"}, []string{"get", "get", "list", "list", "create", "patch", "update", "delete"}},
+	k8sActorServiceAccount: {[]string{"pods", "configmaps", "secrets", "services", "endpointslices", "deployments", "leases"}, []string{"get", "list", "watch", "watch", "patch", "update"}},
+	k8sActorControlPlane:   {[]string{"leases", "pods", "replicasets", "deployments", "endpointslices", "nodes"}, []string{"get", "list", "watch", "update", "update", "create"}},
+	k8sActorNode:           {[]string{"nodes/status", "pods", "pods/status", "leases", "configmaps", "secrets"}, []string{"get", "watch", "list", "patch", "update"}},
+}
+
+var k8sNamespaces = []string{"default", "default", "kube-system", "payments", "monitoring"}
+var k8sAppNames = []string{"web", "api", "checkout", "coredns", "aws-load-balancer-controller", "prometheus"}
+var k8sServiceAccounts = []string{"default", "aws-load-balancer-controller", "karpenter", "coredns", "prometheus", "argocd-application-controller"}
+var k8sKubernetesVersion = "v1.30.4"
+
+// K8sAuditGen generates Kubernetes audit.k8s.io/v1 audit events.
+// CloudWatch delivery produces EKS control plane audit logs of the /aws/eks/<cluster>/cluster log group,
+// while JSON delivery produces kube-apiserver log backend output, including every stage of a request.
+type K8sAuditGen struct {
+	current     []k8sAuditEvent
+	currentSize int64
+	delivery    string
+	accountID   string
+	clusterName string
+}
+
+// k8sAuditCfg specifies the cluster and how audit events are delivered.
+// The cluster name decides the default log group of the CloudWatch exporter.
+type k8sAuditCfg struct {
+	ClusterName    string `yaml:"cluster_name"`
+	DeliveryFormat string `yaml:"delivery_format"`
+}
+
+func NewK8sAuditGen(input conf.InputConfig, output conf.OutputConfig) (*K8sAuditGen, error) {
+	cfg := k8sAuditCfg{ClusterName: conf.DefaultEKSClusterName}
+	err := input.Conf.Decode(&cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode k8s audit configuration: %w", err)
+	}
+
+	// default delivery follows the output, similar to CloudTrail
+	if cfg.DeliveryFormat == "" {
+		cfg.DeliveryFormat = k8sDeliveryJSON
+		if output.LogEvents() {
+			cfg.DeliveryFormat = k8sDeliveryCloudWatch
+		}
+	}
+
+	if cfg.DeliveryFormat != k8sDeliveryCloudWatch && cfg.DeliveryFormat != k8sDeliveryJSON {
+		return nil, fmt.Errorf("unknown k8s audit delivery format: %s", cfg.DeliveryFormat)
+	}
+
+	return &K8sAuditGen{
+		current:     []k8sAuditEvent{},
+		delivery:    cfg.DeliveryFormat,
+		accountID:   randomSampleAccountID(),
+		clusterName: cfg.ClusterName,
+	}, nil
+}
+
+func (k *K8sAuditGen) Generate() (int64, error) {
+	events := buildK8sAuditEvents(newK8sAuditCustomizer(k.delivery == k8sDeliveryCloudWatch, k.accountID))
+	k.current = append(k.current, events...)
+
+	for _, event := range events {
+		marshaled, err := json.Marshal(event)
+		if err != nil {
+			return 0, err
+		}
+		k.currentSize += int64(len(marshaled))
+	}
+
+	return k.currentSize, nil
+}
+
+func (k *K8sAuditGen) GetAndReset() []byte {
+	// both the EKS log group and the log backend carry one event per line
+	marshal := ndjson(k.current)
+
+	k.current = []k8sAuditEvent{}
+	k.currentSize = 0
+	return marshal
+}
+
+func (k *K8sAuditGen) RecordTimestamp(record string) (time.Time, bool) {
+	return jsonTimestamp(record, "stageTimestamp")
+}
+
+// LogDestination returns the log group and stream EKS delivers control plane audit logs of the cluster to.
+// Stream names are derived from the cluster name, giving the same stream across runs.
+func (k *K8sAuditGen) LogDestination() (string, string, bool) {
+	return fmt.Sprintf("/aws/eks/%s/cluster", k.clusterName), fmt.Sprintf("kube-apiserver-audit-%x", md5.Sum([]byte(k.clusterName))), true
+}
+
+// k8sAuditEvent is an audit.k8s.io/v1 Event.
+// See - https://kubernetes.io/docs/reference/config-api/apiserver-audit.v1/#audit-k8s-io-v1-Event
+type k8sAuditEvent struct {
+	Kind                     string            `json:"kind"`
+	APIVersion               string            `json:"apiVersion"`
+	Level                    string            `json:"level"`
+	AuditID                  string            `json:"auditID"`
+	Stage                    string            `json:"stage"`
+	RequestURI               string            `json:"requestURI"`
+	Verb                     string            `json:"verb"`
+	User                     k8sUserInfo       `json:"user"`
+	SourceIPs                []string          `json:"sourceIPs"`
+	UserAgent                string            `json:"userAgent"`
+	ObjectRef                *k8sObjectRef     `json:"objectRef,omitempty"`
+	ResponseStatus           *k8sStatus        `json:"responseStatus,omitempty"`
+	RequestObject            map[string]any    `json:"requestObject,omitempty"`
+	ResponseObject           map[string]any    `json:"responseObject,omitempty"`
+	RequestReceivedTimestamp string            `json:"requestReceivedTimestamp"`
+	StageTimestamp           string            `json:"stageTimestamp"`
+	Annotations              map[string]string `json:"annotations,omitempty"`
+}
+
+type k8sUserInfo struct {
+	Username string              `json:"username"`
+	UID      string              `json:"uid,omitempty"`
+	Groups   []string            `json:"groups"`
+	Extra    map[string][]string `json:"extra,omitempty"`
+}
+
+type k8sObjectRef struct {
+	Resource        string `json:"resource"`
+	Namespace       string `json:"namespace,omitempty"`
+	Name            string `json:"name,omitempty"`
+	APIGroup        string `json:"apiGroup,omitempty"`
+	APIVersion      string `json:"apiVersion"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+	Subresource     string `json:"subresource,omitempty"`
+}
+
+type k8sStatus struct {
+	Metadata struct{} `json:"metadata"`
+	Status   string   `json:"status,omitempty"`
+	Message  string   `json:"message,omitempty"`
+	Reason   string   `json:"reason,omitempty"`
+	Code     int      `json:"code"`
+}
+
+// k8sAuditCustomizer holds parameters of a single API request.
+type k8sAuditCustomizer struct {
+	eks       bool
+	auditID   string
+	level     string
+	verb      string
+	resource  k8sResource
+	namespace string
+	name      string
+	user      k8sUserInfo
+	userAgent string
+	sourceIP  string
+	code      int
+	panic     bool
+	received  time.Time
+	latency   time.Duration
+}
+
+func newK8sAuditCustomizer(eks bool, accountID string) k8sAuditCustomizer {
+	actor := []string{k8sActorHuman, k8sActorServiceAccount, k8sActorServiceAccount, k8sActorControlPlane, k8sActorControlPlane, k8sActorNode}[rand.IntN(6)]
+	ops := k8sActorOperations[actor]
+	resource := k8sResources[ops.resources[rand.IntN(len(ops.resources))]]
+	verb := ops.verbs[rand.IntN(len(ops.verbs))]
+
+	switch resource.subresource {
+	case "log":
+		verb = "get"
+	case "exec":
+		verb = "create"
+	case "status":
+		verb = []string{"patch", "update"}[rand.IntN(2)]
+	}
+
+	c := k8sAuditCustomizer{
+		eks:      eks,
+		auditID:  uuid.NewString(),
+		level:    k8sLevelMetadata,
+		verb:     verb,
+		resource: resource,
+		received: time.Now().UTC(),
+		latency:  time.Duration(rand.IntN(40000)+500) * time.Microsecond,
+		// 1 in 500 requests panic in the API server
+		panic: rand.IntN(500) == 0,
+	}
+
+	if resource.namespaced {
+		c.namespace = k8sNamespace()
+		if resource.resource == "leases" {
+			c.namespace = "kube-node-lease"
+		}
+	}
+
+	// collection requests do not name an object
+	if verb != "list" && verb != "watch" && verb != "create" || resource.subresource != "" {
+		c.name = resource.name()
+	}
+
+	c.user, c.userAgent, c.sourceIP = k8sActorFor(actor, eks, accountID, c.namespace)
+
+	// request bodies of writes are logged, except for secrets and configmaps which may hold sensitive data
+	if (verb == "create" || verb == "update" || verb == "patch") && resource.resource != "secrets" && resource.resource != "configmaps" {
+		c.level = []string{k8sLevelRequest, k8sLevelRequestResponse}[rand.IntN(2)]
+	}
+
+	c.code = k8sResponseCode(verb)
+	if actor == k8sActorHuman && rand.IntN(10) == 0 {
+		c.code = 403
+	}
+
+	if verb == "watch" {
+		// watches stay open until the timeout of the request
+		c.latency = time.Duration(rand.IntN(300)+300) * time.Second
+	}
+
+	return c
+}
+
+// buildK8sAuditEvents returns the events of each stage the request went through.
+// EKS audit policy omits the RequestReceived stage, and only watches have a ResponseStarted stage.
+func buildK8sAuditEvents(c k8sAuditCustomizer) []k8sAuditEvent {
+	var stages []string
+	if !c.eks {
+		stages = append(stages, k8sStageRequestReceived)
+	}
+	if c.verb == "watch" && !c.panic {
+		stages = append(stages, k8sStageResponseStarted)
+	}
+	if c.panic {
+		stages = append(stages, k8sStagePanic)
+	} else {
+		stages = append(stages, k8sStageResponseComplete)
+	}
+
+	events := make([]k8sAuditEvent, 0, len(stages))
+	for _, stage := range stages {
+		events = append(events, buildK8sAuditEvent(c, stage))
+	}
+
+	return events
+}
+
+func buildK8sAuditEvent(c k8sAuditCustomizer, stage string) k8sAuditEvent {
+	r := c.resource
+	stageTime := c.received
+	switch stage {
+	case k8sStageResponseStarted:
+		stageTime = c.received.Add(time.Duration(c.latency.Microseconds()%20000) * time.Microsecond)
+	case k8sStageResponseComplete, k8sStagePanic:
+		stageTime = c.received.Add(c.latency)
+	}
+
+	event := k8sAuditEvent{
+		Kind:       "Event",
+		APIVersion: "audit.k8s.io/v1",
+		Level:      c.level,
+		AuditID:    c.auditID,
+		Stage:      stage,
+		RequestURI: k8sRequestURI(c),
+		Verb:       c.verb,
+		User:       c.user,
+		SourceIPs:  []string{c.sourceIP},
+		UserAgent:  c.userAgent,
+		ObjectRef: &k8sObjectRef{
+			Resource:    r.resource,
+			Namespace:   c.namespace,
+			Name:        c.name,
+			APIGroup:    r.apiGroup,
+			APIVersion:  r.apiVersion,
+			Subresource: r.subresource,
+		},
+		RequestReceivedTimestamp: c.received.Format(k8sTimeFormat),
+		StageTimestamp:           stageTime.Format(k8sTimeFormat),
+	}
+
+	if c.level != k8sLevelMetadata {
+		event.RequestObject = k8sObjectFor(c)
+	}
+
+	// decisions are known once the request is authorized, responses once handled
+	if stage == k8sStageRequestReceived {
+		return event
+	}
+
+	event.Annotations = k8sAnnotations(c)
+	event.ResponseStatus = k8sResponseStatus(c, stage)
+	if stage == k8sStageResponseComplete && c.level == k8sLevelRequestResponse && c.code < 300 {
+		event.ResponseObject = k8sObjectFor(c)
+		event.ResponseObject["metadata"].(map[string]any)["resourceVersion"] = fmt.Sprintf("%d", c.received.Unix()%100000000)
+	}
+
+	return event
+}
+
+// k8sActorFor returns the user, user agent and source IP of the actor.
+// EKS maps IAM principals to Kubernetes users through access entries and runs eks: prefixed components.
+func k8sActorFor(actor string, eks bool, accountID, namespace string) (k8sUserInfo, string, string) {
+	nodeIP := fmt.Sprintf("10.0.%d.%d", rand.IntN(4), rand.IntN(254)+1)
+
+	switch actor {
+	case k8sActorHuman:
+		agent := fmt.Sprintf("kubectl/%s (linux/amd64) kubernetes/%s", k8sKubernetesVersion, randomHexString(7))
+		if !eks {
+			return k8sUserInfo{Username: "kubernetes-admin", Groups: []string{"system:masters", "system:authenticated"}}, agent, randomIP()
+		}
+
+		role := ctRoleNames[rand.IntN(len(ctRoleNames))]
+		session := fmt.Sprintf("user%d", rand.IntN(10))
+		principalID := "AROA" + randomAZ09String(17)
+		arn := fmt.Sprintf("arn:aws:sts::%s:assumed-role/%s/%s", accountID, role, session)
+		return k8sUserInfo{
+			Username: arn,
+			UID:      fmt.Sprintf("aws-iam-authenticator:%s:%s", accountID, principalID),
+			Groups:   []string{"system:authenticated"},
+			Extra: map[string][]string{
+				"accessKeyId":  {"ASIA" + randomAZ09String(16)},
+				"arn":          {arn},
+				"canonicalArn": {fmt.Sprintf("arn:aws:iam::%s:role/%s", accountID, role)},
+				"principalId":  {principalID},
+				"sessionName":  {session},
+				"sigs.k8s.io/aws-iam-authenticator/principalId": {principalID},
+			},
+		}, agent, randomIP()
+	case k8sActorServiceAccount:
+		if namespace == "" || namespace == "kube-node-lease" {
+			namespace = "kube-system"
+		}
+		sa := k8sServiceAccounts[rand.IntN(len(k8sServiceAccounts))]
+		agent := fmt.Sprintf("%s/%s (linux/amd64) kubernetes/%s", sa, k8sKubernetesVersion, randomHexString(7))
+		if sa == "default" {
+			// workloads using client libraries directly
+			agent = "Go-http-client/2.0"
+		}
+		return k8sUserInfo{
+			Username: fmt.Sprintf("system:serviceaccount:%s:%s", namespace, sa),
+			UID:      uuid.NewString(),
+			Groups:   []string{"system:serviceaccounts", "system:serviceaccounts:" + namespace, "system:authenticated"},
+			Extra: map[string][]string{
+				"authentication.kubernetes.io/credential-id": {"JTI=" + uuid.NewString()},
+				"authentication.kubernetes.io/node-name":     {k8sNodeName()},
+				"authentication.kubernetes.io/pod-name":      {k8sPodName()},
+				"authentication.kubernetes.io/pod-uid":       {uuid.NewString()},
+			},
+		}, agent, nodeIP
+	case k8sActorControlPlane:
+		components := []string{"kube-controller-manager", "kube-scheduler"}
+		if eks {
+			components = append(components, "eks:cloud-controller-manager", "eks:node-manager", "eks:certificate-controller")
+		}
+		component := components[rand.IntN(len(components))]
+		username := "system:" + component
+		if strings.HasPrefix(component, "eks:") {
+			username = component
+		}
+		return k8sUserInfo{Username: username, Groups: []string{"system:authenticated"}},
+			fmt.Sprintf("%s/%s (linux/amd64) kubernetes/%s", strings.TrimPrefix(component, "eks:"), k8sKubernetesVersion, randomHexString(7)), "127.0.0.1"
+	default:
+		node := k8sNodeName()
+		user := k8sUserInfo{Username: "system:node:" + node, Groups: []string{"system:nodes", "system:authenticated"}}
+		if eks {
+			// nodes authenticate with their instance role
+			user.UID = fmt.Sprintf("aws-iam-authenticator:%s:AROA%s", accountID, randomAZ09String(17))
+			user.Extra = map[string][]string{"canonicalArn": {fmt.Sprintf("arn:aws:iam::%s:role/eks-node-role", accountID)}}
+		}
+		return user, fmt.Sprintf("kubelet/%s (linux/amd64) kubernetes/%s", k8sKubernetesVersion, randomHexString(7)), nodeIP
+	}
+}
+
+// k8sRequestURI returns the request path of the API request, with the query of collection requests.
+func k8sRequestURI(c k8sAuditCustomizer) string {
+	r := c.resource
+	uri := "/api/" + r.apiVersion
+	if r.apiGroup != "" {
+		uri = fmt.Sprintf("/apis/%s/%s", r.apiGroup, r.apiVersion)
+	}
+	if c.namespace != "" {
+		uri += "/namespaces/" + c.namespace
+	}
+	uri += "/" + r.resource
+	if c.name != "" {
+		uri += "/" + c.name
+	}
+	if r.subresource != "" {
+		uri += "/" + r.subresource
+	}
+
+	switch {
+	case r.subresource == "exec":
+		uri += "?command=sh&container=app&stdin=true&stdout=true&tty=true"
+	case c.verb == "watch":
+		uri += fmt.Sprintf("?allowWatchBookmarks=true&resourceVersion=%d&timeout=%dm%ds&watch=true", c.received.Unix()%100000000, int(c.latency.Minutes()), int(c.latency.Seconds())%60)
+	case c.verb == "list":
+		uri += "?limit=500"
+	}
+
+	return uri
+}
+
+// k8sObjectFor returns the object of the request body, a partial object for patches.
+func k8sObjectFor(c k8sAuditCustomizer) map[string]any {
+	metadata := map[string]any{"name": c.name}
+	if c.namespace != "" {
+		metadata["namespace"] = c.namespace
+	}
+	if c.name == "" {
+		metadata["generateName"] = k8sAppName() + "-"
+	}
+
+	if c.verb == "patch" {
+		return map[string]any{"metadata": map[string]any{"annotations": map[string]any{"kubectl.kubernetes.io/restartedAt": c.received.Format(time.RFC3339)}}}
+	}
+
+	return map[string]any{"kind": c.resource.kind, "apiVersion": strings.TrimPrefix(c.resource.apiGroup+"/"+c.resource.apiVersion, "/"), "metadata": metadata}
+}
+
+func k8sAnnotations(c k8sAuditCustomizer) map[string]string {
+	if c.code == 403 {
+		return map[string]string{"authorization.k8s.io/decision": "forbid", "authorization.k8s.io/reason": ""}
+	}
+
+	username := c.user.Username
+	reason := fmt.Sprintf(`RBAC: allowed by ClusterRoleBinding %q of ClusterRole %q to User %q`, username, username, username)
+	switch {
+	case c.user.Groups[0] == "system:masters":
+		reason = `RBAC: allowed by ClusterRoleBinding "cluster-admin" of ClusterRole "cluster-admin" to Group "system:masters"`
+	case c.user.Groups[0] == "system:nodes":
+		// the Node authorizer does not give a reason
+		reason = ""
+	case strings.HasPrefix(username, "system:serviceaccount:"):
+		parts := strings.Split(username, ":")
+		reason = fmt.Sprintf(`RBAC: allowed by ClusterRoleBinding %q of ClusterRole %q to ServiceAccount "%s/%s"`, parts[3], parts[3], parts[3], parts[2])
+	case strings.HasPrefix(c.user.UID, "aws-iam-authenticator:"):
+		reason = fmt.Sprintf(`EKS Access Policy: allowed by ClusterRoleBinding %q of ClusterRole "arn:aws:eks::aws:cluster-access-policy/AmazonEKSClusterAdminPolicy" to User %q`, username, username)
+	}
+
+	annotations := map[string]string{"authorization.k8s.io/decision": "allow", "authorization.k8s.io/reason": reason}
+	if c.resource.kind == "Pod" && c.verb == "create" && c.resource.subresource == "" {
+		annotations["pod-security.kubernetes.io/enforce-policy"] = "privileged:latest"
+	}
+
+	return annotations
+}
+
+// k8sResponseStatus returns the response status of the stage, a Failure status for errors.
+func k8sResponseStatus(c k8sAuditCustomizer, stage string) *k8sStatus {
+	code := c.code
+	if stage == k8sStagePanic {
+		code = 500
+	}
+
+	status := &k8sStatus{Code: code}
+	if code < 300 {
+		return status
+	}
+
+	object := fmt.Sprintf("%s %q", c.resource.resource, c.name)
+	if c.name == "" {
+		object = c.resource.resource
+	}
+	status.Status = "Failure"
+
+	switch code {
+	case 403:
+		status.Reason = "Forbidden"
+		status.Message = fmt.Sprintf("%s is forbidden: User %q cannot %s resource %q in API group %q", object, c.user.Username, c.verb, c.resource.resource, c.resource.apiGroup)
+		if c.namespace != "" {
+			status.Message += fmt.Sprintf(" in the namespace %q", c.namespace)
+		}
+	case 404:
+		status.Reason = "NotFound"
+		status.Message = object + " not found"
+	case 409:
+		status.Reason = "Conflict"
+		status.Message = fmt.Sprintf("Operation cannot be fulfilled on %s: the object has been modified; please apply your changes to the latest version and try again", object)
+		if c.verb == "create" {
+			status.Reason = "AlreadyExists"
+			status.Message = object + " already exists"
+		}
+	default:
+		status.Reason = "InternalError"
+		status.Message = "Internal error occurred: APIServer panic'd"
+	}
+
+	return status
+}
+
+// k8sResponseCode returns a response code of the verb, mostly successful.
+func k8sResponseCode(verb string) int {
+	switch n := rand.IntN(100); {
+	case verb == "create" && n < 5:
+		return 409
+	case verb == "create":
+		return 201
+	case (verb == "get" || verb == "delete") && n < 8:
+		return 404
+	case verb == "update" && n < 5:
+		return 409
+	default:
+		return 200
+	}
+}
+
+func k8sNamespace() string {
+	return k8sNamespaces[rand.IntN(len(k8sNamespaces))]
+}
+
+func k8sAppName() string {
+	return k8sAppNames[rand.IntN(len(k8sAppNames))]
+}
+
+func k8sPodName() string {
+	return fmt.Sprintf("%s-%s-%s", k8sAppName(), randomHexString(10), strings.ToLower(randomAZ09String(5)))
+}
+
+func k8sNodeName() string {
+	return fmt.Sprintf("ip-10-0-%d-%d.ec2.internal", rand.IntN(4), rand.IntN(254)+1)
+}
@@ -0,0 +1,143 @@
+package internal
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"data-gen/conf"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func Test_buildK8sAuditEvents(t *testing.T) {
+	c := k8sAuditCustomizer{
+		auditID:   "6b1ff5a5-ff7d-4a43-9b39-4d6a4c0bc4a3",
+		level:     k8sLevelMetadata,
+		verb:      "get",
+		resource:  k8sResources["pods"],
+		namespace: "default",
+		name:      "web-7d4b9c8f6c-x2k9p",
+		user:      k8sUserInfo{Username: "kubernetes-admin", Groups: []string{"system:masters", "system:authenticated"}},
+		userAgent: "kubectl/v1.30.4 (linux/amd64) kubernetes/4a5b6c7",
+		sourceIP:  "192.0.2.10",
+		code:      200,
+		received:  time.Date(2026, 10, 19, 10, 20, 30, 123456000, time.UTC),
+		latency:   2 * time.Millisecond,
+	}
+
+	t.Run("Log backend carries all stages of a request", func(t *testing.T) {
+		events := buildK8sAuditEvents(c)
+		require.Len(t, events, 2)
+		require.Equal(t, k8sStageRequestReceived, events[0].Stage)
+		require.Nil(t, events[0].ResponseStatus)
+		require.Equal(t, k8sStageResponseComplete, events[1].Stage)
+		require.Equal(t, events[0].AuditID, events[1].AuditID)
+
+		line, err := json.Marshal(events[1])
+		require.NoError(t, err)
+		require.Equal(t, `{"kind":"Event","apiVersion":"audit.k8s.io/v1","level":"Metadata","auditID":"6b1ff5a5-ff7d-4a43-9b39-4d6a4c0bc4a3","stage":"ResponseComplete","requestURI":"/api/v1/namespaces/default/pods/web-7d4b9c8f6c-x2k9p","verb":"get","user":{"username":"kubernetes-admin","groups":["system:masters","system:authenticated"]},"sourceIPs":["192.0.2.10"],"userAgent":"kubectl/v1.30.4 (linux/amd64) kubernetes/4a5b6c7","objectRef":{"resource":"pods","namespace":"default","name":"web-7d4b9c8f6c-x2k9p","apiVersion":"v1"},"responseStatus":{"metadata":{},"code":200},"requestReceivedTimestamp":"2026-10-19T10:20:30.123456Z","stageTimestamp":"2026-10-19T10:20:30.125456Z","annotations":{"authorization.k8s.io/decision":"allow","authorization.k8s.io/reason":"RBAC: allowed by ClusterRoleBinding \"cluster-admin\" of ClusterRole \"cluster-admin\" to Group \"system:masters\""}}`, string(line))
+	})
+
+	t.Run("EKS omits RequestReceived stage", func(t *testing.T) {
+		eks := c
+		eks.eks = true
+		eks.verb = "watch"
+		eks.name = ""
+
+		events := buildK8sAuditEvents(eks)
+		require.Len(t, events, 2)
+		require.Equal(t, k8sStageResponseStarted, events[0].Stage)
+		require.Equal(t, k8sStageResponseComplete, events[1].Stage)
+		require.True(t, strings.HasSuffix(events[0].RequestURI, "&watch=true"))
+	})
+
+	t.Run("Forbidden requests", func(t *testing.T) {
+		forbidden := c
+		forbidden.code = 403
+		forbidden.user = k8sUserInfo{Username: "system:serviceaccount:payments:default", Groups: []string{"system:serviceaccounts"}}
+
+		events := buildK8sAuditEvents(forbidden)
+		status := events[len(events)-1].ResponseStatus
+		require.Equal(t, "Forbidden", status.Reason)
+		require.Equal(t, `pods "web-7d4b9c8f6c-x2k9p" is forbidden: User "system:serviceaccount:payments:default" cannot get resource "pods" in API group "" in the namespace "default"`, status.Message)
+		require.Equal(t, "forbid", events[len(events)-1].Annotations["authorization.k8s.io/decision"])
+	})
+}
+
+func Test_newK8sAuditCustomizer(t *testing.T) {
+	for _, eks := range []bool{true, false} {
+		for range 1000 {
+			c := newK8sAuditCustomizer(eks, "111122223333")
+			events := buildK8sAuditEvents(c)
+
+			last := events[len(events)-1]
+			require.NotNil(t, last.ResponseStatus)
+			require.Equal(t, c.resource.namespaced, last.ObjectRef.Namespace != "")
+			require.LessOrEqual(t, last.RequestReceivedTimestamp, last.StageTimestamp)
+
+			if eks {
+				require.NotEqual(t, k8sStageRequestReceived, events[0].Stage)
+				require.NotEqual(t, "kubernetes-admin", c.user.Username)
+			} else {
+				require.Equal(t, k8sStageRequestReceived, events[0].Stage)
+				require.NotContains(t, c.user.Username, "eks:")
+			}
+
+			if c.level == k8sLevelMetadata {
+				require.Nil(t, last.RequestObject)
+			}
+			if c.resource.resource == "secrets" {
+				require.Equal(t, k8sLevelMetadata, c.level)
+			}
+		}
+	}
+}
+
+func Test_NewK8sAuditGen(t *testing.T) {
+	gen, err := NewK8sAuditGen(conf.InputConfig{}, conf.OutputConfig{Type: conf.OutputCWLogs})
+	require.NoError(t, err)
+	require.Equal(t, k8sDeliveryCloudWatch, gen.delivery)
+
+	gen, err = NewK8sAuditGen(conf.InputConfig{}, conf.OutputConfig{Type: conf.OutputFile})
+	require.NoError(t, err)
+	require.Equal(t, k8sDeliveryJSON, gen.delivery)
+
+	for range 5 {
+		_, err = gen.Generate()
+		require.NoError(t, err)
+	}
+
+	for _, line := range strings.Split(string(gen.GetAndReset()), "\n") {
+		var event k8sAuditEvent
+		require.NoError(t, json.Unmarshal([]byte(line), &event))
+		require.Equal(t, "audit.k8s.io/v1", event.APIVersion)
+	}
+}
+
+func TestK8sAuditGen_LogDestination(t *testing.T) {
+	gen, err := NewK8sAuditGen(conf.InputConfig{Type: conf.InputK8sAudit}, conf.OutputConfig{Type: conf.OutputCWLogs})
+	require.NoError(t, err)
+
+	group, stream, ok := gen.LogDestination()
+	require.True(t, ok)
+	require.Equal(t, "/aws/eks/"+conf.DefaultEKSClusterName+"/cluster", group)
+	require.True(t, strings.HasPrefix(stream, "kube-apiserver-audit-"))
+	require.Len(t, strings.TrimPrefix(stream, "kube-apiserver-audit-"), 32)
+
+	input := conf.InputConfig{Type: conf.InputK8sAudit}
+	require.NoError(t, yaml.Unmarshal([]byte("cluster_name: prod"), &input.Conf))
+	gen, err = NewK8sAuditGen(input, conf.OutputConfig{Type: conf.OutputCWLogs})
+	require.NoError(t, err)
+
+	group, prodStream, _ := gen.LogDestination()
+	require.Equal(t, "/aws/eks/prod/cluster", group)
+	require.NotEqual(t, stream, prodStream)
+
+	// configuration errors surface on construction rather than falling back to the default cluster
+	require.NoError(t, yaml.Unmarshal([]byte("cluster_name: [prod]"), &input.Conf))
+	_, err = NewK8sAuditGen(input, conf.OutputConfig{Type: conf.OutputCWLogs})
+	require.Error(t, err)
+}
@@ -0,0 +1,466 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"data-gen/conf"
+
+	"github.com/google/uuid"
+)
+
+const (
+	lambdaFormatText = "text"
+	lambdaFormatJSON = "json"
+
+	lambdaRuntimePython = "python3.12"
+	lambdaRuntimeNode   = "nodejs20.x"
+
+	lambdaStatusSuccess = "success"
+	lambdaStatusError   = "error"
+	lambdaStatusTimeout = "timeout"
+
+	lambdaTimeFormat = "2006-01-02T15:04:05.000Z"
+)
+
+// lambdaRuntime describes runtime specific log output and resource figures.
+type lambdaRuntime struct {
+	version string
+	// baseMemoryMB is the memory used by the runtime before running handler code
+	baseMemoryMB int
+	// initMs is the range of cold start init durations
+	initMs [2]int
+	errors [][2]string
+}
+
+var lambdaRuntimes = map[string]lambdaRuntime{
+	lambdaRuntimePython: {
+		version:      "python:3.12.v36",
+		baseMemoryMB: 36,
+		initMs:       [2]int{90, 450},
+		errors: [][2]string{
+			{"KeyError", "'orderId'"},
+			{"ValueError", "invalid literal for int() with base 10: 'abc'"},
+			{"ClientError", "An error occurred (ConditionalCheckFailedException) when calling the PutItem operation: The conditional request failed"},
+		},
+	},
+	lambdaRuntimeNode: {
+		version:      "nodejs:20.v42",
+		baseMemoryMB: 64,
+		initMs:       [2]int{120, 380},
+		errors: [][2]string{
+			{"TypeError", "Cannot read properties of undefined (reading 'id')"},
+			{"ValidationException", "One or more parameter values were invalid: Missing the key id in the item"},
+			{"Error", "connect ECONNREFUSED 10.0.1.23:5432"},
+		},
+	},
+}
+
+// lambdaMessages are function output lines, formatted with a random number.
+var lambdaMessages = []string{
+	"Processing SQS batch of %d records",
+	"Fetched %d items from DynamoDB table orders",
+	"Published message to SNS topic order-events, size %d bytes",
+	"Cache miss for key user#%d",
+	"Received API Gateway event for /orders/%d",
+}
+
+var lambdaWarnMessages = []string{
+	"Retrying DynamoDB request, attempt %d",
+	"Slow response from payments service (%d ms)",
+}
+
+// LambdaGen generates AWS Lambda function logs, one invocation per data point.
+// Invocations carry START, END and REPORT platform lines around function output, or the equivalent JSON log records.
+type LambdaGen struct {
+	cfg            lambdaCfg
+	buf            trackedBuffer
+	runtime        lambdaRuntime
+	runtimeVersion string
+	warm           bool
+}
+
+// lambdaCfg specifies the log format and function settings reflected in REPORT lines.
+type lambdaCfg struct {
+	LogFormat  string `yaml:"log_format"`
+	Runtime    string `yaml:"runtime"`
+	MemorySize int    `yaml:"memory_size"`
+	Timeout    int    `yaml:"timeout"`
+}
+
+func NewLambdaGen(input conf.InputConfig) (*LambdaGen, error) {
+	cfg := lambdaCfg{
+		LogFormat:  lambdaFormatText,
+		Runtime:    lambdaRuntimePython,
+		MemorySize: 128,
+		Timeout:    3,
+	}
+
+	err := input.Conf.Decode(&cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode lambda configuration: %w", err)
+	}
+
+	if cfg.LogFormat != lambdaFormatText && cfg.LogFormat != lambdaFormatJSON {
+		return nil, fmt.Errorf("unknown lambda log format: %s", cfg.LogFormat)
+	}
+
+	runtime, ok := lambdaRuntimes[cfg.Runtime]
+	if !ok {
+		return nil, fmt.Errorf("unknown lambda runtime: %s", cfg.Runtime)
+	}
+
+	if cfg.MemorySize < 128 || cfg.MemorySize > 10240 {
+		return nil, fmt.Errorf("lambda memory_size must be between 128 and 10240 MB")
+	}
+
+	if cfg.Timeout < 1 || cfg.Timeout > 900 {
+		return nil, fmt.Errorf("lambda timeout must be between 1 and 900 seconds")
+	}
+
+	return &LambdaGen{
+		cfg:            cfg,
+		buf:            newTrackedBuffer(),
+		runtime:        runtime,
+		runtimeVersion: fmt.Sprintf("arn:aws:lambda:%s::runtime:%s", randomRegion(), randomHexString(64)),
+	}, nil
+}
+
+func (l *LambdaGen) Generate() (int64, error) {
+	// the first invocation and invocations on new execution environments are cold starts
+	coldStart := !l.warm || rand.IntN(20) == 0
+	l.warm = true
+
+	c := newLambdaCustomizer(l.cfg, l.runtime, coldStart)
+
+	var lines []string
+	if l.cfg.LogFormat == lambdaFormatJSON {
+		var err error
+		lines, err = buildLambdaJSONLines(c, l.cfg, l.runtimeVersion)
+		if err != nil {
+			return 0, err
+		}
+	} else {
+		lines = buildLambdaTextLines(c, l.cfg, l.runtimeVersion)
+	}
+
+	err := l.buf.write([]byte(strings.Join(lines, "\n") + "\n"))
+	if err != nil {
+		return 0, err
+	}
+
+	return l.buf.size(), nil
+}
+
+func (l *LambdaGen) GetAndReset() []byte {
+	return l.buf.getAndReset()
+}
+
+func (l *LambdaGen) RecordTimestamp(record string) (time.Time, bool) {
+	if l.cfg.LogFormat == lambdaFormatJSON {
+		return jsonTimestamp(record, "time", "timestamp")
+	}
+
+	// function output starts with the timestamp, or follows the [LEVEL] field of Python runtimes.
+	// START, END and REPORT lines do not carry a timestamp.
+	if t, ok := fieldTimestamp(record, 0); ok {
+		return t, true
+	}
+
+	return fieldTimestamp(record, 1)
+}
+
+// lambdaMessage is a line of function output, logged at the offset from invocation start.
+type lambdaMessage struct {
+	offset time.Duration
+	level  string
+	text   string
+}
+
+// lambdaCustomizer holds parameters of a single invocation.
+type lambdaCustomizer struct {
+	runtime      string
+	requestID    string
+	start        time.Time
+	coldStart    bool
+	initDuration float64
+	duration     float64
+	maxMemory    int
+	status       string
+	errorType    string
+	errorMessage string
+	messages     []lambdaMessage
+}
+
+func newLambdaCustomizer(cfg lambdaCfg, runtime lambdaRuntime, coldStart bool) lambdaCustomizer {
+	c := lambdaCustomizer{
+		runtime:   cfg.Runtime,
+		requestID: uuid.NewString(),
+		start:     time.Now().UTC(),
+		coldStart: coldStart,
+		status:    lambdaStatusSuccess,
+		// most invocations complete in milliseconds, with a tail of slow invocations
+		duration:  math.Round((rand.Float64()*120+1)*100) / 100,
+		maxMemory: min(runtime.baseMemoryMB+rand.IntN(cfg.MemorySize/2), cfg.MemorySize),
+	}
+
+	if rand.IntN(10) == 0 {
+		c.duration = math.Round((rand.Float64()*1800+200)*100) / 100
+	}
+
+	if coldStart {
+		c.initDuration = math.Round((rand.Float64()*float64(runtime.initMs[1]-runtime.initMs[0])+float64(runtime.initMs[0]))*100) / 100
+	}
+
+	switch n := rand.IntN(100); {
+	case n < 2:
+		c.status = lambdaStatusTimeout
+		c.duration = float64(cfg.Timeout * 1000)
+	case n < 7:
+		c.status = lambdaStatusError
+		e := runtime.errors[rand.IntN(len(runtime.errors))]
+		c.errorType, c.errorMessage = e[0], e[1]
+	}
+
+	for i := range rand.IntN(4) + 1 {
+		level, text := "INFO", lambdaMessages[rand.IntN(len(lambdaMessages))]
+		if rand.IntN(8) == 0 {
+			level, text = "WARN", lambdaWarnMessages[rand.IntN(len(lambdaWarnMessages))]
+		}
+
+		c.messages = append(c.messages, lambdaMessage{
+			offset: time.Duration(c.duration*float64(i)/4*1000) * time.Microsecond,
+			level:  level,
+			text:   fmt.Sprintf(text, rand.IntN(500)+1),
+		})
+	}
+
+	return c
+}
+
+// billedDuration rounds up to the next millisecond. Init phase of cold starts is billed as well.
+func (c lambdaCustomizer) billedDuration() int {
+	return int(math.Ceil(c.duration + c.initDuration))
+}
+
+func (c lambdaCustomizer) end() time.Time {
+	return c.start.Add(time.Duration(c.duration*1000) * time.Microsecond)
+}
+
+// buildLambdaTextLines returns the plain text log lines of the invocation.
+// See - https://docs.aws.amazon.com/lambda/latest/dg/monitoring-cloudwatchlogs-logformat.html
+func buildLambdaTextLines(c lambdaCustomizer, cfg lambdaCfg, runtimeVersionArn string) []string {
+	var lines []string
+	if c.coldStart {
+		lines = append(lines, fmt.Sprintf("INIT_START Runtime Version: %s\tRuntime Version ARN: %s", lambdaRuntimes[c.runtime].version, runtimeVersionArn))
+	}
+
+	lines = append(lines, fmt.Sprintf("START RequestId: %s Version: $LATEST", c.requestID))
+
+	for _, m := range c.messages {
+		lines = append(lines, lambdaTextLine(c, c.start.Add(m.offset), m.level, m.text))
+	}
+
+	switch c.status {
+	case lambdaStatusError:
+		if c.runtime == lambdaRuntimePython {
+			lines = append(lines, fmt.Sprintf("[ERROR] %s: %s", c.errorType, c.errorMessage))
+		} else {
+			invokeErr, _ := json.Marshal(map[string]any{"errorType": c.errorType, "errorMessage": c.errorMessage, "stack": lambdaStackTrace(c)})
+			lines = append(lines, lambdaTextLine(c, c.end(), "ERROR", "Invoke Error \t"+string(invokeErr)))
+		}
+	case lambdaStatusTimeout:
+		lines = append(lines, fmt.Sprintf("%s %s Task timed out after %d.00 seconds", c.end().Format(lambdaTimeFormat), c.requestID, cfg.Timeout))
+	}
+
+	lines = append(lines, fmt.Sprintf("END RequestId: %s", c.requestID))
+
+	report := fmt.Sprintf("REPORT RequestId: %s\tDuration: %.2f ms\tBilled Duration: %d ms\tMemory Size: %d MB\tMax Memory Used: %d MB\t",
+		c.requestID, c.duration, c.billedDuration(), cfg.MemorySize, c.maxMemory)
+	if c.coldStart {
+		report += fmt.Sprintf("Init Duration: %.2f ms\t", c.initDuration)
+	}
+	if c.status == lambdaStatusTimeout {
+		report += "Status: timeout"
+	}
+
+	return append(lines, report)
+}
+
+// lambdaTextLine formats function output the way the runtime's default logger does.
+func lambdaTextLine(c lambdaCustomizer, t time.Time, level, text string) string {
+	if c.runtime == lambdaRuntimePython {
+		return fmt.Sprintf("[%s]\t%s\t%s\t%s", level, t.Format(lambdaTimeFormat), c.requestID, text)
+	}
+
+	return fmt.Sprintf("%s\t%s\t%s\t%s", t.Format(lambdaTimeFormat), c.requestID, level, text)
+}
+
+// lambdaPlatformEvent is a platform log record of the JSON log format.
+type lambdaPlatformEvent struct {
+	Time   string `json:"time"`
+	Type   string `json:"type"`
+	Record any    `json:"record"`
+}
+
+type lambdaInitStartRecord struct {
+	InitializationType string `json:"initializationType"`
+	Phase              string `json:"phase"`
+	RuntimeVersion     string `json:"runtimeVersion"`
+	RuntimeVersionArn  string `json:"runtimeVersionArn"`
+}
+
+type lambdaStartRecord struct {
+	RequestID string `json:"requestId"`
+	Version   string `json:"version"`
+}
+
+type lambdaRuntimeDoneRecord struct {
+	RequestID string             `json:"requestId"`
+	Status    string             `json:"status"`
+	Metrics   lambdaRuntimeUsage `json:"metrics"`
+}
+
+type lambdaRuntimeUsage struct {
+	DurationMs    float64 `json:"durationMs"`
+	ProducedBytes int     `json:"producedBytes"`
+}
+
+type lambdaReportRecord struct {
+	RequestID string        `json:"requestId"`
+	Metrics   lambdaMetrics `json:"metrics"`
+	Status    string        `json:"status"`
+}
+
+type lambdaMetrics struct {
+	DurationMs       float64 `json:"durationMs"`
+	BilledDurationMs int     `json:"billedDurationMs"`
+	MemorySizeMB     int     `json:"memorySizeMB"`
+	MaxMemoryUsedMB  int     `json:"maxMemoryUsedMB"`
+	InitDurationMs   float64 `json:"initDurationMs,omitempty"`
+}
+
+// lambdaApplicationLog is function output of the JSON log format.
+type lambdaApplicationLog struct {
+	Timestamp    string   `json:"timestamp"`
+	Level        string   `json:"level"`
+	Message      string   `json:"message,omitempty"`
+	Logger       string   `json:"logger,omitempty"`
+	RequestID    string   `json:"requestId"`
+	ErrorType    string   `json:"errorType,omitempty"`
+	ErrorMessage string   `json:"errorMessage,omitempty"`
+	StackTrace   []string `json:"stackTrace,omitempty"`
+}
+
+// buildLambdaJSONLines returns the JSON log records of the invocation.
+// See - https://docs.aws.amazon.com/lambda/latest/dg/monitoring-cloudwatchlogs-advanced.html
+func buildLambdaJSONLines(c lambdaCustomizer, cfg lambdaCfg, runtimeVersionArn string) ([]string, error) {
+	var records []any
+	if c.coldStart {
+		records = append(records, lambdaPlatformEvent{
+			Time: c.start.Add(-time.Duration(c.initDuration*1000) * time.Microsecond).Format(lambdaTimeFormat),
+			Type: "platform.initStart",
+			Record: lambdaInitStartRecord{
+				InitializationType: "on-demand",
+				Phase:              "init",
+				RuntimeVersion:     lambdaRuntimes[c.runtime].version,
+				RuntimeVersionArn:  runtimeVersionArn,
+			},
+		})
+	}
+
+	records = append(records, lambdaPlatformEvent{
+		Time:   c.start.Format(lambdaTimeFormat),
+		Type:   "platform.start",
+		Record: lambdaStartRecord{RequestID: c.requestID, Version: "$LATEST"},
+	})
+
+	logger := ""
+	if c.runtime == lambdaRuntimePython {
+		logger = "root"
+	}
+
+	for _, m := range c.messages {
+		records = append(records, lambdaApplicationLog{
+			Timestamp: c.start.Add(m.offset).Format(lambdaTimeFormat),
+			Level:     m.level,
+			Message:   m.text,
+			Logger:    logger,
+			RequestID: c.requestID,
+		})
+	}
+
+	switch c.status {
+	case lambdaStatusError:
+		records = append(records, lambdaApplicationLog{
+			Timestamp:    c.end().Format(lambdaTimeFormat),
+			Level:        "ERROR",
+			RequestID:    c.requestID,
+			ErrorType:    c.errorType,
+			ErrorMessage: c.errorMessage,
+			StackTrace:   lambdaStackTrace(c),
+		})
+	case lambdaStatusTimeout:
+		records = append(records, lambdaApplicationLog{
+			Timestamp: c.end().Format(lambdaTimeFormat),
+			Level:     "ERROR",
+			Message:   fmt.Sprintf("Task timed out after %d.00 seconds", cfg.Timeout),
+			RequestID: c.requestID,
+		})
+	}
+
+	records = append(records,
+		lambdaPlatformEvent{
+			Time: c.end().Format(lambdaTimeFormat),
+			Type: "platform.runtimeDone",
+			Record: lambdaRuntimeDoneRecord{
+				RequestID: c.requestID,
+				Status:    c.status,
+				Metrics:   lambdaRuntimeUsage{DurationMs: c.duration, ProducedBytes: rand.IntN(2048)},
+			},
+		},
+		lambdaPlatformEvent{
+			Time: c.end().Format(lambdaTimeFormat),
+			Type: "platform.report",
+			Record: lambdaReportRecord{
+				RequestID: c.requestID,
+				Metrics: lambdaMetrics{
+					DurationMs:       c.duration,
+					BilledDurationMs: c.billedDuration(),
+					MemorySizeMB:     cfg.MemorySize,
+					MaxMemoryUsedMB:  c.maxMemory,
+					InitDurationMs:   c.initDuration,
+				},
+				Status: c.status,
+			},
+		},
+	)
+
+	lines := make([]string, 0, len(records))
+	for _, record := range records {
+		marshaled, err := json.Marshal(record)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, string(marshaled))
+	}
+
+	return lines, nil
+}
+
+// lambdaStackTrace returns the stack trace frames of the handler error.
+func lambdaStackTrace(c lambdaCustomizer) []string {
+	if c.runtime == lambdaRuntimePython {
+		return []string{"  File \"/var/task/lambda_function.py\", line 18, in lambda_handler\n    order_id = event[\"orderId\"]\n"}
+	}
+
+	return []string{
+		fmt.Sprintf("%s: %s", c.errorType, c.errorMessage),
+		"    at Runtime.handler (file:///var/task/index.mjs:18:31)",
+		"    at Runtime.handleOnceNonStreaming (file:///var/runtime/index.mjs:1173:29)",
+	}
+}
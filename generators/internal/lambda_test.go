@@ -0,0 +1,83 @@
+package internal
+
+import (
+	"encoding/json"
+	"math/rand/v2"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_buildLambdaTextLines(t *testing.T) {
+	cfg := lambdaCfg{LogFormat: lambdaFormatText, Runtime: lambdaRuntimePython, MemorySize: 128, Timeout: 3}
+	c := lambdaCustomizer{
+		runtime:      lambdaRuntimePython,
+		requestID:    "8f507cfc-xmpl-4697-b07a-ac58fc914c95",
+		start:        time.Date(2026, 10, 19, 10, 20, 30, 0, time.UTC),
+		coldStart:    true,
+		initDuration: 128.54,
+		duration:     2.69,
+		maxMemory:    39,
+		status:       lambdaStatusSuccess,
+		messages:     []lambdaMessage{{level: "INFO", text: "Fetched 3 items from DynamoDB table orders"}},
+	}
+
+	t.Run("Cold start invocation", func(t *testing.T) {
+		lines := buildLambdaTextLines(c, cfg, "arn:aws:lambda:us-east-1::runtime:abc")
+		require.Equal(t, []string{
+			"INIT_START Runtime Version: python:3.12.v36\tRuntime Version ARN: arn:aws:lambda:us-east-1::runtime:abc",
+			"START RequestId: 8f507cfc-xmpl-4697-b07a-ac58fc914c95 Version: $LATEST",
+			"[INFO]\t2026-10-19T10:20:30.000Z\t8f507cfc-xmpl-4697-b07a-ac58fc914c95\tFetched 3 items from DynamoDB table orders",
+			"END RequestId: 8f507cfc-xmpl-4697-b07a-ac58fc914c95",
+			"REPORT RequestId: 8f507cfc-xmpl-4697-b07a-ac58fc914c95\tDuration: 2.69 ms\tBilled Duration: 132 ms\tMemory Size: 128 MB\tMax Memory Used: 39 MB\tInit Duration: 128.54 ms\t",
+		}, lines)
+	})
+
+	t.Run("Timed out warm invocation", func(t *testing.T) {
+		timeout := c
+		timeout.coldStart = false
+		timeout.initDuration = 0
+		timeout.duration = 3000
+		timeout.status = lambdaStatusTimeout
+
+		lines := buildLambdaTextLines(timeout, cfg, "")
+		require.Equal(t, "2026-10-19T10:20:33.000Z 8f507cfc-xmpl-4697-b07a-ac58fc914c95 Task timed out after 3.00 seconds", lines[len(lines)-3])
+		require.True(t, strings.HasSuffix(lines[len(lines)-1], "Billed Duration: 3000 ms\tMemory Size: 128 MB\tMax Memory Used: 39 MB\tStatus: timeout"))
+	})
+}
+
+func Test_buildLambdaJSONLines(t *testing.T) {
+	cfg := lambdaCfg{LogFormat: lambdaFormatJSON, Runtime: lambdaRuntimeNode, MemorySize: 512, Timeout: 10}
+
+	for range 200 {
+		coldStart := rand.IntN(2) == 0
+		c := newLambdaCustomizer(cfg, lambdaRuntimes[lambdaRuntimeNode], coldStart)
+		lines, err := buildLambdaJSONLines(c, cfg, "arn:aws:lambda:us-east-1::runtime:abc")
+		require.NoError(t, err)
+
+		var first, report lambdaPlatformEvent
+		require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+		require.NoError(t, json.Unmarshal([]byte(lines[len(lines)-1]), &report))
+
+		if coldStart {
+			require.Equal(t, "platform.initStart", first.Type)
+		} else {
+			require.Equal(t, "platform.start", first.Type)
+		}
+
+		require.Equal(t, "platform.report", report.Type)
+		record := report.Record.(map[string]any)
+		metrics := record["metrics"].(map[string]any)
+		require.Equal(t, c.status, record["status"])
+		require.Equal(t, float64(512), metrics["memorySizeMB"])
+		require.LessOrEqual(t, metrics["maxMemoryUsedMB"], float64(512))
+		require.GreaterOrEqual(t, metrics["billedDurationMs"], metrics["durationMs"])
+		require.Equal(t, coldStart, metrics["initDurationMs"] != nil)
+
+		if c.status == lambdaStatusTimeout {
+			require.Equal(t, float64(10000), metrics["durationMs"])
+		}
+	}
+}
@@ -2,6 +2,7 @@ package internal
 
 import (
 	"fmt"
+	"time"
 
 	"go.elastic.co/ecszap"
 	"go.uber.org/zap"
@@ -43,6 +44,10 @@ func (l *LogGenerator) GetAndReset() []byte {
 	return l.buf.getAndReset()
 }
 
+func (l *LogGenerator) RecordTimestamp(record string) (time.Time, bool) {
+	return jsonTimestamp(record, "@timestamp")
+}
+
 // writer captures Zap logger output for buffering and emission.
 type writer struct {
 	data []byte
@@ -32,6 +32,10 @@ func (m *MetricGenerator) GetAndReset() []byte {
 	return m.buf.getAndReset()
 }
 
+func (m *MetricGenerator) RecordTimestamp(record string) (time.Time, bool) {
+	return jsonTimestamp(record, "timestamp")
+}
+
 func (m *MetricGenerator) makeNewMetricsEntry() ([]byte, error) {
 	t := time.Now().UnixMilli()
 
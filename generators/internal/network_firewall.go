@@ -0,0 +1,374 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"time"
+
+	"data-gen/conf"
+)
+
+const (
+	nfwLogTypeAlert = "alert"
+	nfwLogTypeFlow  = "flow"
+	nfwLogTypeTLS   = "tls"
+
+	nfwTimeFormat = "2006-01-02T15:04:05.000000-0700"
+)
+
+// nfwService is a destination service along with the Suricata app-layer protocol detected for it.
+type nfwService struct {
+	proto    string
+	appProto string
+	port     int
+}
+
+var nfwServices = []nfwService{
+	{"TCP", "tls", 443}, {"TCP", "tls", 443}, {"TCP", "tls", 443},
+	{"TCP", "http", 80}, {"TCP", "http", 80},
+	{"UDP", "dns", 53}, {"UDP", "dns", 53},
+	{"TCP", "ssh", 22},
+	{"UDP", "ntp", 123},
+	{"TCP", "", 5432},
+	{"ICMP", "", 0},
+}
+
+// nfwSignatures are the AWS managed stateful rules raising alerts, ex:- domain list rules, alerting next to the
+// Suricata compatible rules of eveSignatures.
+var nfwSignatures = []eveSignature{
+	{1, 0, "matching TLS denylisted FQDNs", "", 1, "tls"},
+	{2, 0, "matching HTTP denylisted FQDNs", "", 1, "http"},
+	{202, 0, "aws:alert_established", "", 3, ""},
+}
+
+// nfwRevocationStatuses lists certificate revocation outcomes of TLS inspection.
+var nfwRevocationStatuses = []struct{ status, action string }{
+	{"REVOKED", "DROP"}, {"REVOKED", "REJECT"}, {"UNKNOWN", "PASS"}, {"UNKNOWN", "DROP"},
+}
+
+var nfwTLSErrors = []string{
+	"SSL handshake failed: certificate verify failed: unable to get local issuer certificate",
+	"SSL handshake failed: certificate has expired",
+	"SSL handshake failed: unsupported protocol",
+	"Client hello message is malformed",
+}
+
+// NetworkFirewallGen generates AWS Network Firewall alert, flow and TLS logs.
+// Alert and flow logs carry Suricata EVE JSON events wrapped in firewall metadata.
+type NetworkFirewallGen struct {
+	buf          trackedBuffer
+	logTypes     []string
+	firewallName string
+	region       string
+}
+
+// networkFirewallCfg specifies the log types to generate.
+type networkFirewallCfg struct {
+	LogTypes []string `yaml:"log_types"`
+}
+
+func NewNetworkFirewallGen(input conf.InputConfig) (*NetworkFirewallGen, error) {
+	cfg := networkFirewallCfg{LogTypes: []string{nfwLogTypeAlert, nfwLogTypeFlow}}
+	err := input.Conf.Decode(&cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode network firewall configuration: %w", err)
+	}
+
+	if len(cfg.LogTypes) == 0 {
+		return nil, fmt.Errorf("network firewall log_types must not be empty")
+	}
+
+	for _, t := range cfg.LogTypes {
+		if !slices.Contains([]string{nfwLogTypeAlert, nfwLogTypeFlow, nfwLogTypeTLS}, t) {
+			return nil, fmt.Errorf("unknown network firewall log type: %s", t)
+		}
+	}
+
+	return &NetworkFirewallGen{
+		buf:          newTrackedBuffer(),
+		logTypes:     cfg.LogTypes,
+		firewallName: "egress-firewall",
+		region:       randomRegion(),
+	}, nil
+}
+
+func (n *NetworkFirewallGen) Generate() (int64, error) {
+	c := newNFWCustomizer(n.firewallName, n.region)
+
+	var record any
+	switch n.logTypes[rand.IntN(len(n.logTypes))] {
+	case nfwLogTypeAlert:
+		record = buildNFWAlertLog(c)
+	case nfwLogTypeFlow:
+		record = buildNFWFlowLog(c)
+	default:
+		record = buildNFWTLSLog(c)
+	}
+
+	marshaled, err := json.Marshal(record)
+	if err != nil {
+		return 0, err
+	}
+
+	// Network Firewall logs are newline delimited
+	marshaled = append(marshaled, '\n')
+
+	err = n.buf.write(marshaled)
+	if err != nil {
+		return 0, err
+	}
+
+	return n.buf.size(), nil
+}
+
+func (n *NetworkFirewallGen) GetAndReset() []byte {
+	return n.buf.getAndReset()
+}
+
+func (n *NetworkFirewallGen) RecordTimestamp(record string) (time.Time, bool) {
+	return jsonTimestamp(record, "event_timestamp")
+}
+
+// nfwLog is an alert or flow log record.
+// See - https://docs.aws.amazon.com/network-firewall/latest/developerguide/firewall-logging-contents.html
+type nfwLog struct {
+	FirewallName     string   `json:"firewall_name"`
+	AvailabilityZone string   `json:"availability_zone"`
+	EventTimestamp   string   `json:"event_timestamp"`
+	Event            eveEvent `json:"event"`
+}
+
+// nfwTLSLog is a TLS inspection log record, reporting revocation checks or TLS errors.
+// Unlike alert and flow logs, ports are strings and the event timestamp is numeric.
+type nfwTLSLog struct {
+	FirewallName     string      `json:"firewall_name"`
+	AvailabilityZone string      `json:"availability_zone"`
+	EventTimestamp   int64       `json:"event_timestamp"`
+	Event            nfwTLSEvent `json:"event"`
+}
+
+type nfwTLSEvent struct {
+	SrcIP           string              `json:"src_ip"`
+	SrcPort         string              `json:"src_port"`
+	RevocationCheck *nfwRevocationCheck `json:"revocation_check,omitempty"`
+	TLSError        *nfwTLSError        `json:"tls_error,omitempty"`
+	DestIP          string              `json:"dest_ip"`
+	DestPort        string              `json:"dest_port"`
+	Timestamp       string              `json:"timestamp"`
+	SNI             string              `json:"sni"`
+}
+
+type nfwRevocationCheck struct {
+	LeafCertFpr string `json:"leaf_cert_fpr"`
+	Status      string `json:"status"`
+	Action      string `json:"action"`
+}
+
+type nfwTLSError struct {
+	ErrorMessage string `json:"error_message"`
+}
+
+// nfwCustomizer holds the flow details shared by all Network Firewall log types,
+// along with the details specific to each log type.
+type nfwCustomizer struct {
+	firewallName     string
+	availabilityZone string
+	time             time.Time
+	flowID           int64
+	srcIP            string
+	srcPort          int
+	destIP           string
+	service          nfwService
+	domain           string
+	pkts             int
+	bytes            int
+	ageSeconds       int
+	ttl              int
+	tcpReset         bool
+	signature        eveSignature
+	alertAction      string
+	tlsVersion       string
+	httpPath         string
+	httpUserAgent    string
+	revocationCheck  *nfwRevocationCheck
+	tlsError         string
+}
+
+func newNFWCustomizer(firewallName, region string) nfwCustomizer {
+	service := nfwServices[rand.IntN(len(nfwServices))]
+	pkts := rand.IntN(200) + 1
+
+	c := nfwCustomizer{
+		firewallName:     firewallName,
+		availabilityZone: region + string(rune('a'+rand.IntN(3))),
+		time:             time.Now().UTC(),
+		flowID:           rand.Int64N(1 << 51),
+		srcIP:            fmt.Sprintf("10.0.%d.%d", rand.IntN(4), rand.IntN(254)+1),
+		srcPort:          rand.IntN(65535-49152) + 49152,
+		destIP:           randomIP(),
+		service:          service,
+		domain:           []string{"www.", "api.", "cdn."}[rand.IntN(3)] + randomDomain(),
+		pkts:             pkts,
+		bytes:            pkts * (rand.IntN(1400) + 60),
+		ageSeconds:       rand.IntN(120),
+		ttl:              []int{61, 64, 128, 255}[rand.IntN(4)],
+		tcpReset:         rand.IntN(5) == 0,
+		tlsVersion:       []string{"TLS 1.2", "TLS 1.3"}[rand.IntN(2)],
+		httpPath:         httpRequestPaths[rand.IntN(len(httpRequestPaths))],
+		httpUserAgent:    httpRequestUserAgents[rand.IntN(len(httpRequestUserAgents))],
+	}
+
+	if service.proto == "ICMP" {
+		c.srcPort = 0
+	}
+
+	// signatures inspecting an application protocol only match flows of that protocol
+	candidates := slices.DeleteFunc(slices.Concat(nfwSignatures, eveSignatures), func(s eveSignature) bool {
+		return s.appProto != "" && s.appProto != service.appProto
+	})
+	c.signature = candidates[rand.IntN(len(candidates))]
+
+	// domain list deny rules drop traffic, while other rules are configured as alert or drop
+	c.alertAction = "allowed"
+	if c.signature.id <= 2 || rand.IntN(3) == 0 {
+		c.alertAction = "blocked"
+	}
+
+	if rand.IntN(2) == 0 {
+		revocation := nfwRevocationStatuses[rand.IntN(len(nfwRevocationStatuses))]
+		c.revocationCheck = &nfwRevocationCheck{
+			LeafCertFpr: randomHexString(64),
+			Status:      revocation.status,
+			Action:      revocation.action,
+		}
+	} else {
+		c.tlsError = nfwTLSErrors[rand.IntN(len(nfwTLSErrors))]
+	}
+
+	return c
+}
+
+// event returns the EVE event of the flow.
+func (c nfwCustomizer) event(eventType string) eveEvent {
+	e := eveEvent{
+		Timestamp: c.time.Format(nfwTimeFormat),
+		FlowID:    c.flowID,
+		EventType: eventType,
+		SrcIP:     c.srcIP,
+		SrcPort:   c.srcPort,
+		DestIP:    c.destIP,
+		DestPort:  c.service.port,
+		Proto:     c.service.proto,
+		AppProto:  c.service.appProto,
+	}
+
+	if c.service.proto == "ICMP" {
+		// echo request
+		e.ICMPType = 8
+	}
+
+	return e
+}
+
+func buildNFWAlertLog(c nfwCustomizer) nfwLog {
+	e := c.event("alert")
+	e.Alert = &eveAlert{
+		Action:      c.alertAction,
+		SignatureID: c.signature.id,
+		Rev:         c.signature.rev,
+		Signature:   c.signature.signature,
+		Category:    c.signature.category,
+		Severity:    c.signature.severity,
+	}
+
+	switch c.service.appProto {
+	case "tls":
+		e.TLS = &eveTLS{SNI: c.domain, Version: c.tlsVersion}
+	case "http":
+		e.HTTP = &eveHTTP{
+			Hostname:      c.domain,
+			URL:           c.httpPath,
+			HTTPUserAgent: c.httpUserAgent,
+			HTTPMethod:    "GET",
+			Protocol:      "HTTP/1.1",
+			Length:        0,
+		}
+	}
+
+	return nfwLog{
+		FirewallName:     c.firewallName,
+		AvailabilityZone: c.availabilityZone,
+		EventTimestamp:   strconv.FormatInt(c.time.Unix(), 10),
+		Event:            e,
+	}
+}
+
+func buildNFWFlowLog(c nfwCustomizer) nfwLog {
+	e := c.event("netflow")
+	e.Netflow = &eveNetflow{
+		Pkts:   c.pkts,
+		Bytes:  c.bytes,
+		Start:  c.time.Add(-time.Duration(c.ageSeconds) * time.Second).Format(nfwTimeFormat),
+		End:    c.time.Format(nfwTimeFormat),
+		Age:    c.ageSeconds,
+		MinTTL: c.ttl,
+		MaxTTL: c.ttl,
+	}
+
+	if c.service.proto == "TCP" {
+		e.TCP = nfwTCPFlags(c.pkts, c.tcpReset)
+	}
+
+	return nfwLog{
+		FirewallName:     c.firewallName,
+		AvailabilityZone: c.availabilityZone,
+		EventTimestamp:   strconv.FormatInt(c.time.Unix(), 10),
+		Event:            e,
+	}
+}
+
+func buildNFWTLSLog(c nfwCustomizer) nfwTLSLog {
+	e := nfwTLSEvent{
+		SrcIP:           c.srcIP,
+		SrcPort:         strconv.Itoa(c.srcPort),
+		RevocationCheck: c.revocationCheck,
+		DestIP:          c.destIP,
+		DestPort:        "443",
+		Timestamp:       c.time.Format("2006-01-02T15:04:05.000000Z"),
+		SNI:             c.domain,
+	}
+
+	if c.revocationCheck == nil {
+		e.TLSError = &nfwTLSError{ErrorMessage: c.tlsError}
+	}
+
+	return nfwTLSLog{
+		FirewallName:     c.firewallName,
+		AvailabilityZone: c.availabilityZone,
+		EventTimestamp:   c.time.Unix(),
+		Event:            e,
+	}
+}
+
+// nfwTCPFlags returns the cumulative flags of a TCP flow, where single packet flows are bare SYNs.
+// Multi packet flows are closed with FIN or, when reset, with RST.
+func nfwTCPFlags(pkts int, reset bool) *eveTCP {
+	if pkts == 1 {
+		return &eveTCP{TCPFlags: "02", Syn: true}
+	}
+
+	flags := eveTCP{Syn: true, Ack: true, Psh: true, Rst: reset, Fin: !reset}
+
+	var value int
+	for bit, set := range []bool{flags.Fin, flags.Syn, flags.Rst, flags.Psh, flags.Ack} {
+		if set {
+			value |= 1 << bit
+		}
+	}
+	flags.TCPFlags = fmt.Sprintf("%02x", value)
+
+	return &flags
+}
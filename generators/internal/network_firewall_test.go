@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// refer examples of https://docs.aws.amazon.com/network-firewall/latest/developerguide/firewall-logging-contents.html
+const upstreamNFWAlert = `{"firewall_name":"test-firewall","availability_zone":"us-east-1b","event_timestamp":"1602627001","event":{"timestamp":"2020-10-13T22:10:01.006481+0000","flow_id":1582438383425873,"event_type":"alert","src_ip":"203.0.113.4","src_port":55555,"dest_ip":"192.0.2.16","dest_port":111,"proto":"TCP","alert":{"action":"allowed","signature_id":5,"rev":0,"signature":"test_tcp","category":"","severity":1}}}`
+const upstreamNFWFlow = `{"firewall_name":"test-firewall","availability_zone":"us-east-1b","event_timestamp":"1602627001","event":{"timestamp":"2020-10-13T22:10:01.006481+0000","flow_id":1582438383425873,"event_type":"netflow","src_ip":"203.0.113.4","src_port":55555,"dest_ip":"192.0.2.16","dest_port":111,"proto":"TCP","netflow":{"pkts":1,"bytes":60,"start":"2020-10-13T22:10:01.006481+0000","end":"2020-10-13T22:10:01.006481+0000","age":0,"min_ttl":61,"max_ttl":61},"tcp":{"tcp_flags":"02","syn":true}}}`
+
+func Test_buildNFWLogs(t *testing.T) {
+	c := nfwCustomizer{
+		firewallName:     "test-firewall",
+		availabilityZone: "us-east-1b",
+		time:             time.Date(2020, 10, 13, 22, 10, 1, 6481000, time.UTC),
+		flowID:           1582438383425873,
+		srcIP:            "203.0.113.4",
+		srcPort:          55555,
+		destIP:           "192.0.2.16",
+		service:          nfwService{proto: "TCP", port: 111},
+		pkts:             1,
+		bytes:            60,
+		ttl:              61,
+		signature:        eveSignature{id: 5, signature: "test_tcp", severity: 1},
+		alertAction:      "allowed",
+	}
+
+	t.Run("Validate AWS documented alert log", func(t *testing.T) {
+		line, err := json.Marshal(buildNFWAlertLog(c))
+		require.NoError(t, err)
+		require.Equal(t, upstreamNFWAlert, string(line))
+	})
+
+	t.Run("Validate AWS documented flow log", func(t *testing.T) {
+		line, err := json.Marshal(buildNFWFlowLog(c))
+		require.NoError(t, err)
+		require.Equal(t, upstreamNFWFlow, string(line))
+	})
+
+	t.Run("Alerts carry application details matching the signature", func(t *testing.T) {
+		for range 200 {
+			c := newNFWCustomizer("test-firewall", "us-east-1")
+			log := buildNFWAlertLog(c)
+
+			if c.signature.appProto != "" {
+				require.Equal(t, c.service.appProto, c.signature.appProto)
+			}
+			if c.signature.id <= 2 {
+				require.Equal(t, "blocked", log.Event.Alert.Action)
+			}
+			require.Equal(t, c.service.appProto == "tls", log.Event.TLS != nil)
+			require.Equal(t, c.service.appProto == "http", log.Event.HTTP != nil)
+		}
+	})
+
+	t.Run("TLS logs carry either a revocation check or an error", func(t *testing.T) {
+		for range 50 {
+			log := buildNFWTLSLog(newNFWCustomizer("test-firewall", "us-east-1"))
+			require.NotEqual(t, log.Event.RevocationCheck == nil, log.Event.TLSError == nil)
+		}
+	})
+}
+
+func Test_nfwTCPFlags(t *testing.T) {
+	require.Equal(t, &eveTCP{TCPFlags: "1b", Syn: true, Fin: true, Psh: true, Ack: true}, nfwTCPFlags(10, false))
+	require.Equal(t, &eveTCP{TCPFlags: "1e", Syn: true, Rst: true, Psh: true, Ack: true}, nfwTCPFlags(10, true))
+}
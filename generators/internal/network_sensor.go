@@ -0,0 +1,302 @@
+package internal
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	sensorServiceDNS  = "dns"
+	sensorServiceHTTP = "http"
+	sensorServiceTLS  = "ssl"
+
+	// sensorResolver is the internal DNS resolver queried by clients
+	sensorResolver = "10.0.0.2"
+)
+
+// sensorJA3Clients are JA3 strings of TLS client hellos offering TLS 1.2 & 1.3, ex:- browsers and curl.
+var sensorJA3Clients = []string{
+	"771,4865-4866-4867-49195-49199-49196-49200-52393-52392-49171-49172-156-157-47-53,0-23-65281-10-11-35-16-5-13-18-51-45-43-27-17513,29-23-24,0",
+	"771,4866-4867-4865-49196-49200-159-52393-52392-52394-49195-49199-158-49188-49192-107-49187-49191-103-49162-49172-57-49161-49171-51-157-156-61-60-53-47-255,0-11-10-13172-16-22-23-49-13-43-45-51,29-23-30-25-24,0-1-2",
+}
+
+// sensorDNS is a DNS query along with its response.
+type sensorDNS struct {
+	transID int
+	query   string
+	qtype   int
+	rcode   int
+	answers []string
+	ttl     int
+	rtt     time.Duration
+}
+
+// sensorHTTP is a single HTTP request & response of the connection.
+type sensorHTTP struct {
+	method    string
+	host      string
+	uri       string
+	referrer  string
+	userAgent string
+	status    int
+	bodyLen   int
+	mimeType  string
+}
+
+// sensorTLS is the TLS handshake of the connection. Certificates are only visible to sensors up to TLS 1.2.
+type sensorTLS struct {
+	version     string
+	cipher      string
+	curve       string
+	serverName  string
+	subject     string
+	issuer      string
+	serial      string
+	fingerprint string
+	notBefore   time.Time
+	notAfter    time.Time
+	// ja3 & ja3s are the JA3 strings of the client and server hellos
+	ja3  string
+	ja3s string
+}
+
+// sensorFile is a file transferred over the connection, ex:- an HTTP response body or an X.509 certificate.
+type sensorFile struct {
+	fuid     string
+	source   string
+	mimeType string
+	size     int
+	md5      string
+	sha1     string
+	sha256   string
+}
+
+// sensorConnection is a connection observed by a network sensor, shared by all log types of the connection.
+// Originator (orig) and responder (resp) naming follows Zeek, while Suricata logs them as source and destination.
+type sensorConnection struct {
+	uid       string
+	flowID    int64
+	start     time.Time
+	duration  time.Duration
+	origIP    string
+	origPort  int
+	respIP    string
+	respPort  int
+	proto     string
+	service   string
+	origPkts  int
+	respPkts  int
+	origBytes int
+	respBytes int
+	// state & history are the Zeek connection state and history of TCP flags and payloads
+	state   string
+	history string
+	dns     *sensorDNS
+	http    *sensorHTTP
+	tls     *sensorTLS
+	files   []sensorFile
+	alert   *eveSignature
+}
+
+func newSensorConnection() sensorConnection {
+	c := sensorConnection{
+		uid:      "C" + randomAZaz09String(17),
+		flowID:   rand.Int64N(1 << 51),
+		start:    time.Now().UTC().Add(-time.Duration(rand.IntN(5000)) * time.Millisecond),
+		origIP:   fmt.Sprintf("10.0.%d.%d", rand.IntN(4)+1, rand.IntN(250)+2),
+		origPort: rand.IntN(65535-49152) + 49152,
+		respIP:   randomIP(),
+		proto:    "tcp",
+		state:    "SF",
+		history:  "ShADadFf",
+	}
+	domain := []string{"www.", "api.", "cdn."}[rand.IntN(3)] + randomDomain()
+
+	switch n := rand.IntN(100); {
+	case n < 40:
+		c.proto, c.service, c.respIP, c.respPort = "udp", sensorServiceDNS, sensorResolver, 53
+		c.state, c.history = "SF", "Dd"
+		c.dns = &sensorDNS{
+			transID: rand.IntN(65536),
+			query:   domain,
+			qtype:   []int{1, 1, 1, 28, 16}[rand.IntN(5)],
+			ttl:     []int{60, 300, 3600}[rand.IntN(3)],
+			rtt:     time.Duration(rand.IntN(40000)+500) * time.Microsecond,
+		}
+		if rand.IntN(10) == 0 {
+			c.dns.rcode = 3
+		} else {
+			c.dns.answers = []string{randomIP()}
+			if c.dns.qtype == 28 {
+				c.dns.answers = []string{fmt.Sprintf("2606:4700::%x", rand.IntN(0xffff))}
+			} else if c.dns.qtype == 16 {
+				c.dns.answers = []string{"v=spf1 include:_spf." + randomDomain() + " ~all"}
+			}
+		}
+		c.duration = c.dns.rtt
+		c.origPkts, c.respPkts = 1, 1
+		c.origBytes = 17 + len(c.dns.query)
+		c.respBytes = c.origBytes + 16*len(c.dns.answers)
+	case n < 60:
+		c.service, c.respPort = sensorServiceHTTP, 80
+		c.http = &sensorHTTP{
+			method:    []string{"GET", "GET", "GET", "POST"}[rand.IntN(4)],
+			host:      domain,
+			uri:       httpRequestPaths[rand.IntN(len(httpRequestPaths))],
+			userAgent: httpRequestUserAgents[rand.IntN(len(httpRequestUserAgents))],
+			status:    []int{200, 200, 200, 301, 304, 404, 500}[rand.IntN(7)],
+		}
+		if rand.IntN(3) == 0 {
+			c.http.referrer = "https://" + domain + "/"
+		}
+		if c.http.status == 200 {
+			c.http.mimeType = []string{"text/html", "application/json", "image/png", "application/x-dosexec"}[rand.IntN(4)]
+			c.http.bodyLen = rand.IntN(200_000) + 200
+			c.files = append(c.files, newSensorFile("HTTP", c.http.mimeType, c.http.bodyLen))
+		}
+		c.origBytes = 80 + len(c.http.uri) + len(c.http.userAgent) + len(c.http.host)
+		c.respBytes = 200 + c.http.bodyLen
+	case n < 90:
+		c.service, c.respPort = sensorServiceTLS, 443
+		c.tls = &sensorTLS{
+			version:    []string{"TLSv12", "TLSv13", "TLSv13"}[rand.IntN(3)],
+			serverName: domain,
+			curve:      "x25519",
+			ja3:        sensorJA3Clients[rand.IntN(len(sensorJA3Clients))],
+			ja3s:       "771,4865,51-43",
+		}
+		c.tls.cipher = "TLS_AES_128_GCM_SHA256"
+		if c.tls.version == "TLSv12" {
+			c.tls.cipher = "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"
+			c.tls.ja3s = "771,49199,65281-0-11-35-23"
+			c.tls.subject = "CN=" + domain
+			c.tls.issuer = "C=US, O=Let's Encrypt, CN=R11"
+			c.tls.serial = strings.ToUpper(randomHexString(36))
+			c.tls.notBefore = c.start.Truncate(24*time.Hour).AddDate(0, 0, -rand.IntN(60))
+			c.tls.notAfter = c.tls.notBefore.AddDate(0, 0, 90)
+			cert := newSensorFile("SSL", "application/x-x509-user-cert", rand.IntN(800)+1200)
+			c.tls.fingerprint = cert.sha1
+			c.files = append(c.files, cert)
+		}
+		c.origBytes = rand.IntN(4000) + 500
+		c.respBytes = rand.IntN(200_000) + 4000
+	default:
+		// SSH or RDP, possibly unanswered or rejected
+		c.respPort = []int{22, 3389}[rand.IntN(2)]
+		c.origBytes = rand.IntN(4000) + 1000
+		c.respBytes = rand.IntN(8000) + 1000
+		switch rand.IntN(3) {
+		case 0:
+			c.state, c.history, c.origBytes, c.respBytes = "S0", "S", 0, 0
+		case 1:
+			c.state, c.history, c.origBytes, c.respBytes = "REJ", "Sr", 0, 0
+		}
+	}
+
+	if c.proto == "tcp" {
+		c.duration = time.Duration(rand.IntN(30_000)+5) * time.Millisecond
+		c.origPkts = c.origBytes/1400 + 4
+		c.respPkts = c.respBytes/1400 + 3
+		switch c.state {
+		case "S0":
+			c.duration, c.origPkts, c.respPkts = 0, 1, 0
+		case "REJ":
+			c.duration, c.origPkts, c.respPkts = time.Duration(rand.IntN(2000)+100)*time.Microsecond, 1, 1
+		}
+	}
+
+	// connections are logged once closed
+	c.start = c.start.Add(-c.duration)
+
+	// one in five connections raise an alert, with the transaction altered to match the rule
+	i := slices.IndexFunc(eveSignatures, func(s eveSignature) bool {
+		return s.appProto != "" && s.appProto == sensorAppProto(c.service, c.respPort)
+	})
+	if i >= 0 && rand.IntN(5) == 0 {
+		c.alert = &eveSignatures[i]
+		switch c.service {
+		case sensorServiceHTTP:
+			c.http.userAgent = "curl/8.4.0"
+		case sensorServiceDNS:
+			c.dns.query = strings.ToLower(randomAZ09String(16)) + ".onion.ws"
+		case sensorServiceTLS:
+			c.tls.serverName = "api.telegram.org"
+			if c.tls.subject != "" {
+				c.tls.subject = "CN=api.telegram.org"
+			}
+		}
+	}
+
+	return c
+}
+
+func newSensorFile(source string, mimeType string, size int) sensorFile {
+	content := []byte(randomHexString(32))
+	md5Sum, sha1Sum, sha256Sum := md5.Sum(content), sha1.Sum(content), sha256.Sum256(content)
+
+	return sensorFile{
+		fuid:     "F" + randomAZaz09String(17),
+		source:   source,
+		mimeType: mimeType,
+		size:     size,
+		md5:      hex.EncodeToString(md5Sum[:]),
+		sha1:     hex.EncodeToString(sha1Sum[:]),
+		sha256:   hex.EncodeToString(sha256Sum[:]),
+	}
+}
+
+// end returns the time the connection was closed.
+func (c sensorConnection) end() time.Time {
+	return c.start.Add(c.duration)
+}
+
+// sensorAppProto returns the Suricata app-layer protocol rules inspect for a Zeek service, ex:- tls for ssl.
+// SSH rules also inspect connections to port 22 without an established session, ex:- scans.
+func sensorAppProto(service string, respPort int) string {
+	switch {
+	case service == sensorServiceTLS:
+		return "tls"
+	case service == "" && respPort == 22:
+		return "ssh"
+	default:
+		return service
+	}
+}
+
+// sensorIPBytes returns the bytes of IP packets, including IP & transport headers.
+func sensorIPBytes(proto string, pkts int, payload int) int {
+	header := 40
+	if proto == "udp" {
+		header = 28
+	}
+	return pkts*header + payload
+}
+
+// dnsTypeName maps DNS query types to their names.
+func dnsTypeName(qtype int) string {
+	switch qtype {
+	case 1:
+		return "A"
+	case 16:
+		return "TXT"
+	case 28:
+		return "AAAA"
+	default:
+		return fmt.Sprintf("TYPE%d", qtype)
+	}
+}
+
+// dnsRcodeName maps DNS response codes to their names.
+func dnsRcodeName(rcode int) string {
+	if rcode == 3 {
+		return "NXDOMAIN"
+	}
+	return "NOERROR"
+}
@@ -2,43 +2,94 @@ package internal
 
 import (
 	"fmt"
-	"math/rand"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+
+	"data-gen/conf"
+)
+
+const (
+	nlbListenerTLS = "tls"
+	nlbListenerTCP = "tcp"
+	nlbListenerUDP = "udp"
+
+	nlbLogVersion        = "2.0"
+	nlbConnectionVersion = "1.0"
+	nlbTimeFormat        = "2006-01-02T15:04:05"
+
+	// UDP flows have no close handshake and end after the 120 seconds idle timeout of NLB
+	nlbUDPIdleTimeout = 120 * time.Second
 )
 
+var nlbTLS12Ciphers = []string{"ECDHE-RSA-AES128-GCM-SHA256", "ECDHE-RSA-AES256-GCM-SHA384", "ECDHE-ECDSA-AES128-GCM-SHA256", "ECDHE-RSA-AES128-SHA"}
+var nlbTLS13Ciphers = []string{"TLS_AES_128_GCM_SHA256", "TLS_AES_256_GCM_SHA384", "TLS_CHACHA20_POLY1305_SHA256"}
+var nlbNamedGroups = []string{"x25519", "secp256r1", "secp384r1"}
+
+// nlbTLSAlerts are TLS alert codes sent by clients on failed handshakes,
+// ex:- 40 handshake_failure, 42 bad_certificate, 46 certificate_unknown, 48 unknown_ca, 70 protocol_version
+var nlbTLSAlerts = []string{"40", "42", "46", "48", "70", "80"}
+
+// nlbTCPCloseReasons are reasons TCP connections end for, where a reset (rst) is sent by either peer.
+var nlbTCPCloseReasons = []string{"fin", "fin", "fin", "rst_client", "rst_target", "idle_timeout"}
+
+var nlbTCPTargetPorts = []int{22, 80, 5432, 6379}
+var nlbUDPTargetPorts = []int{53, 123, 514}
+
+// nlbALPNPolicies lists client ALPN preference lists with the protocols negotiated with client (fe) and target (be).
+var nlbALPNPolicies = []struct{ clientList, fe, be string }{
+	{`"h2","http/1.1"`, "h2", "h2"},
+	{`"h2","http/1.1"`, "h2", "http/1.1"},
+	{`"http/1.1"`, "http/1.1", "http/1.1"},
+	{"-", "-", "-"},
+}
+
 // NLBgen generates AWS Network Load Balancer TLS logs in standard format.
+// TCP and UDP listeners produce per-connection flow records, see buildNLBConnectionLine.
 type NLBgen struct {
-	buf trackedBuffer
+	buf           trackedBuffer
+	listenerTypes []string
 }
 
-func NewNLBGen() *NLBgen {
-	return &NLBgen{
-		buf: newTrackedBuffer(),
+// nlbCfg specifies the listener types to generate records for.
+type nlbCfg struct {
+	ListenerTypes []string `yaml:"listener_types"`
+}
+
+func NewNLBGen(input conf.InputConfig) (*NLBgen, error) {
+	cfg := nlbCfg{ListenerTypes: []string{nlbListenerTLS}}
+	err := input.Conf.Decode(&cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode nlb configuration: %w", err)
+	}
+
+	if len(cfg.ListenerTypes) == 0 {
+		return nil, fmt.Errorf("nlb listener_types must not be empty")
 	}
+
+	for _, t := range cfg.ListenerTypes {
+		if !slices.Contains([]string{nlbListenerTLS, nlbListenerTCP, nlbListenerUDP}, t) {
+			return nil, fmt.Errorf("unknown nlb listener type: %s", t)
+		}
+	}
+
+	return &NLBgen{
+		buf:           newTrackedBuffer(),
+		listenerTypes: cfg.ListenerTypes,
+	}, nil
 }
 
 func (a *NLBgen) Generate() (int64, error) {
-	customizer := nlbCustomizer{
-		time:              iso8601Now(),
-		name:              fmt.Sprintf("net/my-nlb/%s", randomID()),
-		elbID:             randomID(),
-		clientIPPort:      fmt.Sprintf("%s:%d", randomIP(), randomPort()),
-		destinationIPPort: fmt.Sprintf("%s:%d", randomIP(), randomPort()),
-		conMs:             rand.Intn(1000),
-		tlsHSMs:           rand.Intn(1000),
-		receivedBytes:     randomBytesSize(),
-		sentBytes:         randomBytesSize(),
-		tlsAlert:          "-",
-		certARN:           randomCertArn(),
-		cipher:            randomSSLCipher(),
-		protocol:          randomTLSProtocol(),
-		domain:            randomDomain(),
-		feProtocol:        "-",
-		beProtocol:        "-",
-		alpnList:          "-",
-		creationTime:      iso8601Now(),
+	var line string
+	switch listenerType := a.listenerTypes[rand.IntN(len(a.listenerTypes))]; listenerType {
+	case nlbListenerTLS:
+		line = buildNLBLogLine(newNLBCustomizer())
+	default:
+		line = buildNLBConnectionLine(newNLBConnectionCustomizer(listenerType))
 	}
 
-	err := a.buf.write([]byte(buildNLBLogLine(customizer)))
+	err := a.buf.write([]byte(line))
 	if err != nil {
 		return 0, err
 	}
@@ -50,10 +101,21 @@ func (a *NLBgen) GetAndReset() []byte {
 	return a.buf.getAndReset()
 }
 
+func (a *NLBgen) RecordTimestamp(record string) (time.Time, bool) {
+	// connection records are logged at the end of the connection
+	if strings.HasPrefix(record, nlbListenerTLS+" ") {
+		return fieldTimestamp(record, 2)
+	}
+
+	return fieldTimestamp(record, 3)
+}
+
 // helpers
 
-// nlbCustomizer holds all fields needed to construct an NLB TLS log entry.
+// nlbCustomizer holds all fields needed to construct an NLB log entry.
+// A negative tlsHSMs marks a failed TLS handshake.
 type nlbCustomizer struct {
+	listenerType      string
 	time              string
 	name              string
 	elbID             string
@@ -65,8 +127,10 @@ type nlbCustomizer struct {
 	sentBytes         int
 	tlsAlert          string
 	certARN           string
+	certSerial        string
 	cipher            string
 	protocol          string
+	namedGroup        string
 	domain            string
 	feProtocol        string
 	beProtocol        string
@@ -74,17 +138,150 @@ type nlbCustomizer struct {
 	creationTime      string
 }
 
+func newNLBCustomizer() nlbCustomizer {
+	now := time.Now().UTC()
+	region := randomRegion()
+	lbID := randomHexString(16)
+	conMs := rand.IntN(60_000) + 1
+
+	c := nlbCustomizer{
+		listenerType:      nlbListenerTLS,
+		time:              now.Format(nlbTimeFormat),
+		name:              fmt.Sprintf("net/my-nlb/%s", lbID),
+		elbID:             randomHexString(16),
+		clientIPPort:      fmt.Sprintf("%s:%d", randomIP(), randomPort()),
+		destinationIPPort: fmt.Sprintf("10.0.%d.%d:%d", rand.IntN(4), rand.IntN(254)+1, 443),
+		conMs:             conMs,
+		receivedBytes:     randomBytesSize(),
+		sentBytes:         randomBytesSize(),
+		tlsAlert:          "-",
+		certARN:           "-",
+		certSerial:        "-",
+		cipher:            "-",
+		protocol:          "-",
+		namedGroup:        "-",
+		domain:            "-",
+		feProtocol:        "-",
+		beProtocol:        "-",
+		alpnList:          "-",
+		creationTime:      now.Add(-time.Duration(conMs) * time.Millisecond).Format(nlbTimeFormat),
+	}
+
+	c.tlsHSMs = rand.IntN(min(conMs, 200)) + 1
+	c.certARN = fmt.Sprintf("arn:aws:acm:%s:%s:certificate/%s", region, randomSampleAccountID(), uuids[rand.IntN(len(uuids))])
+	c.certSerial = strings.ToUpper(randomHexString(32))
+	c.namedGroup = nlbNamedGroups[rand.IntN(len(nlbNamedGroups))]
+
+	if rand.IntN(2) == 0 {
+		c.protocol = "tlsv13"
+		c.cipher = nlbTLS13Ciphers[rand.IntN(len(nlbTLS13Ciphers))]
+	} else {
+		c.protocol = "tlsv12"
+		c.cipher = nlbTLS12Ciphers[rand.IntN(len(nlbTLS12Ciphers))]
+	}
+
+	if rand.IntN(4) > 0 {
+		// server name indication
+		c.domain = fmt.Sprintf("www.%s", randomDomain())
+	}
+
+	alpn := nlbALPNPolicies[rand.IntN(len(nlbALPNPolicies))]
+	c.alpnList, c.feProtocol, c.beProtocol = alpn.clientList, alpn.fe, alpn.be
+
+	// 10% chance of a failed handshake, where nothing is negotiated and no data is exchanged with the target
+	if rand.IntN(10) == 0 {
+		c.tlsHSMs = -1
+		c.conMs = rand.IntN(200) + 1
+		c.tlsAlert = nlbTLSAlerts[rand.IntN(len(nlbTLSAlerts))]
+		c.cipher, c.protocol, c.namedGroup = "-", "-", "-"
+		c.feProtocol, c.beProtocol = "-", "-"
+		c.receivedBytes = rand.IntN(600) + 100
+		c.sentBytes = rand.IntN(200)
+	}
+
+	return c
+}
+
 func buildNLBLogLine(input nlbCustomizer) string {
-	// Construct the log line
-	log := fmt.Sprintf(
+	return fmt.Sprintf(
 		"%s %s %s %s %s %s %s %d %d %d %d %s %s %s %s %s %s %s %s %s %s %s\n",
-		"tls", "2.0", input.time, input.name, input.elbID, input.clientIPPort, input.destinationIPPort, input.conMs,
+		input.listenerType, nlbLogVersion, input.time, input.name, input.elbID, input.clientIPPort, input.destinationIPPort, input.conMs,
 		input.tlsHSMs, input.receivedBytes, input.sentBytes,
-		input.tlsAlert, input.certARN, "-", // Placeholder for future fields
-		input.cipher, input.protocol, "-", // Placeholder for future fields
+		input.tlsAlert, input.certARN, input.certSerial,
+		input.cipher, input.protocol, input.namedGroup,
 		input.domain,
 		input.feProtocol, input.beProtocol, input.alpnList, input.creationTime,
 	)
+}
+
+// nlbConnectionCustomizer holds all fields needed to construct a TCP or UDP connection record.
+// Inbound counters are traffic from the client to the target, outbound counters the traffic back to the client.
+type nlbConnectionCustomizer struct {
+	listenerType string
+	start        time.Time
+	end          time.Time
+	name         string
+	listenerID   string
+	clientIP     string
+	clientPort   int
+	targetIP     string
+	targetPort   int
+	packetsIn    int
+	bytesIn      int
+	packetsOut   int
+	bytesOut     int
+	closeReason  string
+}
+
+func newNLBConnectionCustomizer(listenerType string) nlbConnectionCustomizer {
+	end := time.Now().UTC()
+
+	c := nlbConnectionCustomizer{
+		listenerType: listenerType,
+		name:         fmt.Sprintf("net/my-nlb/%s", randomHexString(16)),
+		listenerID:   randomHexString(16),
+		clientIP:     randomIP(),
+		clientPort:   randomPort(),
+		targetIP:     fmt.Sprintf("10.0.%d.%d", rand.IntN(4), rand.IntN(254)+1),
+		end:          end,
+	}
+
+	if listenerType == nlbListenerUDP {
+		// request & response datagrams, ex:- DNS or NTP, followed by the idle timeout
+		c.targetPort = nlbUDPTargetPorts[rand.IntN(len(nlbUDPTargetPorts))]
+		c.packetsIn = rand.IntN(4) + 1
+		c.packetsOut = rand.IntN(c.packetsIn + 1)
+		c.bytesIn = c.packetsIn * (rand.IntN(450) + 50)
+		c.bytesOut = c.packetsOut * (rand.IntN(900) + 50)
+		c.start = end.Add(-nlbUDPIdleTimeout - time.Duration(rand.IntN(5_000))*time.Millisecond)
+		c.closeReason = "idle_timeout"
+		return c
+	}
+
+	// handshake and close packets carry no payload, data packets up to the MSS
+	c.targetPort = nlbTCPTargetPorts[rand.IntN(len(nlbTCPTargetPorts))]
+	c.bytesIn = randomBytesSize()
+	c.bytesOut = randomBytesSize()
+	c.packetsIn = 3 + c.bytesIn/(rand.IntN(1_200)+260)
+	c.packetsOut = 2 + c.bytesOut/(rand.IntN(1_200)+260)
+	c.start = end.Add(-time.Duration(rand.IntN(300_000)+1) * time.Millisecond)
+	c.closeReason = nlbTCPCloseReasons[rand.IntN(len(nlbTCPCloseReasons))]
+	if c.closeReason == "idle_timeout" {
+		// TCP idle timeout of NLB defaults to 350 seconds
+		c.start = end.Add(-350*time.Second - time.Duration(rand.IntN(60_000))*time.Millisecond)
+	}
+
+	return c
+}
 
-	return log
+// buildNLBConnectionLine formats a TCP or UDP connection record, a space delimited flow record of the form
+// <type> <version> <start> <end> <load balancer> <listener> <client ip> <client port> <target ip> <target port>
+// <packets in> <bytes in> <packets out> <bytes out> <close reason>
+func buildNLBConnectionLine(input nlbConnectionCustomizer) string {
+	return fmt.Sprintf(
+		"%s %s %s %s %s %s %s %d %s %d %d %d %d %d %s\n",
+		input.listenerType, nlbConnectionVersion, input.start.Format(nlbTimeFormat), input.end.Format(nlbTimeFormat),
+		input.name, input.listenerID, input.clientIP, input.clientPort, input.targetIP, input.targetPort,
+		input.packetsIn, input.bytesIn, input.packetsOut, input.bytesOut, input.closeReason,
+	)
 }
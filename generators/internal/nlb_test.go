@@ -1,8 +1,11 @@
 package internal
 
 import (
+	"net"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -13,6 +16,7 @@ const upstreamNLB = `tls 2.0 2020-04-01T08:51:42 net/my-network-loadbalancer/c6e
 func Test_buildNLB(t *testing.T) {
 	t.Run("Validate AWS documented HTTP NLB line", func(t *testing.T) {
 		line := buildNLBLogLine(nlbCustomizer{
+			listenerType:      "tls",
 			time:              "2020-04-01T08:51:42",
 			name:              "net/my-network-loadbalancer/c6e77e28c25b2234",
 			elbID:             "g3d4b5e8bb8464cd",
@@ -24,8 +28,10 @@ func Test_buildNLB(t *testing.T) {
 			sentBytes:         246,
 			tlsAlert:          "-",
 			certARN:           "arn:aws:acm:us-east-2:671290407336:certificate/2a108f19-aded-46b0-8493-c63eb1ef4a99",
+			certSerial:        "-",
 			cipher:            "ECDHE-RSA-AES128-SHA",
 			protocol:          "tlsv12",
+			namedGroup:        "-",
 			domain:            "my-network-loadbalancer-c6e77e28c25b2234.elb.us-east-2.amazonaws.com",
 			feProtocol:        "h2",
 			beProtocol:        "h2",
@@ -35,4 +41,49 @@ func Test_buildNLB(t *testing.T) {
 
 		require.Equal(t, upstreamNLB, strings.TrimSpace(line))
 	})
+	t.Run("TCP and UDP listeners log connection records", func(t *testing.T) {
+		for _, listenerType := range []string{nlbListenerTCP, nlbListenerUDP} {
+			for range 50 {
+				fields := strings.Fields(buildNLBConnectionLine(newNLBConnectionCustomizer(listenerType)))
+				require.Len(t, fields, 15)
+				require.Equal(t, listenerType, fields[0])
+				require.Equal(t, nlbConnectionVersion, fields[1])
+
+				start, err := time.Parse(nlbTimeFormat, fields[2])
+				require.NoError(t, err)
+				end, err := time.Parse(nlbTimeFormat, fields[3])
+				require.NoError(t, err)
+				require.False(t, end.Before(start))
+
+				require.True(t, strings.HasPrefix(fields[4], "net/my-nlb/"))
+				require.NotNil(t, net.ParseIP(fields[6]))
+				require.NotNil(t, net.ParseIP(fields[8]))
+				// ports, packets and bytes
+				for _, i := range []int{7, 9, 10, 11, 12, 13} {
+					_, err = strconv.Atoi(fields[i])
+					require.NoError(t, err, fields[i])
+				}
+
+				switch listenerType {
+				case nlbListenerTCP:
+					require.Contains(t, nlbTCPCloseReasons, fields[14])
+				case nlbListenerUDP:
+					require.Equal(t, "idle_timeout", fields[14])
+				}
+			}
+		}
+	})
+
+	t.Run("Failed TLS handshakes log -1 handshake time", func(t *testing.T) {
+		for range 200 {
+			c := newNLBCustomizer()
+			if c.tlsHSMs < 0 {
+				require.NotEqual(t, "-", c.tlsAlert)
+				require.Equal(t, "-", c.cipher)
+				require.Equal(t, "-1", strings.Fields(buildNLBLogLine(c))[8])
+				return
+			}
+			require.LessOrEqual(t, c.tlsHSMs, c.conMs)
+		}
+	})
 }
@@ -0,0 +1,269 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	r53LogVersion = "1.100000"
+
+	r53ActionAllow = "ALLOW"
+	r53ActionBlock = "BLOCK"
+	r53ActionAlert = "ALERT"
+)
+
+// r53QueryTypes lists query types weighted towards address lookups.
+var r53QueryTypes = []string{"A", "A", "A", "A", "AAAA", "AAAA", "CNAME", "MX", "TXT", "PTR", "SRV", "NS"}
+
+// r53Rcodes lists response codes weighted towards successful resolution.
+var r53Rcodes = []string{"NOERROR", "NOERROR", "NOERROR", "NOERROR", "NOERROR", "NOERROR", "NOERROR", "NXDOMAIN", "NXDOMAIN", "SERVFAIL", "REFUSED"}
+
+// r53BlockResponses lists DNS Firewall block responses, ex:- NODATA answers with no records.
+var r53BlockResponses = []string{"NODATA", "NXDOMAIN", "OVERRIDE"}
+
+// r53FirewallDomains are domains matching DNS Firewall domain lists.
+var r53FirewallDomains = []string{"malware-c2.example.net", "crypto-miner.example.org", "phishing-login.example.com", "dga-x7k2q9.example.biz"}
+
+// Route53ResolverGen generates Amazon Route 53 Resolver query logs in JSON format.
+type Route53ResolverGen struct {
+	buf       trackedBuffer
+	accountID string
+	region    string
+	vpcID     string
+}
+
+func NewRoute53ResolverGen() *Route53ResolverGen {
+	return &Route53ResolverGen{
+		buf:       newTrackedBuffer(),
+		accountID: randomSampleAccountID(),
+		region:    randomRegion(),
+		vpcID:     "vpc-" + randomHexString(17),
+	}
+}
+
+func (r *Route53ResolverGen) Generate() (int64, error) {
+	marshaled, err := json.Marshal(buildRoute53ResolverLog(newRoute53ResolverCustomizer(r.accountID, r.region, r.vpcID)))
+	if err != nil {
+		return 0, err
+	}
+
+	// Resolver query logs are newline delimited
+	marshaled = append(marshaled, '\n')
+
+	err = r.buf.write(marshaled)
+	if err != nil {
+		return 0, err
+	}
+
+	return r.buf.size(), nil
+}
+
+func (r *Route53ResolverGen) GetAndReset() []byte {
+	return r.buf.getAndReset()
+}
+
+func (r *Route53ResolverGen) RecordTimestamp(record string) (time.Time, bool) {
+	return jsonTimestamp(record, "query_timestamp")
+}
+
+// route53ResolverLog is a Resolver query log record.
+// DNS Firewall fields are only present when a firewall rule matched the query.
+// See - https://docs.aws.amazon.com/Route53/latest/DeveloperGuide/resolver-query-logs-format.html
+type route53ResolverLog struct {
+	Version              string      `json:"version"`
+	AccountID            string      `json:"account_id"`
+	Region               string      `json:"region"`
+	VPCID                string      `json:"vpc_id"`
+	QueryTimestamp       string      `json:"query_timestamp"`
+	QueryName            string      `json:"query_name"`
+	QueryType            string      `json:"query_type"`
+	QueryClass           string      `json:"query_class"`
+	Rcode                string      `json:"rcode"`
+	Answers              []r53Answer `json:"answers"`
+	SrcAddr              string      `json:"srcaddr"`
+	SrcPort              string      `json:"srcport"`
+	Transport            string      `json:"transport"`
+	SrcIDs               r53SrcIDs   `json:"srcids"`
+	FirewallRuleAction   string      `json:"firewall_rule_action,omitempty"`
+	FirewallRuleGroupID  string      `json:"firewall_rule_group_id,omitempty"`
+	FirewallDomainListID string      `json:"firewall_domain_list_id,omitempty"`
+}
+
+type r53Answer struct {
+	Rdata string `json:"Rdata"`
+	Type  string `json:"Type"`
+	Class string `json:"Class"`
+}
+
+// r53SrcIDs identifies the query origin, an instance within the VPC or an inbound Resolver endpoint.
+type r53SrcIDs struct {
+	Instance         string `json:"instance,omitempty"`
+	ResolverEndpoint string `json:"resolver_endpoint,omitempty"`
+}
+
+// route53ResolverCustomizer holds parameters for generating a Resolver query log entry.
+type route53ResolverCustomizer struct {
+	accountID        string
+	region           string
+	vpcID            string
+	time             time.Time
+	queryName        string
+	queryType        string
+	rcode            string
+	answers          []r53Answer
+	srcAddr          string
+	srcPort          int
+	transport        string
+	instanceID       string
+	resolverEndpoint string
+	firewallAction   string
+	firewallGroupID  string
+	firewallListID   string
+}
+
+func newRoute53ResolverCustomizer(accountID, region, vpcID string) route53ResolverCustomizer {
+	c := route53ResolverCustomizer{
+		accountID: accountID,
+		region:    region,
+		vpcID:     vpcID,
+		time:      time.Now().UTC(),
+		queryType: r53QueryTypes[rand.IntN(len(r53QueryTypes))],
+		rcode:     r53Rcodes[rand.IntN(len(r53Rcodes))],
+		answers:   []r53Answer{},
+		srcAddr:   fmt.Sprintf("10.0.%d.%d", rand.IntN(4), rand.IntN(254)+1),
+		srcPort:   rand.IntN(65535-1024) + 1024,
+		transport: "UDP",
+	}
+
+	if rand.IntN(10) == 0 {
+		c.transport = "TCP"
+	}
+
+	// queries forwarded from on-premises networks arrive through an inbound endpoint
+	if rand.IntN(5) == 0 {
+		c.srcAddr = fmt.Sprintf("192.168.%d.%d", rand.IntN(8), rand.IntN(254)+1)
+		c.resolverEndpoint = "rslvr-in-" + randomHexString(17)
+	} else {
+		c.instanceID = "i-" + randomHexString(17)
+	}
+
+	c.queryName = r53QueryName(c.queryType)
+
+	// 10% of queries match a DNS Firewall rule
+	if rand.IntN(10) == 0 {
+		c.queryName = r53FirewallDomains[rand.IntN(len(r53FirewallDomains))] + "."
+		c.queryType = "A"
+		c.firewallGroupID = "rslvr-frg-" + randomHexString(17)
+		c.firewallListID = "rslvr-fdl-" + randomHexString(17)
+		c.firewallAction = []string{r53ActionBlock, r53ActionBlock, r53ActionAlert, r53ActionAllow}[rand.IntN(4)]
+
+		if c.firewallAction == r53ActionBlock {
+			c.rcode, c.answers = r53BlockedAnswer(r53BlockResponses[rand.IntN(len(r53BlockResponses))])
+			return c
+		}
+
+		c.rcode = "NOERROR"
+	}
+
+	if c.rcode == "NOERROR" {
+		c.answers = r53Answers(c.queryName, c.queryType)
+	}
+
+	return c
+}
+
+func buildRoute53ResolverLog(c route53ResolverCustomizer) route53ResolverLog {
+	return route53ResolverLog{
+		Version:        r53LogVersion,
+		AccountID:      c.accountID,
+		Region:         c.region,
+		VPCID:          c.vpcID,
+		QueryTimestamp: c.time.Format(time.RFC3339),
+		QueryName:      c.queryName,
+		QueryType:      c.queryType,
+		QueryClass:     "IN",
+		Rcode:          c.rcode,
+		Answers:        c.answers,
+		SrcAddr:        c.srcAddr,
+		SrcPort:        strconv.Itoa(c.srcPort),
+		Transport:      c.transport,
+		SrcIDs: r53SrcIDs{
+			Instance:         c.instanceID,
+			ResolverEndpoint: c.resolverEndpoint,
+		},
+		FirewallRuleAction:   c.firewallAction,
+		FirewallRuleGroupID:  c.firewallGroupID,
+		FirewallDomainListID: c.firewallListID,
+	}
+}
+
+// r53QueryName returns a fully qualified query name suiting the query type.
+func r53QueryName(queryType string) string {
+	domain := randomDomain()
+
+	switch queryType {
+	case "PTR":
+		return fmt.Sprintf("%d.%d.%d.%d.in-addr.arpa.", rand.IntN(254)+1, rand.IntN(256), rand.IntN(256), ipPrefix[rand.IntN(len(ipPrefix))])
+	case "SRV":
+		return fmt.Sprintf("_%s._tcp.%s.", []string{"ldap", "kerberos", "sip"}[rand.IntN(3)], domain)
+	case "MX", "TXT", "NS":
+		return domain + "."
+	default:
+		return []string{"www.", "api.", "cdn.", "db.internal."}[rand.IntN(4)] + domain + "."
+	}
+}
+
+// r53Answers returns resolved records, where CNAME lookups and aliased names carry the chain to the final records.
+func r53Answers(queryName, queryType string) []r53Answer {
+	switch queryType {
+	case "A":
+		answers := []r53Answer{}
+		if strings.HasPrefix(queryName, "cdn.") {
+			answers = append(answers, r53Answer{Rdata: fmt.Sprintf("d%s.cloudfront.net.", strings.ToLower(randomAZ09String(13))), Type: "CNAME", Class: "IN"})
+		}
+		for range rand.IntN(3) + 1 {
+			answers = append(answers, r53Answer{Rdata: randomIP(), Type: "A", Class: "IN"})
+		}
+		return answers
+	case "AAAA":
+		return []r53Answer{{Rdata: fmt.Sprintf("2600:1f18:%s:%s::%x", randomHexString(4), randomHexString(4), rand.IntN(0xffff)), Type: "AAAA", Class: "IN"}}
+	case "CNAME":
+		return []r53Answer{{Rdata: "lb-" + randomHexString(8) + ".elb.amazonaws.com.", Type: "CNAME", Class: "IN"}}
+	case "MX":
+		return []r53Answer{
+			{Rdata: "10 mail1." + queryName, Type: "MX", Class: "IN"},
+			{Rdata: "20 mail2." + queryName, Type: "MX", Class: "IN"},
+		}
+	case "TXT":
+		return []r53Answer{{Rdata: `"v=spf1 include:_spf.` + strings.TrimSuffix(queryName, ".") + ` ~all"`, Type: "TXT", Class: "IN"}}
+	case "PTR":
+		return []r53Answer{{Rdata: fmt.Sprintf("ec2-%s.compute.amazonaws.com.", strings.ReplaceAll(randomIP(), ".", "-")), Type: "PTR", Class: "IN"}}
+	case "SRV":
+		_, domain, _ := strings.Cut(queryName, "._tcp.")
+		return []r53Answer{{Rdata: "0 100 389 dc1." + domain, Type: "SRV", Class: "IN"}}
+	case "NS":
+		return []r53Answer{
+			{Rdata: fmt.Sprintf("ns-%d.awsdns-%02d.com.", rand.IntN(2048), rand.IntN(64)), Type: "NS", Class: "IN"},
+			{Rdata: fmt.Sprintf("ns-%d.awsdns-%02d.org.", rand.IntN(2048), rand.IntN(64)), Type: "NS", Class: "IN"},
+		}
+	}
+
+	return []r53Answer{}
+}
+
+// r53BlockedAnswer returns the response code and answers DNS Firewall sends for a block response.
+func r53BlockedAnswer(response string) (string, []r53Answer) {
+	switch response {
+	case "NXDOMAIN":
+		return "NXDOMAIN", []r53Answer{}
+	case "OVERRIDE":
+		return "NOERROR", []r53Answer{{Rdata: "blocked.walled-garden.example.com.", Type: "CNAME", Class: "IN"}}
+	default:
+		return "NOERROR", []r53Answer{}
+	}
+}
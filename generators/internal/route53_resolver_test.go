@@ -0,0 +1,62 @@
+package internal
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// refer example of https://docs.aws.amazon.com/Route53/latest/DeveloperGuide/resolver-query-logs-example-json.html
+const upstreamRoute53Resolver = `{"version":"1.100000","account_id":"111122223333","region":"us-east-1","vpc_id":"vpc-00000000000000000","query_timestamp":"2022-10-19T16:09:15Z","query_name":"example.com.","query_type":"A","query_class":"IN","rcode":"NOERROR","answers":[{"Rdata":"93.184.216.34","Type":"A","Class":"IN"}],"srcaddr":"10.0.0.1","srcport":"55555","transport":"UDP","srcids":{"instance":"i-00000000000000000"}}`
+
+func Test_buildRoute53ResolverLog(t *testing.T) {
+	t.Run("Validate AWS documented query log", func(t *testing.T) {
+		line, err := json.Marshal(buildRoute53ResolverLog(route53ResolverCustomizer{
+			accountID:  "111122223333",
+			region:     "us-east-1",
+			vpcID:      "vpc-00000000000000000",
+			time:       time.Date(2022, 10, 19, 16, 9, 15, 0, time.UTC),
+			queryName:  "example.com.",
+			queryType:  "A",
+			rcode:      "NOERROR",
+			answers:    []r53Answer{{Rdata: "93.184.216.34", Type: "A", Class: "IN"}},
+			srcAddr:    "10.0.0.1",
+			srcPort:    55555,
+			transport:  "UDP",
+			instanceID: "i-00000000000000000",
+		}))
+		require.NoError(t, err)
+		require.Equal(t, upstreamRoute53Resolver, string(line))
+	})
+
+	t.Run("Answers are consistent with rcode and firewall action", func(t *testing.T) {
+		for range 500 {
+			c := newRoute53ResolverCustomizer("111122223333", "us-east-1", "vpc-00000000000000000")
+			require.True(t, strings.HasSuffix(c.queryName, "."))
+			require.NotEqual(t, c.instanceID == "", c.resolverEndpoint == "")
+
+			if c.rcode != "NOERROR" {
+				require.Empty(t, c.answers)
+			}
+
+			switch c.firewallAction {
+			case "":
+				require.Empty(t, c.firewallGroupID)
+				if c.rcode == "NOERROR" {
+					require.NotEmpty(t, c.answers)
+				}
+			case r53ActionBlock:
+				require.Contains(t, []string{"NOERROR", "NXDOMAIN"}, c.rcode)
+				for _, answer := range c.answers {
+					require.Equal(t, "CNAME", answer.Type)
+				}
+			default:
+				require.Equal(t, "NOERROR", c.rcode)
+				require.NotEmpty(t, c.answers)
+			}
+		}
+	})
+}
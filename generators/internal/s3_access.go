@@ -0,0 +1,231 @@
+package internal
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// s3AccessOperations maps CloudTrail S3 event names to server access log operations,
+// keeping both generators consistent for the same kind of request.
+var s3AccessOperations = map[string]string{
+	"PutObject":    "REST.PUT.OBJECT",
+	"GetObject":    "REST.GET.OBJECT",
+	"DeleteObject": "REST.DELETE.OBJECT",
+	"ListObjects":  "REST.GET.BUCKET",
+}
+
+var s3AccessHTTPMethods = map[string]string{
+	"PutObject":    "PUT",
+	"GetObject":    "GET",
+	"DeleteObject": "DELETE",
+	"ListObjects":  "GET",
+}
+
+var s3AccessUserAgents = []string{
+	"aws-cli/2.15.0 Python/3.11.6 Linux/6.1.0 exe/x86_64.amzn.2 command/s3.cp",
+	"aws-sdk-go-v2/1.24.0 os/linux lang/go#1.21.5 md/GOOS#linux api/s3#1.47.7",
+	"Boto3/1.34.2 md/Botocore#1.34.2 Python/3.12.0 Linux/6.1.0",
+	"S3Console/0.4",
+}
+
+// s3AccessBucketCount is the number of buckets access logs are generated for.
+const s3AccessBucketCount = 5
+
+// S3AccessGen generates AWS S3 server access logs.
+// See - https://docs.aws.amazon.com/AmazonS3/latest/userguide/LogFormat.html
+type S3AccessGen struct {
+	buf     trackedBuffer
+	buckets []s3AccessBucket
+}
+
+// s3AccessBucket is a logged bucket, whose owner and region stay the same across log lines.
+type s3AccessBucket struct {
+	name   string
+	owner  string
+	region string
+}
+
+func NewS3AccessGen() *S3AccessGen {
+	buckets := make([]s3AccessBucket, 0, s3AccessBucketCount)
+	for len(buckets) < s3AccessBucketCount {
+		name := randomBucketName()
+		if slices.ContainsFunc(buckets, func(b s3AccessBucket) bool { return b.name == name }) {
+			continue
+		}
+
+		buckets = append(buckets, s3AccessBucket{
+			name:   name,
+			owner:  randomHexString(64),
+			region: randomRegion(),
+		})
+	}
+
+	return &S3AccessGen{
+		buf:     newTrackedBuffer(),
+		buckets: buckets,
+	}
+}
+
+func (s *S3AccessGen) Generate() (int64, error) {
+	bucket := s.buckets[rand.IntN(len(s.buckets))]
+
+	err := s.buf.write([]byte(buildS3AccessLogLine(newS3AccessCustomizer(bucket))))
+	if err != nil {
+		return 0, err
+	}
+
+	return s.buf.size(), nil
+}
+
+func (s *S3AccessGen) GetAndReset() []byte {
+	return s.buf.getAndReset()
+}
+
+func (s *S3AccessGen) RecordTimestamp(record string) (time.Time, bool) {
+	return bracketedTimestamp(record)
+}
+
+// s3AccessCustomizer holds all fields needed to construct an S3 server access log entry.
+type s3AccessCustomizer struct {
+	bucketOwner        string
+	bucket             string
+	time               time.Time
+	remoteIP           string
+	requester          string
+	requestID          string
+	operation          string
+	key                string
+	requestURI         string
+	httpStatus         int
+	errorCode          string
+	bytesSent          int
+	objectSize         int
+	totalTime          int
+	turnAroundTime     int
+	referer            string
+	userAgent          string
+	versionID          string
+	hostID             string
+	signatureVersion   string
+	cipherSuite        string
+	authenticationType string
+	hostHeader         string
+	tlsVersion         string
+	accessPointARN     string
+	aclRequired        string
+}
+
+func newS3AccessCustomizer(bucket s3AccessBucket) s3AccessCustomizer {
+	eventName := randomS3EventName()
+	accountID := randomSampleAccountID()
+
+	c := s3AccessCustomizer{
+		bucketOwner:        bucket.owner,
+		bucket:             bucket.name,
+		time:               time.Now().UTC(),
+		remoteIP:           randomIP(),
+		requester:          randomIAMArn(accountID, fmt.Sprintf("user%d", rand.IntN(10))),
+		requestID:          strings.ToUpper(randomHexString(16)),
+		operation:          s3AccessOperations[eventName],
+		key:                "-",
+		httpStatus:         200,
+		errorCode:          "-",
+		bytesSent:          0,
+		objectSize:         0,
+		totalTime:          rand.IntN(200) + 1,
+		referer:            "-",
+		userAgent:          s3AccessUserAgents[rand.IntN(len(s3AccessUserAgents))],
+		versionID:          "-",
+		hostID:             randomAZaz09String(76) + "=",
+		signatureVersion:   "SigV4",
+		cipherSuite:        randomSSLCipher(),
+		authenticationType: "AuthHeader",
+		hostHeader:         fmt.Sprintf("%s.s3.%s.amazonaws.com", bucket.name, bucket.region),
+		tlsVersion:         randomTLSProtocol(),
+		accessPointARN:     "-",
+		aclRequired:        "-",
+	}
+	c.turnAroundTime = rand.IntN(c.totalTime) + 1
+
+	path := "/" + bucket.name
+	switch eventName {
+	case "PutObject", "GetObject", "DeleteObject":
+		c.key = randomS3ObjectKey()
+		path = fmt.Sprintf("/%s/%s", bucket.name, c.key)
+	case "ListObjects":
+		path = fmt.Sprintf("/%s?list-type=2&prefix=&max-keys=1000", bucket.name)
+	}
+	c.requestURI = fmt.Sprintf("%s %s HTTP/1.1", s3AccessHTTPMethods[eventName], path)
+
+	switch eventName {
+	case "GetObject":
+		c.objectSize = rand.IntN(50_000_000) + 1
+		c.bytesSent = c.objectSize
+	case "PutObject":
+		c.objectSize = rand.IntN(50_000_000) + 1
+	case "DeleteObject":
+		c.httpStatus = 204
+	case "ListObjects":
+		c.bytesSent = rand.IntN(20_000) + 300
+	}
+
+	// pre-signed URL access uses query string authentication
+	if rand.IntN(10) == 0 {
+		c.authenticationType = "QueryString"
+	}
+
+	// anonymous requests carry no requester
+	if rand.IntN(20) == 0 {
+		c.requester = "-"
+		c.signatureVersion = "-"
+		c.authenticationType = "-"
+	}
+
+	// 10% chance of error
+	if rand.IntN(10) == 0 {
+		c.httpStatus, c.errorCode = randomS3AccessError(eventName)
+		c.bytesSent = 243
+		c.objectSize = 0
+	}
+
+	return c
+}
+
+func randomS3AccessError(eventName string) (int, string) {
+	if eventName == "GetObject" && rand.IntN(2) == 0 {
+		return 404, "NoSuchKey"
+	}
+
+	if rand.IntN(5) == 0 {
+		return 503, "SlowDown"
+	}
+
+	return 403, "AccessDenied"
+}
+
+func buildS3AccessLogLine(c s3AccessCustomizer) string {
+	return fmt.Sprintf(
+		"%s %s [%s] %s %s %s %s %s \"%s\" %d %s %s %s %d %s \"%s\" \"%s\" %s %s %s %s %s %s %s %s %s\n",
+		c.bucketOwner, c.bucket, c.time.Format("02/Jan/2006:15:04:05 -0700"),
+		c.remoteIP, c.requester, c.requestID, c.operation, c.key,
+		c.requestURI, c.httpStatus, c.errorCode,
+		s3AccessNumber(c.bytesSent), s3AccessNumber(c.objectSize),
+		c.totalTime, s3AccessNumber(c.turnAroundTime),
+		c.referer, c.userAgent, c.versionID, c.hostID,
+		c.signatureVersion, c.cipherSuite, c.authenticationType,
+		c.hostHeader, c.tlsVersion, c.accessPointARN, c.aclRequired,
+	)
+}
+
+// s3AccessNumber renders numeric fields, where S3 logs "-" for values that are not applicable.
+func s3AccessNumber(v int) string {
+	if v == 0 {
+		return "-"
+	}
+
+	return strconv.Itoa(v)
+}
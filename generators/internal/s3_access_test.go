@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// refer example of https://docs.aws.amazon.com/AmazonS3/latest/userguide/LogFormat.html
+const upstreamS3Access = "79a59df900b949e55d96a1e698fbacedfd6e09d98eacf8f8d5218e7cd47ef2be awsexamplebucket1 [06/Feb/2019:00:00:38 +0000] 192.0.2.3 79a59df900b949e55d96a1e698fbacedfd6e09d98eacf8f8d5218e7cd47ef2be 3E57427F3EXAMPLE REST.GET.VERSIONING - \"GET /awsexamplebucket1?versioning HTTP/1.1\" 200 - 113 - 7 - \"-\" \"S3Console/0.4\" - s9lzHYrFp76ZVxRcpX9+5cjAnEH2ROuNkd2BHfIa6UkFVdtjf5mKR3/eTPFvsiP/XV/VLi31234= SigV4 ECDHE-RSA-AES128-GCM-SHA256 AuthHeader awsexamplebucket1.s3.us-west-1.amazonaws.com TLSV1.2 arn:aws:s3:us-west-1:123456789012:accesspoint/example-AP Yes"
+
+func Test_buildS3AccessLogLine(t *testing.T) {
+	t.Run("Validate AWS documented access log line", func(t *testing.T) {
+		line := buildS3AccessLogLine(s3AccessCustomizer{
+			bucketOwner:        "79a59df900b949e55d96a1e698fbacedfd6e09d98eacf8f8d5218e7cd47ef2be",
+			bucket:             "awsexamplebucket1",
+			time:               time.Date(2019, 2, 6, 0, 0, 38, 0, time.UTC),
+			remoteIP:           "192.0.2.3",
+			requester:          "79a59df900b949e55d96a1e698fbacedfd6e09d98eacf8f8d5218e7cd47ef2be",
+			requestID:          "3E57427F3EXAMPLE",
+			operation:          "REST.GET.VERSIONING",
+			key:                "-",
+			requestURI:         "GET /awsexamplebucket1?versioning HTTP/1.1",
+			httpStatus:         200,
+			errorCode:          "-",
+			bytesSent:          113,
+			totalTime:          7,
+			referer:            "-",
+			userAgent:          "S3Console/0.4",
+			versionID:          "-",
+			hostID:             "s9lzHYrFp76ZVxRcpX9+5cjAnEH2ROuNkd2BHfIa6UkFVdtjf5mKR3/eTPFvsiP/XV/VLi31234=",
+			signatureVersion:   "SigV4",
+			cipherSuite:        "ECDHE-RSA-AES128-GCM-SHA256",
+			authenticationType: "AuthHeader",
+			hostHeader:         "awsexamplebucket1.s3.us-west-1.amazonaws.com",
+			tlsVersion:         "TLSV1.2",
+			accessPointARN:     "arn:aws:s3:us-west-1:123456789012:accesspoint/example-AP",
+			aclRequired:        "Yes",
+		})
+
+		require.Equal(t, upstreamS3Access, strings.TrimSuffix(line, "\n"))
+	})
+
+	t.Run("Generated line keeps request and operation consistent", func(t *testing.T) {
+		for range 50 {
+			c := newS3AccessCustomizer(s3AccessBucket{name: "bucket-001", owner: randomHexString(64), region: "us-east-1"})
+			require.Contains(t, c.requestURI, "/"+c.bucket)
+			if c.key != "-" {
+				require.True(t, strings.HasSuffix(c.requestURI, "/"+c.key+" HTTP/1.1"))
+				require.Contains(t, []string{"REST.GET.OBJECT", "REST.PUT.OBJECT", "REST.DELETE.OBJECT"}, c.operation)
+			}
+		}
+	})
+
+	t.Run("Bucket owner stays fixed per bucket", func(t *testing.T) {
+		gen := NewS3AccessGen()
+		require.Len(t, gen.buckets, s3AccessBucketCount)
+
+		for range 50 {
+			_, err := gen.Generate()
+			require.NoError(t, err)
+		}
+
+		owners := map[string]string{}
+		for _, line := range strings.Split(strings.TrimSuffix(string(gen.GetAndReset()), "\n"), "\n") {
+			fields := strings.Fields(line)
+			owner, bucket := fields[0], fields[1]
+			if previous, ok := owners[bucket]; ok {
+				require.Equal(t, previous, owner)
+			}
+			owners[bucket] = owner
+		}
+	})
+}
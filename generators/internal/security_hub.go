@@ -0,0 +1,509 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+
+	"data-gen/conf"
+
+	"github.com/google/uuid"
+)
+
+const (
+	asffSchemaVersion = "2018-10-08"
+	asffStandard      = "aws-foundational-security-best-practices/v/1.0.0"
+
+	asffWorkflowNew        = "NEW"
+	asffWorkflowNotified   = "NOTIFIED"
+	asffWorkflowSuppressed = "SUPPRESSED"
+	asffWorkflowResolved   = "RESOLVED"
+)
+
+// asffSeverityNormalized maps severity labels to the normalized score Security Hub controls report.
+var asffSeverityNormalized = map[string]int{"INFORMATIONAL": 0, "LOW": 1, "MEDIUM": 40, "HIGH": 70, "CRITICAL": 90}
+
+// asffThreatPurposes maps GuardDuty threat purposes to ASFF finding type namespaces and categories.
+var asffThreatPurposes = map[string]string{
+	"Backdoor":            "TTPs/Command and Control",
+	"CredentialAccess":    "TTPs/Credential Access",
+	"CryptoCurrency":      "Effects/Resource Consumption",
+	"Discovery":           "TTPs/Discovery",
+	"Execution":           "TTPs/Execution",
+	"Exfiltration":        "Effects/Data Exfiltration",
+	"Policy":              "Software and Configuration Checks/Policy",
+	"PrivilegeEscalation": "TTPs/Privilege Escalation",
+	"Recon":               "TTPs/Discovery",
+	"Trojan":              "TTPs/Exfiltration",
+	"UnauthorizedAccess":  "TTPs/Initial Access",
+}
+
+// securityHubControl is a Security Hub security control evaluating a resource type.
+type securityHubControl struct {
+	id           string
+	resource     string
+	resourceType string
+	severity     string
+	title        string
+	remediation  string
+}
+
+// securityHubControls lists AWS Foundational Security Best Practices controls per resource type.
+// See - https://docs.aws.amazon.com/securityhub/latest/userguide/securityhub-controls-reference.html
+var securityHubControls = []securityHubControl{
+	{"EC2.8", findingResourceEC2, "AwsEc2Instance", "HIGH", "EC2 instances should use Instance Metadata Service Version 2 (IMDSv2)", "ec2-controls.html#ec2-8"},
+	{"EC2.9", findingResourceEC2, "AwsEc2Instance", "HIGH", "Amazon EC2 instances should not have a public IPv4 address", "ec2-controls.html#ec2-9"},
+	{"EC2.19", findingResourceEC2, "AwsEc2SecurityGroup", "CRITICAL", "Security groups should not allow unrestricted access to ports with high risk", "ec2-controls.html#ec2-19"},
+	{"S3.5", findingResourceS3, "AwsS3Bucket", "MEDIUM", "S3 general purpose buckets should require requests to use SSL", "s3-controls.html#s3-5"},
+	{"S3.8", findingResourceS3, "AwsS3Bucket", "HIGH", "S3 general purpose buckets should block public access", "s3-controls.html#s3-8"},
+	{"S3.9", findingResourceS3, "AwsS3Bucket", "MEDIUM", "S3 general purpose bucket server access logging should be enabled", "s3-controls.html#s3-9"},
+	{"IAM.3", findingResourceIAM, "AwsIamUser", "MEDIUM", "IAM users' access keys should be rotated every 90 days or less", "iam-controls.html#iam-3"},
+	{"IAM.5", findingResourceIAM, "AwsIamUser", "MEDIUM", "MFA should be enabled for all IAM users that have a console password", "iam-controls.html#iam-5"},
+	{"IAM.6", findingResourceIAM, "AwsAccount", "CRITICAL", "Hardware MFA should be enabled for the root user", "iam-controls.html#iam-6"},
+	{"EKS.1", findingResourceEKS, "AwsEksCluster", "HIGH", "EKS cluster endpoints should not be publicly accessible", "eks-controls.html#eks-1"},
+	{"EKS.2", findingResourceEKS, "AwsEksCluster", "HIGH", "EKS clusters should run on a supported Kubernetes version", "eks-controls.html#eks-2"},
+}
+
+// SecurityHubGen generates AWS Security Hub findings in the AWS Security Finding Format (ASFF).
+// Findings are either security control evaluations or GuardDuty findings imported into Security Hub.
+type SecurityHubGen struct {
+	buf          trackedBuffer
+	delivery     string
+	controls     []securityHubControl
+	findingTypes []guardDutyFindingType
+	accountID    string
+	region       string
+	detectorID   string
+}
+
+func NewSecurityHubGen(input conf.InputConfig) (*SecurityHubGen, error) {
+	cfg, err := decodeFindingCfg(input, "security hub")
+	if err != nil {
+		return nil, err
+	}
+
+	var controls []securityHubControl
+	for _, control := range securityHubControls {
+		if slices.Contains(cfg.ResourceTypes, control.resource) {
+			controls = append(controls, control)
+		}
+	}
+
+	return &SecurityHubGen{
+		buf:          newTrackedBuffer(),
+		delivery:     cfg.DeliveryFormat,
+		controls:     controls,
+		findingTypes: guardDutyFindingTypesFor(cfg.ResourceTypes),
+		accountID:    randomSampleAccountID(),
+		region:       randomRegion(),
+		detectorID:   randomHexString(32),
+	}, nil
+}
+
+func (s *SecurityHubGen) Generate() (int64, error) {
+	var finding asffFinding
+	if rand.IntN(2) == 0 {
+		control := s.controls[rand.IntN(len(s.controls))]
+		finding = buildControlFinding(newControlFindingCustomizer(control, s.accountID, s.region))
+	} else {
+		findingType := s.findingTypes[rand.IntN(len(s.findingTypes))]
+		finding = asffFindingFromGuardDuty(buildGuardDutyFinding(newGuardDutyCustomizer(findingType, s.accountID, s.region, s.detectorID)), randomWorkflowStatus())
+	}
+
+	var record any = finding
+	if s.delivery == findingDeliveryEventBridge {
+		record = securityHubEventBridgeEvent(finding)
+	}
+
+	marshaled, err := json.Marshal(record)
+	if err != nil {
+		return 0, err
+	}
+
+	// findings are newline delimited
+	marshaled = append(marshaled, '\n')
+
+	err = s.buf.write(marshaled)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.buf.size(), nil
+}
+
+func (s *SecurityHubGen) GetAndReset() []byte {
+	return s.buf.getAndReset()
+}
+
+func (s *SecurityHubGen) RecordTimestamp(record string) (time.Time, bool) {
+	// EventBridge events carry the event time in the envelope
+	return jsonTimestamp(record, "time", "UpdatedAt")
+}
+
+// asffFinding is a finding in the AWS Security Finding Format.
+// See - https://docs.aws.amazon.com/securityhub/latest/userguide/securityhub-findings-format-syntax.html
+type asffFinding struct {
+	SchemaVersion         string                    `json:"SchemaVersion"`
+	ID                    string                    `json:"Id"`
+	ProductArn            string                    `json:"ProductArn"`
+	ProductName           string                    `json:"ProductName"`
+	CompanyName           string                    `json:"CompanyName"`
+	Region                string                    `json:"Region"`
+	GeneratorID           string                    `json:"GeneratorId"`
+	AwsAccountID          string                    `json:"AwsAccountId"`
+	Types                 []string                  `json:"Types"`
+	FirstObservedAt       string                    `json:"FirstObservedAt"`
+	LastObservedAt        string                    `json:"LastObservedAt"`
+	CreatedAt             string                    `json:"CreatedAt"`
+	UpdatedAt             string                    `json:"UpdatedAt"`
+	Severity              asffSeverity              `json:"Severity"`
+	Title                 string                    `json:"Title"`
+	Description           string                    `json:"Description"`
+	Remediation           *asffRemediation          `json:"Remediation,omitempty"`
+	ProductFields         map[string]string         `json:"ProductFields"`
+	Resources             []asffResource            `json:"Resources"`
+	Compliance            *asffCompliance           `json:"Compliance,omitempty"`
+	WorkflowState         string                    `json:"WorkflowState"`
+	Workflow              asffWorkflow              `json:"Workflow"`
+	RecordState           string                    `json:"RecordState"`
+	FindingProviderFields asffFindingProviderFields `json:"FindingProviderFields"`
+}
+
+type asffSeverity struct {
+	Label      string `json:"Label"`
+	Normalized int    `json:"Normalized"`
+	Original   string `json:"Original,omitempty"`
+	Product    any    `json:"Product,omitempty"`
+}
+
+type asffRemediation struct {
+	Recommendation asffRecommendation `json:"Recommendation"`
+}
+
+type asffRecommendation struct {
+	Text string `json:"Text"`
+	URL  string `json:"Url"`
+}
+
+type asffResource struct {
+	Type      string         `json:"Type"`
+	ID        string         `json:"Id"`
+	Partition string         `json:"Partition"`
+	Region    string         `json:"Region"`
+	Details   map[string]any `json:"Details,omitempty"`
+}
+
+type asffCompliance struct {
+	Status            string `json:"Status"`
+	SecurityControlID string `json:"SecurityControlId"`
+}
+
+type asffWorkflow struct {
+	Status string `json:"Status"`
+}
+
+type asffFindingProviderFields struct {
+	Severity asffProviderSeverity `json:"Severity"`
+	Types    []string             `json:"Types"`
+}
+
+type asffProviderSeverity struct {
+	Label    string `json:"Label"`
+	Original string `json:"Original"`
+}
+
+// controlFindingCustomizer holds parameters for generating a security control finding.
+type controlFindingCustomizer struct {
+	control        securityHubControl
+	resource       findingResource
+	id             string
+	passed         bool
+	workflowStatus string
+	archived       bool
+	firstObserved  time.Time
+	lastObserved   time.Time
+}
+
+// newControlFindingCustomizer derives a consistent workflow for the control evaluation.
+// Passed controls are resolved by Security Hub, while failed controls await triage or were suppressed.
+func newControlFindingCustomizer(control securityHubControl, accountID, region string) controlFindingCustomizer {
+	now := time.Now().UTC()
+
+	c := controlFindingCustomizer{
+		control:       control,
+		resource:      newFindingResource(control.resource, accountID, region),
+		id:            uuid.NewString(),
+		passed:        rand.IntN(3) == 0,
+		firstObserved: now.Add(-time.Duration(rand.IntN(30*24)+1) * time.Hour),
+		lastObserved:  now.Add(-time.Duration(rand.IntN(3600)) * time.Second),
+		// findings of deleted resources are archived
+		archived: rand.IntN(20) == 0,
+	}
+
+	c.workflowStatus = asffWorkflowResolved
+	if !c.passed {
+		c.workflowStatus = []string{asffWorkflowNew, asffWorkflowNew, asffWorkflowNotified, asffWorkflowSuppressed}[rand.IntN(4)]
+	}
+
+	return c
+}
+
+func buildControlFinding(c controlFindingCustomizer) asffFinding {
+	r := c.resource
+	generatorID := fmt.Sprintf("%s/%s", asffStandard, c.control.id)
+	types := []string{"Software and Configuration Checks/Industry and Regulatory Standards/AWS-Foundational-Security-Best-Practices"}
+
+	label, status := c.control.severity, "FAILED"
+	if c.passed {
+		label, status = "INFORMATIONAL", "PASSED"
+	}
+
+	recordState := "ACTIVE"
+	if c.archived {
+		recordState = "ARCHIVED"
+	}
+
+	return asffFinding{
+		SchemaVersion:   asffSchemaVersion,
+		ID:              fmt.Sprintf("arn:aws:securityhub:%s:%s:subscription/%s/finding/%s", r.region, r.accountID, generatorID, c.id),
+		ProductArn:      fmt.Sprintf("arn:aws:securityhub:%s::product/aws/securityhub", r.region),
+		ProductName:     "Security Hub",
+		CompanyName:     "AWS",
+		Region:          r.region,
+		GeneratorID:     generatorID,
+		AwsAccountID:    r.accountID,
+		Types:           types,
+		FirstObservedAt: c.firstObserved.Format(findingTimeFormat),
+		LastObservedAt:  c.lastObserved.Format(findingTimeFormat),
+		CreatedAt:       c.firstObserved.Format(findingTimeFormat),
+		UpdatedAt:       c.lastObserved.Format(findingTimeFormat),
+		Severity: asffSeverity{
+			Label:      label,
+			Normalized: asffSeverityNormalized[label],
+			Original:   label,
+		},
+		Title:       fmt.Sprintf("%s %s", c.control.id, c.control.title),
+		Description: fmt.Sprintf("This control checks whether %s.", strings.ToLower(c.control.title[:1])+c.control.title[1:]),
+		Remediation: &asffRemediation{Recommendation: asffRecommendation{
+			Text: "For information on how to correct this issue, consult the AWS Security Hub controls documentation.",
+			URL:  "https://docs.aws.amazon.com/securityhub/latest/userguide/" + c.control.remediation,
+		}},
+		ProductFields: map[string]string{
+			"RelatedAWSResources:0/type":        "AWS::Config::ConfigRule",
+			"aws/securityhub/ProductName":       "Security Hub",
+			"aws/securityhub/CompanyName":       "AWS",
+			"aws/securityhub/annotation":        status,
+			"aws/securityhub/FindingId":         fmt.Sprintf("arn:aws:securityhub:%s::product/aws/securityhub/%s", r.region, c.id),
+			"aws/securityhub/ProductArn":        fmt.Sprintf("arn:aws:securityhub:%s::product/aws/securityhub", r.region),
+			"aws/securityhub/SecurityControlId": c.control.id,
+		},
+		Resources:     []asffResource{asffResourceFor(r, c.control.resourceType)},
+		Compliance:    &asffCompliance{Status: status, SecurityControlID: c.control.id},
+		WorkflowState: asffWorkflowState(c.workflowStatus),
+		Workflow:      asffWorkflow{Status: c.workflowStatus},
+		RecordState:   recordState,
+		FindingProviderFields: asffFindingProviderFields{
+			Severity: asffProviderSeverity{Label: label, Original: label},
+			Types:    types,
+		},
+	}
+}
+
+// asffFindingFromGuardDuty converts a GuardDuty finding the way Security Hub imports it.
+// Archived GuardDuty findings are imported as archived records.
+func asffFindingFromGuardDuty(finding guardDutyFinding, workflowStatus string) asffFinding {
+	label := findingSeverityLabel(finding.Severity)
+	purpose, _, _ := strings.Cut(finding.Type, ":")
+	types := []string{fmt.Sprintf("%s/%s", asffThreatPurposes[purpose], strings.ReplaceAll(finding.Type, "/", "-"))}
+	productArn := fmt.Sprintf("arn:aws:securityhub:%s::product/aws/guardduty", finding.Region)
+
+	recordState := "ACTIVE"
+	if finding.Service.Archived {
+		recordState = "ARCHIVED"
+	}
+
+	resource := gdResourceToASFF(finding)
+
+	return asffFinding{
+		SchemaVersion:   asffSchemaVersion,
+		ID:              finding.Arn,
+		ProductArn:      productArn,
+		ProductName:     "GuardDuty",
+		CompanyName:     "Amazon",
+		Region:          finding.Region,
+		GeneratorID:     fmt.Sprintf("arn:aws:guardduty:%s:%s:detector/%s", finding.Region, finding.AccountID, finding.Service.DetectorID),
+		AwsAccountID:    finding.AccountID,
+		Types:           types,
+		FirstObservedAt: finding.Service.EventFirstSeen,
+		LastObservedAt:  finding.Service.EventLastSeen,
+		CreatedAt:       finding.CreatedAt,
+		UpdatedAt:       finding.UpdatedAt,
+		Severity: asffSeverity{
+			Label:      label,
+			Normalized: int(math.Round(finding.Severity * 10)),
+			Product:    finding.Severity,
+		},
+		Title:       finding.Title,
+		Description: finding.Description,
+		ProductFields: map[string]string{
+			"aws/guardduty/service/action/actionType": finding.Service.Action.ActionType,
+			"aws/guardduty/service/archived":          fmt.Sprintf("%t", finding.Service.Archived),
+			"aws/guardduty/service/count":             fmt.Sprintf("%d", finding.Service.Count),
+			"aws/guardduty/service/detectorId":        finding.Service.DetectorID,
+			"aws/guardduty/service/resourceRole":      finding.Service.ResourceRole,
+			"aws/securityhub/FindingId":               fmt.Sprintf("%s/%s", productArn, finding.Arn),
+			"aws/securityhub/ProductName":             "GuardDuty",
+			"aws/securityhub/CompanyName":             "Amazon",
+		},
+		Resources:     []asffResource{resource},
+		WorkflowState: asffWorkflowState(workflowStatus),
+		Workflow:      asffWorkflow{Status: workflowStatus},
+		RecordState:   recordState,
+		FindingProviderFields: asffFindingProviderFields{
+			Severity: asffProviderSeverity{Label: label, Original: fmt.Sprintf("%.1f", finding.Severity)},
+			Types:    types,
+		},
+	}
+}
+
+// gdResourceToASFF returns the ASFF resource of the GuardDuty finding resource.
+func gdResourceToASFF(finding guardDutyFinding) asffResource {
+	r := finding.Resource
+
+	switch {
+	case r.InstanceDetails != nil:
+		return asffResource{
+			Type:      "AwsEc2Instance",
+			ID:        fmt.Sprintf("arn:aws:ec2:%s:%s:instance/%s", finding.Region, finding.AccountID, r.InstanceDetails.InstanceID),
+			Partition: "aws",
+			Region:    finding.Region,
+			Details: map[string]any{"AwsEc2Instance": map[string]any{
+				"Type":          r.InstanceDetails.InstanceType,
+				"ImageId":       r.InstanceDetails.ImageID,
+				"IpV4Addresses": []string{r.InstanceDetails.NetworkInterfaces[0].PrivateIPAddress, r.InstanceDetails.NetworkInterfaces[0].PublicIP},
+				"VpcId":         r.InstanceDetails.NetworkInterfaces[0].VPCID,
+				"SubnetId":      r.InstanceDetails.NetworkInterfaces[0].SubnetID,
+				"LaunchedAt":    r.InstanceDetails.LaunchTime,
+			}},
+		}
+	case len(r.S3BucketDetails) > 0:
+		return asffResource{
+			Type:      "AwsS3Bucket",
+			ID:        r.S3BucketDetails[0].Arn,
+			Partition: "aws",
+			Region:    finding.Region,
+			Details: map[string]any{"AwsS3Bucket": map[string]any{
+				"OwnerId":   r.S3BucketDetails[0].Owner.ID,
+				"CreatedAt": r.S3BucketDetails[0].CreatedAt,
+			}},
+		}
+	case r.EKSClusterDetails != nil:
+		return asffResource{
+			Type:      "AwsEksCluster",
+			ID:        r.EKSClusterDetails.Arn,
+			Partition: "aws",
+			Region:    finding.Region,
+			Details: map[string]any{"AwsEksCluster": map[string]any{
+				"Name": r.EKSClusterDetails.Name,
+				"Arn":  r.EKSClusterDetails.Arn,
+			}},
+		}
+	default:
+		return asffResource{
+			Type:      "AwsIamAccessKey",
+			ID:        fmt.Sprintf("AWS::IAM::AccessKey:%s", r.AccessKeyDetails.AccessKeyID),
+			Partition: "aws",
+			Region:    finding.Region,
+			Details: map[string]any{"AwsIamAccessKey": map[string]any{
+				"PrincipalId":   r.AccessKeyDetails.PrincipalID,
+				"PrincipalName": r.AccessKeyDetails.UserName,
+				"PrincipalType": r.AccessKeyDetails.UserType,
+			}},
+		}
+	}
+}
+
+// asffResourceFor returns the ASFF resource of the given type for a shared finding resource.
+func asffResourceFor(r findingResource, resourceType string) asffResource {
+	resource := asffResource{
+		Type:      resourceType,
+		ID:        r.arn(),
+		Partition: "aws",
+		Region:    r.region,
+	}
+
+	switch resourceType {
+	case "AwsEc2Instance":
+		resource.Details = map[string]any{resourceType: map[string]any{
+			"Type":          r.instanceType,
+			"ImageId":       r.imageID,
+			"IpV4Addresses": []string{r.privateIP, r.publicIP},
+			"VpcId":         r.vpcID,
+			"SubnetId":      r.subnetID,
+			"LaunchedAt":    r.createdAt.Format(findingTimeFormat),
+		}}
+	case "AwsEc2SecurityGroup":
+		resource.ID = fmt.Sprintf("arn:aws:ec2:%s:%s:security-group/%s", r.region, r.accountID, r.securityGroupID)
+		resource.Details = map[string]any{resourceType: map[string]any{
+			"GroupName": "web-sg",
+			"GroupId":   r.securityGroupID,
+			"OwnerId":   r.accountID,
+			"VpcId":     r.vpcID,
+		}}
+	case "AwsS3Bucket":
+		resource.Details = map[string]any{resourceType: map[string]any{
+			"OwnerId":   randomHexString(64),
+			"CreatedAt": r.createdAt.Format(findingTimeFormat),
+		}}
+	case "AwsIamUser":
+		resource.Details = map[string]any{resourceType: map[string]any{
+			"UserName":   r.userName,
+			"UserId":     r.principalID,
+			"Path":       "/",
+			"CreateDate": r.createdAt.Format(findingTimeFormat),
+		}}
+	case "AwsAccount":
+		resource.ID = fmt.Sprintf("AWS::::Account:%s", r.accountID)
+	case "AwsEksCluster":
+		resource.Details = map[string]any{resourceType: map[string]any{
+			"Name":    r.clusterName,
+			"Arn":     r.arn(),
+			"Version": r.kubernetesVersion,
+		}}
+	}
+
+	return resource
+}
+
+// asffWorkflowState returns the deprecated WorkflowState matching a workflow status.
+func asffWorkflowState(status string) string {
+	switch status {
+	case asffWorkflowNotified:
+		return "ASSIGNED"
+	case asffWorkflowSuppressed:
+		return "DEFERRED"
+	case asffWorkflowResolved:
+		return "RESOLVED"
+	default:
+		return "NEW"
+	}
+}
+
+func randomWorkflowStatus() string {
+	return []string{asffWorkflowNew, asffWorkflowNew, asffWorkflowNotified, asffWorkflowSuppressed, asffWorkflowResolved}[rand.IntN(5)]
+}
+
+func securityHubEventBridgeEvent(finding asffFinding) eventBridgeEvent[asffFindings] {
+	updatedAt, _ := time.Parse(findingTimeFormat, finding.UpdatedAt)
+	return newFindingEventBridgeEvent("Security Hub Findings - Imported", "aws.securityhub", finding.AwsAccountID, finding.Region, updatedAt,
+		[]string{fmt.Sprintf("%s/%s", finding.ProductArn, finding.ID)}, asffFindings{Findings: []asffFinding{finding}})
+}
+
+// asffFindings is the EventBridge event detail of Security Hub findings.
+type asffFindings struct {
+	Findings []asffFinding `json:"findings"`
+}
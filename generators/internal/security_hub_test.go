@@ -0,0 +1,88 @@
+package internal
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"data-gen/conf"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func Test_buildControlFinding(t *testing.T) {
+	t.Run("Workflow and severity follow compliance status", func(t *testing.T) {
+		for _, control := range securityHubControls {
+			for range 50 {
+				c := newControlFindingCustomizer(control, "111122223333", "us-east-1")
+				finding := buildControlFinding(c)
+
+				require.Equal(t, asffSchemaVersion, finding.SchemaVersion)
+				require.Equal(t, control.id, finding.Compliance.SecurityControlID)
+				require.Equal(t, control.resourceType, finding.Resources[0].Type)
+				require.Equal(t, finding.Severity.Label, finding.FindingProviderFields.Severity.Label)
+				require.Equal(t, asffSeverityNormalized[finding.Severity.Label], finding.Severity.Normalized)
+				require.Equal(t, asffWorkflowState(finding.Workflow.Status), finding.WorkflowState)
+
+				if c.passed {
+					require.Equal(t, "PASSED", finding.Compliance.Status)
+					require.Equal(t, "INFORMATIONAL", finding.Severity.Label)
+					require.Equal(t, asffWorkflowResolved, finding.Workflow.Status)
+				} else {
+					require.Equal(t, "FAILED", finding.Compliance.Status)
+					require.Equal(t, control.severity, finding.Severity.Label)
+					require.NotEqual(t, asffWorkflowResolved, finding.Workflow.Status)
+				}
+			}
+		}
+	})
+}
+
+func Test_asffFindingFromGuardDuty(t *testing.T) {
+	t.Run("Imported findings keep GuardDuty severity, type and state", func(t *testing.T) {
+		for _, findingType := range guardDutyFindingTypes {
+			for range 20 {
+				gdFinding := buildGuardDutyFinding(newGuardDutyCustomizer(findingType, "111122223333", "us-east-1", "12abc34d567e8fa901bc2d34e56789f0"))
+				finding := asffFindingFromGuardDuty(gdFinding, asffWorkflowNotified)
+
+				require.Equal(t, "arn:aws:securityhub:us-east-1::product/aws/guardduty", finding.ProductArn)
+				require.Equal(t, gdFinding.Arn, finding.ID)
+				require.Equal(t, findingSeverityLabel(gdFinding.Severity), finding.Severity.Label)
+				require.Equal(t, gdFinding.Severity, finding.Severity.Product)
+				require.Len(t, finding.Types, 1)
+				require.False(t, strings.HasPrefix(finding.Types[0], "/"), finding.Types[0])
+				require.Equal(t, gdFinding.Service.Archived, finding.RecordState == "ARCHIVED")
+				require.Equal(t, "ASSIGNED", finding.WorkflowState)
+				require.Nil(t, finding.Compliance)
+				require.NotEmpty(t, finding.Resources[0].ID)
+			}
+		}
+	})
+}
+
+func Test_NewSecurityHubGen(t *testing.T) {
+	t.Run("EventBridge delivery wraps findings of selected resource types", func(t *testing.T) {
+		var input conf.InputConfig
+		require.NoError(t, yaml.Unmarshal([]byte("{resource_types: [eks], delivery_format: eventbridge}"), &input.Conf))
+
+		gen, err := NewSecurityHubGen(input)
+		require.NoError(t, err)
+
+		for range 20 {
+			_, err = gen.Generate()
+			require.NoError(t, err)
+
+			var event eventBridgeEvent[asffFindings]
+			require.NoError(t, json.Unmarshal(gen.GetAndReset(), &event))
+			require.Equal(t, "Security Hub Findings - Imported", event.DetailType)
+			require.Equal(t, "aws.securityhub", event.Source)
+			require.Len(t, event.Detail.Findings, 1)
+			require.Equal(t, "AwsEksCluster", event.Detail.Findings[0].Resources[0].Type)
+		}
+
+		require.NoError(t, yaml.Unmarshal([]byte("{resource_types: []}"), &input.Conf))
+		_, err = NewSecurityHubGen(input)
+		require.Error(t, err)
+	})
+}
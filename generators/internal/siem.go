@@ -0,0 +1,404 @@
+package internal
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"data-gen/conf"
+)
+
+const (
+	siemFormatCEF  = "CEF"
+	siemFormatLEEF = "LEEF"
+
+	// siemLEEFTimeFormat is the devTimeFormat of LEEF devTime attributes, a Java SimpleDateFormat pattern
+	siemLEEFTimeFormat = "MMM dd yyyy HH:mm:ss.SSS z"
+	siemLEEFTimeLayout = "Jan 02 2006 15:04:05.000 MST"
+)
+
+// siemDefaultProducts is the default device product of each source.
+var siemDefaultProducts = map[string]string{
+	conf.InputWAF: "WAF",
+	conf.InputVPC: "VPC Flow Logs",
+	conf.InputCT:  "CloudTrail",
+}
+
+var (
+	// headers escape pipes & backslashes, while CEF extension values escape equal signs, backslashes & newlines
+	siemHeaderEscaper   = strings.NewReplacer(`\`, `\\`, "|", `\|`)
+	cefExtensionEscaper = strings.NewReplacer(`\`, `\\`, "=", `\=`, "\r", `\r`, "\n", `\n`)
+	// LEEF attributes are tab delimited, hence values escape tabs along with backslashes & newlines
+	leefAttributeEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\r", `\r`, "\n", `\n`)
+)
+
+// SIEMGen generates ArcSight CEF or QRadar LEEF events, mapping WAF, VPC flow log or CloudTrail records
+// to the standard extension keys of the format.
+type SIEMGen struct {
+	buf      trackedBuffer
+	format   string
+	cfg      siemCfg
+	webACLID string
+	ctEvents []cloudTrailEventSpec
+}
+
+// siemCfg specifies the source records and the device reported in event headers.
+type siemCfg struct {
+	Source  string `yaml:"source"`
+	Vendor  string `yaml:"vendor"`
+	Product string `yaml:"product"`
+	Version string `yaml:"version"`
+}
+
+func NewSIEMGen(input conf.InputConfig) (*SIEMGen, error) {
+	cfg := siemCfg{Source: conf.InputWAF, Vendor: "AWS", Version: "1.0"}
+
+	err := input.Conf.Decode(&cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s configuration: %w", strings.ToLower(input.Type), err)
+	}
+
+	cfg.Source = strings.ToUpper(cfg.Source)
+	product, ok := siemDefaultProducts[cfg.Source]
+	if !ok {
+		return nil, fmt.Errorf("unknown %s source: %s", strings.ToLower(input.Type), cfg.Source)
+	}
+	if cfg.Product == "" {
+		cfg.Product = product
+	}
+
+	// insight events summarize API activity, rather than being security events of a caller
+	var ctEvents []cloudTrailEventSpec
+	for _, spec := range cloudTrailEvents {
+		if spec.category != ctCategoryInsight {
+			ctEvents = append(ctEvents, spec)
+		}
+	}
+
+	return &SIEMGen{
+		buf:      newTrackedBuffer(),
+		format:   input.Type,
+		cfg:      cfg,
+		webACLID: randomWAFACLID(),
+		ctEvents: ctEvents,
+	}, nil
+}
+
+func (s *SIEMGen) Generate() (int64, error) {
+	var event siemEvent
+	switch s.cfg.Source {
+	case conf.InputVPC:
+		event = siemEventOfVPC(newVPCCustomizer(5))
+	case conf.InputCT:
+		event = siemEventOfCloudTrail(cloudTrailRecordFor(newCloudTrailCustomizer(s.ctEvents[rand.IntN(len(s.ctEvents))])))
+	default:
+		event = siemEventOfWAF(buildWAFLogLine(newWAFCustomizer(s.webACLID)))
+	}
+
+	line := buildCEFEvent(s.cfg, event)
+	if s.format == siemFormatLEEF {
+		line = buildLEEFEvent(s.cfg, event)
+	}
+
+	err := s.buf.write([]byte(line + "\n"))
+	if err != nil {
+		return 0, err
+	}
+
+	return s.buf.size(), nil
+}
+
+func (s *SIEMGen) GetAndReset() []byte {
+	return s.buf.getAndReset()
+}
+
+func (s *SIEMGen) RecordTimestamp(record string) (time.Time, bool) {
+	// CEF rt in epoch milliseconds & LEEF devTime
+	if s.format == siemFormatLEEF {
+		return siemTimestamp(record, "devTime=", "\t")
+	}
+
+	return siemTimestamp(record, "rt=", " ")
+}
+
+// siemTimestamp parses the timestamp of a CEF extension or LEEF attribute, delimited by spaces or tabs respectively.
+// ex:- rt=1792405230123 or devTime=Oct 19 2026 10:20:30.123 UTC
+func siemTimestamp(line string, key string, delimiter string) (time.Time, bool) {
+	// the key follows the last header field or the delimiter, as keys such as start= end with rt=
+	idx := strings.Index(line, "|"+key)
+	if idx < 0 {
+		idx = strings.Index(line, delimiter+key)
+	}
+	if idx < 0 {
+		return time.Time{}, false
+	}
+
+	value, _, _ := strings.Cut(line[idx+1+len(key):], delimiter)
+	if t, err := time.Parse("Jan 02 2006 15:04:05.000 MST", value); err == nil {
+		return t, true
+	}
+
+	return parseTimestamp(value)
+}
+
+// siemEvent is a source record, normalized to the common parts of CEF & LEEF events.
+type siemEvent struct {
+	time        time.Time
+	signatureID string
+	name        string
+	severity    int
+	category    string
+	fields      []siemField
+}
+
+// siemField is an event value along with its CEF extension key and LEEF attribute key.
+// Fields without a key for the format are left out of its events.
+type siemField struct {
+	cef   string
+	leef  string
+	value string
+}
+
+// siemCustomString maps a value without a standard CEF key to the numbered cs<n> key, labelled by cs<n>Label.
+// LEEF allows custom attributes, hence the label is used as the attribute key.
+func siemCustomString(n int, label string, value string) []siemField {
+	return []siemField{
+		{cef: fmt.Sprintf("cs%dLabel", n), value: label},
+		{cef: fmt.Sprintf("cs%d", n), leef: label, value: value},
+	}
+}
+
+// siemCustomNumber is the numeric counterpart of siemCustomString, using cn<n> keys.
+func siemCustomNumber(n int, label string, value int) []siemField {
+	return []siemField{
+		{cef: fmt.Sprintf("cn%dLabel", n), value: label},
+		{cef: fmt.Sprintf("cn%d", n), leef: label, value: strconv.Itoa(value)},
+	}
+}
+
+func siemEventOfWAF(l wafLog) siemEvent {
+	e := siemEvent{
+		time:        time.UnixMilli(l.Timestamp),
+		signatureID: l.TerminatingRuleID,
+		category:    "waf",
+	}
+
+	switch l.Action {
+	case wafActionAllow:
+		e.name, e.severity = "Request allowed", 1
+	case wafActionBlock:
+		e.name, e.severity = "Request blocked", 7
+		if l.TerminatingRuleType == wafRuleTypeRateBased {
+			e.severity = 5
+		}
+	default:
+		e.name, e.severity = "Request challenged with "+strings.ToLower(l.Action), 4
+	}
+
+	var userAgent string
+	for _, header := range l.HTTPRequest.Headers {
+		if header.Name == "User-Agent" {
+			userAgent = header.Value
+		}
+	}
+
+	url := fmt.Sprintf("%s://%s%s", strings.ToLower(l.HTTPRequest.Scheme), l.HTTPRequest.Host, l.HTTPRequest.URI)
+	if l.HTTPRequest.Args != "" {
+		url += "?" + l.HTTPRequest.Args
+	}
+
+	var labels []string
+	for _, label := range l.Labels {
+		labels = append(labels, label.Name)
+	}
+
+	e.fields = []siemField{
+		{cef: "act", leef: "action", value: l.Action},
+		{cef: "src", leef: "src", value: l.HTTPRequest.ClientIP},
+		{cef: "requestMethod", leef: "requestMethod", value: l.HTTPRequest.HTTPMethod},
+		{cef: "request", leef: "url", value: url},
+		{cef: "requestClientApplication", leef: "userAgent", value: userAgent},
+		{cef: "app", leef: "app", value: l.HTTPRequest.HTTPVersion},
+		{cef: "externalId", leef: "requestId", value: l.HTTPRequest.RequestID},
+		{cef: "deviceExternalId", leef: "resource", value: l.HTTPSourceID},
+	}
+	e.fields = append(e.fields, siemCustomString(1, "webAclId", l.WebACLID)...)
+	e.fields = append(e.fields, siemCustomString(2, "terminatingRuleType", l.TerminatingRuleType)...)
+	e.fields = append(e.fields, siemCustomString(3, "country", l.HTTPRequest.Country)...)
+	e.fields = append(e.fields, siemCustomString(4, "httpSourceName", l.HTTPSourceName)...)
+	if len(labels) > 0 {
+		e.fields = append(e.fields, siemCustomString(5, "labels", strings.Join(labels, ","))...)
+	}
+
+	return e
+}
+
+func siemEventOfVPC(c vpcCustomizer) siemEvent {
+	e := siemEvent{
+		time:        time.Unix(c.End, 0),
+		signatureID: c.Action,
+		category:    "flow",
+	}
+
+	// NODATA and SKIPDATA records carry no traffic
+	if c.LogStatus != vpcStatusOK {
+		e.signatureID, e.name, e.severity = c.LogStatus, "No traffic captured", 2
+		if c.LogStatus == vpcStatusSkipData {
+			e.name = "Flow records skipped"
+		}
+		e.fields = []siemField{
+			{cef: "deviceInboundInterface", leef: "interface", value: c.InterfaceID},
+			{cef: "start", leef: "startTime", value: strconv.FormatInt(c.Start*1000, 10)},
+			{cef: "end", leef: "endTime", value: strconv.FormatInt(c.End*1000, 10)},
+		}
+		e.fields = append(e.fields, siemCustomString(1, "accountId", c.AccountID)...)
+		return e
+	}
+
+	e.name, e.severity = "Flow accepted", 1
+	if c.Action == "REJECT" {
+		e.name, e.severity = "Flow rejected", 5
+	}
+
+	// CEF deviceDirection is 0 for inbound and 1 for outbound traffic
+	direction, iface := "0", "deviceInboundInterface"
+	if c.FlowDirection == "egress" {
+		direction, iface = "1", "deviceOutboundInterface"
+	}
+
+	e.fields = []siemField{
+		{cef: "act", leef: "action", value: c.Action},
+		{cef: "src", leef: "src", value: c.SrcAddr},
+		{cef: "dst", leef: "dst", value: c.DstAddr},
+		{cef: "proto", leef: "proto", value: vpcProtocolName(c.Protocol)},
+		{cef: "deviceDirection", leef: "direction", value: direction},
+		{cef: iface, leef: "interface", value: c.InterfaceID},
+		{cef: "start", leef: "startTime", value: strconv.FormatInt(c.Start*1000, 10)},
+		{cef: "end", leef: "endTime", value: strconv.FormatInt(c.End*1000, 10)},
+		// flow log records are unidirectional, hence bytes & packets are sent by the source
+		{cef: "out", leef: "srcBytes", value: strconv.Itoa(c.Bytes)},
+	}
+	if c.Protocol != vpcProtocolICMP {
+		e.fields = append(e.fields,
+			siemField{cef: "spt", leef: "srcPort", value: strconv.Itoa(c.SrcPort)},
+			siemField{cef: "dpt", leef: "dstPort", value: strconv.Itoa(c.DstPort)},
+		)
+	}
+	if c.RejectReason != "" {
+		e.fields = append(e.fields, siemField{cef: "reason", leef: "reason", value: c.RejectReason})
+	}
+	packets := siemCustomNumber(1, "packets", c.Packets)
+	packets[1].leef = "srcPackets"
+	e.fields = append(e.fields, packets...)
+	e.fields = append(e.fields, siemCustomString(1, "accountId", c.AccountID)...)
+	e.fields = append(e.fields, siemCustomString(2, "vpcId", c.VpcID)...)
+	e.fields = append(e.fields, siemCustomString(3, "subnetId", c.SubnetID)...)
+	if c.InstanceID != "" {
+		e.fields = append(e.fields, siemCustomString(4, "instanceId", c.InstanceID)...)
+	}
+
+	return e
+}
+
+func siemEventOfCloudTrail(r cloudTrailRecord) siemEvent {
+	eventTime, _ := time.Parse(time.RFC3339Nano, r.EventTime)
+	e := siemEvent{
+		time:        eventTime,
+		signatureID: r.EventName,
+		name:        r.EventType + " " + r.EventName,
+		severity:    2,
+		category:    r.EventCategory,
+	}
+
+	outcome := "success"
+	switch {
+	case r.ErrorCode != "" || r.ErrorMessage != "":
+		e.severity, outcome = 6, "failure"
+	case r.ReadOnly != nil && !*r.ReadOnly:
+		e.severity = 4
+	}
+
+	// AWS services calling on behalf of the account are recorded as the source, rather than an IP address
+	src := []siemField{{cef: "shost", leef: "srcHost", value: r.SourceIPAddress}}
+	if net.ParseIP(r.SourceIPAddress) != nil {
+		src = []siemField{{cef: "src", leef: "src", value: r.SourceIPAddress}}
+	}
+
+	user := r.UserIdentity.UserName
+	if user == "" && r.UserIdentity.SessionContext != nil && r.UserIdentity.SessionContext.SessionIssuer != nil {
+		user = r.UserIdentity.SessionContext.SessionIssuer.UserName
+	}
+	if user == "" {
+		user = r.UserIdentity.InvokedBy
+	}
+
+	e.fields = append(src,
+		siemField{cef: "suser", leef: "usrName", value: user},
+		siemField{cef: "suid", leef: "principalId", value: r.UserIdentity.PrincipalID},
+		siemField{cef: "requestClientApplication", leef: "userAgent", value: r.UserAgent},
+		siemField{cef: "outcome", leef: "outcome", value: outcome},
+		siemField{cef: "reason", leef: "reason", value: r.ErrorCode},
+		siemField{cef: "msg", leef: "msg", value: r.ErrorMessage},
+		siemField{cef: "externalId", leef: "eventId", value: r.EventID},
+		siemField{cef: "deviceExternalId", leef: "accountName", value: r.RecipientAccountID},
+	)
+	e.fields = append(e.fields, siemCustomString(1, "eventSource", r.EventSource)...)
+	e.fields = append(e.fields, siemCustomString(2, "awsRegion", r.AwsRegion)...)
+	e.fields = append(e.fields, siemCustomString(3, "userIdentityType", r.UserIdentity.Type)...)
+	if r.UserIdentity.Arn != "" {
+		e.fields = append(e.fields, siemCustomString(4, "userIdentityArn", r.UserIdentity.Arn)...)
+	}
+
+	return e
+}
+
+// buildCEFEvent renders the event as CEF:Version|Device Vendor|Device Product|Device Version|Signature ID|Name|Severity|Extension.
+func buildCEFEvent(cfg siemCfg, e siemEvent) string {
+	extensions := []string{"rt=" + strconv.FormatInt(e.time.UnixMilli(), 10), "cat=" + cefExtensionEscaper.Replace(e.category)}
+	for _, f := range e.fields {
+		if f.cef == "" || f.value == "" {
+			continue
+		}
+		extensions = append(extensions, f.cef+"="+cefExtensionEscaper.Replace(f.value))
+	}
+
+	return fmt.Sprintf("CEF:0|%s|%s|%s|%s|%s|%d|%s",
+		siemHeaderEscaper.Replace(cfg.Vendor), siemHeaderEscaper.Replace(cfg.Product), siemHeaderEscaper.Replace(cfg.Version),
+		siemHeaderEscaper.Replace(e.signatureID), siemHeaderEscaper.Replace(e.name), e.severity, strings.Join(extensions, " "))
+}
+
+// buildLEEFEvent renders the event as LEEF:2.0|Vendor|Product|Version|EventID|DelimiterCharacter|Attributes, delimited by tabs.
+func buildLEEFEvent(cfg siemCfg, e siemEvent) string {
+	attributes := []string{
+		"devTime=" + e.time.UTC().Format(siemLEEFTimeLayout),
+		"devTimeFormat=" + siemLEEFTimeFormat,
+		"cat=" + leefAttributeEscaper.Replace(e.category),
+		"sev=" + strconv.Itoa(e.severity),
+	}
+	for _, f := range e.fields {
+		if f.leef == "" || f.value == "" {
+			continue
+		}
+		attributes = append(attributes, f.leef+"="+leefAttributeEscaper.Replace(f.value))
+	}
+
+	return fmt.Sprintf("LEEF:2.0|%s|%s|%s|%s|x09|%s",
+		siemHeaderEscaper.Replace(cfg.Vendor), siemHeaderEscaper.Replace(cfg.Product), siemHeaderEscaper.Replace(cfg.Version),
+		siemHeaderEscaper.Replace(e.signatureID), strings.Join(attributes, "\t"))
+}
+
+func vpcProtocolName(protocol int) string {
+	switch protocol {
+	case vpcProtocolTCP:
+		return "TCP"
+	case vpcProtocolUDP:
+		return "UDP"
+	case vpcProtocolICMP:
+		return "ICMP"
+	default:
+		return strconv.Itoa(protocol)
+	}
+}
@@ -0,0 +1,148 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"data-gen/conf"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func Test_buildSIEMEvent(t *testing.T) {
+	cfg := siemCfg{Vendor: "Acme|Corp", Product: "WAF", Version: `1.0\beta`}
+	e := siemEvent{
+		time:        time.Date(2026, 10, 19, 10, 20, 30, 123e6, time.UTC),
+		signatureID: "BlockSQLi",
+		name:        "Request blocked",
+		severity:    7,
+		category:    "waf",
+		fields: append([]siemField{
+			{cef: "act", leef: "action", value: "BLOCK"},
+			{cef: "request", leef: "url", value: `https://www.example.com/search?q=1=1|a\b`},
+			{cef: "msg", leef: "msg", value: "line1\nline2\tend"},
+			{cef: "reason", leef: "reason", value: ""},
+			{leef: "srcPackets", value: "10"},
+		}, siemCustomString(1, "country", "DE")...),
+	}
+
+	t.Run("CEF", func(t *testing.T) {
+		require.Equal(t, `CEF:0|Acme\|Corp|WAF|1.0\\beta|BlockSQLi|Request blocked|7|rt=1792405230123 cat=waf act=BLOCK `+
+			`request=https://www.example.com/search?q\=1\=1|a\\b msg=line1\nline2`+"\t"+`end cs1Label=country cs1=DE`, buildCEFEvent(cfg, e))
+	})
+
+	t.Run("LEEF", func(t *testing.T) {
+		require.Equal(t, `LEEF:2.0|Acme\|Corp|WAF|1.0\\beta|BlockSQLi|x09|`+strings.Join([]string{
+			"devTime=Oct 19 2026 10:20:30.123 UTC",
+			"devTimeFormat=MMM dd yyyy HH:mm:ss.SSS z",
+			"cat=waf",
+			"sev=7",
+			"action=BLOCK",
+			`url=https://www.example.com/search?q=1=1|a\\b`,
+			`msg=line1\nline2\tend`,
+			"srcPackets=10",
+			"country=DE",
+		}, "\t"), buildLEEFEvent(cfg, e))
+	})
+}
+
+func Test_siemEventOf(t *testing.T) {
+	keys := func(e siemEvent) map[string]string {
+		values := map[string]string{}
+		for _, f := range e.fields {
+			values[f.cef] = f.value
+		}
+		return values
+	}
+
+	t.Run("VPC flow", func(t *testing.T) {
+		c := vpcCustomizer{
+			AccountID:     "123456789012",
+			InterfaceID:   "eni-1235b8ca123456789",
+			SrcAddr:       "172.31.16.139",
+			DstAddr:       "172.31.16.21",
+			SrcPort:       20641,
+			DstPort:       22,
+			Protocol:      vpcProtocolTCP,
+			Packets:       20,
+			Bytes:         4249,
+			Start:         1418530010,
+			End:           1418530070,
+			Action:        "REJECT",
+			LogStatus:     vpcStatusOK,
+			FlowDirection: "egress",
+		}
+
+		e := siemEventOfVPC(c)
+		require.Equal(t, "REJECT", e.signatureID)
+		require.Equal(t, 5, e.severity)
+		require.Equal(t, time.Unix(1418530070, 0), e.time)
+
+		values := keys(e)
+		require.Equal(t, "TCP", values["proto"])
+		require.Equal(t, "20641", values["spt"])
+		require.Equal(t, "4249", values["out"])
+		require.Equal(t, "1", values["deviceDirection"])
+		require.Equal(t, "eni-1235b8ca123456789", values["deviceOutboundInterface"])
+		require.Equal(t, "packets", values["cn1Label"])
+		require.Equal(t, "20", values["cn1"])
+	})
+
+	t.Run("CloudTrail failure", func(t *testing.T) {
+		readOnly := false
+		r := cloudTrailRecord{
+			EventTime:       "2026-10-19T10:20:30.123456Z",
+			EventName:       "CreateAccessKey",
+			EventType:       eventType,
+			EventSource:     "iam.amazonaws.com",
+			ErrorCode:       "AccessDenied",
+			ReadOnly:        &readOnly,
+			SourceIPAddress: "autoscaling.amazonaws.com",
+			UserIdentity:    UserIdentity{Type: "AWSService", InvokedBy: "autoscaling.amazonaws.com"},
+		}
+
+		e := siemEventOfCloudTrail(r)
+		require.Equal(t, "AwsApiCall CreateAccessKey", e.name)
+		require.Equal(t, 6, e.severity)
+
+		values := keys(e)
+		require.Equal(t, "failure", values["outcome"])
+		require.Equal(t, "autoscaling.amazonaws.com", values["shost"])
+		require.NotContains(t, values, "src")
+		require.Equal(t, "autoscaling.amazonaws.com", values["suser"])
+	})
+}
+
+func Test_NewSIEMGen(t *testing.T) {
+	for _, format := range []string{conf.InputCEF, conf.InputLEEF} {
+		for _, source := range []string{conf.InputWAF, conf.InputVPC, "cloudtrail"} {
+			t.Run(format+" of "+source, func(t *testing.T) {
+				input := conf.InputConfig{Type: format}
+				require.NoError(t, yaml.Unmarshal([]byte("source: "+source+"\nvendor: Acme"), &input.Conf))
+
+				gen, err := NewSIEMGen(input)
+				require.NoError(t, err)
+
+				for range 100 {
+					_, err = gen.Generate()
+					require.NoError(t, err)
+				}
+
+				for _, line := range strings.Split(strings.TrimSuffix(string(gen.GetAndReset()), "\n"), "\n") {
+					require.True(t, strings.HasPrefix(line, map[string]string{conf.InputCEF: "CEF:0|Acme|", conf.InputLEEF: "LEEF:2.0|Acme|"}[format]))
+					require.Len(t, strings.Split(line, "|"), map[string]int{conf.InputCEF: 8, conf.InputLEEF: 7}[format], line)
+				}
+			})
+		}
+	}
+
+	t.Run("Invalid source", func(t *testing.T) {
+		input := conf.InputConfig{Type: conf.InputCEF}
+		require.NoError(t, yaml.Unmarshal([]byte("source: ALB"), &input.Conf))
+
+		_, err := NewSIEMGen(input)
+		require.ErrorContains(t, err, "unknown cef source: ALB")
+	})
+}
@@ -0,0 +1,319 @@
+package internal
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"data-gen/conf"
+)
+
+const (
+	suricataEventAlert = "alert"
+	suricataEventFlow  = "flow"
+	suricataEventDNS   = "dns"
+	suricataEventHTTP  = "http"
+	suricataEventTLS   = "tls"
+
+	suricataTimeFormat     = "2006-01-02T15:04:05.000000-0700"
+	suricataCertTimeFormat = "2006-01-02T15:04:05"
+)
+
+// suricataTLSVersions maps Zeek TLS version names to the Suricata ones.
+var suricataTLSVersions = map[string]string{"TLSv12": "TLS 1.2", "TLSv13": "TLS 1.3"}
+
+// SuricataGen generates Suricata EVE JSON alert, flow, dns, http and tls events.
+// Events of a connection share the flow ID, with app-layer events logged ahead of alerts and the flow event.
+type SuricataGen struct {
+	buf        trackedBuffer
+	eventTypes []string
+}
+
+// suricataCfg specifies the EVE event types to generate.
+type suricataCfg struct {
+	EventTypes []string `yaml:"event_types"`
+}
+
+func NewSuricataGen(input conf.InputConfig) (*SuricataGen, error) {
+	cfg := suricataCfg{
+		EventTypes: []string{suricataEventAlert, suricataEventFlow, suricataEventDNS, suricataEventHTTP, suricataEventTLS},
+	}
+	err := input.Conf.Decode(&cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode suricata configuration: %w", err)
+	}
+
+	if len(cfg.EventTypes) == 0 {
+		return nil, fmt.Errorf("suricata event_types must not be empty")
+	}
+
+	for _, t := range cfg.EventTypes {
+		if !slices.Contains([]string{suricataEventAlert, suricataEventFlow, suricataEventDNS, suricataEventHTTP, suricataEventTLS}, t) {
+			return nil, fmt.Errorf("unknown suricata event type: %s", t)
+		}
+	}
+
+	return &SuricataGen{
+		buf:        newTrackedBuffer(),
+		eventTypes: cfg.EventTypes,
+	}, nil
+}
+
+func (s *SuricataGen) Generate() (int64, error) {
+	// connections without events of the selected types are not logged, ex:- connections without alerts for alert events
+	var events []eveEvent
+	for len(events) == 0 {
+		events = suricataEvents(newSensorConnection(), s.eventTypes)
+	}
+
+	for _, e := range events {
+		marshaled, err := json.Marshal(e)
+		if err != nil {
+			return 0, err
+		}
+
+		err = s.buf.write(append(marshaled, '\n'))
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return s.buf.size(), nil
+}
+
+func (s *SuricataGen) GetAndReset() []byte {
+	return s.buf.getAndReset()
+}
+
+func (s *SuricataGen) RecordTimestamp(record string) (time.Time, bool) {
+	return jsonTimestamp(record, "timestamp")
+}
+
+// suricataEvents returns events of the connection for the given event types, in logging order.
+func suricataEvents(c sensorConnection, eventTypes []string) []eveEvent {
+	var events []eveEvent
+
+	dns, http, tls := suricataDNSEvents(c), suricataHTTPEvent(c), suricataTLSEvent(c)
+	if slices.Contains(eventTypes, suricataEventDNS) {
+		events = append(events, dns...)
+	}
+	if http != nil && slices.Contains(eventTypes, suricataEventHTTP) {
+		events = append(events, *http)
+	}
+	if tls != nil && slices.Contains(eventTypes, suricataEventTLS) {
+		events = append(events, *tls)
+	}
+
+	if c.alert != nil && slices.Contains(eventTypes, suricataEventAlert) {
+		// alerts carry app-layer metadata of the transaction matching the rule
+		e := c.event(suricataEventAlert, c.start)
+		switch {
+		case len(dns) > 0:
+			e = dns[0]
+		case http != nil:
+			e = *http
+		case tls != nil:
+			e = *tls
+		}
+
+		e.EventType = suricataEventAlert
+		e.Alert = &eveAlert{
+			Action:      "allowed",
+			GID:         1,
+			SignatureID: c.alert.id,
+			Rev:         c.alert.rev,
+			Signature:   c.alert.signature,
+			Category:    c.alert.category,
+			Severity:    c.alert.severity,
+		}
+		e.AppProto = suricataAppProto(c)
+		e.Direction = "to_server"
+
+		// alerts are raised mid-flow, with about half of the packets seen
+		toServer, toClient := max(1, c.origPkts/2), c.respPkts/2
+		e.Flow = eveFlowCounters{
+			PktsToserver:  toServer,
+			PktsToclient:  toClient,
+			BytesToserver: sensorIPBytes(c.proto, toServer, c.origBytes*toServer/c.origPkts),
+			BytesToclient: sensorIPBytes(c.proto, toClient, c.respBytes*toClient/max(1, c.respPkts)),
+			Start:         c.start.Format(suricataTimeFormat),
+		}
+		events = append(events, e)
+	}
+
+	if slices.Contains(eventTypes, suricataEventFlow) {
+		events = append(events, suricataFlowEvent(c))
+	}
+
+	return events
+}
+
+// event returns an event of the connection in the client to server direction.
+func (c sensorConnection) event(eventType string, t time.Time) eveEvent {
+	return eveEvent{
+		Timestamp: t.Format(suricataTimeFormat),
+		FlowID:    c.flowID,
+		InIface:   "eth0",
+		EventType: eventType,
+		SrcIP:     c.origIP,
+		SrcPort:   c.origPort,
+		DestIP:    c.respIP,
+		DestPort:  c.respPort,
+		Proto:     strings.ToUpper(c.proto),
+	}
+}
+
+// suricataAppProto returns the app-layer protocol detected for the connection, if any.
+func suricataAppProto(c sensorConnection) string {
+	switch {
+	case c.service == sensorServiceTLS:
+		return "tls"
+	case c.service != "":
+		return c.service
+	case c.state != "SF":
+		return ""
+	case c.respPort == 22:
+		return "ssh"
+	default:
+		return "rdp"
+	}
+}
+
+func suricataDNSEvents(c sensorConnection) []eveEvent {
+	d := c.dns
+	if d == nil {
+		return nil
+	}
+
+	txID := 0
+	query := c.event(suricataEventDNS, c.start)
+	query.TxID = &txID
+	query.DNS = &eveDNS{Type: "query", ID: d.transID, RD: true, RRName: d.query, RRType: dnsTypeName(d.qtype)}
+
+	answer := c.event(suricataEventDNS, c.start.Add(d.rtt))
+	answer.SrcIP, answer.SrcPort, answer.DestIP, answer.DestPort = c.respIP, c.respPort, c.origIP, c.origPort
+	answer.TxID = &txID
+	answer.DNS = &eveDNS{
+		Version: 2,
+		Type:    "answer",
+		ID:      d.transID,
+		Flags:   fmt.Sprintf("818%d", d.rcode),
+		QR:      true,
+		RD:      true,
+		RA:      true,
+		RRName:  d.query,
+		RRType:  dnsTypeName(d.qtype),
+		RCode:   dnsRcodeName(d.rcode),
+	}
+	for _, rdata := range d.answers {
+		answer.DNS.Answers = append(answer.DNS.Answers, eveDNSAnswer{RRName: d.query, RRType: dnsTypeName(d.qtype), TTL: d.ttl, RData: rdata})
+	}
+	if len(d.answers) > 0 {
+		answer.DNS.Grouped = map[string][]string{dnsTypeName(d.qtype): d.answers}
+	}
+
+	return []eveEvent{query, answer}
+}
+
+func suricataHTTPEvent(c sensorConnection) *eveEvent {
+	h := c.http
+	if h == nil {
+		return nil
+	}
+
+	txID := 0
+	e := c.event(suricataEventHTTP, c.start.Add(c.duration/10))
+	e.TxID = &txID
+	e.HTTP = &eveHTTP{
+		Hostname:        h.host,
+		URL:             h.uri,
+		HTTPUserAgent:   h.userAgent,
+		HTTPContentType: h.mimeType,
+		HTTPRefer:       h.referrer,
+		HTTPMethod:      h.method,
+		Protocol:        "HTTP/1.1",
+		Status:          h.status,
+		Length:          h.bodyLen,
+	}
+	return &e
+}
+
+func suricataTLSEvent(c sensorConnection) *eveEvent {
+	t := c.tls
+	if t == nil {
+		return nil
+	}
+
+	e := c.event(suricataEventTLS, c.start.Add(c.duration/20))
+	e.TLS = &eveTLS{
+		SNI:     t.serverName,
+		Version: suricataTLSVersions[t.version],
+		JA3:     suricataJA3Of(t.ja3),
+		JA3S:    suricataJA3Of(t.ja3s),
+	}
+
+	if t.subject != "" {
+		e.TLS.Subject = t.subject
+		e.TLS.IssuerDN = t.issuer
+		e.TLS.Serial = suricataColonHex(t.serial)
+		e.TLS.Fingerprint = suricataColonHex(t.fingerprint)
+		e.TLS.NotBefore = t.notBefore.Format(suricataCertTimeFormat)
+		e.TLS.NotAfter = t.notAfter.Format(suricataCertTimeFormat)
+	}
+	return &e
+}
+
+func suricataFlowEvent(c sensorConnection) eveEvent {
+	// flags seen to server (ts) and to client (tc), ex:- 1b for SYN, FIN, PSH & ACK
+	state := "established"
+	var tcp *eveTCP
+	switch {
+	case c.proto != "tcp":
+	case c.state == "S0":
+		state = "new"
+		tcp = &eveTCP{TCPFlags: "02", TCPFlagsTS: "02", TCPFlagsTC: "00", Syn: true, State: "syn_sent"}
+	case c.state == "REJ":
+		state = "closed"
+		tcp = &eveTCP{TCPFlags: "16", TCPFlagsTS: "02", TCPFlagsTC: "14", Syn: true, Rst: true, Ack: true, State: "closed"}
+	default:
+		state = "closed"
+		tcp = &eveTCP{TCPFlags: "1b", TCPFlagsTS: "1b", TCPFlagsTC: "1b", Syn: true, Fin: true, Psh: true, Ack: true, State: "closed"}
+	}
+
+	e := c.event(suricataEventFlow, c.end())
+	e.AppProto = suricataAppProto(c)
+	e.TCP = tcp
+	e.Flow = eveFlow{
+		eveFlowCounters: eveFlowCounters{
+			PktsToserver:  c.origPkts,
+			PktsToclient:  c.respPkts,
+			BytesToserver: sensorIPBytes(c.proto, c.origPkts, c.origBytes),
+			BytesToclient: sensorIPBytes(c.proto, c.respPkts, c.respBytes),
+			Start:         c.start.Format(suricataTimeFormat),
+		},
+		End:     c.end().Format(suricataTimeFormat),
+		Age:     int(c.end().Unix() - c.start.Unix()),
+		State:   state,
+		Reason:  "timeout",
+		Alerted: c.alert != nil,
+	}
+	return e
+}
+
+func suricataJA3Of(s string) *eveJA3 {
+	sum := md5.Sum([]byte(s))
+	return &eveJA3{Hash: hex.EncodeToString(sum[:]), String: s}
+}
+
+// suricataColonHex formats hex digits as colon separated bytes, ex:- 0A:1B:2C.
+func suricataColonHex(h string) string {
+	var pairs []string
+	for i := 0; i+1 < len(h); i += 2 {
+		pairs = append(pairs, h[i:i+2])
+	}
+	return strings.Join(pairs, ":")
+}
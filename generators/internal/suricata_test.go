@@ -0,0 +1,158 @@
+package internal
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"data-gen/conf"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func Test_suricataEvents(t *testing.T) {
+	start := time.Date(2026, 10, 19, 10, 20, 30, 123456000, time.UTC)
+	all := []string{suricataEventAlert, suricataEventFlow, suricataEventDNS, suricataEventHTTP, suricataEventTLS}
+
+	t.Run("DNS query raising an alert", func(t *testing.T) {
+		c := sensorConnection{
+			flowID:    1312985439030158,
+			start:     start,
+			duration:  30 * time.Millisecond,
+			origIP:    "10.0.1.15",
+			origPort:  52381,
+			respIP:    sensorResolver,
+			respPort:  53,
+			proto:     "udp",
+			service:   sensorServiceDNS,
+			origPkts:  1,
+			respPkts:  1,
+			origBytes: 42,
+			respBytes: 58,
+			state:     "SF",
+			dns:       &sensorDNS{transID: 4242, query: "abc.onion.ws", qtype: 1, answers: []string{"192.0.2.10"}, ttl: 300, rtt: 30 * time.Millisecond},
+			alert:     &eveSignatures[1],
+		}
+
+		events := suricataEvents(c, all)
+		require.Len(t, events, 4)
+		for i, eventType := range []string{"dns", "dns", "alert", "flow"} {
+			require.Equal(t, eventType, events[i].EventType)
+			require.Equal(t, c.flowID, events[i].FlowID)
+		}
+
+		answer := events[1]
+		require.Equal(t, sensorResolver, answer.SrcIP)
+		require.Equal(t, 2, answer.DNS.Version)
+		require.Equal(t, "NOERROR", answer.DNS.RCode)
+		require.Equal(t, map[string][]string{"A": {"192.0.2.10"}}, answer.DNS.Grouped)
+		require.Equal(t, "2026-10-19T10:20:30.153456+0000", answer.Timestamp)
+
+		alert := events[2]
+		require.Equal(t, 2027863, alert.Alert.SignatureID)
+		require.Equal(t, "query", alert.DNS.Type)
+		require.Equal(t, "to_server", alert.Direction)
+		require.Equal(t, "dns", alert.AppProto)
+
+		flow := events[3].Flow.(eveFlow)
+		require.True(t, flow.Alerted)
+		require.Equal(t, 70, flow.BytesToserver)
+		require.Nil(t, events[3].TCP)
+
+		require.Len(t, suricataEvents(c, []string{suricataEventHTTP, suricataEventTLS}), 0)
+	})
+
+	t.Run("TLS 1.2 handshake", func(t *testing.T) {
+		c := sensorConnection{
+			start:    start,
+			duration: 2 * time.Second,
+			proto:    "tcp",
+			service:  sensorServiceTLS,
+			state:    "SF",
+			origPkts: 4,
+			tls: &sensorTLS{
+				version:     "TLSv12",
+				serverName:  "www.example.com",
+				subject:     "CN=www.example.com",
+				serial:      "0A1B2C",
+				fingerprint: "aabbcc",
+				notBefore:   time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC),
+				ja3s:        "771,49199,65281-0-11-35-23",
+			},
+		}
+
+		events := suricataEvents(c, all)
+		require.Len(t, events, 2)
+
+		tls := events[0].TLS
+		require.Equal(t, "TLS 1.2", tls.Version)
+		require.Equal(t, "0A:1B:2C", tls.Serial)
+		require.Equal(t, "aa:bb:cc", tls.Fingerprint)
+		require.Equal(t, "2026-09-01T00:00:00", tls.NotBefore)
+		require.Equal(t, &eveJA3{Hash: "098e26e2609212ac1bfac552fbe04127", String: "771,49199,65281-0-11-35-23"}, tls.JA3S)
+
+		require.Equal(t, "tls", events[1].AppProto)
+		require.Equal(t, "1b", events[1].TCP.TCPFlags)
+		require.Equal(t, "2026-10-19T10:20:32.123456+0000", events[1].Timestamp)
+	})
+}
+
+func Test_NewSuricataGen(t *testing.T) {
+	t.Run("All event types", func(t *testing.T) {
+		gen, err := NewSuricataGen(conf.InputConfig{})
+		require.NoError(t, err)
+
+		for range 200 {
+			_, err = gen.Generate()
+			require.NoError(t, err)
+		}
+
+		// each connection ends with a flow event, sharing the flow ID of preceding events
+		var flowID int64
+		for _, line := range strings.Split(strings.TrimSuffix(string(gen.GetAndReset()), "\n"), "\n") {
+			var event eveEvent
+			require.NoError(t, json.Unmarshal([]byte(line), &event))
+			if flowID != 0 {
+				require.Equal(t, flowID, event.FlowID)
+			}
+
+			flowID = event.FlowID
+			if event.EventType == suricataEventFlow {
+				flowID = 0
+			}
+		}
+		require.Zero(t, flowID)
+	})
+
+	t.Run("Alerts only", func(t *testing.T) {
+		var input conf.InputConfig
+		require.NoError(t, yaml.Unmarshal([]byte("event_types: [alert]"), &input.Conf))
+
+		gen, err := NewSuricataGen(input)
+		require.NoError(t, err)
+
+		for range 20 {
+			_, err = gen.Generate()
+			require.NoError(t, err)
+		}
+
+		lines := strings.Split(strings.TrimSuffix(string(gen.GetAndReset()), "\n"), "\n")
+		require.Len(t, lines, 20)
+		for _, line := range lines {
+			var event eveEvent
+			require.NoError(t, json.Unmarshal([]byte(line), &event))
+			require.Equal(t, suricataEventAlert, event.EventType)
+			require.NotZero(t, event.Alert.SignatureID)
+		}
+	})
+
+	t.Run("Invalid event type", func(t *testing.T) {
+		var input conf.InputConfig
+		require.NoError(t, yaml.Unmarshal([]byte("event_types: [alert, anomaly]"), &input.Conf))
+
+		_, err := NewSuricataGen(input)
+		require.ErrorContains(t, err, "unknown suricata event type: anomaly")
+	})
+}
@@ -0,0 +1,396 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+
+	"data-gen/conf"
+)
+
+const (
+	syslogFormatRFC3164  = "rfc3164"
+	syslogFormatRFC5424  = "rfc5424"
+	syslogFormatJournald = "journald"
+
+	syslogRFC5424TimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+)
+
+// syslog facilities & severities of RFC 5424
+const (
+	syslogFacilityKern     = 0
+	syslogFacilityDaemon   = 3
+	syslogFacilityCron     = 9
+	syslogFacilityAuthPriv = 10
+
+	syslogSeverityAlert   = 1
+	syslogSeverityErr     = 3
+	syslogSeverityWarning = 4
+	syslogSeverityNotice  = 5
+	syslogSeverityInfo    = 6
+)
+
+// syslogProgram describes a process logging to syslog, along with its journald trusted fields.
+type syslogProgram struct {
+	ident    string
+	comm     string
+	exe      string
+	unit     string
+	facility int
+	// logPID is set for programs tagging messages with their PID
+	logPID bool
+	// transport is the journald transport the program logs through
+	transport string
+}
+
+var (
+	syslogSSHD    = syslogProgram{"sshd", "sshd", "/usr/sbin/sshd", "ssh.service", syslogFacilityAuthPriv, true, "syslog"}
+	syslogSudo    = syslogProgram{"sudo", "sudo", "/usr/bin/sudo", "", syslogFacilityAuthPriv, false, "syslog"}
+	syslogCron    = syslogProgram{"CRON", "cron", "/usr/sbin/cron", "cron.service", syslogFacilityCron, true, "syslog"}
+	syslogKernel  = syslogProgram{"kernel", "", "", "", syslogFacilityKern, false, "kernel"}
+	syslogSystemd = syslogProgram{"systemd", "systemd", "/usr/lib/systemd/systemd", "init.scope", syslogFacilityDaemon, true, "journal"}
+)
+
+// syslogService is a systemd unit running an application process.
+type syslogService struct {
+	unit        string
+	description string
+	comm        string
+	uid         int
+}
+
+var syslogServices = []syslogService{
+	{"nginx.service", "A high performance web server and a reverse proxy server", "nginx", 33},
+	{"postgresql@16-main.service", "PostgreSQL Cluster 16-main", "postgres", 113},
+	{"orders.service", "Order processing service", "java", 1001},
+	{"docker.service", "Docker Application Container Engine", "dockerd", 0},
+}
+
+var syslogHostnames = []string{"web-01", "web-02", "app-01", "db-01", "bastion-01"}
+var syslogLoginUsers = []string{"ubuntu", "ec2-user", "alice", "bob", "deploy"}
+var syslogInvalidUsers = []string{"admin", "test", "oracle", "postgres", "user", "guest", "ftpuser"}
+var syslogSudoCommands = []string{
+	"/usr/bin/systemctl restart nginx",
+	"/usr/bin/apt-get update",
+	"/usr/bin/tail -f /var/log/auth.log",
+	"/usr/bin/docker ps",
+	"/bin/cat /etc/shadow",
+	"/usr/bin/journalctl -u orders.service",
+}
+var syslogCronJobs = []string{
+	"/usr/local/bin/backup.sh",
+	"test -x /usr/sbin/anacron || { cd / && run-parts --report /etc/cron.hourly; }",
+	"/usr/lib/php/sessionclean",
+	"/usr/local/bin/rotate-logs --compress",
+}
+
+// SyslogGen generates Linux system logs of sshd, sudo, cron, kernel and systemd with RFC 3164, RFC 5424 or journald JSON format.
+// Each data point is a single event, which may log multiple messages (eg:- a service restart).
+type SyslogGen struct {
+	buf    trackedBuffer
+	format string
+	hosts  []*syslogHost
+}
+
+// syslogCfg specifies the output format of messages.
+type syslogCfg struct {
+	LogFormat string `yaml:"log_format"`
+}
+
+func NewSyslogGen(input conf.InputConfig) (*SyslogGen, error) {
+	cfg := syslogCfg{LogFormat: syslogFormatRFC3164}
+
+	err := input.Conf.Decode(&cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode syslog configuration: %w", err)
+	}
+
+	switch cfg.LogFormat {
+	case syslogFormatRFC3164, syslogFormatRFC5424, syslogFormatJournald:
+	default:
+		return nil, fmt.Errorf("unknown syslog log format: %s", cfg.LogFormat)
+	}
+
+	var hosts []*syslogHost
+	for _, name := range syslogHostnames {
+		hosts = append(hosts, newSyslogHost(name))
+	}
+
+	return &SyslogGen{
+		buf:    newTrackedBuffer(),
+		format: cfg.LogFormat,
+		hosts:  hosts,
+	}, nil
+}
+
+func (s *SyslogGen) Generate() (int64, error) {
+	host := s.hosts[rand.IntN(len(s.hosts))]
+
+	var lines []string
+	for _, m := range newSyslogEvent(host, time.Now().UTC()) {
+		line, err := buildSyslogLine(m, s.format)
+		if err != nil {
+			return 0, err
+		}
+		lines = append(lines, line)
+	}
+
+	err := s.buf.write([]byte(strings.Join(lines, "\n") + "\n"))
+	if err != nil {
+		return 0, err
+	}
+
+	return s.buf.size(), nil
+}
+
+func (s *SyslogGen) GetAndReset() []byte {
+	return s.buf.getAndReset()
+}
+
+func (s *SyslogGen) RecordTimestamp(record string) (time.Time, bool) {
+	switch s.format {
+	case syslogFormatJournald:
+		return jsonTimestamp(record, "__REALTIME_TIMESTAMP")
+	case syslogFormatRFC5424:
+		return fieldTimestamp(record, 1)
+	}
+
+	return rfc3164Timestamp(record)
+}
+
+// rfc3164Timestamp parses the timestamp following the PRI part, ex:- <38>Oct  9 22:33:20.
+// The year is not logged, hence the current year is assumed unless it results in a future timestamp.
+func rfc3164Timestamp(line string) (time.Time, bool) {
+	start := strings.IndexByte(line, '>') + 1
+	if start == 0 || len(line) < start+len(time.Stamp) {
+		return time.Time{}, false
+	}
+
+	t, err := time.Parse(time.Stamp, line[start:start+len(time.Stamp)])
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	now := time.Now().UTC()
+	t = t.AddDate(now.Year(), 0, 0)
+	if t.After(now.Add(24 * time.Hour)) {
+		t = t.AddDate(-1, 0, 0)
+	}
+
+	return t, true
+}
+
+// syslogHost is a machine of the fleet, with journal identifiers of its current boot.
+type syslogHost struct {
+	name      string
+	machineID string
+	bootID    string
+	booted    time.Time
+	// seqnum is the journal sequence number of the last message
+	seqnum uint64
+}
+
+func newSyslogHost(name string) *syslogHost {
+	return &syslogHost{
+		name:      name,
+		machineID: randomHexString(32),
+		bootID:    randomHexString(32),
+		booted:    time.Now().UTC().Add(-time.Duration(rand.IntN(30*24)+1) * time.Hour),
+		seqnum:    uint64(rand.IntN(1_000_000)),
+	}
+}
+
+// syslogMessage is a single message logged by a program.
+type syslogMessage struct {
+	time     time.Time
+	host     string
+	program  syslogProgram
+	severity int
+	// pid is the process ID of the program, zero for kernel messages
+	pid int
+	// unit is the systemd unit the message is about, set for systemd messages only
+	unit    string
+	message string
+	// journal fields of the host
+	machineID  string
+	bootID     string
+	monotonic  time.Duration
+	seqnum     uint64
+	cursorHash uint64
+}
+
+// newSyslogEvent returns the messages of a random event on the host, starting at the given time.
+func newSyslogEvent(h *syslogHost, t time.Time) []syslogMessage {
+	var messages []syslogMessage
+	add := func(p syslogProgram, severity, pid int, message string) {
+		h.seqnum++
+		messages = append(messages, syslogMessage{
+			time:       t,
+			host:       h.name,
+			program:    p,
+			severity:   severity,
+			pid:        pid,
+			message:    message,
+			machineID:  h.machineID,
+			bootID:     h.bootID,
+			monotonic:  t.Sub(h.booted),
+			seqnum:     h.seqnum,
+			cursorHash: rand.Uint64(),
+		})
+		// subsequent messages of the event are logged shortly after
+		t = t.Add(time.Duration(rand.IntN(5000)+1) * time.Microsecond)
+	}
+	addUnit := func(severity int, unit string, message string) {
+		add(syslogSystemd, severity, 1, message)
+		messages[len(messages)-1].unit = unit
+	}
+
+	pid := rand.IntN(60000) + 1000
+	ip := randomIP()
+	port := rand.IntN(65535-32768) + 32768
+	user := syslogLoginUsers[rand.IntN(len(syslogLoginUsers))]
+	service := syslogServices[rand.IntN(len(syslogServices))]
+
+	switch n := rand.IntN(100); {
+	case n < 30:
+		// password guessing against existing and invalid users
+		target := "root"
+		if rand.IntN(2) == 0 {
+			target = "invalid user " + syslogInvalidUsers[rand.IntN(len(syslogInvalidUsers))]
+		}
+		add(syslogSSHD, syslogSeverityInfo, pid, fmt.Sprintf("Failed password for %s from %s port %d ssh2", target, ip, port))
+	case n < 40:
+		invalid := syslogInvalidUsers[rand.IntN(len(syslogInvalidUsers))]
+		add(syslogSSHD, syslogSeverityInfo, pid, fmt.Sprintf("Invalid user %s from %s port %d", invalid, ip, port))
+		add(syslogSSHD, syslogSeverityInfo, pid, fmt.Sprintf("Connection closed by invalid user %s %s port %d [preauth]", invalid, ip, port))
+	case n < 52:
+		method := fmt.Sprintf("publickey for %s from %s port %d ssh2: ED25519 SHA256:%s", user, ip, port, randomAZaz09String(43))
+		if rand.IntN(5) == 0 {
+			method = fmt.Sprintf("password for %s from %s port %d ssh2", user, ip, port)
+		}
+		add(syslogSSHD, syslogSeverityInfo, pid, "Accepted "+method)
+		add(syslogSSHD, syslogSeverityInfo, pid, fmt.Sprintf("pam_unix(sshd:session): session opened for user %s(uid=1000) by (uid=0)", user))
+	case n < 64:
+		command := syslogSudoCommands[rand.IntN(len(syslogSudoCommands))]
+		add(syslogSudo, syslogSeverityNotice, pid, fmt.Sprintf("%8s : TTY=pts/%d ; PWD=/home/%s ; USER=root ; COMMAND=%s", user, rand.IntN(4), user, command))
+		add(syslogSudo, syslogSeverityInfo, pid, fmt.Sprintf("pam_unix(sudo:session): session opened for user root(uid=0) by %s(uid=1000)", user))
+	case n < 67:
+		command := syslogSudoCommands[rand.IntN(len(syslogSudoCommands))]
+		add(syslogSudo, syslogSeverityAlert, pid, fmt.Sprintf("%8s : 3 incorrect password attempts ; TTY=pts/%d ; PWD=/home/%s ; USER=root ; COMMAND=%s", user, rand.IntN(4), user, command))
+	case n < 85:
+		job := syslogCronJobs[rand.IntN(len(syslogCronJobs))]
+		add(syslogCron, syslogSeverityInfo, pid, "pam_unix(cron:session): session opened for user root(uid=0) by (uid=0)")
+		add(syslogCron, syslogSeverityInfo, pid+1, fmt.Sprintf("(root) CMD (%s)", job))
+		add(syslogCron, syslogSeverityInfo, pid, "pam_unix(cron:session): session closed for user root")
+	case n < 90:
+		add(syslogKernel, syslogSeverityWarning, 0, fmt.Sprintf(
+			"[UFW BLOCK] IN=eth0 OUT= MAC=%s SRC=%s DST=10.0.1.%d LEN=60 TOS=0x00 PREC=0x00 TTL=%d ID=%d DF PROTO=TCP SPT=%d DPT=%d WINDOW=64240 RES=0x00 SYN URGP=0",
+			ufwMACHeader(), ip, rand.IntN(254)+1, rand.IntN(64)+48, rand.IntN(65535), port, []int{22, 23, 445, 3389, 5432}[rand.IntN(5)]))
+	case n < 93:
+		rss := rand.IntN(4_000_000) + 500_000
+		add(syslogKernel, syslogSeverityErr, 0, fmt.Sprintf(
+			"Out of memory: Killed process %d (%s) total-vm:%dkB, anon-rss:%dkB, file-rss:0kB, shmem-rss:0kB, UID:%d pgtables:%dkB oom_score_adj:0",
+			pid, service.comm, rss*2, rss, service.uid, rss/512))
+		addUnit(syslogSeverityWarning, service.unit, service.unit+": A process of this unit has been killed by the OOM killer.")
+		addUnit(syslogSeverityNotice, service.unit, service.unit+": Main process exited, code=killed, status=9/KILL")
+		addUnit(syslogSeverityWarning, service.unit, service.unit+": Failed with result 'oom-kill'.")
+	default:
+		addUnit(syslogSeverityNotice, service.unit, service.unit+": Main process exited, code=exited, status=1/FAILURE")
+		addUnit(syslogSeverityWarning, service.unit, service.unit+": Failed with result 'exit-code'.")
+		addUnit(syslogSeverityInfo, service.unit, fmt.Sprintf("%s: Scheduled restart job, restart counter is at %d.", service.unit, rand.IntN(5)+1))
+		addUnit(syslogSeverityInfo, service.unit, fmt.Sprintf("Stopped %s - %s.", service.unit, service.description))
+		addUnit(syslogSeverityInfo, service.unit, fmt.Sprintf("Started %s - %s.", service.unit, service.description))
+	}
+
+	return messages
+}
+
+// journalEntry is a journal entry in `journalctl -o json` output, where all values are strings.
+type journalEntry struct {
+	Cursor             string `json:"__CURSOR"`
+	RealtimeTimestamp  string `json:"__REALTIME_TIMESTAMP"`
+	MonotonicTimestamp string `json:"__MONOTONIC_TIMESTAMP"`
+	BootID             string `json:"_BOOT_ID"`
+	MachineID          string `json:"_MACHINE_ID"`
+	Hostname           string `json:"_HOSTNAME"`
+	Transport          string `json:"_TRANSPORT"`
+	Priority           string `json:"PRIORITY"`
+	SyslogFacility     string `json:"SYSLOG_FACILITY"`
+	SyslogIdentifier   string `json:"SYSLOG_IDENTIFIER"`
+	SyslogPID          string `json:"SYSLOG_PID,omitempty"`
+	PID                string `json:"_PID,omitempty"`
+	UID                string `json:"_UID,omitempty"`
+	GID                string `json:"_GID,omitempty"`
+	Comm               string `json:"_COMM,omitempty"`
+	Exe                string `json:"_EXE,omitempty"`
+	SystemdUnit        string `json:"_SYSTEMD_UNIT,omitempty"`
+	Unit               string `json:"UNIT,omitempty"`
+	Message            string `json:"MESSAGE"`
+}
+
+// buildSyslogLine renders the message in the given format.
+// RFC 3164 & RFC 5424 lines are written as forwarded by rsyslog, with the PRI part of facility * 8 + severity.
+func buildSyslogLine(m syslogMessage, format string) (string, error) {
+	pri := m.program.facility*8 + m.severity
+
+	procID := "-"
+	tag := m.program.ident
+	if m.program.logPID {
+		procID = strconv.Itoa(m.pid)
+		tag = fmt.Sprintf("%s[%d]", m.program.ident, m.pid)
+	}
+
+	switch format {
+	case syslogFormatRFC5424:
+		return fmt.Sprintf("<%d>1 %s %s %s %s - - %s", pri, m.time.Format(syslogRFC5424TimeFormat), m.host, m.program.ident, procID, m.message), nil
+	case syslogFormatJournald:
+		entry := journalEntry{
+			Cursor: fmt.Sprintf("s=%s;i=%x;b=%s;m=%x;t=%x;x=%016x",
+				m.machineID, m.seqnum, m.bootID, m.monotonic.Microseconds(), m.time.UnixMicro(), m.cursorHash),
+			RealtimeTimestamp:  strconv.FormatInt(m.time.UnixMicro(), 10),
+			MonotonicTimestamp: strconv.FormatInt(m.monotonic.Microseconds(), 10),
+			BootID:             m.bootID,
+			MachineID:          m.machineID,
+			Hostname:           m.host,
+			Transport:          m.program.transport,
+			Priority:           strconv.Itoa(m.severity),
+			SyslogFacility:     strconv.Itoa(m.program.facility),
+			SyslogIdentifier:   m.program.ident,
+			Comm:               m.program.comm,
+			Exe:                m.program.exe,
+			SystemdUnit:        m.program.unit,
+			Unit:               m.unit,
+			Message:            m.message,
+		}
+		if m.program.logPID {
+			entry.SyslogPID = strconv.Itoa(m.pid)
+		}
+		// all programs other than the kernel run as root
+		if m.pid > 0 {
+			entry.PID = strconv.Itoa(m.pid)
+			entry.UID = "0"
+			entry.GID = "0"
+		}
+
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal journal entry: %w", err)
+		}
+		return string(line), nil
+	default:
+		return fmt.Sprintf("<%d>%s %s %s: %s", pri, m.time.Format(time.Stamp), m.host, tag, m.message), nil
+	}
+}
+
+// ufwMACHeader returns the destination MAC, source MAC and EtherType of an IPv4 frame, as logged by UFW.
+func ufwMACHeader() string {
+	var octets []string
+	for range 12 {
+		octets = append(octets, fmt.Sprintf("%02x", rand.IntN(256)))
+	}
+
+	return strings.Join(octets, ":") + ":08:00"
+}
@@ -0,0 +1,127 @@
+package internal
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"data-gen/conf"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func Test_buildSyslogLine(t *testing.T) {
+	m := syslogMessage{
+		time:       time.Date(2026, 10, 9, 10, 20, 30, 123456000, time.UTC),
+		host:       "bastion-01",
+		program:    syslogSSHD,
+		severity:   syslogSeverityInfo,
+		pid:        4123,
+		message:    "Failed password for root from 203.0.113.5 port 52344 ssh2",
+		machineID:  "8f2c6d0e4b1a4c3d9e7f6a5b4c3d2e1f",
+		bootID:     "1a2b3c4d5e6f40718293a4b5c6d7e8f9",
+		monotonic:  90 * time.Second,
+		seqnum:     26,
+		cursorHash: 255,
+	}
+
+	t.Run("RFC 3164", func(t *testing.T) {
+		line, err := buildSyslogLine(m, syslogFormatRFC3164)
+		require.NoError(t, err)
+		require.Equal(t, "<86>Oct  9 10:20:30 bastion-01 sshd[4123]: Failed password for root from 203.0.113.5 port 52344 ssh2", line)
+	})
+
+	t.Run("RFC 5424", func(t *testing.T) {
+		line, err := buildSyslogLine(m, syslogFormatRFC5424)
+		require.NoError(t, err)
+		require.Equal(t, "<86>1 2026-10-09T10:20:30.123456Z bastion-01 sshd 4123 - - Failed password for root from 203.0.113.5 port 52344 ssh2", line)
+	})
+
+	t.Run("Messages without PID", func(t *testing.T) {
+		sudo := m
+		sudo.program = syslogSudo
+		sudo.severity = syslogSeverityNotice
+		sudo.message = "   alice : TTY=pts/0 ; PWD=/home/alice ; USER=root ; COMMAND=/usr/bin/docker ps"
+
+		line, err := buildSyslogLine(sudo, syslogFormatRFC3164)
+		require.NoError(t, err)
+		require.Equal(t, "<85>Oct  9 10:20:30 bastion-01 sudo:    alice : TTY=pts/0 ; PWD=/home/alice ; USER=root ; COMMAND=/usr/bin/docker ps", line)
+
+		line, err = buildSyslogLine(sudo, syslogFormatRFC5424)
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(line, "<85>1 2026-10-09T10:20:30.123456Z bastion-01 sudo - - - "))
+	})
+
+	t.Run("journald JSON", func(t *testing.T) {
+		line, err := buildSyslogLine(m, syslogFormatJournald)
+		require.NoError(t, err)
+		require.Equal(t, `{"__CURSOR":"s=8f2c6d0e4b1a4c3d9e7f6a5b4c3d2e1f;i=1a;b=1a2b3c4d5e6f40718293a4b5c6d7e8f9;m=55d4a80;t=65d65b29f39c0;x=00000000000000ff",`+
+			`"__REALTIME_TIMESTAMP":"1791541230123456","__MONOTONIC_TIMESTAMP":"90000000","_BOOT_ID":"1a2b3c4d5e6f40718293a4b5c6d7e8f9",`+
+			`"_MACHINE_ID":"8f2c6d0e4b1a4c3d9e7f6a5b4c3d2e1f","_HOSTNAME":"bastion-01","_TRANSPORT":"syslog","PRIORITY":"6","SYSLOG_FACILITY":"10",`+
+			`"SYSLOG_IDENTIFIER":"sshd","SYSLOG_PID":"4123","_PID":"4123","_UID":"0","_GID":"0","_COMM":"sshd","_EXE":"/usr/sbin/sshd",`+
+			`"_SYSTEMD_UNIT":"ssh.service","MESSAGE":"Failed password for root from 203.0.113.5 port 52344 ssh2"}`, line)
+	})
+}
+
+func Test_newSyslogEvent(t *testing.T) {
+	host := newSyslogHost("web-01")
+
+	for range 500 {
+		seqnum := host.seqnum
+		messages := newSyslogEvent(host, time.Now().UTC())
+		require.NotEmpty(t, messages)
+
+		for i, m := range messages {
+			require.Equal(t, "web-01", m.host)
+			require.Equal(t, seqnum+uint64(i)+1, m.seqnum)
+			require.Positive(t, m.monotonic)
+			require.NotEmpty(t, m.message)
+
+			// only the kernel logs without a process
+			require.Equal(t, m.program == syslogKernel, m.pid == 0)
+			if m.program == syslogSystemd {
+				require.Equal(t, 1, m.pid)
+				require.NotEmpty(t, m.unit)
+			}
+			if i > 0 {
+				require.True(t, m.time.After(messages[i-1].time))
+			}
+		}
+	}
+}
+
+func Test_NewSyslogGen(t *testing.T) {
+	t.Run("journald entries", func(t *testing.T) {
+		var input conf.InputConfig
+		require.NoError(t, yaml.Unmarshal([]byte("log_format: journald"), &input.Conf))
+
+		gen, err := NewSyslogGen(input)
+		require.NoError(t, err)
+
+		for range 100 {
+			_, err = gen.Generate()
+			require.NoError(t, err)
+		}
+
+		for _, line := range strings.Split(strings.TrimSuffix(string(gen.GetAndReset()), "\n"), "\n") {
+			var entry map[string]string
+			require.NoError(t, json.Unmarshal([]byte(line), &entry))
+
+			priority, err := strconv.Atoi(entry["PRIORITY"])
+			require.NoError(t, err)
+			require.LessOrEqual(t, priority, 7)
+			require.Contains(t, syslogHostnames, entry["_HOSTNAME"])
+		}
+	})
+
+	t.Run("Invalid log format", func(t *testing.T) {
+		var input conf.InputConfig
+		require.NoError(t, yaml.Unmarshal([]byte("log_format: gelf"), &input.Conf))
+
+		_, err := NewSyslogGen(input)
+		require.ErrorContains(t, err, "unknown syslog log format")
+	})
+}
@@ -0,0 +1,85 @@
+package internal
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Generators implement RecordTimestamp(record string) (time.Time, bool) to expose the timestamp carried by a single
+// generated record, used by outputs stamping events with record timestamps such as CloudWatch Logs.
+// Helpers below parse the timestamp formats shared by generators.
+
+// jsonTimestamp returns the timestamp of the first present top level key of a JSON record.
+func jsonTimestamp(record string, keys ...string) (time.Time, bool) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(record), &fields); err != nil {
+		return time.Time{}, false
+	}
+
+	for _, key := range keys {
+		raw, ok := fields[key]
+		if !ok {
+			continue
+		}
+
+		var str string
+		if err := json.Unmarshal(raw, &str); err == nil {
+			return parseTimestamp(str)
+		}
+
+		return parseTimestamp(string(raw))
+	}
+
+	return time.Time{}, false
+}
+
+// fieldTimestamp returns the timestamp of the whitespace delimited field at the given index.
+func fieldTimestamp(record string, index int) (time.Time, bool) {
+	fields := strings.Fields(record)
+	if index < 0 || len(fields) <= index {
+		return time.Time{}, false
+	}
+
+	return parseTimestamp(fields[index])
+}
+
+// bracketedTimestamp returns the timestamp within the first pair of square brackets, ex:- [10/Oct/2000:13:55:36 -0700].
+func bracketedTimestamp(record string) (time.Time, bool) {
+	start := strings.IndexByte(record, '[')
+	end := strings.IndexByte(record, ']')
+	if start < 0 || end < start {
+		return time.Time{}, false
+	}
+
+	return parseTimestamp(record[start+1 : end])
+}
+
+// parseTimestamp accepts RFC3339, Suricata, CLF or HAProxy timestamps, or numeric epoch values in seconds or milliseconds.
+// Fractional epoch values are accepted in seconds.
+func parseTimestamp(value string) (time.Time, bool) {
+	if epoch, err := strconv.ParseInt(value, 10, 64); err == nil {
+		// values beyond year 33658 in seconds are treated as milliseconds, and likewise milliseconds as microseconds
+		if epoch > 1e15 {
+			return time.UnixMicro(epoch), true
+		}
+		if epoch > 1e12 {
+			return time.UnixMilli(epoch), true
+		}
+		return time.Unix(epoch, 0), true
+	}
+
+	if epoch, err := strconv.ParseFloat(value, 64); err == nil {
+		return time.UnixMicro(int64(math.Round(epoch * 1e6))), true
+	}
+
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02T15:04:05.999999-0700", "02/Jan/2006:15:04:05 -0700", "02/Jan/2006:15:04:05.000"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
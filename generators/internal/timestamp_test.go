@@ -0,0 +1,296 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"data-gen/conf"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// timestamper is implemented by generators whose records carry their own timestamp.
+type timestamper interface {
+	RecordTimestamp(record string) (time.Time, bool)
+}
+
+func TestRecordTimestamp(t *testing.T) {
+	stdout := conf.OutputConfig{Type: conf.OutputStdout}
+
+	tests := []struct {
+		name     string
+		config   string
+		newGen   func(input conf.InputConfig) (timestamper, error)
+		record   string
+		expected time.Time
+	}{
+		{
+			name:     "CloudTrail",
+			newGen:   func(input conf.InputConfig) (timestamper, error) { return NewCloudTrailGen(input, stdout) },
+			record:   `{"eventTime":"2019-02-01T03:18:19Z"}`,
+			expected: time.Date(2019, 2, 1, 3, 18, 19, 0, time.UTC),
+		},
+		{
+			name:     "CloudFront",
+			newGen:   func(input conf.InputConfig) (timestamper, error) { return NewCloudFrontGen(input, stdout) },
+			record:   "2019-12-04\t21:02:31\tLAX1\t392\t192.0.2.100\tGET",
+			expected: time.Date(2019, 12, 4, 21, 2, 31, 0, time.UTC),
+		},
+		{
+			name:     "CloudFront real-time",
+			config:   "{log_format: realtime, fields: [c-ip, timestamp]}",
+			newGen:   func(input conf.InputConfig) (timestamper, error) { return NewCloudFrontGen(input, stdout) },
+			record:   "1575493351.123\t192.0.2.100",
+			expected: time.UnixMilli(1575493351123),
+		},
+		{
+			name:     "S3 access",
+			newGen:   func(conf.InputConfig) (timestamper, error) { return NewS3AccessGen(), nil },
+			record:   "79a59df900b949e55d96a1e698fbacedfd6e09d98eacf8f8d5218e7cd47ef2be amzn-s3-demo-bucket1 [06/Feb/2019:00:00:38 +0000] 192.0.2.3",
+			expected: time.Date(2019, 2, 6, 0, 0, 38, 0, time.UTC),
+		},
+		{
+			name:     "WAF",
+			newGen:   func(conf.InputConfig) (timestamper, error) { return NewWAFGen(), nil },
+			record:   `{"timestamp":1683355579981}`,
+			expected: time.UnixMilli(1683355579981),
+		},
+		{
+			name:     "Network Firewall",
+			newGen:   func(input conf.InputConfig) (timestamper, error) { return NewNetworkFirewallGen(input) },
+			record:   `{"firewall_name":"test-firewall","event_timestamp":"1602627001"}`,
+			expected: time.Unix(1602627001, 0),
+		},
+		{
+			name:     "Route 53 Resolver",
+			newGen:   func(conf.InputConfig) (timestamper, error) { return NewRoute53ResolverGen(), nil },
+			record:   `{"version":"1.100000","query_timestamp":"2022-10-19T16:09:15Z"}`,
+			expected: time.Date(2022, 10, 19, 16, 9, 15, 0, time.UTC),
+		},
+		{
+			name:     "GuardDuty",
+			newGen:   func(input conf.InputConfig) (timestamper, error) { return NewGuardDutyGen(input) },
+			record:   `{"schemaVersion":"2.0","updatedAt":"2024-03-01T10:20:30.123Z"}`,
+			expected: time.Date(2024, 3, 1, 10, 20, 30, 123e6, time.UTC),
+		},
+		{
+			name:     "Security Hub",
+			newGen:   func(input conf.InputConfig) (timestamper, error) { return NewSecurityHubGen(input) },
+			record:   `{"SchemaVersion":"2018-10-08","UpdatedAt":"2024-03-01T10:20:30.123Z"}`,
+			expected: time.Date(2024, 3, 1, 10, 20, 30, 123e6, time.UTC),
+		},
+		{
+			name:     "API Gateway JSON",
+			newGen:   func(input conf.InputConfig) (timestamper, error) { return NewAPIGatewayGen(input) },
+			record:   `{"requestId":"c6af9ac6-7b61-11e6-9a41-93e8deadbeef","requestTime":"19/Oct/2026:10:20:30 +0000"}`,
+			expected: time.Date(2026, 10, 19, 10, 20, 30, 0, time.UTC),
+		},
+		{
+			name:     "API Gateway CLF",
+			config:   "log_format: clf",
+			newGen:   func(input conf.InputConfig) (timestamper, error) { return NewAPIGatewayGen(input) },
+			record:   `192.0.2.10 - - [19/Oct/2026:10:20:30 +0000] "GET /pets HTTP/1.1" 200 512 c6af9ac6-7b61-11e6-9a41-93e8deadbeef`,
+			expected: time.Date(2026, 10, 19, 10, 20, 30, 0, time.UTC),
+		},
+		{
+			name:     "Lambda JSON",
+			config:   "log_format: json",
+			newGen:   func(input conf.InputConfig) (timestamper, error) { return NewLambdaGen(input) },
+			record:   `{"time":"2026-10-19T10:20:30.456Z","type":"platform.start","record":{"requestId":"6f7f0961-1e7b-4d3a-9c1f-3d5e8e1b2a10","version":"$LATEST"}}`,
+			expected: time.Date(2026, 10, 19, 10, 20, 30, 456e6, time.UTC),
+		},
+		{
+			name:     "Lambda text",
+			newGen:   func(input conf.InputConfig) (timestamper, error) { return NewLambdaGen(input) },
+			record:   "2026-10-19T10:20:30.456Z\t6f7f0961-1e7b-4d3a-9c1f-3d5e8e1b2a10\tINFO\tProcessed 12 records",
+			expected: time.Date(2026, 10, 19, 10, 20, 30, 456e6, time.UTC),
+		},
+		{
+			name:     "Lambda text of Python runtimes",
+			newGen:   func(input conf.InputConfig) (timestamper, error) { return NewLambdaGen(input) },
+			record:   "[WARNING]\t2026-10-19T10:20:30.456Z\t6f7f0961-1e7b-4d3a-9c1f-3d5e8e1b2a10\tRetrying downstream call",
+			expected: time.Date(2026, 10, 19, 10, 20, 30, 456e6, time.UTC),
+		},
+		{
+			name:     "Kubernetes audit",
+			newGen:   func(input conf.InputConfig) (timestamper, error) { return NewK8sAuditGen(input, stdout) },
+			record:   `{"kind":"Event","apiVersion":"audit.k8s.io/v1","requestReceivedTimestamp":"2026-10-19T10:20:30.123456Z","stageTimestamp":"2026-10-19T10:20:30.234567Z"}`,
+			expected: time.Date(2026, 10, 19, 10, 20, 30, 234567000, time.UTC),
+		},
+		{
+			name:     "Container json-file",
+			config:   "log_format: docker",
+			newGen:   func(input conf.InputConfig) (timestamper, error) { return NewContainerLogsGen(input) },
+			record:   `{"log":"{\"@timestamp\":\"2026-10-19T10:20:30.000Z\"}\n","stream":"stdout","time":"2026-10-19T10:20:30.123456789Z"}`,
+			expected: time.Date(2026, 10, 19, 10, 20, 30, 123456789, time.UTC),
+		},
+		{
+			name:     "Container CRI",
+			newGen:   func(input conf.InputConfig) (timestamper, error) { return NewContainerLogsGen(input) },
+			record:   "2026-10-19T10:20:30.123456789Z stderr F error: upstream request timeout after 250 ms",
+			expected: time.Date(2026, 10, 19, 10, 20, 30, 123456789, time.UTC),
+		},
+		{
+			name:     "Access log",
+			newGen:   func(input conf.InputConfig) (timestamper, error) { return NewAccessLogGen(input) },
+			record:   `192.0.2.10 - - [19/Oct/2026:10:20:30 +0000] "GET /index.html HTTP/1.1" 200 2326 "-" "curl/8.4.0"`,
+			expected: time.Date(2026, 10, 19, 10, 20, 30, 0, time.UTC),
+		},
+		{
+			name:     "HAProxy access log",
+			config:   "preset: haproxy",
+			newGen:   func(input conf.InputConfig) (timestamper, error) { return NewAccessLogGen(input) },
+			record:   `192.0.2.10:58080 [19/Oct/2026:10:20:30.456] https-in~ app/app1 0/0/1/12/14 200 512 - - ---- 1/1/0/0/0 0/0 "GET / HTTP/1.1"`,
+			expected: time.Date(2026, 10, 19, 10, 20, 30, 456e6, time.UTC),
+		},
+		{
+			name:     "nginx JSON access log",
+			config:   "preset: nginx_json",
+			newGen:   func(input conf.InputConfig) (timestamper, error) { return NewAccessLogGen(input) },
+			record:   `{"time_local":"19/Oct/2026:10:20:30 +0000","remote_addr":"192.0.2.10","request":"GET / HTTP/1.1"}`,
+			expected: time.Date(2026, 10, 19, 10, 20, 30, 0, time.UTC),
+		},
+		{
+			name:     "RFC 5424 syslog",
+			config:   "log_format: rfc5424",
+			newGen:   func(input conf.InputConfig) (timestamper, error) { return NewSyslogGen(input) },
+			record:   "<86>1 2026-10-19T10:20:30.123456Z web-01 sshd 4123 - - Accepted publickey for ubuntu",
+			expected: time.Date(2026, 10, 19, 10, 20, 30, 123456000, time.UTC),
+		},
+		{
+			name:     "journald",
+			config:   "log_format: journald",
+			newGen:   func(input conf.InputConfig) (timestamper, error) { return NewSyslogGen(input) },
+			record:   `{"__CURSOR":"s=abc;i=1","__REALTIME_TIMESTAMP":"1792405230123456","MESSAGE":"Accepted publickey for ubuntu"}`,
+			expected: time.UnixMicro(1792405230123456),
+		},
+		{
+			name:     "auditd",
+			newGen:   func(input conf.InputConfig) (timestamper, error) { return NewAuditdGen(input) },
+			record:   `type=CWD msg=audit(1792405230.123:24287): cwd="/home/alice"`,
+			expected: time.UnixMilli(1792405230123),
+		},
+		{
+			name:     "Windows event XML",
+			newGen:   func(input conf.InputConfig) (timestamper, error) { return NewWindowsEventsGen(input) },
+			record:   `<Event xmlns='http://schemas.microsoft.com/win/2004/08/events/event'><System><EventID>4624</EventID><TimeCreated SystemTime='2026-10-19T10:20:30.1234567Z'/></System></Event>`,
+			expected: time.Date(2026, 10, 19, 10, 20, 30, 123456700, time.UTC),
+		},
+		{
+			name:     "Winlogbeat",
+			config:   "log_format: winlogbeat",
+			newGen:   func(input conf.InputConfig) (timestamper, error) { return NewWindowsEventsGen(input) },
+			record:   `{"@timestamp":"2026-10-19T10:20:30.123Z","event":{"code":"4624"},"winlog":{"channel":"Security"}}`,
+			expected: time.Date(2026, 10, 19, 10, 20, 30, 123e6, time.UTC),
+		},
+		{
+			name: "CEF",
+			newGen: func(input conf.InputConfig) (timestamper, error) {
+				input.Type = conf.InputCEF
+				return NewSIEMGen(input)
+			},
+			record:   `CEF:0|AWS|VPC Flow Logs|1.0|ACCEPT|Flow accepted|1|start=1792405200000 rt=1792405230123 cat=flow`,
+			expected: time.UnixMilli(1792405230123),
+		},
+		{
+			name: "LEEF",
+			newGen: func(input conf.InputConfig) (timestamper, error) {
+				input.Type = conf.InputLEEF
+				return NewSIEMGen(input)
+			},
+			record:   "LEEF:2.0|AWS|WAF|1.0|Default_Action|x09|devTime=Oct 19 2026 10:20:30.123 UTC\tdevTimeFormat=MMM dd yyyy HH:mm:ss.SSS z\tcat=waf",
+			expected: time.Date(2026, 10, 19, 10, 20, 30, 123e6, time.UTC),
+		},
+		{
+			name:     "Zeek TSV",
+			newGen:   func(input conf.InputConfig) (timestamper, error) { return NewZeekGen(input, stdout) },
+			record:   "1792405230.123456\tCHhAvVGS1DHFjwGM9\t10.0.1.15\t52381\t10.0.0.2\t53\tudp\tdns",
+			expected: time.UnixMicro(1792405230123456),
+		},
+		{
+			name:     "Zeek JSON",
+			config:   "log_format: json",
+			newGen:   func(input conf.InputConfig) (timestamper, error) { return NewZeekGen(input, stdout) },
+			record:   `{"_path":"conn","ts":1792405230.123456,"uid":"CHhAvVGS1DHFjwGM9"}`,
+			expected: time.UnixMicro(1792405230123456),
+		},
+		{
+			name:     "Suricata",
+			newGen:   func(input conf.InputConfig) (timestamper, error) { return NewSuricataGen(input) },
+			record:   `{"timestamp":"2026-10-19T10:20:30.123456+0000","flow_id":1312985439030158,"event_type":"flow"}`,
+			expected: time.Date(2026, 10, 19, 10, 20, 30, 123456000, time.UTC),
+		},
+		{
+			name:     "VPC",
+			newGen:   func(input conf.InputConfig) (timestamper, error) { return NewVPCGen(input, stdout) },
+			record:   "2 123456789010 eni-1235b8ca123456789 172.31.16.139 172.31.16.21 20641 22 6 20 4249 1418530010 1418530070 ACCEPT OK",
+			expected: time.Unix(1418530010, 0),
+		},
+		{
+			name:     "VPC custom format",
+			config:   "fields: [version, vpc-id, start, end, log-status]",
+			newGen:   func(input conf.InputConfig) (timestamper, error) { return NewVPCGen(input, stdout) },
+			record:   "5 vpc-abcdefab012345678 1418530010 1418530070 OK",
+			expected: time.Unix(1418530010, 0),
+		},
+		{
+			name:     "ALB connection",
+			config:   "log_type: connection",
+			newGen:   func(input conf.InputConfig) (timestamper, error) { return NewALBGen(input) },
+			record:   "2023-10-04T17:12:29.311497Z 192.0.2.1 39455 443",
+			expected: time.Date(2023, 10, 4, 17, 12, 29, 311497000, time.UTC),
+		},
+		{
+			name:     "NLB",
+			newGen:   func(input conf.InputConfig) (timestamper, error) { return NewNLBGen(input) },
+			record:   "tls 2.0 2020-04-01T08:51:42 net/my-network-loadbalancer/c6e77e28c25b2234",
+			expected: time.Date(2020, 4, 1, 8, 51, 42, 0, time.UTC),
+		},
+		{
+			name:     "NLB connection",
+			newGen:   func(input conf.InputConfig) (timestamper, error) { return NewNLBGen(input) },
+			record:   "tcp 1.0 2020-04-01T08:50:10 2020-04-01T08:51:42 net/my-nlb/c6e77e28c25b2234 g3d4b5e8bb8464cd",
+			expected: time.Date(2020, 4, 1, 8, 51, 42, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var input conf.InputConfig
+			require.NoError(t, yaml.Unmarshal([]byte(tt.config), &input.Conf))
+
+			gen, err := tt.newGen(input)
+			require.NoError(t, err)
+
+			ts, ok := gen.RecordTimestamp(tt.record)
+			require.True(t, ok)
+			require.True(t, tt.expected.Equal(ts), ts)
+		})
+	}
+
+	t.Run("RFC 3164 syslog", func(t *testing.T) {
+		gen, err := NewSyslogGen(conf.InputConfig{})
+		require.NoError(t, err)
+
+		ts, ok := gen.RecordTimestamp("<86>Jan  2 03:04:05 web-01 sshd[4123]: Accepted publickey for ubuntu")
+		require.True(t, ok)
+		require.Contains(t, []int{time.Now().Year(), time.Now().Year() - 1}, ts.Year())
+		require.Equal(t, "01-02T03:04:05", ts.Format("01-02T15:04:05"))
+	})
+
+	t.Run("Records without timestamps", func(t *testing.T) {
+		zeek, err := NewZeekGen(conf.InputConfig{}, stdout)
+		require.NoError(t, err)
+		_, ok := zeek.RecordTimestamp("#fields\tts\tuid")
+		require.False(t, ok)
+
+		lambda, err := NewLambdaGen(conf.InputConfig{})
+		require.NoError(t, err)
+		_, ok = lambda.RecordTimestamp("START RequestId: 6f7f0961-1e7b-4d3a-9c1f-3d5e8e1b2a10 Version: $LATEST")
+		require.False(t, ok)
+
+		_, ok = NewLogGenerator().RecordTimestamp("no timestamp")
+		require.False(t, ok)
+	})
+}
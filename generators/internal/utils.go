@@ -30,16 +30,11 @@ var sampleAccountIDs = []string{"123456789012", "987654321098", "111122223333",
 var sampleDomains = []string{"example.com", "test.com", "sample.org", "demo.net"}
 var sampleENIIDs = []string{"eni-12345678", "eni-87654321", "eni-11223344", "eni-44332211"}
 var samplePrincipalIDs = []string{"AID1234567890", "AID0987654321", "AID1111222233", "AID7777888899"}
-var sampleRuleIDs = []string{"rule-1", "rule-2", "rule-3", "rule-4", "rule-5"}
-var statuses = []string{"200", "400", "500"}
 var tlsCiphers = []string{"ECDHE-RSA-AES128-GCM-SHA256", "ECDHE-RSA-AES256-GCM-SHA384", "AES128-GCM-SHA256"}
 var tlsProtocols = []string{"TLSv1.2", "TLSv1.3"}
 var uriPaths = []string{"/", "/home", "/api/resource", "/login"}
 var userAgents = []string{"Mozilla/5.0, AppleWebKit/537.36, Chrome/58.0.3029.110, Safari/537.3", "curl/7.46.0", "cloudtrail.amazonaws.com", "firehose.amazonaws.com"}
 var uuids = []string{"550e8400-e29b-41d4-a716-446655440000", "123e4567-e89b-12d3-a456-426614174000", "9b2c3d4e-5f6a-7b8c-9d0e-1f2a3b4c5d6e"}
-var wafActions = []string{"ALLOW", "BLOCK", "COUNT"}
-var wafRuleTypes = []string{"REGULAR", "RATE_BASED", "GROUP"}
-var wafSampleHTTPSourceNames = []string{"ALB", "CloudFront", "API Gateway"}
 var firstNames = []string{"John", "Jane", "Michael", "Sarah", "David", "Emily", "Robert", "Lisa", "William", "Anna"}
 var lastNames = []string{"Doe", "Smith", "Johnson", "Williams", "Brown", "Jones", "Garcia", "Miller", "Davis", "Wilson"}
 
@@ -69,11 +64,6 @@ func unixSeconds(delay int) int64 {
 	return time.Now().Unix() + int64(delay)
 }
 
-// randomProcessingTime returns a random float as string between 0.500 and 1.499
-func randomProcessingTime() float32 {
-	return (0.5 + rand.Float32()*1000) / 1000
-}
-
 func randomID() string {
 	return randomIDs[rand.Intn(len(randomIDs))]
 }
@@ -82,10 +72,6 @@ func randomCertArn() string {
 	return certARN[rand.Intn(len(certARN))]
 }
 
-func randomStatus() string {
-	return statuses[rand.Intn(len(statuses))]
-}
-
 func randomBytesSize() int {
 	return rand.Intn(5000-200) + 200
 }
@@ -130,15 +116,13 @@ func randomPort() int {
 	return rand.Intn(59090-58080) + 58080
 }
 
-func ctUserIdentity() UserIdentity {
+func ctUserIdentity(accountID string) UserIdentity {
 	userName := fmt.Sprintf("user%d", rand.Intn(10))
 
 	if rand.Intn(10) == 0 {
 		userName = fmt.Sprintf("%s@email.com", userName)
 	}
 
-	accountID := randomSampleAccountID()
-
 	arn := randomIAMArn(accountID, userName)
 
 	return UserIdentity{
@@ -171,6 +155,15 @@ func randomAZ09String(size int) string {
 	return string(key)
 }
 
+func randomHexString(size int) string {
+	const hexChars = "0123456789abcdef"
+	key := make([]byte, size)
+	for i := range key {
+		key[i] = hexChars[rand.Intn(len(hexChars))]
+	}
+	return string(key)
+}
+
 func randomSampleAccountID() string {
 	return sampleAccountIDs[rand.Intn(len(sampleAccountIDs))]
 }
@@ -266,54 +259,14 @@ func randomURIPath() string {
 	return uriPaths[rand.Intn(len(uriPaths))]
 }
 
-func randomWafHeaders() []wafHttpHeader {
-	cType := wafHttpHeader{
-		Name:  "Content-Type",
-		Value: contentTypes[rand.Intn(len(contentTypes))],
-	}
-
-	uAgent := wafHttpHeader{
-		Name:  "User-Agent",
-		Value: userAgents[rand.Intn(len(userAgents))],
-	}
-
-	accept := wafHttpHeader{
-		Name:  "Accept",
-		Value: "*/*",
-	}
-
-	keepAlive := wafHttpHeader{
-		Name:  "Connection",
-		Value: "keep-alive",
-	}
-
-	return []wafHttpHeader{cType, uAgent, accept, keepAlive}
-}
-
-func randomWAFRuleId() string {
-	return sampleRuleIDs[rand.Intn(len(sampleRuleIDs))]
-}
-
 func randomCountryCode() string {
 	return countryCodes[rand.Intn(len(countryCodes))]
 }
 
-func randomWafRuleType() string {
-	return wafRuleTypes[rand.Intn(len(wafRuleTypes))]
-}
-
-func randomWafAction() string {
-	return wafActions[rand.Intn(len(wafActions))]
-}
-
 func randomWAFACLID() string {
 	return fmt.Sprintf("arn:aws:wafv2:%s:%s:regional/webacl/sample-web-acl/%s", randomRegion(), randomSampleAccountID(), uuids[rand.Intn(len(uuids))])
 }
 
-func randomWafSourceName() string {
-	return wafSampleHTTPSourceNames[rand.Intn(len(wafSampleHTTPSourceNames))]
-}
-
 func randomLogString(size int) string {
 	var buildBytes []byte
 	for len(buildBytes) < size {
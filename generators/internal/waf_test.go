@@ -11,12 +11,26 @@ import (
 const sample = `
 {
    "timestamp":1683355579981,
+   "formatVersion":1,
    "webaclId": "111122223333:example-web-acl",
    "terminatingRuleId":"RateBasedRule",
    "terminatingRuleType":"RATE_BASED",
    "action":"BLOCK",
+   "terminatingRuleMatchDetails":[],
    "httpSourceName":"APIGW",
    "httpSourceId":"EXAMPLE11:rjvegx5guh:CanaryTest",
+   "ruleGroupList":[],
+   "rateBasedRuleList":[
+      {
+         "rateBasedRuleId":"7c968ef6-32ec-4fee-96cc-51198e412e7f",
+         "rateBasedRuleName":"RateBasedRule",
+         "limitKey":"IP",
+         "maxRateAllowed":100,
+         "evaluationWindowSec":"120"
+      }
+   ],
+   "nonTerminatingMatchingRules":[],
+   "requestHeadersInserted":null,
    "responseCodeSent":null,
    "httpRequest":{
       "clientIp":"52.46.82.45",
@@ -50,13 +64,25 @@ const sample = `
 
 func TestWafLogGenerating(t *testing.T) {
 	customizer := wafCustomizer{
-		timeStampMillis: 1683355579981,
-		webACLID:        "111122223333:example-web-acl",
-		ruleID:          "RateBasedRule",
-		ruleType:        "RATE_BASED",
-		action:          "BLOCK",
-		httpSourceName:  "APIGW",
-		httpSourceID:    "EXAMPLE11:rjvegx5guh:CanaryTest",
+		timeStampMillis:             1683355579981,
+		webACLID:                    "111122223333:example-web-acl",
+		ruleID:                      "RateBasedRule",
+		ruleType:                    "RATE_BASED",
+		action:                      "BLOCK",
+		httpSourceName:              "APIGW",
+		httpSourceID:                "EXAMPLE11:rjvegx5guh:CanaryTest",
+		terminatingRuleMatchDetails: []wafMatchDetail{},
+		ruleGroupList:               []wafRuleGroup{},
+		rateBasedRuleList: []wafRateBasedRule{
+			{
+				RateBasedRuleID:     "7c968ef6-32ec-4fee-96cc-51198e412e7f",
+				RateBasedRuleName:   "RateBasedRule",
+				LimitKey:            "IP",
+				MaxRateAllowed:      100,
+				EvaluationWindowSec: "120",
+			},
+		},
+		nonTerminatingMatchingRules: []wafMatchingRule{},
 		httpRequest: wafHttpRequest{
 			ClientIP: "52.46.82.45",
 			Country:  "FR",
@@ -88,3 +114,65 @@ func TestWafLogGenerating(t *testing.T) {
 
 	require.Equal(t, marshal, generated)
 }
+
+func Test_newWAFCustomizer(t *testing.T) {
+	seen := map[string]bool{}
+
+	for range 2000 {
+		c := newWAFCustomizer("111122223333:example-web-acl")
+		seen[c.action] = true
+
+		switch c.action {
+		case wafActionAllow:
+			require.Contains(t, []string{wafDefaultAction, "AllowOfficeIPs"}, c.ruleID)
+			require.Nil(t, c.responseCode)
+			require.Nil(t, c.challengeResponse)
+			for _, group := range c.ruleGroupList {
+				require.Nil(t, group.TerminatingRule)
+			}
+			for _, rule := range c.nonTerminatingMatchingRules {
+				require.Equal(t, wafActionCount, rule.Action)
+			}
+			if c.captchaResponse != nil {
+				require.Zero(t, c.captchaResponse.ResponseCode)
+				require.NotZero(t, c.captchaResponse.SolveTimestamp)
+			}
+		case wafActionBlock:
+			require.NotEqual(t, wafDefaultAction, c.ruleID)
+			require.Nil(t, c.captchaResponse)
+			require.Nil(t, c.challengeResponse)
+
+			switch c.ruleType {
+			case wafRuleTypeManaged:
+				require.Len(t, c.ruleGroupList, 1)
+				require.NotNil(t, c.ruleGroupList[0].TerminatingRule)
+				require.Equal(t, wafActionBlock, c.ruleGroupList[0].TerminatingRule.Action)
+				require.Len(t, c.labels, 1)
+			case wafRuleTypeRateBased:
+				require.Len(t, c.rateBasedRuleList, 1)
+				require.Equal(t, c.ruleID, c.rateBasedRuleList[0].RateBasedRuleName)
+			default:
+				require.Equal(t, wafRuleTypeRegular, c.ruleType)
+				require.Equal(t, "awswaf:clientip:geo:country:"+c.httpRequest.Country, c.labels[0].Name)
+			}
+		case wafActionCaptcha:
+			require.NotNil(t, c.captchaResponse)
+			require.Equal(t, 405, c.captchaResponse.ResponseCode)
+			require.NotEmpty(t, c.captchaResponse.FailureReason)
+			require.Nil(t, c.challengeResponse)
+		case wafActionChallenge:
+			require.NotNil(t, c.challengeResponse)
+			require.Equal(t, 202, c.challengeResponse.ResponseCode)
+			require.NotEmpty(t, c.challengeResponse.FailureReason)
+			require.Nil(t, c.captchaResponse)
+		default:
+			require.Failf(t, "unexpected action", "action %s", c.action)
+		}
+
+		if c.requestBodySize > 0 {
+			require.LessOrEqual(t, c.requestBodySizeInspected, c.requestBodySize)
+		}
+	}
+
+	require.Len(t, seen, 4)
+}
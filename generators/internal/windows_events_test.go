@@ -0,0 +1,168 @@
+package internal
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"data-gen/conf"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func Test_buildWindowsEventXML(t *testing.T) {
+	c := windowsEventCustomizer{
+		def:      windowsEventDefs[0],
+		time:     time.Date(2026, 10, 19, 10, 20, 30, 123456700, time.UTC),
+		recordID: 5213647,
+		computer: "DC01.corp.example.com",
+		threadID: 1820,
+		data: []windowsEventData{
+			{"TargetUserName", "alice.smith"},
+			{"LogonProcessName", "User32 "},
+			{"CommandLine", `net group "Domain Admins" /domain`},
+		},
+	}
+
+	t.Run("Security event", func(t *testing.T) {
+		require.Equal(t, `<Event xmlns='http://schemas.microsoft.com/win/2004/08/events/event'><System>`+
+			`<Provider Name='Microsoft-Windows-Security-Auditing' Guid='{54849625-5478-4994-a5ba-3e3b0328c30d}'/>`+
+			`<EventID>4624</EventID><Version>2</Version><Level>0</Level><Task>12544</Task><Opcode>0</Opcode><Keywords>0x8020000000000000</Keywords>`+
+			`<TimeCreated SystemTime='2026-10-19T10:20:30.1234567Z'/><EventRecordID>5213647</EventRecordID><Correlation/>`+
+			`<Execution ProcessID='664' ThreadID='1820'/><Channel>Security</Channel><Computer>DC01.corp.example.com</Computer><Security/></System>`+
+			`<EventData><Data Name='TargetUserName'>alice.smith</Data><Data Name='LogonProcessName'>User32 </Data>`+
+			`<Data Name='CommandLine'>net group &quot;Domain Admins&quot; /domain</Data></EventData></Event>`, buildWindowsEventXML(c))
+	})
+
+	t.Run("Classic provider event", func(t *testing.T) {
+		scm := c
+		scm.def = windowsEventDefs[len(windowsEventDefs)-2]
+		scm.userSID = windowsSystemSID
+		scm.data = []windowsEventData{{"param1", "Windows Update"}, {"param2", "running"}}
+		scm.binary = windowsBinary("wuauserv/4")
+
+		line := buildWindowsEventXML(scm)
+		require.Contains(t, line, `<Provider Name='Service Control Manager' Guid='{555908d1-a6d7-4695-8e1e-26931d2012f4}' EventSourceName='Service Control Manager'/>`)
+		require.Contains(t, line, `<EventID Qualifiers='16384'>7036</EventID><Version>0</Version><Level>4</Level><Task>0</Task><Opcode>0</Opcode><Keywords>0x8080000000000000</Keywords>`)
+		require.Contains(t, line, `<Channel>System</Channel><Computer>DC01.corp.example.com</Computer><Security UserID='S-1-5-18'/>`)
+		require.True(t, strings.HasSuffix(line, `<Data Name='param2'>running</Data><Binary>770075006100750073006500720076002F003400</Binary></EventData></Event>`))
+	})
+}
+
+func Test_newWindowsEventCustomizer(t *testing.T) {
+	// EventData fields each event ID must carry
+	fields := map[int][]string{
+		4624: {"SubjectUserSid", "TargetUserSid", "TargetLogonId", "LogonType", "AuthenticationPackageName", "WorkstationName", "IpAddress", "ElevatedToken"},
+		4625: {"TargetUserSid", "TargetUserName", "Status", "FailureReason", "SubStatus", "LogonType", "IpAddress", "IpPort"},
+		4634: {"TargetUserSid", "TargetLogonId", "LogonType"},
+		4672: {"SubjectUserSid", "SubjectLogonId", "PrivilegeList"},
+		4688: {"SubjectUserSid", "NewProcessId", "NewProcessName", "TokenElevationType", "CommandLine", "ParentProcessName", "MandatoryLabel"},
+		4720: {"TargetUserName", "TargetSid", "SubjectUserSid", "SamAccountName", "UserAccountControl"},
+		4732: {"MemberName", "MemberSid", "TargetUserName", "TargetSid", "SubjectUserSid"},
+		4740: {"TargetUserName", "TargetDomainName", "TargetSid", "SubjectUserSid"},
+		4768: {"TargetUserName", "ServiceName", "TicketOptions", "Status", "TicketEncryptionType", "PreAuthType", "IpAddress"},
+		4769: {"TargetUserName", "ServiceName", "ServiceSid", "TicketEncryptionType", "Status", "LogonGuid"},
+		7036: {"param1", "param2"},
+		7045: {"ServiceName", "ImagePath", "ServiceType", "StartType", "AccountName"},
+	}
+	require.Len(t, fields, len(windowsEventDefs))
+
+	for _, def := range windowsEventDefs {
+		for range 50 {
+			c := newWindowsEventCustomizer(def, 1)
+			require.NotEmpty(t, c.message)
+
+			names := make(map[string]bool, len(c.data))
+			for _, d := range c.data {
+				require.False(t, names[d.name], "duplicate field %s of event %d", d.name, def.id)
+				names[d.name] = true
+			}
+			for _, name := range fields[def.id] {
+				require.True(t, names[name], "missing field %s of event %d", name, def.id)
+			}
+
+			// only failed logons and failed pre-authentication are audit failures
+			if c.failure {
+				require.Contains(t, []int{4625, 4768}, def.id)
+			}
+		}
+	}
+}
+
+func Test_NewWindowsEventsGen(t *testing.T) {
+	t.Run("XML events", func(t *testing.T) {
+		var input conf.InputConfig
+		gen, err := NewWindowsEventsGen(input)
+		require.NoError(t, err)
+
+		for range 200 {
+			_, err = gen.Generate()
+			require.NoError(t, err)
+		}
+
+		for _, line := range strings.Split(strings.TrimSuffix(string(gen.GetAndReset()), "\n"), "\n") {
+			var event struct {
+				EventID int    `xml:"System>EventID"`
+				Channel string `xml:"System>Channel"`
+				Data    []struct {
+					Name  string `xml:"Name,attr"`
+					Value string `xml:",chardata"`
+				} `xml:"EventData>Data"`
+			}
+			require.NoError(t, xml.Unmarshal([]byte(line), &event))
+			require.NotZero(t, event.EventID)
+			require.Contains(t, []string{windowsChannelSecurity, windowsChannelSystem}, event.Channel)
+			require.NotEmpty(t, event.Data)
+		}
+	})
+
+	t.Run("Winlogbeat events of selected IDs", func(t *testing.T) {
+		var input conf.InputConfig
+		require.NoError(t, yaml.Unmarshal([]byte("log_format: winlogbeat\nevent_ids: [4625, 7045]"), &input.Conf))
+
+		gen, err := NewWindowsEventsGen(input)
+		require.NoError(t, err)
+
+		for range 100 {
+			_, err = gen.Generate()
+			require.NoError(t, err)
+		}
+
+		var records []uint64
+		for _, line := range strings.Split(strings.TrimSuffix(string(gen.GetAndReset()), "\n"), "\n") {
+			var event winlogbeatEvent
+			require.NoError(t, json.Unmarshal([]byte(line), &event))
+			require.Contains(t, []string{"4625", "7045"}, event.Event.Code)
+			require.Equal(t, event.Event.Code, event.Winlog.EventID)
+
+			if event.Event.Code == "4625" {
+				require.Equal(t, "failure", event.Event.Outcome)
+				require.Equal(t, []string{"Audit Failure"}, event.Winlog.Keywords)
+				require.NotEmpty(t, event.Winlog.EventData["SubStatus"])
+				records = append(records, event.Winlog.RecordID)
+			} else {
+				require.Equal(t, windowsChannelSystem, event.Winlog.Channel)
+				require.NotNil(t, event.Winlog.User)
+			}
+		}
+
+		// record IDs increase within a channel
+		for i := 1; i < len(records); i++ {
+			require.Greater(t, records[i], records[i-1])
+		}
+	})
+
+	t.Run("Invalid configuration", func(t *testing.T) {
+		var input conf.InputConfig
+		require.NoError(t, yaml.Unmarshal([]byte("log_format: evtx"), &input.Conf))
+		_, err := NewWindowsEventsGen(input)
+		require.ErrorContains(t, err, "unknown windows events log format")
+
+		require.NoError(t, yaml.Unmarshal([]byte("event_ids: [4624, 1102]"), &input.Conf))
+		_, err = NewWindowsEventsGen(input)
+		require.ErrorContains(t, err, "unsupported windows event ID: 1102")
+	})
+}
@@ -0,0 +1,431 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"data-gen/conf"
+)
+
+const (
+	zeekLogConn  = "conn"
+	zeekLogDNS   = "dns"
+	zeekLogHTTP  = "http"
+	zeekLogSSL   = "ssl"
+	zeekLogFiles = "files"
+
+	zeekFormatTSV  = "tsv"
+	zeekFormatJSON = "json"
+
+	zeekOpenFormat = "2006-01-02-15-04-05"
+)
+
+// zeekField is a log field along with its Zeek type, as listed in the #fields & #types headers.
+type zeekField struct {
+	name string
+	typ  string
+}
+
+var zeekConnID = []zeekField{
+	{"id.orig_h", "addr"}, {"id.orig_p", "port"}, {"id.resp_h", "addr"}, {"id.resp_p", "port"},
+}
+
+// zeekSchemas are the default fields of each log type, as of Zeek 6.
+var zeekSchemas = map[string][]zeekField{
+	zeekLogConn: slices.Concat([]zeekField{{"ts", "time"}, {"uid", "string"}}, zeekConnID, []zeekField{
+		{"proto", "enum"}, {"service", "string"}, {"duration", "interval"}, {"orig_bytes", "count"},
+		{"resp_bytes", "count"}, {"conn_state", "string"}, {"local_orig", "bool"}, {"local_resp", "bool"},
+		{"missed_bytes", "count"}, {"history", "string"}, {"orig_pkts", "count"}, {"orig_ip_bytes", "count"},
+		{"resp_pkts", "count"}, {"resp_ip_bytes", "count"}, {"tunnel_parents", "set[string]"},
+	}),
+	zeekLogDNS: slices.Concat([]zeekField{{"ts", "time"}, {"uid", "string"}}, zeekConnID, []zeekField{
+		{"proto", "enum"}, {"trans_id", "count"}, {"rtt", "interval"}, {"query", "string"}, {"qclass", "count"},
+		{"qclass_name", "string"}, {"qtype", "count"}, {"qtype_name", "string"}, {"rcode", "count"},
+		{"rcode_name", "string"}, {"AA", "bool"}, {"TC", "bool"}, {"RD", "bool"}, {"RA", "bool"}, {"Z", "count"},
+		{"answers", "vector[string]"}, {"TTLs", "vector[interval]"}, {"rejected", "bool"},
+	}),
+	zeekLogHTTP: slices.Concat([]zeekField{{"ts", "time"}, {"uid", "string"}}, zeekConnID, []zeekField{
+		{"trans_depth", "count"}, {"method", "string"}, {"host", "string"}, {"uri", "string"},
+		{"referrer", "string"}, {"version", "string"}, {"user_agent", "string"}, {"origin", "string"},
+		{"request_body_len", "count"}, {"response_body_len", "count"}, {"status_code", "count"},
+		{"status_msg", "string"}, {"info_code", "count"}, {"info_msg", "string"}, {"tags", "set[enum]"},
+		{"username", "string"}, {"password", "string"}, {"proxied", "set[string]"},
+		{"orig_fuids", "vector[string]"}, {"orig_filenames", "vector[string]"}, {"orig_mime_types", "vector[string]"},
+		{"resp_fuids", "vector[string]"}, {"resp_filenames", "vector[string]"}, {"resp_mime_types", "vector[string]"},
+	}),
+	zeekLogSSL: slices.Concat([]zeekField{{"ts", "time"}, {"uid", "string"}}, zeekConnID, []zeekField{
+		{"version", "string"}, {"cipher", "string"}, {"curve", "string"}, {"server_name", "string"},
+		{"resumed", "bool"}, {"last_alert", "string"}, {"next_protocol", "string"}, {"established", "bool"},
+		{"ssl_history", "string"}, {"cert_chain_fps", "vector[string]"}, {"client_cert_chain_fps", "vector[string]"},
+		{"sni_matches_cert", "bool"},
+	}),
+	zeekLogFiles: slices.Concat([]zeekField{{"ts", "time"}, {"fuid", "string"}, {"uid", "string"}}, zeekConnID, []zeekField{
+		{"source", "string"}, {"depth", "count"}, {"analyzers", "set[string]"}, {"mime_type", "string"},
+		{"filename", "string"}, {"duration", "interval"}, {"local_orig", "bool"}, {"is_orig", "bool"},
+		{"seen_bytes", "count"}, {"total_bytes", "count"}, {"missing_bytes", "count"}, {"overflow_bytes", "count"},
+		{"timedout", "bool"}, {"parent_fuid", "string"}, {"md5", "string"}, {"sha1", "string"}, {"sha256", "string"},
+		{"extracted", "string"}, {"extracted_cutoff", "bool"}, {"extracted_size", "count"},
+	}),
+}
+
+var zeekTSVEscaper = strings.NewReplacer("\t", `\x09`, "\n", `\x0a`, "\r", `\x0d`)
+
+// ZeekGen generates Zeek conn, dns, http, ssl and files logs.
+// Records of a connection share the connection UID, while files are linked to the connection carrying them.
+// Each batch holds a single log, as Zeek writes a log file per log type, rotating log types across batches.
+type ZeekGen struct {
+	format    string
+	logTypes  []string
+	logEvents bool
+	logs      map[string]*trackedBuffer
+	// next is the index of the log type the rotation continues from
+	next int
+}
+
+// zeekCfg specifies the log types to generate and the log writer format.
+type zeekCfg struct {
+	LogTypes  []string `yaml:"log_types"`
+	LogFormat string   `yaml:"log_format"`
+}
+
+func NewZeekGen(input conf.InputConfig, output conf.OutputConfig) (*ZeekGen, error) {
+	cfg := zeekCfg{
+		LogTypes:  []string{zeekLogConn, zeekLogDNS, zeekLogHTTP, zeekLogSSL, zeekLogFiles},
+		LogFormat: zeekFormatTSV,
+	}
+	err := input.Conf.Decode(&cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode zeek configuration: %w", err)
+	}
+
+	if cfg.LogFormat != zeekFormatTSV && cfg.LogFormat != zeekFormatJSON {
+		return nil, fmt.Errorf("unknown zeek log format: %s", cfg.LogFormat)
+	}
+
+	if len(cfg.LogTypes) == 0 {
+		return nil, fmt.Errorf("zeek log_types must not be empty")
+	}
+
+	logs := make(map[string]*trackedBuffer, len(cfg.LogTypes))
+	for _, t := range cfg.LogTypes {
+		if _, ok := zeekSchemas[t]; !ok {
+			return nil, fmt.Errorf("unknown zeek log type: %s", t)
+		}
+		buf := newTrackedBuffer()
+		logs[t] = &buf
+	}
+
+	return &ZeekGen{
+		format:    cfg.LogFormat,
+		logTypes:  cfg.LogTypes,
+		logEvents: output.LogEvents(),
+		logs:      logs,
+	}, nil
+}
+
+func (z *ZeekGen) Generate() (int64, error) {
+	// connections without records of the selected log types are not observed, ex:- SSH connections for dns logs
+	var records map[string][][]any
+	for len(records) == 0 {
+		records = zeekRecords(newSensorConnection(), z.logTypes)
+	}
+
+	for _, logType := range z.logTypes {
+		for _, record := range records[logType] {
+			var data []byte
+
+			// Similar to CloudFront standard logs, each log file of the batch starts with headers,
+			// which are not included in CloudWatch log events.
+			if z.format == zeekFormatTSV && z.logs[logType].size() == 0 && !z.logEvents {
+				data = []byte(zeekTSVHeader(logType, time.Now().UTC()))
+			}
+
+			if z.format == zeekFormatTSV {
+				data = append(data, buildZeekTSVRecord(record)...)
+			} else {
+				line, err := buildZeekJSONRecord(logType, record)
+				if err != nil {
+					return 0, err
+				}
+				data = append(data, line...)
+			}
+
+			err := z.logs[logType].write(append(data, '\n'))
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	// the size of the batch to emit, logs of other types are kept for the following batches
+	return z.logs[z.logTypes[z.nextLog()]].size(), nil
+}
+
+func (z *ZeekGen) GetAndReset() []byte {
+	i := z.nextLog()
+	logType := z.logTypes[i]
+	z.next = (i + 1) % len(z.logTypes)
+
+	if z.logs[logType].size() == 0 {
+		return nil
+	}
+
+	data := z.logs[logType].getAndReset()
+	if z.format == zeekFormatTSV && !z.logEvents {
+		data = append(data, fmt.Sprintf("#close\t%s\n", time.Now().UTC().Format(zeekOpenFormat))...)
+	}
+	return data
+}
+
+// nextLog returns the index of the log type the next batch holds, the first log type with records in rotation order.
+func (z *ZeekGen) nextLog() int {
+	for n := range len(z.logTypes) {
+		i := (z.next + n) % len(z.logTypes)
+		if z.logs[z.logTypes[i]].size() > 0 {
+			return i
+		}
+	}
+
+	return z.next
+}
+
+func (z *ZeekGen) RecordTimestamp(record string) (time.Time, bool) {
+	if z.format == zeekFormatJSON {
+		return jsonTimestamp(record, "ts")
+	}
+
+	// ts is the first field of TSV logs, while headers do not carry a timestamp
+	return fieldTimestamp(record, 0)
+}
+
+// zeekTSVHeader returns the headers of a log file of the given type, opened at the given time.
+func zeekTSVHeader(logType string, open time.Time) string {
+	var names, types []string
+	for _, f := range zeekSchemas[logType] {
+		names = append(names, f.name)
+		types = append(types, f.typ)
+	}
+
+	return strings.Join([]string{
+		`#separator \x09`,
+		"#set_separator\t,",
+		"#empty_field\t(empty)",
+		"#unset_field\t-",
+		"#path\t" + logType,
+		"#open\t" + open.Format(zeekOpenFormat),
+		"#fields\t" + strings.Join(names, "\t"),
+		"#types\t" + strings.Join(types, "\t"),
+	}, "\n") + "\n"
+}
+
+// buildZeekTSVRecord renders record values in schema order. Nil values are unset and logged as "-",
+// while empty strings and containers are logged as "(empty)".
+func buildZeekTSVRecord(record []any) string {
+	values := make([]string, 0, len(record))
+	for _, v := range record {
+		var value string
+		switch v := v.(type) {
+		case nil:
+			value = "-"
+		case time.Time:
+			value = zeekTime(v)
+		case time.Duration:
+			value = zeekInterval(v)
+		case bool:
+			value = "F"
+			if v {
+				value = "T"
+			}
+		case int:
+			value = strconv.Itoa(v)
+		case string:
+			value = zeekTSVEscaper.Replace(v)
+		case []string:
+			escaped := make([]string, 0, len(v))
+			for _, s := range v {
+				escaped = append(escaped, strings.ReplaceAll(zeekTSVEscaper.Replace(s), ",", `\x2c`))
+			}
+			value = strings.Join(escaped, ",")
+		case []time.Duration:
+			intervals := make([]string, 0, len(v))
+			for _, d := range v {
+				intervals = append(intervals, zeekInterval(d))
+			}
+			value = strings.Join(intervals, ",")
+		}
+
+		if value == "" {
+			value = "(empty)"
+		}
+		values = append(values, value)
+	}
+
+	return strings.Join(values, "\t")
+}
+
+// buildZeekJSONRecord renders a JSON record with fields in schema order, omitting unset fields.
+// Records are prefixed with the log path, same as Zeek JSON streaming logs.
+func buildZeekJSONRecord(logType string, record []any) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(`{"_path":"` + logType + `"`)
+
+	for i, f := range zeekSchemas[logType] {
+		var value any
+		switch v := record[i].(type) {
+		case nil:
+			continue
+		case time.Time:
+			value = json.Number(zeekTime(v))
+		case time.Duration:
+			value = json.Number(zeekInterval(v))
+		case []time.Duration:
+			intervals := make([]json.Number, 0, len(v))
+			for _, d := range v {
+				intervals = append(intervals, json.Number(zeekInterval(d)))
+			}
+			value = intervals
+		default:
+			value = v
+		}
+
+		marshaled, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+
+		buf.WriteString(`,"` + f.name + `":`)
+		buf.Write(marshaled)
+	}
+
+	buf.WriteString("}")
+	return buf.Bytes(), nil
+}
+
+// zeekTime formats time as epoch seconds with microsecond precision.
+func zeekTime(t time.Time) string {
+	return fmt.Sprintf("%d.%06d", t.Unix(), t.Nanosecond()/1000)
+}
+
+func zeekInterval(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 6, 64)
+}
+
+// zeekRecords returns records of the connection for each of the given log types, with values in schema order.
+func zeekRecords(c sensorConnection, logTypes []string) map[string][][]any {
+	id := []any{c.origIP, c.origPort, c.respIP, c.respPort}
+	records := map[string][][]any{}
+
+	for _, logType := range logTypes {
+		switch logType {
+		case zeekLogConn:
+			records[logType] = [][]any{zeekConnRecord(c, id)}
+		case zeekLogDNS:
+			if c.dns != nil {
+				records[logType] = [][]any{zeekDNSRecord(c, id)}
+			}
+		case zeekLogHTTP:
+			if c.http != nil {
+				records[logType] = [][]any{zeekHTTPRecord(c, id)}
+			}
+		case zeekLogSSL:
+			if c.tls != nil {
+				records[logType] = [][]any{zeekSSLRecord(c, id)}
+			}
+		case zeekLogFiles:
+			for _, f := range c.files {
+				records[logType] = append(records[logType], zeekFilesRecord(c, id, f))
+			}
+		}
+	}
+
+	return records
+}
+
+func zeekConnRecord(c sensorConnection, id []any) []any {
+	var service, duration, origBytes, respBytes any
+	if c.service != "" {
+		service = c.service
+	}
+
+	// unanswered connection attempts have no duration & payload sizes
+	if c.state != "S0" {
+		duration, origBytes, respBytes = c.duration, c.origBytes, c.respBytes
+	}
+
+	return slices.Concat([]any{c.start, c.uid}, id, []any{
+		c.proto, service, duration, origBytes, respBytes, c.state, true, c.respIP == sensorResolver, 0, c.history,
+		c.origPkts, sensorIPBytes(c.proto, c.origPkts, c.origBytes), c.respPkts, sensorIPBytes(c.proto, c.respPkts, c.respBytes),
+		nil,
+	})
+}
+
+func zeekDNSRecord(c sensorConnection, id []any) []any {
+	d := c.dns
+
+	var answers, ttls any
+	if len(d.answers) > 0 {
+		answers = d.answers
+		ttls = []time.Duration{time.Duration(d.ttl) * time.Second}
+	}
+
+	return slices.Concat([]any{c.start, c.uid}, id, []any{
+		c.proto, d.transID, d.rtt, d.query, 1, "C_INTERNET", d.qtype, dnsTypeName(d.qtype), d.rcode, dnsRcodeName(d.rcode),
+		false, false, true, true, 0, answers, ttls, false,
+	})
+}
+
+func zeekHTTPRecord(c sensorConnection, id []any) []any {
+	h := c.http
+
+	var referrer, respFuids, respMimeTypes any
+	if h.referrer != "" {
+		referrer = h.referrer
+	}
+	if len(c.files) > 0 {
+		respFuids, respMimeTypes = []string{c.files[0].fuid}, []string{c.files[0].mimeType}
+	}
+
+	return slices.Concat([]any{c.start.Add(c.duration / 10), c.uid}, id, []any{
+		1, h.method, h.host, h.uri, referrer, "1.1", h.userAgent, nil, 0, h.bodyLen, h.status, http.StatusText(h.status),
+		nil, nil, []string{}, nil, nil, nil, nil, nil, nil, respFuids, nil, respMimeTypes,
+	})
+}
+
+func zeekSSLRecord(c sensorConnection, id []any) []any {
+	t := c.tls
+
+	// TLS 1.3 encrypts certificates, leaving the certificate chain & SNI match unknown
+	history, certChain, sniMatches := "CsiI", any([]string{}), any(nil)
+	if t.version == "TLSv12" {
+		history, sniMatches = "CsxknGIi", t.serverName == strings.TrimPrefix(t.subject, "CN=")
+		certChain = []string{c.files[0].sha256}
+	}
+
+	return slices.Concat([]any{c.start.Add(c.duration / 20), c.uid}, id, []any{
+		t.version, t.cipher, t.curve, t.serverName, false, nil, nil, true, history, certChain, []string{}, sniMatches,
+	})
+}
+
+func zeekFilesRecord(c sensorConnection, id []any, f sensorFile) []any {
+	analyzers := []string{"MD5", "SHA1", "SHA256"}
+	totalBytes := any(f.size)
+	switch {
+	case f.source == "SSL":
+		analyzers, totalBytes = append(analyzers, "X509"), nil
+	case f.mimeType == "application/x-dosexec":
+		analyzers = append(analyzers, "PE")
+	}
+
+	// files flow from the responder, ex:- HTTP response bodies & server certificates
+	return slices.Concat([]any{c.start.Add(c.duration / 5), f.fuid, c.uid}, id, []any{
+		f.source, 0, analyzers, f.mimeType, nil, time.Duration(rand.IntN(2000)) * time.Microsecond, nil, false,
+		f.size, totalBytes, 0, 0, false, nil, f.md5, f.sha1, f.sha256, nil, nil, nil,
+	})
+}
@@ -0,0 +1,158 @@
+package internal
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"data-gen/conf"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func Test_buildZeekRecord(t *testing.T) {
+	ts := time.Date(2026, 10, 19, 10, 20, 30, 123456789, time.UTC)
+	record := []any{
+		ts, "CHhAvVGS1DHFjwGM9", "10.0.1.15", 52381, "10.0.0.2", 53, "udp", 4242, 1500 * time.Microsecond,
+		"www.example.com", 1, "C_INTERNET", 16, "TXT", 0, "NOERROR", false, false, true, true, 0,
+		[]string{"v=spf1 a,mx\t-all", ""}, []time.Duration{time.Minute}, nil,
+	}
+
+	t.Run("TSV", func(t *testing.T) {
+		require.Equal(t, strings.Join([]string{
+			"1792405230.123456", "CHhAvVGS1DHFjwGM9", "10.0.1.15", "52381", "10.0.0.2", "53", "udp", "4242", "0.001500",
+			"www.example.com", "1", "C_INTERNET", "16", "TXT", "0", "NOERROR", "F", "F", "T", "T", "0",
+			`v=spf1 a\x2cmx\x09-all,`, "60.000000", "-",
+		}, "\t"), buildZeekTSVRecord(record))
+
+		require.Equal(t, "(empty)\t(empty)", buildZeekTSVRecord([]any{"", []string{}}))
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		line, err := buildZeekJSONRecord(zeekLogDNS, record)
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(string(line), `{"_path":"dns","ts":1792405230.123456,"uid":"CHhAvVGS1DHFjwGM9","id.orig_h":"10.0.1.15"`))
+		require.True(t, strings.HasSuffix(string(line), `"answers":["v=spf1 a,mx\t-all",""],"TTLs":[60.000000]}`))
+	})
+
+	t.Run("Header", func(t *testing.T) {
+		header := zeekTSVHeader(zeekLogFiles, ts)
+		require.True(t, strings.HasPrefix(header, "#separator \\x09\n#set_separator\t,\n#empty_field\t(empty)\n#unset_field\t-\n#path\tfiles\n#open\t2026-10-19-10-20-30\n"))
+		require.Contains(t, header, "#fields\tts\tfuid\tuid\tid.orig_h\tid.orig_p\tid.resp_h\tid.resp_p\tsource\tdepth\tanalyzers\t")
+		require.Contains(t, header, "#types\ttime\tstring\tstring\taddr\tport\taddr\tport\tstring\tcount\tset[string]\t")
+	})
+}
+
+func Test_zeekRecords(t *testing.T) {
+	for range 200 {
+		c := newSensorConnection()
+		records := zeekRecords(c, []string{zeekLogConn, zeekLogDNS, zeekLogHTTP, zeekLogSSL, zeekLogFiles})
+
+		for logType, rs := range records {
+			for _, r := range rs {
+				require.Len(t, r, len(zeekSchemas[logType]), logType)
+			}
+		}
+
+		require.Len(t, records[zeekLogConn], 1)
+		require.Equal(t, c.dns != nil, len(records[zeekLogDNS]) == 1)
+		require.Equal(t, c.http != nil, len(records[zeekLogHTTP]) == 1)
+		require.Equal(t, c.tls != nil, len(records[zeekLogSSL]) == 1)
+		require.Len(t, records[zeekLogFiles], len(c.files))
+	}
+}
+
+func Test_NewZeekGen(t *testing.T) {
+	t.Run("TSV logs", func(t *testing.T) {
+		gen, err := NewZeekGen(conf.InputConfig{}, conf.OutputConfig{Type: conf.OutputStdout})
+		require.NoError(t, err)
+
+		for range 200 {
+			_, err = gen.Generate()
+			require.NoError(t, err)
+		}
+
+		// each batch is a single log file, with records matching the #fields header and carrying UIDs of logged connections
+		uids := map[string]bool{}
+		var paths []string
+		for range len(gen.logTypes) {
+			batch := string(gen.GetAndReset())
+			require.Equal(t, 1, strings.Count(batch, "#path\t"))
+			require.Equal(t, 1, strings.Count(batch, "#close\t"))
+
+			var path string
+			var fields []string
+			for _, line := range strings.Split(strings.TrimSuffix(batch, "\n"), "\n") {
+				switch {
+				case strings.HasPrefix(line, "#path\t"):
+					path = strings.TrimPrefix(line, "#path\t")
+					paths = append(paths, path)
+				case strings.HasPrefix(line, "#fields\t"):
+					fields = strings.Split(line, "\t")[1:]
+				case strings.HasPrefix(line, "#"):
+				default:
+					require.NotEmpty(t, path)
+					values := strings.Split(line, "\t")
+					require.Len(t, values, len(fields), line)
+
+					uid := values[1]
+					if path == zeekLogFiles {
+						uid = values[2]
+					}
+					if path == zeekLogConn {
+						uids[uid] = true
+					}
+					require.True(t, uids[uid], "unknown uid of %s log: %s", path, line)
+				}
+			}
+		}
+		require.Equal(t, gen.logTypes, paths)
+		require.Len(t, uids, 200)
+		require.Empty(t, gen.GetAndReset())
+	})
+
+	t.Run("JSON logs of selected types", func(t *testing.T) {
+		var input conf.InputConfig
+		require.NoError(t, yaml.Unmarshal([]byte("log_format: json\nlog_types: [dns]"), &input.Conf))
+
+		gen, err := NewZeekGen(input, conf.OutputConfig{Type: conf.OutputStdout})
+		require.NoError(t, err)
+
+		for range 50 {
+			_, err = gen.Generate()
+			require.NoError(t, err)
+		}
+
+		lines := strings.Split(strings.TrimSuffix(string(gen.GetAndReset()), "\n"), "\n")
+		require.Len(t, lines, 50)
+		for _, line := range lines {
+			var record map[string]any
+			require.NoError(t, json.Unmarshal([]byte(line), &record))
+			require.Equal(t, zeekLogDNS, record["_path"])
+			require.Equal(t, "udp", record["proto"])
+			require.Contains(t, record, "ts")
+		}
+	})
+
+	t.Run("TSV logs to CloudWatch Logs", func(t *testing.T) {
+		gen, err := NewZeekGen(conf.InputConfig{}, conf.OutputConfig{Type: conf.OutputCWLogs})
+		require.NoError(t, err)
+
+		_, err = gen.Generate()
+		require.NoError(t, err)
+		require.NotContains(t, string(gen.GetAndReset()), "#")
+	})
+
+	t.Run("Invalid configuration", func(t *testing.T) {
+		var input conf.InputConfig
+		require.NoError(t, yaml.Unmarshal([]byte("log_format: ascii"), &input.Conf))
+		_, err := NewZeekGen(input, conf.OutputConfig{})
+		require.ErrorContains(t, err, "unknown zeek log format: ascii")
+
+		require.NoError(t, yaml.Unmarshal([]byte("log_types: [conn, x509]"), &input.Conf))
+		_, err = NewZeekGen(input, conf.OutputConfig{})
+		require.ErrorContains(t, err, "unknown zeek log type: x509")
+	})
+}